@@ -1,5 +1,9 @@
 package action
 
+import (
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
 type ProtocolVersion int
 
 type Action interface {
@@ -22,3 +26,11 @@ type Action interface {
 	Resume() (interface{}, error)
 	Cancel() error
 }
+
+// ProgressReporter is implemented by asynchronous actions
+// that report progress of their running task through get_task
+type ProgressReporter interface {
+	// TrackProgress reports the progress of the task about to run to its own
+	// tracker until the returned func is called
+	TrackProgress(tracker *boshtask.ProgressTracker) (untrack func())
+}
@@ -9,6 +9,8 @@ import (
 
 	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
@@ -27,6 +29,7 @@ type ApplyAction struct {
 	settingsService boshsettings.Service
 	instanceDir     string
 	fs              boshsys.FileSystem
+	progress        *boshtask.RunningProgress
 }
 
 func NewApply(
@@ -35,12 +38,14 @@ func NewApply(
 	settingsService boshsettings.Service,
 	dirProvider directories.Provider,
 	fs boshsys.FileSystem,
+	progress *boshtask.RunningProgress,
 ) (action ApplyAction) {
 	action.applier = applier
 	action.specService = specService
 	action.settingsService = settingsService
 	action.instanceDir = dirProvider.InstanceDir()
 	action.fs = fs
+	action.progress = progress
 	return
 }
 
@@ -59,31 +64,100 @@ func (a ApplyAction) IsLoggable() bool {
 func (a ApplyAction) Run(desiredSpec boshas.V1ApplySpec) (string, error) {
 	settings := a.settingsService.GetSettings()
 
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting current apply spec")
+	}
+
 	resolvedDesiredSpec, err := a.specService.PopulateDHCPNetworks(desiredSpec, settings)
 	if err != nil {
 		return "", bosherr.WrapError(err, "Resolving dynamic networks")
 	}
 
-	if desiredSpec.ConfigurationHash != "" {
+	applied := desiredSpec.ConfigurationHash != ""
+
+	if applied {
+		// A previous spec that was never committed belongs to an apply that was
+		// not followed by a start. The current spec becomes the one to roll back
+		// to so that bundles of older specs are not kept indefinitely.
+		err = a.specService.Commit()
+		if err != nil {
+			return "", bosherr.WrapError(err, "Forgetting previous apply spec")
+		}
+
 		err = a.applier.Apply(resolvedDesiredSpec)
 		if err != nil {
-			return "", bosherr.WrapError(err, "Applying")
+			return "", a.rollback(currentSpec, false, bosherr.WrapError(err, "Applying"))
 		}
 	}
 
 	err = a.specService.Set(resolvedDesiredSpec)
 	if err != nil {
-		return "", bosherr.WrapError(err, "Persisting apply spec")
+		if !applied {
+			return "", bosherr.WrapError(err, "Persisting apply spec")
+		}
+		return "", a.rollback(currentSpec, false, bosherr.WrapError(err, "Persisting apply spec"))
 	}
 
 	err = a.writeInstanceData(resolvedDesiredSpec)
 	if err != nil {
-		return "", err
+		if !applied {
+			return "", err
+		}
+		return "", a.rollback(currentSpec, true, err)
+	}
+
+	if applied {
+		// Bundles of the last started spec are kept until the start action
+		// commits the new one so that a failed start can be rolled back
+		previousSpec, found, err := a.specService.GetPrevious()
+		if err != nil {
+			return "", bosherr.WrapError(err, "Getting previous apply spec")
+		}
+
+		keepSpecs := []boshas.ApplySpec{resolvedDesiredSpec}
+		if found {
+			keepSpecs = append(keepSpecs, previousSpec)
+		}
+
+		err = a.applier.KeepOnly(keepSpecs...)
+		if err != nil {
+			return "", bosherr.WrapError(err, "Removing unused bundles")
+		}
 	}
 
 	return "applied", nil
 }
 
+// rollback restores the bundles, job configuration and stored spec that
+// were current before the apply. The returned error tells the director
+// whether the instance was rolled back; on the first deploy it is not.
+func (a ApplyAction) rollback(currentSpec boshas.V1ApplySpec, specPersisted bool, cause error) error {
+	if !currentSpec.IsDeployed() {
+		return rollbackError(cause, false)
+	}
+
+	err := a.applier.Rollback(currentSpec)
+	if err != nil {
+		return rollbackError(bosherr.NewMultiError(cause, bosherr.WrapError(err, "Rolling back to the previous apply spec")), false)
+	}
+
+	if specPersisted {
+		err = a.specService.Set(currentSpec)
+		if err != nil {
+			return rollbackError(bosherr.NewMultiError(cause, bosherr.WrapError(err, "Restoring the previous apply spec")), false)
+		}
+	}
+
+	return rollbackError(bosherr.WrapError(cause, "Rolled back to the previous apply spec"), true)
+}
+
+// rollbackError reports whether the instance was rolled back next to the
+// error message so that the director does not have to parse it
+func rollbackError(err error, rolledBack bool) error {
+	return boshhandler.NewDetailedError(err, map[string]interface{}{"rolled_back": rolledBack})
+}
+
 func (a ApplyAction) writeInstanceData(spec boshas.V1ApplySpec) error {
 	err := a.writeInstanceField("id", spec.NodeID)
 	if err != nil {
@@ -129,6 +203,12 @@ func (a ApplyAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
 
+// Cancel stops further downloads; the apply is then rolled back
 func (a ApplyAction) Cancel() error {
-	return errors.New("not supported")
+	a.progress.Cancel()
+	return nil
+}
+
+func (a ApplyAction) TrackProgress(tracker *boshtask.ProgressTracker) func() {
+	return a.progress.Track(tracker)
 }
@@ -14,6 +14,8 @@ import (
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
 	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 	fakesettings "github.com/cloudfoundry/bosh-agent/v2/settings/fakes"
@@ -27,6 +29,7 @@ var _ = Describe("ApplyAction", func() {
 		dirProvider     boshdir.Provider
 		applyAction     action.ApplyAction
 		fs              boshsys.FileSystem
+		progress        *boshtask.RunningProgress
 	)
 
 	BeforeEach(func() {
@@ -35,15 +38,39 @@ var _ = Describe("ApplyAction", func() {
 		settingsService = &fakesettings.FakeSettingsService{}
 		dirProvider = boshdir.NewProvider("/var/vcap")
 		fs = fakesys.NewFakeFileSystem()
-		applyAction = action.NewApply(applier, specService, settingsService, dirProvider, fs)
+		progress = boshtask.NewRunningProgress()
+		applyAction = action.NewApply(applier, specService, settingsService, dirProvider, fs, progress)
 	})
 
 	AssertActionIsAsynchronous(applyAction)
 	AssertActionIsNotPersistent(applyAction)
 	AssertActionIsLoggable(applyAction)
-	AssertActionIsNotCancelable(applyAction)
 	AssertActionIsNotResumable(applyAction)
 
+	It("cancels downloads of the running task", func() {
+		tracker := boshtask.NewProgressTracker()
+		untrack := applyAction.TrackProgress(tracker)
+		defer untrack()
+
+		Expect(applyAction.Cancel()).To(Succeed())
+		Expect(tracker.Cancelled()).To(BeTrue())
+	})
+
+	It("reports download progress to the tracker of the running task", func() {
+		tracker := boshtask.NewProgressTracker()
+		untrack := applyAction.TrackProgress(tracker)
+
+		progress.AddTotal(3)
+		progress.StepDone()
+		progress.StepDone()
+		untrack()
+		progress.StepDone()
+
+		reported, found := tracker.Progress()
+		Expect(found).To(BeTrue())
+		Expect(reported).To(Equal(boshtask.Progress{Done: 2, Total: 3}))
+	})
+
 	Describe("Run", func() {
 		settings := boshsettings.Settings{AgentID: "fake-agent-id"}
 
@@ -52,7 +79,10 @@ var _ = Describe("ApplyAction", func() {
 		})
 
 		Context("when desired spec has configuration hash", func() {
-			currentApplySpec := boshas.V1ApplySpec{ConfigurationHash: "fake-current-config-hash"}
+			currentApplySpec := boshas.V1ApplySpec{
+				ConfigurationHash: "fake-current-config-hash",
+				JobSpec:           boshas.JobSpec{JobTemplateSpecs: []boshas.JobTemplateSpec{{Name: "fake-job"}}},
+			}
 			desiredApplySpec := boshas.V1ApplySpec{ConfigurationHash: "fake-desired-config-hash"}
 			populatedDesiredApplySpec := boshas.V1ApplySpec{
 				ConfigurationHash: "fake-populated-desired-config-hash",
@@ -133,6 +163,40 @@ var _ = Describe("ApplyAction", func() {
 								Expect(err).To(HaveOccurred())
 								Expect(err.Error()).To(ContainSubstring("fake-set-error"))
 							})
+
+							It("rolls back to the current spec", func() {
+								specService.SetErr = errors.New("fake-set-error")
+
+								_, err := applyAction.Run(desiredApplySpec)
+								Expect(err).To(HaveOccurred())
+								Expect(err.Error()).To(ContainSubstring("Rolled back to the previous apply spec"))
+								Expect(applier.RollbackPreviousApplySpec).To(Equal(currentApplySpec))
+							})
+						})
+
+						It("keeps bundles of the desired and the current spec", func() {
+							_, err := applyAction.Run(desiredApplySpec)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(applier.KeepOnlyApplySpecs).To(Equal([]boshas.ApplySpec{populatedDesiredApplySpec, currentApplySpec}))
+							Expect(applier.RolledBack).To(BeFalse())
+						})
+
+						It("forgets a previous spec that was applied but never started", func() {
+							specService.PreviousSpec = boshas.V1ApplySpec{ConfigurationHash: "fake-never-started-config-hash"}
+							specService.PreviousFound = true
+
+							_, err := applyAction.Run(desiredApplySpec)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(specService.PreviousSpec).To(Equal(currentApplySpec))
+							Expect(applier.KeepOnlyApplySpecs).To(Equal([]boshas.ApplySpec{populatedDesiredApplySpec, currentApplySpec}))
+						})
+
+						It("returns error when removing unused bundles fails", func() {
+							applier.KeepOnlyError = errors.New("fake-keep-only-error")
+
+							_, err := applyAction.Run(desiredApplySpec)
+							Expect(err).To(HaveOccurred())
+							Expect(err.Error()).To(ContainSubstring("fake-keep-only-error"))
 						})
 					})
 
@@ -152,6 +216,39 @@ var _ = Describe("ApplyAction", func() {
 							Expect(err).To(HaveOccurred())
 							Expect(specService.Spec).To(Equal(currentApplySpec))
 						})
+
+						It("rolls back to the current spec and reports the rollback", func() {
+							_, err := applyAction.Run(desiredApplySpec)
+							Expect(err).To(HaveOccurred())
+							Expect(err.Error()).To(ContainSubstring("Rolled back to the previous apply spec"))
+							Expect(err.(boshhandler.DetailedError).Details).To(Equal(map[string]interface{}{"rolled_back": true}))
+
+							Expect(applier.RolledBack).To(BeTrue())
+							Expect(applier.RollbackPreviousApplySpec).To(Equal(currentApplySpec))
+							Expect(applier.KeptOnly).To(BeFalse())
+						})
+
+						It("reports both errors when rolling back fails", func() {
+							applier.RollbackError = errors.New("fake-rollback-error")
+
+							_, err := applyAction.Run(desiredApplySpec)
+							Expect(err).To(HaveOccurred())
+							Expect(err.Error()).To(ContainSubstring("fake-apply-error"))
+							Expect(err.Error()).To(ContainSubstring("fake-rollback-error"))
+							Expect(err.Error()).ToNot(ContainSubstring("Rolled back to the previous apply spec"))
+							Expect(err.(boshhandler.DetailedError).Details).To(Equal(map[string]interface{}{"rolled_back": false}))
+						})
+
+						It("does not roll back to the empty spec of a new VM on the first deploy", func() {
+							specService.Spec = boshas.V1ApplySpec{}
+
+							_, err := applyAction.Run(desiredApplySpec)
+							Expect(err).To(HaveOccurred())
+							Expect(err.Error()).To(ContainSubstring("fake-apply-error"))
+							Expect(err.Error()).ToNot(ContainSubstring("Rolled back to the previous apply spec"))
+							Expect(err.(boshhandler.DetailedError).Details).To(Equal(map[string]interface{}{"rolled_back": false}))
+							Expect(applier.RolledBack).To(BeFalse())
+						})
 					})
 				})
 
@@ -219,6 +316,7 @@ var _ = Describe("ApplyAction", func() {
 						_, err := applyAction.Run(desiredApplySpec)
 						Expect(err).ToNot(HaveOccurred())
 						Expect(applier.Applied).To(BeFalse())
+						Expect(applier.KeptOnly).To(BeFalse())
 					})
 				})
 
@@ -237,6 +335,7 @@ var _ = Describe("ApplyAction", func() {
 						_, err := applyAction.Run(desiredApplySpec)
 						Expect(err).To(HaveOccurred())
 						Expect(applier.Applied).To(BeFalse())
+						Expect(applier.RolledBack).To(BeFalse())
 					})
 				})
 			})
@@ -19,6 +19,9 @@ type BundleLogsRequest struct {
 
 	LogType string   `json:"log_type"`
 	Filters []string `json:"filters"`
+
+	// Time window, size limit, job selection and journald output of the bundle
+	logstarprovider.Options
 }
 
 type BundleLogsResponse struct {
@@ -48,7 +51,7 @@ func (a BundleLogsAction) IsLoggable() bool {
 }
 
 func (a BundleLogsAction) Run(request BundleLogsRequest) (BundleLogsResponse, error) {
-	tarball, err := a.logsTarProvider.Get(request.LogType, request.Filters)
+	tarball, err := a.logsTarProvider.Get(request.LogType, request.Filters, request.Options)
 	if err != nil {
 		return BundleLogsResponse{}, err
 	}
@@ -1,11 +1,14 @@
 package action_test
 
 import (
+	"encoding/json"
 	"errors"
+	"time"
 
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 
+	"github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider"
 	fakelogstarprovider "github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider/logstarproviderfakes"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -50,13 +53,39 @@ var _ = Describe("FetchLogsAction", func() {
 			_, err := action.Run(request)
 			Expect(err).ToNot(HaveOccurred())
 
-			logType, filters := logsTarProvider.GetArgsForCall(0)
+			logType, filters, _ := logsTarProvider.GetArgsForCall(0)
 			Expect(logType).To(Equal("job"))
 			Expect(filters).To(Equal([]string{"foo", "bar"}))
 
 			Expect(logsTarProvider.CleanUpCallCount()).To(BeZero())
 		})
 
+		It("passes the logs options of the request to logstarprovider", func() {
+			request := BundleLogsRequest{}
+			err := json.Unmarshal([]byte(`{
+				"log_type": "job,agent",
+				"since": "2024-05-01T10:00:00Z",
+				"until": "2024-05-01T12:00:00Z",
+				"max_bytes": 1048576,
+				"jobs": ["fake-job"],
+				"include_journal": true
+			}`), &request)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = action.Run(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			logType, _, options := logsTarProvider.GetArgsForCall(0)
+			Expect(logType).To(Equal("job,agent"))
+			Expect(options).To(Equal(logstarprovider.Options{
+				Since:          time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+				Until:          time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+				MaxBytes:       1048576,
+				Jobs:           []string{"fake-job"},
+				IncludeJournal: true,
+			}))
+		})
+
 		It("returns the expected logs tarball path and sha512", func() {
 			logsTarProvider.GetReturns("/tmp/logsinhere.tgz", nil)
 
@@ -8,14 +8,18 @@ import (
 
 	boshmodels "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshcomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
 )
 
 type CompilePackageAction struct {
 	compiler boshcomp.Compiler
+	progress *boshtask.RunningProgress
 }
 
-func NewCompilePackage(compiler boshcomp.Compiler) (compilePackage CompilePackageAction) {
+func NewCompilePackage(compiler boshcomp.Compiler, progress *boshtask.RunningProgress) (compilePackage CompilePackageAction) {
 	compilePackage.compiler = compiler
+	compilePackage.progress = progress
 	return
 }
 
@@ -54,9 +58,9 @@ func (a CompilePackageAction) Run(blobID string, multiDigest boshcrypto.Multiple
 		})
 	}
 
-	uploadedBlobID, uploadedDigest, err := a.compiler.Compile(pkg, modelsDeps)
+	uploadedBlobID, uploadedDigest, compileLog, err := a.compiler.Compile(pkg, modelsDeps)
 	if err != nil {
-		return val, bosherr.WrapErrorf(err, "Compiling package %s", pkg.Name)
+		return val, compileError(err, pkg.Name, compileLog)
 	}
 
 	result := map[string]string{
@@ -64,12 +68,20 @@ func (a CompilePackageAction) Run(blobID string, multiDigest boshcrypto.Multiple
 		"sha1":         uploadedDigest.String(),
 	}
 
+	if compileLog.BlobID != "" {
+		result["compile_log_blobstore_id"] = compileLog.BlobID
+	}
+
 	val = map[string]interface{}{
 		"result": result,
 	}
 	return val, nil
 }
 
+func (a CompilePackageAction) TrackProgress(tracker *boshtask.ProgressTracker) func() {
+	return a.progress.Track(tracker)
+}
+
 func (a CompilePackageAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
@@ -77,3 +89,23 @@ func (a CompilePackageAction) Resume() (interface{}, error) {
 func (a CompilePackageAction) Cancel() error {
 	return errors.New("not supported")
 }
+
+// compileError points to the uploaded output of the packaging script
+// since the error only contains its truncated output
+func compileError(err error, pkgName string, log boshcomp.CompileLog) error {
+	if log.BlobID != "" {
+		return boshhandler.NewDetailedError(
+			bosherr.WrapErrorf(err, "Compiling package %s (compile log blobstore ID '%s')", pkgName, log.BlobID),
+			map[string]interface{}{"compile_log_blobstore_id": log.BlobID},
+		)
+	}
+
+	if log.Uploaded {
+		return boshhandler.NewDetailedError(
+			bosherr.WrapErrorf(err, "Compiling package %s (compile log uploaded to its signed URL)", pkgName),
+			map[string]interface{}{"compile_log_uploaded": true},
+		)
+	}
+
+	return bosherr.WrapErrorf(err, "Compiling package %s", pkgName)
+}
@@ -13,6 +13,8 @@ import (
 	boshmodels "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshcomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
 	fakecomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler/fakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
 )
 
 func getCompileActionArguments() (blobID string, multiDigest boshcrypto.MultipleDigest, name, version string, deps boshcomp.Dependencies) {
@@ -40,12 +42,14 @@ func getCompileActionArguments() (blobID string, multiDigest boshcrypto.Multiple
 var _ = Describe("CompilePackageAction", func() {
 	var (
 		compiler *fakecomp.FakeCompiler
+		progress *boshtask.RunningProgress
 		action   boshaction.CompilePackageAction
 	)
 
 	BeforeEach(func() {
 		compiler = fakecomp.NewFakeCompiler()
-		action = boshaction.NewCompilePackage(compiler)
+		progress = boshtask.NewRunningProgress()
+		action = boshaction.NewCompilePackage(compiler, progress)
 	})
 
 	AssertActionIsAsynchronous(action)
@@ -122,6 +126,22 @@ var _ = Describe("CompilePackageAction", func() {
 			Expect(compiler.CompileDeps).To(ConsistOf(expectedDeps))
 		})
 
+		It("returns the blob id of the compile log", func() {
+			compiler.CompileBlobID = "my-blob-id"
+			compiler.CompileDigest = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "some checksum")
+			compiler.CompileLog = boshcomp.CompileLog{BlobID: "my-log-blob-id", Uploaded: true}
+
+			value, err := action.Run(getCompileActionArguments())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal(map[string]interface{}{
+				"result": map[string]string{
+					"blobstore_id":             "my-blob-id",
+					"sha1":                     "some checksum",
+					"compile_log_blobstore_id": "my-log-blob-id",
+				},
+			}))
+		})
+
 		It("returns error when compile fails", func() {
 			compiler.CompileErr = errors.New("fake-compile-error")
 
@@ -129,5 +149,35 @@ var _ = Describe("CompilePackageAction", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-compile-error"))
 		})
+
+		It("returns error pointing to the compile log when compile fails after running the packaging script", func() {
+			compiler.CompileErr = errors.New("fake-compile-error")
+			compiler.CompileLog = boshcomp.CompileLog{BlobID: "fake-log-blob-id", Uploaded: true}
+
+			_, err := action.Run(getCompileActionArguments())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("compile log blobstore ID 'fake-log-blob-id'"))
+			Expect(err.Error()).To(ContainSubstring("fake-compile-error"))
+
+			var detailedErr boshhandler.DetailedError
+			Expect(errors.As(err, &detailedErr)).To(BeTrue())
+			Expect(detailedErr.Details).To(Equal(map[string]interface{}{"compile_log_blobstore_id": "fake-log-blob-id"}))
+		})
+	})
+
+	Describe("TrackProgress", func() {
+		It("reports progress of the compilation to the tracker of the running task", func() {
+			tracker := boshtask.NewProgressTracker()
+			untrack := action.TrackProgress(tracker)
+			defer untrack()
+
+			progress.AddTotal(4)
+			progress.StepDone()
+			_, _ = progress.Write([]byte("fake-output"))
+
+			value, reported := tracker.Progress()
+			Expect(reported).To(BeTrue())
+			Expect(value).To(Equal(boshtask.Progress{Done: 1, Total: 4, Output: "fake-output"}))
+		})
 	})
 })
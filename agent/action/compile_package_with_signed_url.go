@@ -4,15 +4,16 @@ import (
 	"errors"
 
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
-	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	boshmodels "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshcomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
 type CompilePackageWithSignedURLRequest struct {
 	PackageGetSignedURL string            `json:"package_get_signed_url"`
 	UploadSignedURL     string            `json:"upload_signed_url"`
+	LogUploadSignedURL  string            `json:"log_upload_signed_url"`
 	BlobstoreHeaders    map[string]string `json:"blobstore_headers"`
 
 	Digest  boshcrypto.MultipleDigest `json:"digest"`
@@ -23,11 +24,13 @@ type CompilePackageWithSignedURLRequest struct {
 
 type CompilePackageWithSignedURL struct {
 	compiler boshcomp.Compiler
+	progress *boshtask.RunningProgress
 }
 
-func NewCompilePackageWithSignedURL(compiler boshcomp.Compiler) (compilePackage CompilePackageWithSignedURL) {
+func NewCompilePackageWithSignedURL(compiler boshcomp.Compiler, progress *boshtask.RunningProgress) (compilePackage CompilePackageWithSignedURL) {
 	return CompilePackageWithSignedURL{
 		compiler: compiler,
+		progress: progress,
 	}
 }
 
@@ -38,6 +41,7 @@ func (a CompilePackageWithSignedURL) Run(request CompilePackageWithSignedURLRequ
 		Version:             request.Version,
 		PackageGetSignedURL: request.PackageGetSignedURL,
 		UploadSignedURL:     request.UploadSignedURL,
+		LogUploadSignedURL:  request.LogUploadSignedURL,
 		BlobstoreHeaders:    request.BlobstoreHeaders,
 	}
 
@@ -56,20 +60,32 @@ func (a CompilePackageWithSignedURL) Run(request CompilePackageWithSignedURLRequ
 		})
 	}
 
-	_, uploadedDigest, err := a.compiler.Compile(pkg, modelsDeps)
+	_, uploadedDigest, compileLog, err := a.compiler.Compile(pkg, modelsDeps)
 	if err != nil {
-		return map[string]interface{}{}, bosherr.WrapErrorf(err, "Compiling package %s", pkg.Name)
+		return map[string]interface{}{}, compileError(err, pkg.Name, compileLog)
 	}
 
-	result := map[string]string{
+	result := map[string]interface{}{
 		"sha1": uploadedDigest.String(),
 	}
 
+	// Logs uploaded to their signed URL have no blob ID, so the director is
+	// only told that the log was uploaded
+	if compileLog.BlobID != "" {
+		result["compile_log_blobstore_id"] = compileLog.BlobID
+	} else if compileLog.Uploaded {
+		result["compile_log_uploaded"] = true
+	}
+
 	return map[string]interface{}{
 		"result": result,
 	}, nil
 }
 
+func (a CompilePackageWithSignedURL) TrackProgress(tracker *boshtask.ProgressTracker) func() {
+	return a.progress.Track(tracker)
+}
+
 func (a CompilePackageWithSignedURL) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
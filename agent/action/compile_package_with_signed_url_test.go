@@ -13,12 +13,15 @@ import (
 	boshmodels "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshcomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
 	fakecomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler/fakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
 )
 
 func getCompileWithSignedURLActionArguments() boshaction.CompilePackageWithSignedURLRequest {
 	return boshaction.CompilePackageWithSignedURLRequest{
 		PackageGetSignedURL: "fake/get/url",
 		UploadSignedURL:     "fake/upload/url",
+		LogUploadSignedURL:  "fake/log/upload/url",
 		Name:                "fake-package-name",
 		Version:             "fake-package-version",
 		Digest:              boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1")),
@@ -47,12 +50,14 @@ func getCompileWithSignedURLActionArguments() boshaction.CompilePackageWithSigne
 var _ = Describe("CompilePackageWithSignedURL", func() {
 	var (
 		compiler *fakecomp.FakeCompiler
+		progress *boshtask.RunningProgress
 		action   boshaction.CompilePackageWithSignedURL
 	)
 
 	BeforeEach(func() {
 		compiler = fakecomp.NewFakeCompiler()
-		action = boshaction.NewCompilePackageWithSignedURL(compiler)
+		progress = boshtask.NewRunningProgress()
+		action = boshaction.NewCompilePackageWithSignedURL(compiler, progress)
 	})
 
 	AssertActionIsAsynchronous(action)
@@ -96,12 +101,13 @@ var _ = Describe("CompilePackageWithSignedURL", func() {
 				Name:                "fake-package-name",
 				PackageGetSignedURL: "fake/get/url",
 				UploadSignedURL:     "fake/upload/url",
+				LogUploadSignedURL:  "fake/log/upload/url",
 				Version:             "fake-package-version",
 				BlobstoreHeaders:    map[string]string{"header": "value"},
 			}
 
 			expectedValue := map[string]interface{}{
-				"result": map[string]string{
+				"result": map[string]interface{}{
 					"sha1": "some checksum",
 				},
 			}
@@ -138,6 +144,34 @@ var _ = Describe("CompilePackageWithSignedURL", func() {
 			Expect(compiler.CompileDeps).To(ConsistOf(expectedDeps))
 		})
 
+		It("returns the blob id of the compile log", func() {
+			compiler.CompileDigest = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "some checksum")
+			compiler.CompileLog = boshcomp.CompileLog{BlobID: "my-log-blob-id", Uploaded: true}
+
+			value, err := action.Run(getCompileWithSignedURLActionArguments())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal(map[string]interface{}{
+				"result": map[string]interface{}{
+					"sha1":                     "some checksum",
+					"compile_log_blobstore_id": "my-log-blob-id",
+				},
+			}))
+		})
+
+		It("reports that the compile log was uploaded to its signed URL", func() {
+			compiler.CompileDigest = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "some checksum")
+			compiler.CompileLog = boshcomp.CompileLog{Uploaded: true}
+
+			value, err := action.Run(getCompileWithSignedURLActionArguments())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal(map[string]interface{}{
+				"result": map[string]interface{}{
+					"sha1":                 "some checksum",
+					"compile_log_uploaded": true,
+				},
+			}))
+		})
+
 		It("returns error when compile fails", func() {
 			compiler.CompileErr = errors.New("fake-compile-error")
 
@@ -145,5 +179,48 @@ var _ = Describe("CompilePackageWithSignedURL", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-compile-error"))
 		})
+
+		It("returns error pointing to the compile log when compile fails after running the packaging script", func() {
+			compiler.CompileErr = errors.New("fake-compile-error")
+			compiler.CompileLog = boshcomp.CompileLog{BlobID: "fake-log-blob-id", Uploaded: true}
+
+			_, err := action.Run(getCompileWithSignedURLActionArguments())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("compile log blobstore ID 'fake-log-blob-id'"))
+			Expect(err.Error()).To(ContainSubstring("fake-compile-error"))
+
+			var detailedErr boshhandler.DetailedError
+			Expect(errors.As(err, &detailedErr)).To(BeTrue())
+			Expect(detailedErr.Details).To(Equal(map[string]interface{}{"compile_log_blobstore_id": "fake-log-blob-id"}))
+		})
+
+		It("returns error reporting that the compile log was uploaded to its signed URL when compile fails", func() {
+			compiler.CompileErr = errors.New("fake-compile-error")
+			compiler.CompileLog = boshcomp.CompileLog{Uploaded: true}
+
+			_, err := action.Run(getCompileWithSignedURLActionArguments())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("compile log uploaded to its signed URL"))
+
+			var detailedErr boshhandler.DetailedError
+			Expect(errors.As(err, &detailedErr)).To(BeTrue())
+			Expect(detailedErr.Details).To(Equal(map[string]interface{}{"compile_log_uploaded": true}))
+		})
+	})
+
+	Describe("TrackProgress", func() {
+		It("reports progress of the compilation to the tracker of the running task", func() {
+			tracker := boshtask.NewProgressTracker()
+			untrack := action.TrackProgress(tracker)
+			defer untrack()
+
+			progress.AddTotal(4)
+			progress.StepDone()
+			_, _ = progress.Write([]byte("fake-output"))
+
+			value, reported := tracker.Progress()
+			Expect(reported).To(BeTrue())
+			Expect(value).To(Equal(boshtask.Progress{Done: 1, Total: 4, Output: "fake-output"}))
+		})
 	})
 })
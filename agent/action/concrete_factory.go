@@ -1,6 +1,8 @@
 package action
 
 import (
+	"code.cloudfoundry.org/clock"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
@@ -29,18 +31,23 @@ func NewFactory(
 	// last argument.
 	sensitiveBlobManager boshagentblob.BlobManagerInterface,
 	taskService boshtask.Service,
+	taskHistory boshtask.History,
 	notifier boshnotif.Notifier,
 	applier boshappl.Applier,
+	applyProgress *boshtask.RunningProgress,
 	compiler boshcomp.Compiler,
+	compileProgress *boshtask.RunningProgress,
 	jobSupervisor boshjobsuper.JobSupervisor,
 	specService boshas.V1Service,
 	jobScriptProvider boshscript.JobScriptProvider,
 	logger boshlog.Logger,
-	blobstoreDelegator blobdelegator.BlobstoreDelegator) (factory Factory) {
+	blobstoreDelegator blobdelegator.BlobstoreDelegator,
+	blobCache boshagentblob.BlobCache) (factory Factory) {
 	dirProvider := platform.GetDirProvider()
 	vitalsService := platform.GetVitalsService()
 	certManager := platform.GetCertManager()
 	logsTarProvider := platform.GetLogsTarProvider()
+	diskFreezer := NewDiskFreezer(settingsService, platform, dirProvider, jobScriptProvider, specService, clock.NewClock(), logger)
 
 	return concreteFactory{
 		availableActions: map[string]Action{
@@ -52,6 +59,10 @@ func NewFactory(
 			"get_task":    NewGetTask(taskService),
 			"cancel_task": NewCancelTask(taskService),
 
+			// Task history
+			"list_tasks":       NewListTasks(taskHistory),
+			"get_task_history": NewGetTaskHistory(taskHistory),
+
 			// VM admin
 			"ssh":                        NewSSH(settingsService, platform, dirProvider, logger),
 			"bundle_logs":                NewBundleLogs(logsTarProvider, platform.GetFs()),
@@ -62,18 +73,19 @@ func NewFactory(
 			"remove_file":                NewRemoveFile(platform.GetFs()),
 
 			// Job management
-			"prepare":    NewPrepare(applier),
-			"apply":      NewApply(applier, specService, settingsService, dirProvider, platform.GetFs()),
+			"prepare":    NewPrepare(applier, applyProgress),
+			"apply":      NewApply(applier, specService, settingsService, dirProvider, platform.GetFs(), applyProgress),
+			"plan_apply": NewPlanApply(applier, specService, settingsService),
 			"start":      NewStart(jobSupervisor, applier, specService),
 			"stop":       NewStop(jobSupervisor),
 			"drain":      NewDrain(notifier, specService, jobScriptProvider, jobSupervisor, logger),
-			"get_state":  NewGetState(settingsService, specService, jobSupervisor, vitalsService),
+			"get_state":  NewGetState(settingsService, specService, jobSupervisor, vitalsService, blobCache),
 			"run_errand": NewRunErrand(specService, dirProvider.JobsDir(), platform.GetRunner(), logger),
 			"run_script": NewRunScript(jobScriptProvider, specService, logger),
 
 			// Compilation
-			"compile_package":                 NewCompilePackage(compiler),
-			"compile_package_with_signed_url": NewCompilePackageWithSignedURL(compiler),
+			"compile_package":                 NewCompilePackage(compiler, compileProgress),
+			"compile_package_with_signed_url": NewCompilePackageWithSignedURL(compiler, compileProgress),
 
 			// Rendered Templates
 			"upload_blob": NewUploadBlobAction(sensitiveBlobManager),
@@ -87,6 +99,9 @@ func NewFactory(
 			"remove_persistent_disk": NewRemovePersistentDiskAction(settingsService),
 			"add_dynamic_disk":       NewAddDynamicDiskAction(settingsService, platform),
 			"remove_dynamic_disk":    NewRemoveDynamicDiskAction(platform),
+			"freeze_disk":            NewFreezeDisk(diskFreezer),
+			"thaw_disk":              NewThawDisk(diskFreezer),
+			"grow_persistent_disk":   NewGrowPersistentDisk(settingsService, platform),
 
 			// ARP cache management
 			"delete_arp_entries": NewDeleteARPEntries(platform),
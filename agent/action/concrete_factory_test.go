@@ -1,6 +1,7 @@
 package action_test
 
 import (
+	"code.cloudfoundry.org/clock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -20,6 +21,7 @@ import (
 	fakeagentblobstore "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore/blobstorefakes"
 	fakecomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler/fakes"
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/fakes"
 	fakenotif "github.com/cloudfoundry/bosh-agent/v2/notification/fakes"
@@ -34,6 +36,7 @@ var _ = Describe("concreteFactory", func() {
 		platform          *platformfakes.FakePlatform
 		blobManager       *fakeagentblobstore.FakeBlobManagerInterface
 		taskService       *faketask.FakeService
+		taskHistory       *faketask.FakeHistory
 		notifier          *fakenotif.FakeNotifier
 		applier           *fakeappl.FakeApplier
 		compiler          *fakecomp.FakeCompiler
@@ -44,6 +47,9 @@ var _ = Describe("concreteFactory", func() {
 		logger            boshlog.Logger
 		fileSystem        *fakesys.FakeFileSystem
 		blobDelegator     *fakeblobdelegator.FakeBlobstoreDelegator
+		blobCache         *fakeagentblobstore.FakeBlobCache
+		applyProgress     *boshtask.RunningProgress
+		compileProgress   *boshtask.RunningProgress
 	)
 
 	BeforeEach(func() {
@@ -56,6 +62,7 @@ var _ = Describe("concreteFactory", func() {
 
 		blobManager = &fakeagentblobstore.FakeBlobManagerInterface{}
 		taskService = &faketask.FakeService{}
+		taskHistory = faketask.NewFakeHistory()
 		notifier = fakenotif.NewFakeNotifier()
 		applier = fakeappl.NewFakeApplier()
 		compiler = fakecomp.NewFakeCompiler()
@@ -64,23 +71,34 @@ var _ = Describe("concreteFactory", func() {
 		jobScriptProvider = &scriptfakes.FakeJobScriptProvider{}
 		logger = boshlog.NewLogger(boshlog.LevelNone)
 		blobDelegator = &fakeblobdelegator.FakeBlobstoreDelegator{}
+		blobCache = &fakeagentblobstore.FakeBlobCache{}
+		applyProgress = boshtask.NewRunningProgress()
+		compileProgress = boshtask.NewRunningProgress()
 
 		factory = boshaction.NewFactory(
 			settingsService,
 			platform,
 			blobManager,
 			taskService,
+			taskHistory,
 			notifier,
 			applier,
+			applyProgress,
 			compiler,
+			compileProgress,
 			jobSupervisor,
 			specService,
 			jobScriptProvider,
 			logger,
 			blobDelegator,
+			blobCache,
 		)
 	})
 
+	diskFreezer := func() *boshaction.DiskFreezer {
+		return boshaction.NewDiskFreezer(settingsService, platform, boshdir.NewProvider("/var/vcap"), jobScriptProvider, specService, clock.NewClock(), logger)
+	}
+
 	It("returns error if boshaction cannot be created", func() {
 		action, err := factory.Create("fake-unknown-boshaction")
 		Expect(err).To(HaveOccurred())
@@ -96,9 +114,16 @@ var _ = Describe("concreteFactory", func() {
 			settingsService,
 			boshdir.NewProvider("/var/vcap"),
 			fileSystem,
+			applyProgress,
 		)))
 	})
 
+	It("plan_apply", func() {
+		action, err := factory.Create("plan_apply")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewPlanApply(applier, specService, settingsService)))
+	})
+
 	It("drain", func() {
 		action, err := factory.Create("drain")
 		Expect(err).ToNot(HaveOccurred())
@@ -142,10 +167,22 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(Equal(boshaction.NewCancelTask(taskService)))
 	})
 
+	It("list_tasks", func() {
+		action, err := factory.Create("list_tasks")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewListTasks(taskHistory)))
+	})
+
+	It("get_task_history", func() {
+		action, err := factory.Create("get_task_history")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewGetTaskHistory(taskHistory)))
+	})
+
 	It("get_state", func() {
 		action, err := factory.Create("get_state")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(boshaction.NewGetState(settingsService, specService, jobSupervisor, platform.GetVitalsService())))
+		Expect(action).To(Equal(boshaction.NewGetState(settingsService, specService, jobSupervisor, platform.GetVitalsService(), blobCache)))
 	})
 
 	It("list_disk", func() {
@@ -208,16 +245,34 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(Equal(boshaction.NewUnmountDisk(settingsService, platform)))
 	})
 
+	It("freeze_disk", func() {
+		action, err := factory.Create("freeze_disk")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewFreezeDisk(diskFreezer())))
+	})
+
+	It("grow_persistent_disk", func() {
+		action, err := factory.Create("grow_persistent_disk")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewGrowPersistentDisk(settingsService, platform)))
+	})
+
+	It("thaw_disk", func() {
+		action, err := factory.Create("thaw_disk")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewThawDisk(diskFreezer())))
+	})
+
 	It("compile_package", func() {
 		action, err := factory.Create("compile_package")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(boshaction.NewCompilePackage(compiler)))
+		Expect(action).To(Equal(boshaction.NewCompilePackage(compiler, compileProgress)))
 	})
 
 	It("compile_package_with_signed_url", func() {
 		action, err := factory.Create("compile_package_with_signed_url")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(boshaction.NewCompilePackageWithSignedURL(compiler)))
+		Expect(action).To(Equal(boshaction.NewCompilePackageWithSignedURL(compiler, compileProgress)))
 	})
 
 	It("run_errand", func() {
@@ -237,7 +292,7 @@ var _ = Describe("concreteFactory", func() {
 	It("prepare", func() {
 		action, err := factory.Create("prepare")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(boshaction.NewPrepare(applier)))
+		Expect(action).To(Equal(boshaction.NewPrepare(applier, applyProgress)))
 	})
 
 	It("delete_arp_entries", func() {
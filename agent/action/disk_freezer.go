@@ -0,0 +1,220 @@
+package action
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
+	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+const (
+	DefaultFreezeDiskTimeoutSeconds = 300
+
+	// thawRetryInterval is the time between attempts to thaw a disk automatically
+	thawRetryInterval = 10 * time.Second
+
+	preSnapshotScriptName  = "pre-snapshot"
+	postSnapshotScriptName = "post-snapshot"
+)
+
+type frozenDisk struct {
+	timer  clock.Timer
+	thawed chan struct{}
+}
+
+// DiskFreezer quiesces the mounted persistent disk so that IaaS snapshots are consistent.
+// It is shared by the freeze_disk and thaw_disk actions so that a disk can be thawed
+// automatically when thaw_disk is never called.
+type DiskFreezer struct {
+	settingsService boshsettings.Service
+	platform        boshplatform.Platform
+	dirProvider     boshdirs.Provider
+	scriptProvider  boshscript.JobScriptProvider
+	specService     boshas.V1Service
+
+	timeService clock.Clock
+	lock        sync.Mutex
+	frozenDisks map[string]frozenDisk
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewDiskFreezer(
+	settingsService boshsettings.Service,
+	platform boshplatform.Platform,
+	dirProvider boshdirs.Provider,
+	scriptProvider boshscript.JobScriptProvider,
+	specService boshas.V1Service,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) *DiskFreezer {
+	return &DiskFreezer{
+		settingsService: settingsService,
+		platform:        platform,
+		dirProvider:     dirProvider,
+		scriptProvider:  scriptProvider,
+		specService:     specService,
+
+		timeService: timeService,
+		frozenDisks: map[string]frozenDisk{},
+
+		logTag: "DiskFreezer",
+		logger: logger,
+	}
+}
+
+// Freeze runs the jobs' pre-snapshot scripts and freezes the file system of the disk.
+// The disk is thawed after the timeout unless Thaw is called first.
+func (f *DiskFreezer) Freeze(diskCID string, timeout time.Duration) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, found := f.frozenDisks[diskCID]; found {
+		return bosherr.Errorf("Persistent disk '%s' is already frozen", diskCID)
+	}
+
+	mountPoint, err := f.mountPoint(diskCID)
+	if err != nil {
+		return err
+	}
+
+	err = f.runScripts(preSnapshotScriptName)
+	if err != nil {
+		return f.resumeJobs(bosherr.WrapError(err, "Running pre-snapshot scripts"))
+	}
+
+	_, _, _, err = f.platform.GetRunner().RunCommand("fsfreeze", "--freeze", mountPoint)
+	if err != nil {
+		return f.resumeJobs(bosherr.WrapErrorf(err, "Freezing file system at '%s'", mountPoint))
+	}
+
+	disk := frozenDisk{timer: f.timeService.NewTimer(timeout), thawed: make(chan struct{})}
+	f.frozenDisks[diskCID] = disk
+
+	go f.thawAfterTimeout(diskCID, disk, timeout)
+
+	return nil
+}
+
+// Thaw unfreezes the file system of the disk and runs the jobs' post-snapshot scripts
+func (f *DiskFreezer) Thaw(diskCID string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	disk, found := f.frozenDisks[diskCID]
+	if !found {
+		return bosherr.Errorf("Persistent disk '%s' is not frozen, it may have been thawed automatically", diskCID)
+	}
+
+	mountPoint, err := f.mountPoint(diskCID)
+	if err != nil {
+		return err
+	}
+
+	_, _, _, err = f.platform.GetRunner().RunCommand("fsfreeze", "--unfreeze", mountPoint)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Thawing file system at '%s'", mountPoint)
+	}
+
+	// The timer stays armed when thawing fails so that thawing is retried after the timeout
+	disk.timer.Stop()
+	close(disk.thawed)
+	delete(f.frozenDisks, diskCID)
+
+	err = f.runScripts(postSnapshotScriptName)
+	if err != nil {
+		return bosherr.WrapError(err, "Running post-snapshot scripts")
+	}
+
+	return nil
+}
+
+// thawAfterTimeout keeps trying to thaw the disk until it is not frozen anymore,
+// since jobs cannot write to a frozen disk
+func (f *DiskFreezer) thawAfterTimeout(diskCID string, disk frozenDisk, timeout time.Duration) {
+	defer f.logger.HandlePanic("DiskFreezer Thaw After Timeout")
+
+	for {
+		select {
+		case <-disk.thawed:
+			return
+		case <-disk.timer.C():
+		}
+
+		f.logger.Warn(f.logTag, "Thawing persistent disk '%s' since it was not thawed within %s", diskCID, timeout)
+
+		err := f.Thaw(diskCID)
+		if err == nil {
+			return
+		}
+
+		if !f.isFrozen(disk) {
+			f.logger.Error(f.logTag, "Thawing persistent disk '%s' automatically: %s", diskCID, err.Error())
+			return
+		}
+
+		f.logger.Error(f.logTag, "Thawing persistent disk '%s' automatically, retrying in %s: %s", diskCID, thawRetryInterval, err.Error())
+		disk.timer.Reset(thawRetryInterval)
+	}
+}
+
+func (f *DiskFreezer) isFrozen(disk frozenDisk) bool {
+	select {
+	case <-disk.thawed:
+		return false
+	default:
+		return true
+	}
+}
+
+func (f *DiskFreezer) mountPoint(diskCID string) (string, error) {
+	diskSettings, err := f.settingsService.GetPersistentDiskSettings(diskCID)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting persistent disk settings")
+	}
+
+	mounted, err := f.platform.IsPersistentDiskMounted(diskSettings)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Checking whether persistent disk is mounted")
+	}
+
+	if !mounted {
+		return "", bosherr.Errorf("Persistent disk '%s' is not mounted", diskCID)
+	}
+
+	return f.dirProvider.StoreDir(), nil
+}
+
+// resumeJobs gives jobs that already prepared for the snapshot a chance to resume
+func (f *DiskFreezer) resumeJobs(err error) error {
+	scriptErr := f.runScripts(postSnapshotScriptName)
+	if scriptErr != nil {
+		f.logger.Error(f.logTag, "Running post-snapshot scripts: %s", scriptErr.Error())
+	}
+
+	return err
+}
+
+func (f *DiskFreezer) runScripts(scriptName string) error {
+	currentSpec, err := f.specService.Get()
+	if err != nil {
+		return bosherr.WrapError(err, "Getting current spec")
+	}
+
+	scripts := make([]boshscript.Script, 0, len(currentSpec.Jobs()))
+	for _, job := range currentSpec.Jobs() {
+		scripts = append(scripts, f.scriptProvider.NewScript(job.BundleName(), scriptName, nil))
+	}
+
+	return f.scriptProvider.NewParallelScript(scriptName, scripts).Run()
+}
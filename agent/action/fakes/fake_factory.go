@@ -5,16 +5,17 @@ import (
 	"fmt"
 
 	boshaction "github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
 type FakeFactory struct {
-	registeredActions    map[string]*TestAction
+	registeredActions    map[string]boshaction.Action
 	registeredActionErrs map[string]error
 }
 
 func NewFakeFactory() *FakeFactory {
 	return &FakeFactory{
-		registeredActions:    make(map[string]*TestAction),
+		registeredActions:    make(map[string]boshaction.Action),
 		registeredActionErrs: make(map[string]error),
 	}
 }
@@ -29,7 +30,7 @@ func (f *FakeFactory) Create(method string) (boshaction.Action, error) {
 	return nil, errors.New("Action not found") //nolint:staticcheck
 }
 
-func (f *FakeFactory) RegisterAction(method string, action *TestAction) {
+func (f *FakeFactory) RegisterAction(method string, action boshaction.Action) {
 	if a := f.registeredActions[method]; a != nil {
 		panic(fmt.Sprintf("Action is already registered: %v", a))
 	}
@@ -84,3 +85,15 @@ func (a *TestAction) Cancel() error {
 	a.Canceled = true
 	return a.CancelErr
 }
+
+type TestProgressAction struct {
+	TestAction
+
+	Tracker   *boshtask.ProgressTracker
+	Untracked bool
+}
+
+func (a *TestProgressAction) TrackProgress(tracker *boshtask.ProgressTracker) func() {
+	a.Tracker = tracker
+	return func() { a.Untracked = true }
+}
@@ -36,8 +36,13 @@ func (a FetchLogsAction) IsLoggable() bool {
 	return true
 }
 
-func (a FetchLogsAction) Run(logTypes string, filters []string) (value map[string]string, err error) {
-	tarball, err := a.logsTarProvider.Get(logTypes, filters)
+func (a FetchLogsAction) Run(logTypes string, filters []string, options ...logstarprovider.Options) (value map[string]string, err error) {
+	var logsOptions logstarprovider.Options
+	if len(options) > 0 {
+		logsOptions = options[0]
+	}
+
+	tarball, err := a.logsTarProvider.Get(logTypes, filters, logsOptions)
 	if err != nil {
 		return
 	}
@@ -2,11 +2,13 @@ package action_test
 
 import (
 	"errors"
+	"time"
 
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider"
 	fakelogstarprovider "github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider/logstarproviderfakes"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -48,9 +50,20 @@ var _ = Describe("FetchLogsAction", func() {
 			_, err := action.Run("job", []string{"foo", "bar"})
 			Expect(err).ToNot(HaveOccurred())
 
-			logType, filters := logsTarProvider.GetArgsForCall(0)
+			logType, filters, options := logsTarProvider.GetArgsForCall(0)
 			Expect(logType).To(Equal("job"))
 			Expect(filters).To(Equal([]string{"foo", "bar"}))
+			Expect(options.IsZero()).To(BeTrue())
+		})
+
+		It("passes logs options to logstarprovider", func() {
+			since := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+			_, err := action.Run("job", []string{}, logstarprovider.Options{Since: since, MaxBytes: 1024, Jobs: []string{"fake-job"}})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, options := logsTarProvider.GetArgsForCall(0)
+			Expect(options).To(Equal(logstarprovider.Options{Since: since, MaxBytes: 1024, Jobs: []string{"fake-job"}}))
 		})
 
 		It("returns the expected log blob", func() {
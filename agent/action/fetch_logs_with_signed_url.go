@@ -15,6 +15,9 @@ type FetchLogsWithSignedURLRequest struct {
 	LogType          string            `json:"log_type"`
 	Filters          []string          `json:"filters"`
 	BlobstoreHeaders map[string]string `json:"blobstore_headers"`
+
+	// Time window, size limit, job selection and journald output of the bundle
+	logstarprovider.Options
 }
 
 type FetchLogsWithSignedURLResponse struct {
@@ -47,7 +50,7 @@ func (a FetchLogsWithSignedURLAction) IsLoggable() bool {
 }
 
 func (a FetchLogsWithSignedURLAction) Run(request FetchLogsWithSignedURLRequest) (FetchLogsWithSignedURLResponse, error) {
-	tarball, err := a.logsTarProvider.Get(request.LogType, request.Filters)
+	tarball, err := a.logsTarProvider.Get(request.LogType, request.Filters, request.Options)
 	if err != nil {
 		return FetchLogsWithSignedURLResponse{}, err
 	}
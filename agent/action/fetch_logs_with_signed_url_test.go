@@ -6,6 +6,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider"
 	fakelogstarprovider "github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider/logstarproviderfakes"
 
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
@@ -58,11 +59,23 @@ var _ = Describe("FetchLogsWithSignedURLAction", func() {
 			})
 			Expect(err).ToNot(HaveOccurred())
 
-			logType, filters := logsTarProvider.GetArgsForCall(0)
+			logType, filters, _ := logsTarProvider.GetArgsForCall(0)
 			Expect(logType).To(Equal("job"))
 			Expect(filters).To(Equal([]string{"foo", "bar"}))
 		})
 
+		It("passes the logs options of the request to logstarprovider", func() {
+			_, err := action.Run(boshaction.FetchLogsWithSignedURLRequest{
+				SignedURL: "foobar",
+				LogType:   "job",
+				Options:   logstarprovider.Options{MaxBytes: 1024, IncludeJournal: true},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, options := logsTarProvider.GetArgsForCall(0)
+			Expect(options).To(Equal(logstarprovider.Options{MaxBytes: 1024, IncludeJournal: true}))
+		})
+
 		It("returns the expected log blob", func() {
 			multidigestSha := boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "sec_dep_sha1"))
 			sha1 := multidigestSha.String()
@@ -0,0 +1,54 @@
+package action
+
+import (
+	"errors"
+	"time"
+)
+
+type FreezeDiskOptions struct {
+	// Seconds after which the disk is thawed when thaw_disk is not called;
+	// defaults to DefaultFreezeDiskTimeoutSeconds
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
+type FreezeDiskAction struct {
+	diskFreezer *DiskFreezer
+}
+
+func NewFreezeDisk(diskFreezer *DiskFreezer) FreezeDiskAction {
+	return FreezeDiskAction{diskFreezer: diskFreezer}
+}
+
+func (a FreezeDiskAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a FreezeDiskAction) IsPersistent() bool {
+	return false
+}
+
+func (a FreezeDiskAction) IsLoggable() bool {
+	return true
+}
+
+func (a FreezeDiskAction) Run(diskCID string, options ...FreezeDiskOptions) (map[string]string, error) {
+	timeout := time.Duration(DefaultFreezeDiskTimeoutSeconds) * time.Second
+	if len(options) > 0 && options[0].TimeoutSeconds > 0 {
+		timeout = time.Duration(options[0].TimeoutSeconds) * time.Second
+	}
+
+	err := a.diskFreezer.Freeze(diskCID, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{}, nil
+}
+
+func (a FreezeDiskAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a FreezeDiskAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,183 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	fakeapplyspec "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/script/scriptfakes"
+	"github.com/cloudfoundry/bosh-agent/v2/platform/platformfakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+	fakesettings "github.com/cloudfoundry/bosh-agent/v2/settings/fakes"
+)
+
+var _ = Describe("FreezeDiskAction", func() {
+	var (
+		platform          *platformfakes.FakePlatform
+		cmdRunner         *fakesys.FakeCmdRunner
+		settingsService   *fakesettings.FakeSettingsService
+		specService       *fakeapplyspec.FakeV1Service
+		jobScriptProvider *scriptfakes.FakeJobScriptProvider
+		parallelScripts   map[string]*scriptfakes.FakeCancellableScript
+		timeService       *fakeclock.FakeClock
+		diskFreezer       *action.DiskFreezer
+		freezeDiskAction  action.FreezeDiskAction
+	)
+
+	BeforeEach(func() {
+		platform = &platformfakes.FakePlatform{}
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		platform.GetRunnerReturns(cmdRunner)
+		platform.IsPersistentDiskMountedReturns(true, nil)
+
+		settingsService = &fakesettings.FakeSettingsService{
+			PersistentDiskSettings: map[string]boshsettings.DiskSettings{
+				"vol-123": {ID: "vol-123", Path: "/dev/sdf"},
+			},
+		}
+
+		specService = fakeapplyspec.NewFakeV1Service()
+		specService.Spec.RenderedTemplatesArchiveSpec = &applyspec.RenderedTemplatesArchiveSpec{}
+		specService.Spec.JobSpec.JobTemplateSpecs = []applyspec.JobTemplateSpec{{Name: "fake-job-1"}, {Name: "fake-job-2"}}
+
+		parallelScripts = map[string]*scriptfakes.FakeCancellableScript{
+			"pre-snapshot":  {},
+			"post-snapshot": {},
+		}
+		jobScriptProvider = &scriptfakes.FakeJobScriptProvider{}
+		jobScriptProvider.NewScriptStub = func(jobName, scriptName string, _ map[string]string) boshscript.Script {
+			script := &scriptfakes.FakeScript{}
+			script.TagReturns(jobName + "/" + scriptName)
+			return script
+		}
+		jobScriptProvider.NewParallelScriptStub = func(scriptName string, _ []boshscript.Script) boshscript.CancellableScript {
+			return parallelScripts[scriptName]
+		}
+
+		timeService = fakeclock.NewFakeClock(time.Now())
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		diskFreezer = action.NewDiskFreezer(settingsService, platform, boshdirs.NewProvider("/var/vcap"), jobScriptProvider, specService, timeService, logger)
+		freezeDiskAction = action.NewFreezeDisk(diskFreezer)
+	})
+
+	AssertActionIsAsynchronous(action.FreezeDiskAction{})
+	AssertActionIsNotPersistent(action.FreezeDiskAction{})
+	AssertActionIsLoggable(action.FreezeDiskAction{})
+
+	AssertActionIsNotResumable(action.FreezeDiskAction{})
+	AssertActionIsNotCancelable(action.FreezeDiskAction{})
+
+	It("runs the pre-snapshot scripts of all jobs and then freezes the store directory", func() {
+		parallelScripts["pre-snapshot"].RunStub = func() error {
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+			return nil
+		}
+
+		result, err := freezeDiskAction.Run("vol-123")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(map[string]string{}))
+
+		Expect(platform.IsPersistentDiskMountedArgsForCall(0)).To(Equal(boshsettings.DiskSettings{ID: "vol-123", Path: "/dev/sdf"}))
+
+		Expect(jobScriptProvider.NewScriptCallCount()).To(Equal(2))
+		jobName, scriptName, _ := jobScriptProvider.NewScriptArgsForCall(0)
+		Expect(jobName).To(Equal("fake-job-1"))
+		Expect(scriptName).To(Equal("pre-snapshot"))
+
+		scriptName, scripts := jobScriptProvider.NewParallelScriptArgsForCall(0)
+		Expect(scriptName).To(Equal("pre-snapshot"))
+		Expect(scripts).To(HaveLen(2))
+		Expect(parallelScripts["pre-snapshot"].RunCallCount()).To(Equal(1))
+		Expect(parallelScripts["post-snapshot"].RunCallCount()).To(Equal(0))
+
+		Expect(cmdRunner.RunCommands).To(Equal([][]string{{"fsfreeze", "--freeze", "/var/vcap/store"}}))
+	})
+
+	It("returns an error when the disk is already frozen", func() {
+		_, err := freezeDiskAction.Run("vol-123")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = freezeDiskAction.Run("vol-123")
+		Expect(err).To(MatchError("Persistent disk 'vol-123' is already frozen"))
+		Expect(cmdRunner.RunCommands).To(HaveLen(1))
+	})
+
+	It("returns an error when the disk is not mounted", func() {
+		platform.IsPersistentDiskMountedReturns(false, nil)
+
+		_, err := freezeDiskAction.Run("vol-123")
+		Expect(err).To(MatchError("Persistent disk 'vol-123' is not mounted"))
+		Expect(parallelScripts["pre-snapshot"].RunCallCount()).To(Equal(0))
+		Expect(cmdRunner.RunCommands).To(BeEmpty())
+	})
+
+	It("resumes the jobs without freezing when a pre-snapshot script fails", func() {
+		parallelScripts["pre-snapshot"].RunReturns(errors.New("fake-script-error"))
+
+		_, err := freezeDiskAction.Run("vol-123")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-script-error"))
+
+		Expect(parallelScripts["post-snapshot"].RunCallCount()).To(Equal(1))
+		Expect(cmdRunner.RunCommands).To(BeEmpty())
+	})
+
+	It("resumes the jobs when the file system cannot be frozen", func() {
+		cmdRunner.AddCmdResult("fsfreeze --freeze /var/vcap/store", fakesys.FakeCmdResult{Error: errors.New("fake-freeze-error")})
+
+		_, err := freezeDiskAction.Run("vol-123")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-freeze-error"))
+
+		Expect(parallelScripts["post-snapshot"].RunCallCount()).To(Equal(1))
+
+		_, err = freezeDiskAction.Run("vol-123")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("thaws the disk automatically after the timeout", func() {
+		_, err := freezeDiskAction.Run("vol-123", action.FreezeDiskOptions{TimeoutSeconds: 30})
+		Expect(err).ToNot(HaveOccurred())
+
+		timeService.WaitForWatcherAndIncrement(29 * time.Second)
+		Consistently(parallelScripts["post-snapshot"].RunCallCount).Should(Equal(0))
+
+		timeService.Increment(time.Second)
+		Eventually(parallelScripts["post-snapshot"].RunCallCount).Should(Equal(1))
+
+		err = diskFreezer.Thaw("vol-123")
+		Expect(err).To(MatchError("Persistent disk 'vol-123' is not frozen, it may have been thawed automatically"))
+	})
+
+	It("retries thawing the disk automatically when it fails", func() {
+		_, err := freezeDiskAction.Run("vol-123", action.FreezeDiskOptions{TimeoutSeconds: 30})
+		Expect(err).ToNot(HaveOccurred())
+		cmdRunner.AddCmdResult("fsfreeze --unfreeze /var/vcap/store", fakesys.FakeCmdResult{Error: errors.New("fake-thaw-error")})
+
+		timeService.WaitForWatcherAndIncrement(30 * time.Second)
+		Eventually(func() int { return len(cmdRunner.RunCommands) }).Should(Equal(2))
+		Expect(parallelScripts["post-snapshot"].RunCallCount()).To(Equal(0))
+
+		timeService.WaitForWatcherAndIncrement(10 * time.Second)
+		Eventually(parallelScripts["post-snapshot"].RunCallCount).Should(Equal(1))
+	})
+
+	It("thaws the disk automatically after the default timeout", func() {
+		_, err := freezeDiskAction.Run("vol-123")
+		Expect(err).ToNot(HaveOccurred())
+
+		timeService.WaitForWatcherAndIncrement(action.DefaultFreezeDiskTimeoutSeconds * time.Second)
+		Eventually(parallelScripts["post-snapshot"].RunCallCount).Should(Equal(1))
+	})
+})
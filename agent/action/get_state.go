@@ -6,6 +6,7 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	boshagentblob "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
 	boshvitals "github.com/cloudfoundry/bosh-agent/v2/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
@@ -16,6 +17,7 @@ type GetStateAction struct {
 	specService     boshas.V1Service
 	jobSupervisor   boshjobsuper.JobSupervisor
 	vitalsService   boshvitals.Service
+	blobCache       boshagentblob.BlobCache
 }
 
 func NewGetState(
@@ -23,11 +25,13 @@ func NewGetState(
 	specService boshas.V1Service,
 	jobSupervisor boshjobsuper.JobSupervisor,
 	vitalsService boshvitals.Service,
+	blobCache boshagentblob.BlobCache,
 ) (action GetStateAction) {
 	action.settingsService = settingsService
 	action.specService = specService
 	action.jobSupervisor = jobSupervisor
 	action.vitalsService = vitalsService
+	action.blobCache = blobCache
 	return
 }
 
@@ -51,6 +55,8 @@ type GetStateV1ApplySpec struct {
 	Vitals    *boshvitals.Vitals     `json:"vitals,omitempty"`
 	Processes []boshjobsuper.Process `json:"processes,omitempty"`
 	VM        boshsettings.VM        `json:"vm"`
+
+	BlobCache boshagentblob.BlobCacheStats `json:"blob_cache"`
 }
 
 func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
@@ -84,6 +90,7 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 		vitalsReference,
 		processes,
 		settings.VM,
+		a.blobCache.Stats(),
 	}
 
 	if value.NetworkSpecs == nil {
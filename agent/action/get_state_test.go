@@ -11,6 +11,8 @@ import (
 	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	boshagentblob "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore"
+	fakeagentblob "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore/blobstorefakes"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/fakes"
 	boshvitals "github.com/cloudfoundry/bosh-agent/v2/platform/vitals"
@@ -25,6 +27,7 @@ var _ = Describe("GetState", func() {
 		specService     *fakeas.FakeV1Service
 		jobSupervisor   *fakejobsuper.FakeJobSupervisor
 		vitalsService   *vitalsfakes.FakeService
+		blobCache       *fakeagentblob.FakeBlobCache
 		getStateAction  action.GetStateAction
 	)
 
@@ -33,7 +36,8 @@ var _ = Describe("GetState", func() {
 		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
 		specService = fakeas.NewFakeV1Service()
 		vitalsService = &vitalsfakes.FakeService{}
-		getStateAction = action.NewGetState(settingsService, specService, jobSupervisor, vitalsService)
+		blobCache = &fakeagentblob.FakeBlobCache{}
+		getStateAction = action.NewGetState(settingsService, specService, jobSupervisor, vitalsService, blobCache)
 	})
 
 	AssertActionIsNotAsynchronous(getStateAction)
@@ -176,6 +180,16 @@ var _ = Describe("GetState", func() {
 				})
 			})
 
+			It("returns blob cache statistics", func() {
+				blobCache.StatsReturns(boshagentblob.BlobCacheStats{Hits: 3, Misses: 1, Entries: 2, SizeBytes: 2048, MaxSizeBytes: 4096})
+
+				state, err := getStateAction.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				boshassert.MatchesJSONString(GinkgoT(), state.BlobCache,
+					`{"hits":3,"misses":1,"evictions":0,"entries":2,"size_bytes":2048,"max_size_bytes":4096}`)
+			})
+
 			Context("when vitals cannot be retrieved", func() {
 				BeforeEach(func() {
 					vitalsService.GetReturns(boshvitals.Vitals{}, errors.New("fake-vitals-get-error"))
@@ -36,10 +36,18 @@ func (a GetTaskAction) Run(taskID string) (interface{}, error) {
 	}
 
 	if task.State == boshtask.StateRunning {
-		return boshtask.StateValue{
+		stateValue := boshtask.StateValue{
 			AgentTaskID: task.ID,
 			State:       task.State,
-		}, nil
+		}
+
+		if task.ProgressFunc != nil {
+			if progress, reported := task.ProgressFunc(); reported {
+				stateValue.Progress = &progress
+			}
+		}
+
+		return stateValue, nil
 	}
 
 	if task.Error != nil {
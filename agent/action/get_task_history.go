@@ -0,0 +1,51 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+type GetTaskHistoryAction struct {
+	taskHistory boshtask.History
+}
+
+func NewGetTaskHistory(taskHistory boshtask.History) (getTaskHistory GetTaskHistoryAction) {
+	getTaskHistory.taskHistory = taskHistory
+	return
+}
+
+func (a GetTaskHistoryAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a GetTaskHistoryAction) IsPersistent() bool {
+	return false
+}
+
+func (a GetTaskHistoryAction) IsLoggable() bool {
+	return true
+}
+
+func (a GetTaskHistoryAction) Run(taskID string) (boshtask.HistoryEntry, error) {
+	entry, found, err := a.taskHistory.Find(taskID)
+	if err != nil {
+		return boshtask.HistoryEntry{}, bosherr.WrapErrorf(err, "Finding task %s in history", taskID)
+	}
+
+	if !found {
+		return boshtask.HistoryEntry{}, bosherr.Errorf("Task with id %s could not be found in history", taskID)
+	}
+
+	return entry, nil
+}
+
+func (a GetTaskHistoryAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a GetTaskHistoryAction) Cancel() error {
+	return errors.New("not supported")
+}
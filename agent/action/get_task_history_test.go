@@ -0,0 +1,60 @@
+package action_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
+)
+
+var _ = Describe("GetTaskHistory", func() {
+	var (
+		taskHistory          *faketask.FakeHistory
+		getTaskHistoryAction action.GetTaskHistoryAction
+	)
+
+	BeforeEach(func() {
+		taskHistory = faketask.NewFakeHistory()
+		getTaskHistoryAction = action.NewGetTaskHistory(taskHistory)
+	})
+
+	AssertActionIsNotAsynchronous(getTaskHistoryAction)
+	AssertActionIsNotPersistent(getTaskHistoryAction)
+	AssertActionIsLoggable(getTaskHistoryAction)
+
+	AssertActionIsNotResumable(getTaskHistoryAction)
+	AssertActionIsNotCancelable(getTaskHistoryAction)
+
+	It("returns the completed task including its result", func() {
+		entry := boshtask.HistoryEntry{
+			TaskID: "fake-task-id",
+			Method: "apply",
+			State:  boshtask.StateDone,
+			Value:  json.RawMessage(`"applied"`),
+		}
+		taskHistory.RecordedEntries = []boshtask.HistoryEntry{entry}
+
+		found, err := getTaskHistoryAction.Run("fake-task-id")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(Equal(entry))
+	})
+
+	It("returns an error when the task is not in the history", func() {
+		_, err := getTaskHistoryAction.Run("fake-task-id")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Task with id fake-task-id could not be found in history"))
+	})
+
+	It("returns an error when the history cannot be read", func() {
+		taskHistory.EntriesErr = errors.New("fake-history-error")
+
+		_, err := getTaskHistoryAction.Run("fake-task-id")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-history-error"))
+	})
+})
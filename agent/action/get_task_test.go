@@ -45,6 +45,38 @@ var _ = Describe("GetTask", func() {
 			`{"agent_task_id":"fake-task-id","state":"running"}`)
 	})
 
+	It("returns progress of a running task", func() {
+		taskService.StartedTasks["fake-task-id"] = boshtask.Task{
+			ID:    "fake-task-id",
+			State: boshtask.StateRunning,
+			ProgressFunc: func() (boshtask.Progress, bool) {
+				return boshtask.Progress{Done: 3, Total: 31}, true
+			},
+		}
+
+		taskValue, err := getTaskAction.Run("fake-task-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		boshassert.MatchesJSONString(GinkgoT(), taskValue,
+			`{"agent_task_id":"fake-task-id","state":"running","progress":{"done":3,"total":31}}`)
+	})
+
+	It("omits progress of a running task that has not reported any", func() {
+		taskService.StartedTasks["fake-task-id"] = boshtask.Task{
+			ID:    "fake-task-id",
+			State: boshtask.StateRunning,
+			ProgressFunc: func() (boshtask.Progress, bool) {
+				return boshtask.Progress{}, false
+			},
+		}
+
+		taskValue, err := getTaskAction.Run("fake-task-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		boshassert.MatchesJSONString(GinkgoT(), taskValue,
+			`{"agent_task_id":"fake-task-id","state":"running"}`)
+	})
+
 	It("returns a failed task", func() {
 		taskService.StartedTasks["fake-task-id"] = boshtask.Task{
 			ID:    "fake-task-id",
@@ -0,0 +1,61 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+type GrowPersistentDiskAction struct {
+	settingsService boshsettings.Service
+	platform        boshplatform.Platform
+}
+
+func NewGrowPersistentDisk(
+	settingsService boshsettings.Service,
+	platform boshplatform.Platform,
+) GrowPersistentDiskAction {
+	return GrowPersistentDiskAction{
+		settingsService: settingsService,
+		platform:        platform,
+	}
+}
+
+func (a GrowPersistentDiskAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a GrowPersistentDiskAction) IsPersistent() bool {
+	return false
+}
+
+func (a GrowPersistentDiskAction) IsLoggable() bool {
+	return true
+}
+
+// Run grows the partition and file system of the mounted persistent disk
+// after the IaaS grew its volume, without unmounting it
+func (a GrowPersistentDiskAction) Run(diskCID string) (boshplatform.PersistentDiskGrowth, error) {
+	diskSettings, err := a.settingsService.GetPersistentDiskSettings(diskCID)
+	if err != nil {
+		return boshplatform.PersistentDiskGrowth{}, bosherr.WrapError(err, "Getting persistent disk settings")
+	}
+
+	growth, err := a.platform.GrowPersistentDisk(diskSettings)
+	if err != nil {
+		return boshplatform.PersistentDiskGrowth{}, bosherr.WrapError(err, "Growing persistent disk")
+	}
+
+	return growth, nil
+}
+
+func (a GrowPersistentDiskAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a GrowPersistentDiskAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,73 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
+	"github.com/cloudfoundry/bosh-agent/v2/platform/platformfakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/v2/settings/fakes"
+)
+
+var _ = Describe("GrowPersistentDiskAction", func() {
+	var (
+		platform                 *platformfakes.FakePlatform
+		settingsService          *fakesettings.FakeSettingsService
+		growPersistentDiskAction action.GrowPersistentDiskAction
+	)
+
+	BeforeEach(func() {
+		platform = &platformfakes.FakePlatform{}
+		settingsService = &fakesettings.FakeSettingsService{
+			PersistentDiskSettings: map[string]boshsettings.DiskSettings{
+				"vol-123": {ID: "vol-123", Path: "/dev/sdf"},
+			},
+		}
+		growPersistentDiskAction = action.NewGrowPersistentDisk(settingsService, platform)
+	})
+
+	AssertActionIsAsynchronous(action.GrowPersistentDiskAction{})
+	AssertActionIsNotPersistent(action.GrowPersistentDiskAction{})
+	AssertActionIsLoggable(action.GrowPersistentDiskAction{})
+
+	AssertActionIsNotResumable(action.GrowPersistentDiskAction{})
+	AssertActionIsNotCancelable(action.GrowPersistentDiskAction{})
+
+	It("grows the persistent disk and reports its sizes", func() {
+		growth := boshplatform.PersistentDiskGrowth{
+			DeviceSizeBeforeInBytes:    10,
+			DeviceSizeAfterInBytes:     20,
+			PartitionSizeBeforeInBytes: 9,
+			PartitionSizeAfterInBytes:  19,
+		}
+		platform.GrowPersistentDiskReturns(growth, nil)
+
+		result, err := growPersistentDiskAction.Run("vol-123")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(growth))
+
+		Expect(platform.GrowPersistentDiskCallCount()).To(Equal(1))
+		Expect(platform.GrowPersistentDiskArgsForCall(0)).To(Equal(boshsettings.DiskSettings{ID: "vol-123", Path: "/dev/sdf"}))
+	})
+
+	It("returns an error when the disk settings cannot be found", func() {
+		settingsService.GetPersistentDiskSettingsError = errors.New("fake-settings-error")
+
+		_, err := growPersistentDiskAction.Run("vol-123")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-settings-error"))
+		Expect(platform.GrowPersistentDiskCallCount()).To(Equal(0))
+	})
+
+	It("returns an error when growing the disk fails", func() {
+		platform.GrowPersistentDiskReturns(boshplatform.PersistentDiskGrowth{}, errors.New("fake-grow-error"))
+
+		_, err := growPersistentDiskAction.Run("vol-123")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-grow-error"))
+	})
+})
@@ -0,0 +1,69 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+type ListTasksAction struct {
+	taskHistory boshtask.History
+}
+
+func NewListTasks(taskHistory boshtask.History) (listTasks ListTasksAction) {
+	listTasks.taskHistory = taskHistory
+	return
+}
+
+func (a ListTasksAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a ListTasksAction) IsPersistent() bool {
+	return false
+}
+
+func (a ListTasksAction) IsLoggable() bool {
+	return true
+}
+
+// Run returns completed tasks without their results to keep the response small;
+// use get_task_history to retrieve the result of a specific task.
+func (a ListTasksAction) Run(methods ...string) ([]boshtask.HistoryEntry, error) {
+	entries, err := a.taskHistory.Entries()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing task history")
+	}
+
+	listed := []boshtask.HistoryEntry{}
+
+	for _, entry := range entries {
+		if len(methods) > 0 && !containsMethod(methods, entry.Method) {
+			continue
+		}
+
+		entry.Value = nil
+		listed = append(listed, entry)
+	}
+
+	return listed, nil
+}
+
+func (a ListTasksAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a ListTasksAction) Cancel() error {
+	return errors.New("not supported")
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
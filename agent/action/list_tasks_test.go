@@ -0,0 +1,66 @@
+package action_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
+)
+
+var _ = Describe("ListTasks", func() {
+	var (
+		taskHistory     *faketask.FakeHistory
+		listTasksAction action.ListTasksAction
+	)
+
+	BeforeEach(func() {
+		taskHistory = faketask.NewFakeHistory()
+		listTasksAction = action.NewListTasks(taskHistory)
+	})
+
+	AssertActionIsNotAsynchronous(listTasksAction)
+	AssertActionIsNotPersistent(listTasksAction)
+	AssertActionIsLoggable(listTasksAction)
+
+	AssertActionIsNotResumable(listTasksAction)
+	AssertActionIsNotCancelable(listTasksAction)
+
+	BeforeEach(func() {
+		taskHistory.RecordedEntries = []boshtask.HistoryEntry{
+			{TaskID: "fake-task-id-1", Method: "apply", State: boshtask.StateDone, Value: json.RawMessage(`"applied"`)},
+			{TaskID: "fake-task-id-2", Method: "compile_package", State: boshtask.StateFailed, Error: "fake-error"},
+		}
+	})
+
+	It("returns completed tasks without their results", func() {
+		entries, err := listTasksAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+
+		boshassert.MatchesJSONString(GinkgoT(), entries, `[`+
+			`{"agent_task_id":"fake-task-id-2","method":"compile_package","state":"failed","started_at":"0001-01-01T00:00:00Z","finished_at":"0001-01-01T00:00:00Z","error":"fake-error"},`+
+			`{"agent_task_id":"fake-task-id-1","method":"apply","state":"done","started_at":"0001-01-01T00:00:00Z","finished_at":"0001-01-01T00:00:00Z"}`+
+			`]`)
+	})
+
+	It("filters tasks by method", func() {
+		entries, err := listTasksAction.Run("apply")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].TaskID).To(Equal("fake-task-id-1"))
+	})
+
+	It("returns an error when the history cannot be read", func() {
+		taskHistory.EntriesErr = errors.New("fake-history-error")
+
+		_, err := listTasksAction.Run()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-history-error"))
+	})
+})
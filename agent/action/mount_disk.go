@@ -6,6 +6,7 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
+	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 )
@@ -66,6 +67,9 @@ func (a MountDiskAction) Run(diskCid string) (interface{}, error) {
 	}
 
 	err = a.diskMounter.MountPersistentDisk(diskSettings, mountPoint)
+	if errors.Is(err, boshplatform.ErrPersistentDiskNotEncrypted) {
+		return nil, bosherr.WrapErrorf(err, "Mounting persistent disk '%s' with encryption enabled: copy its data to a new disk or disable persistent disk encryption", diskCid)
+	}
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Mounting persistent disk")
 	}
@@ -10,6 +10,7 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
 	"github.com/cloudfoundry/bosh-agent/v2/platform/platformfakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
@@ -114,6 +115,20 @@ var _ = Describe("MountDiskAction", func() {
 							Expect(settingsService.SavePersistentDiskSettingsCallCount).To(Equal(0))
 						})
 					})
+
+					Context("when the disk was created before encryption was enabled", func() {
+						BeforeEach(func() {
+							platform.MountPersistentDiskReturns(boshplatform.ErrPersistentDiskNotEncrypted)
+						})
+
+						It("returns an error explaining how to use the disk", func() {
+							_, err := mountDiskAction.Run("fake-disk-cid")
+							Expect(err).To(MatchError(
+								"Mounting persistent disk 'fake-disk-cid' with encryption enabled: copy its data to a new disk or disable persistent disk encryption: " +
+									boshplatform.ErrPersistentDiskNotEncrypted.Error(),
+							))
+						})
+					})
 				})
 
 				Context("when disk cid cannot be resolved to a device path from infrastructure settings", func() {
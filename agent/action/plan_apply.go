@@ -0,0 +1,112 @@
+package action
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+// PlanApplyAction reports what the apply action would do for a desired spec
+// without installing bundles, reconfiguring jobs or persisting the spec
+type PlanApplyAction struct {
+	applier         boshappl.Applier
+	specService     boshas.V1Service
+	settingsService boshsettings.Service
+}
+
+type PlanApplyResult struct {
+	// Empty when the desired spec has no configuration hash since apply
+	// only persists such specs
+	Applier *boshappl.Plan `json:"applier,omitempty"`
+
+	PersistentDiskChanged bool     `json:"persistent_disk_changed"`
+	NetworksChanged       []string `json:"networks_changed"`
+}
+
+func NewPlanApply(
+	applier boshappl.Applier,
+	specService boshas.V1Service,
+	settingsService boshsettings.Service,
+) (action PlanApplyAction) {
+	action.applier = applier
+	action.specService = specService
+	action.settingsService = settingsService
+	return
+}
+
+func (a PlanApplyAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a PlanApplyAction) IsPersistent() bool {
+	return false
+}
+
+func (a PlanApplyAction) IsLoggable() bool {
+	return true
+}
+
+func (a PlanApplyAction) Run(desiredSpec boshas.V1ApplySpec) (PlanApplyResult, error) {
+	settings := a.settingsService.GetSettings()
+
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		return PlanApplyResult{}, bosherr.WrapError(err, "Getting current apply spec")
+	}
+
+	resolvedDesiredSpec, err := a.specService.PopulateDHCPNetworks(desiredSpec, settings)
+	if err != nil {
+		return PlanApplyResult{}, bosherr.WrapError(err, "Resolving dynamic networks")
+	}
+
+	result := PlanApplyResult{
+		PersistentDiskChanged: currentSpec.PersistentDisk != resolvedDesiredSpec.PersistentDisk,
+		NetworksChanged:       changedNetworks(currentSpec.NetworkSpecs, resolvedDesiredSpec.NetworkSpecs),
+	}
+
+	if desiredSpec.ConfigurationHash != "" {
+		plan, err := a.applier.Plan(currentSpec, resolvedDesiredSpec)
+		if err != nil {
+			return PlanApplyResult{}, bosherr.WrapError(err, "Planning apply")
+		}
+
+		result.Applier = &plan
+	}
+
+	return result, nil
+}
+
+func changedNetworks(current, desired map[string]boshas.NetworkSpec) []string {
+	changed := []string{}
+
+	for name, desiredNetwork := range desired {
+		currentNetwork, found := current[name]
+		if !found || !reflect.DeepEqual(currentNetwork.Fields, desiredNetwork.Fields) {
+			changed = append(changed, name)
+		}
+	}
+
+	for name := range current {
+		if _, found := desired[name]; !found {
+			changed = append(changed, name)
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
+
+func (a PlanApplyAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a PlanApplyAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,133 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/v2/settings/fakes"
+)
+
+var _ = Describe("PlanApplyAction", func() {
+	var (
+		applier         *fakeappl.FakeApplier
+		specService     *fakeas.FakeV1Service
+		settingsService *fakesettings.FakeSettingsService
+		planApplyAction action.PlanApplyAction
+	)
+
+	BeforeEach(func() {
+		applier = fakeappl.NewFakeApplier()
+		specService = fakeas.NewFakeV1Service()
+		settingsService = &fakesettings.FakeSettingsService{}
+		planApplyAction = action.NewPlanApply(applier, specService, settingsService)
+	})
+
+	AssertActionIsNotAsynchronous(planApplyAction)
+	AssertActionIsNotPersistent(planApplyAction)
+	AssertActionIsLoggable(planApplyAction)
+	AssertActionIsNotCancelable(planApplyAction)
+	AssertActionIsNotResumable(planApplyAction)
+
+	Describe("Run", func() {
+		var (
+			currentApplySpec boshas.V1ApplySpec
+			desiredApplySpec boshas.V1ApplySpec
+		)
+
+		BeforeEach(func() {
+			settingsService.Settings = boshsettings.Settings{AgentID: "fake-agent-id"}
+
+			currentApplySpec = boshas.V1ApplySpec{
+				ConfigurationHash: "fake-current-config-hash",
+				PersistentDisk:    1024,
+				NetworkSpecs: map[string]boshas.NetworkSpec{
+					"default": {Fields: map[string]interface{}{"ip": "10.0.0.2"}},
+					"old":     {Fields: map[string]interface{}{"ip": "10.0.1.2"}},
+					"same":    {Fields: map[string]interface{}{"ip": "10.0.2.2"}},
+				},
+			}
+			specService.Spec = currentApplySpec
+
+			desiredApplySpec = boshas.V1ApplySpec{ConfigurationHash: "fake-desired-config-hash"}
+			specService.PopulateDHCPNetworksResultSpec = boshas.V1ApplySpec{
+				ConfigurationHash: "fake-desired-config-hash",
+				PersistentDisk:    2048,
+				NetworkSpecs: map[string]boshas.NetworkSpec{
+					"default": {Fields: map[string]interface{}{"ip": "10.0.0.3"}},
+					"new":     {Fields: map[string]interface{}{"ip": "10.0.3.2"}},
+					"same":    {Fields: map[string]interface{}{"ip": "10.0.2.2"}},
+				},
+			}
+
+			applier.PlanResult = boshappl.Plan{JobsToDownload: []string{"fake-job/fake-version"}}
+		})
+
+		It("plans applying the desired spec with dynamic networks populated", func() {
+			result, err := planApplyAction.Run(desiredApplySpec)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(specService.PopulateDHCPNetworksSpec).To(Equal(desiredApplySpec))
+			Expect(applier.PlanCurrentApplySpec).To(Equal(currentApplySpec))
+			Expect(applier.PlanDesiredApplySpec).To(Equal(specService.PopulateDHCPNetworksResultSpec))
+			Expect(result.Applier).To(Equal(&applier.PlanResult))
+		})
+
+		It("reports persistent disk and network changes", func() {
+			result, err := planApplyAction.Run(desiredApplySpec)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(result.PersistentDiskChanged).To(BeTrue())
+			Expect(result.NetworksChanged).To(Equal([]string{"default", "new", "old"}))
+		})
+
+		It("does not apply or persist the desired spec", func() {
+			_, err := planApplyAction.Run(desiredApplySpec)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(applier.Applied).To(BeFalse())
+			Expect(specService.ActionsCalled).To(Equal([]string{"Get", "PopulateDHCPNetworks"}))
+		})
+
+		It("does not plan the applier when desired spec has no configuration hash", func() {
+			specService.PopulateDHCPNetworksResultSpec.ConfigurationHash = ""
+
+			result, err := planApplyAction.Run(boshas.V1ApplySpec{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(applier.Planned).To(BeFalse())
+			Expect(result.Applier).To(BeNil())
+		})
+
+		It("returns error when getting the current spec fails", func() {
+			specService.GetErr = errors.New("fake-get-error")
+
+			_, err := planApplyAction.Run(desiredApplySpec)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-error"))
+		})
+
+		It("returns error when resolving dynamic networks fails", func() {
+			specService.PopulateDHCPNetworksErr = errors.New("fake-populate-dhcp-networks-err")
+
+			_, err := planApplyAction.Run(desiredApplySpec)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-populate-dhcp-networks-err"))
+		})
+
+		It("returns error when planning fails", func() {
+			applier.PlanError = errors.New("fake-plan-error")
+
+			_, err := planApplyAction.Run(desiredApplySpec)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-plan-error"))
+		})
+	})
+})
@@ -7,14 +7,17 @@ import (
 
 	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
 type PrepareAction struct {
-	applier boshappl.Applier
+	applier  boshappl.Applier
+	progress *boshtask.RunningProgress
 }
 
-func NewPrepare(applier boshappl.Applier) (action PrepareAction) {
+func NewPrepare(applier boshappl.Applier, progress *boshtask.RunningProgress) (action PrepareAction) {
 	action.applier = applier
+	action.progress = progress
 	return action
 }
 
@@ -43,6 +46,12 @@ func (a PrepareAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
 
+// Cancel stops further downloads
 func (a PrepareAction) Cancel() error {
-	return errors.New("not supported")
+	a.progress.Cancel()
+	return nil
+}
+
+func (a PrepareAction) TrackProgress(tracker *boshtask.ProgressTracker) func() {
+	return a.progress.Track(tracker)
 }
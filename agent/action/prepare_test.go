@@ -9,17 +9,20 @@ import (
 	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
 var _ = Describe("PrepareAction", func() {
 	var (
 		applier       *fakeappl.FakeApplier
+		progress      *boshtask.RunningProgress
 		prepareAction action.PrepareAction
 	)
 
 	BeforeEach(func() {
 		applier = fakeappl.NewFakeApplier()
-		prepareAction = action.NewPrepare(applier)
+		progress = boshtask.NewRunningProgress()
+		prepareAction = action.NewPrepare(applier, progress)
 	})
 
 	AssertActionIsAsynchronous(prepareAction)
@@ -27,7 +30,29 @@ var _ = Describe("PrepareAction", func() {
 	AssertActionIsLoggable(prepareAction)
 
 	AssertActionIsNotResumable(prepareAction)
-	AssertActionIsNotCancelable(prepareAction)
+
+	It("cancels downloads of the running task", func() {
+		tracker := boshtask.NewProgressTracker()
+		untrack := prepareAction.TrackProgress(tracker)
+		defer untrack()
+
+		Expect(prepareAction.Cancel()).To(Succeed())
+		Expect(tracker.Cancelled()).To(BeTrue())
+	})
+
+	It("reports download progress to the tracker of the running task", func() {
+		tracker := boshtask.NewProgressTracker()
+		untrack := prepareAction.TrackProgress(tracker)
+
+		progress.AddTotal(4)
+		progress.StepDone()
+		untrack()
+		progress.StepDone()
+
+		reported, found := tracker.Progress()
+		Expect(found).To(BeTrue())
+		Expect(reported).To(Equal(boshtask.Progress{Done: 1, Total: 4}))
+	})
 
 	Describe("Run", func() {
 		desiredApplySpec := boshas.V1ApplySpec{ConfigurationHash: "fake-desired-config-hash"}
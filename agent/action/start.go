@@ -46,13 +46,26 @@ func (a StartAction) Run() (value string, err error) {
 
 	err = a.applier.ConfigureJobs(desiredApplySpec)
 	if err != nil {
-		err = bosherr.WrapErrorf(err, "Configuring jobs")
+		err = a.rollback(bosherr.WrapErrorf(err, "Configuring jobs"))
 		return
 	}
 
 	err = a.jobSupervisor.Start()
 	if err != nil {
-		err = bosherr.WrapError(err, "Starting Monitored Services")
+		err = a.rollback(bosherr.WrapError(err, "Starting Monitored Services"))
+		return
+	}
+
+	// Jobs are running so bundles of the previous spec are no longer needed
+	err = a.applier.KeepOnly(desiredApplySpec)
+	if err != nil {
+		err = bosherr.WrapError(err, "Removing unused bundles")
+		return
+	}
+
+	err = a.specService.Commit()
+	if err != nil {
+		err = bosherr.WrapError(err, "Committing apply spec")
 		return
 	}
 
@@ -60,6 +73,36 @@ func (a StartAction) Run() (value string, err error) {
 	return
 }
 
+// rollback restores the last started spec if the current one was never started
+// and starts its jobs again. On the first deploy there is no started spec to restore.
+func (a StartAction) rollback(cause error) error {
+	previousApplySpec, found, err := a.specService.GetPrevious()
+	if err != nil {
+		return bosherr.NewMultiError(cause, bosherr.WrapError(err, "Getting previous apply spec"))
+	}
+
+	if !found || !previousApplySpec.IsDeployed() {
+		return cause
+	}
+
+	err = a.applier.Rollback(previousApplySpec)
+	if err != nil {
+		return rollbackError(bosherr.NewMultiError(cause, bosherr.WrapError(err, "Rolling back to the previous apply spec")), false)
+	}
+
+	err = a.specService.Rollback()
+	if err != nil {
+		return rollbackError(bosherr.NewMultiError(cause, bosherr.WrapError(err, "Restoring the previous apply spec")), false)
+	}
+
+	err = a.jobSupervisor.Start()
+	if err != nil {
+		return rollbackError(bosherr.NewMultiError(cause, bosherr.WrapError(err, "Starting the jobs of the previous apply spec")), true)
+	}
+
+	return rollbackError(bosherr.WrapError(cause, "Rolled back to the previous apply spec"), true)
+}
+
 func (a StartAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
@@ -7,8 +7,10 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
 	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
+	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/fakes"
 )
 
@@ -59,4 +61,102 @@ var _ = Describe("Start", func() {
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("Configuring jobs"))
 	})
+
+	It("removes bundles of the previous spec and commits the current spec", func() {
+		specService.Spec = boshas.V1ApplySpec{ConfigurationHash: "fake-current-config-hash"}
+
+		_, err := startAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(applier.KeepOnlyApplySpecs).To(Equal([]boshas.ApplySpec{specService.Spec}))
+		Expect(specService.ActionsCalled).To(ContainElement("Commit"))
+	})
+
+	It("returns error when committing the current spec fails", func() {
+		specService.CommitErr = errors.New("fake-commit-error")
+
+		_, err := startAction.Run()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-commit-error"))
+	})
+
+	Context("when a spec was applied but never started", func() {
+		previousApplySpec := boshas.V1ApplySpec{
+			ConfigurationHash: "fake-previous-config-hash",
+			JobSpec:           boshas.JobSpec{JobTemplateSpecs: []boshas.JobTemplateSpec{{Name: "fake-job"}}},
+		}
+
+		BeforeEach(func() {
+			specService.Spec = boshas.V1ApplySpec{ConfigurationHash: "fake-current-config-hash"}
+			specService.PreviousSpec = previousApplySpec
+			specService.PreviousFound = true
+		})
+
+		It("rolls back to the previous spec when configuring jobs fails", func() {
+			applier.ConfiguredError = errors.New("fake-configure-error")
+
+			_, err := startAction.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-configure-error"))
+			Expect(err.Error()).To(ContainSubstring("Rolled back to the previous apply spec"))
+
+			Expect(applier.RollbackPreviousApplySpec).To(Equal(previousApplySpec))
+			Expect(specService.Spec).To(Equal(previousApplySpec))
+			Expect(applier.KeptOnly).To(BeFalse())
+			Expect(err.(boshhandler.DetailedError).Details).To(Equal(map[string]interface{}{"rolled_back": true}))
+		})
+
+		It("starts the jobs of the previous spec after rolling back", func() {
+			applier.ConfiguredError = errors.New("fake-configure-error")
+
+			_, err := startAction.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(jobSupervisor.Started).To(BeTrue())
+		})
+
+		It("rolls back to the previous spec when starting monitored services fails", func() {
+			jobSupervisor.StartErr = errors.New("fake-start-error")
+
+			_, err := startAction.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Starting the jobs of the previous apply spec"))
+			Expect(applier.RollbackPreviousApplySpec).To(Equal(previousApplySpec))
+			Expect(specService.Spec).To(Equal(previousApplySpec))
+			Expect(err.(boshhandler.DetailedError).Details).To(Equal(map[string]interface{}{"rolled_back": true}))
+		})
+
+		It("reports both errors when rolling back fails", func() {
+			applier.ConfiguredError = errors.New("fake-configure-error")
+			applier.RollbackError = errors.New("fake-rollback-error")
+
+			_, err := startAction.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-configure-error"))
+			Expect(err.Error()).To(ContainSubstring("fake-rollback-error"))
+			Expect(specService.ActionsCalled).ToNot(ContainElement("Rollback"))
+		})
+	})
+
+	It("does not roll back to the empty spec of a new VM when the first start fails", func() {
+		specService.Spec = boshas.V1ApplySpec{ConfigurationHash: "fake-current-config-hash"}
+		specService.PreviousSpec = boshas.V1ApplySpec{}
+		specService.PreviousFound = true
+		applier.ConfiguredError = errors.New("fake-configure-error")
+
+		_, err := startAction.Run()
+		Expect(err).To(MatchError(ContainSubstring("fake-configure-error")))
+		Expect(err.Error()).ToNot(ContainSubstring("Rolled back"))
+		Expect(applier.RolledBack).To(BeFalse())
+		Expect(specService.ActionsCalled).ToNot(ContainElement("Rollback"))
+		Expect(specService.Spec.ConfigurationHash).To(Equal("fake-current-config-hash"))
+	})
+
+	It("does not roll back when there is no previous spec", func() {
+		applier.ConfiguredError = errors.New("fake-configure-error")
+
+		_, err := startAction.Run()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).ToNot(ContainSubstring("Rolled back"))
+		Expect(applier.RolledBack).To(BeFalse())
+	})
 })
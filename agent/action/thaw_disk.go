@@ -0,0 +1,43 @@
+package action
+
+import (
+	"errors"
+)
+
+type ThawDiskAction struct {
+	diskFreezer *DiskFreezer
+}
+
+func NewThawDisk(diskFreezer *DiskFreezer) ThawDiskAction {
+	return ThawDiskAction{diskFreezer: diskFreezer}
+}
+
+// IsAsynchronous is false so that thawing is not queued behind other tasks while the disk is frozen
+func (a ThawDiskAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a ThawDiskAction) IsPersistent() bool {
+	return false
+}
+
+func (a ThawDiskAction) IsLoggable() bool {
+	return true
+}
+
+func (a ThawDiskAction) Run(diskCID string) (map[string]string, error) {
+	err := a.diskFreezer.Thaw(diskCID)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{}, nil
+}
+
+func (a ThawDiskAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a ThawDiskAction) Cancel() error {
+	return errors.New("not supported")
+}
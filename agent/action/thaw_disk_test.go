@@ -0,0 +1,133 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	fakeapplyspec "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/script/scriptfakes"
+	"github.com/cloudfoundry/bosh-agent/v2/platform/platformfakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+	fakesettings "github.com/cloudfoundry/bosh-agent/v2/settings/fakes"
+)
+
+var _ = Describe("ThawDiskAction", func() {
+	var (
+		platform          *platformfakes.FakePlatform
+		cmdRunner         *fakesys.FakeCmdRunner
+		jobScriptProvider *scriptfakes.FakeJobScriptProvider
+		parallelScripts   map[string]*scriptfakes.FakeCancellableScript
+		freezeDiskAction  action.FreezeDiskAction
+		thawDiskAction    action.ThawDiskAction
+	)
+
+	BeforeEach(func() {
+		platform = &platformfakes.FakePlatform{}
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		platform.GetRunnerReturns(cmdRunner)
+		platform.IsPersistentDiskMountedReturns(true, nil)
+
+		settingsService := &fakesettings.FakeSettingsService{
+			PersistentDiskSettings: map[string]boshsettings.DiskSettings{
+				"vol-123": {ID: "vol-123", Path: "/dev/sdf"},
+			},
+		}
+
+		specService := fakeapplyspec.NewFakeV1Service()
+		specService.Spec.RenderedTemplatesArchiveSpec = &applyspec.RenderedTemplatesArchiveSpec{}
+		specService.Spec.JobSpec.JobTemplateSpecs = []applyspec.JobTemplateSpec{{Name: "fake-job"}}
+
+		parallelScripts = map[string]*scriptfakes.FakeCancellableScript{
+			"pre-snapshot":  {},
+			"post-snapshot": {},
+		}
+		jobScriptProvider = &scriptfakes.FakeJobScriptProvider{}
+		jobScriptProvider.NewScriptReturns(&scriptfakes.FakeScript{})
+		jobScriptProvider.NewParallelScriptStub = func(scriptName string, _ []boshscript.Script) boshscript.CancellableScript {
+			return parallelScripts[scriptName]
+		}
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		diskFreezer := action.NewDiskFreezer(
+			settingsService,
+			platform,
+			boshdirs.NewProvider("/var/vcap"),
+			jobScriptProvider,
+			specService,
+			fakeclock.NewFakeClock(time.Now()),
+			logger,
+		)
+		freezeDiskAction = action.NewFreezeDisk(diskFreezer)
+		thawDiskAction = action.NewThawDisk(diskFreezer)
+	})
+
+	AssertActionIsNotAsynchronous(action.ThawDiskAction{})
+	AssertActionIsNotPersistent(action.ThawDiskAction{})
+	AssertActionIsLoggable(action.ThawDiskAction{})
+
+	AssertActionIsNotResumable(action.ThawDiskAction{})
+	AssertActionIsNotCancelable(action.ThawDiskAction{})
+
+	Context("when the disk is frozen", func() {
+		BeforeEach(func() {
+			_, err := freezeDiskAction.Run("vol-123")
+			Expect(err).ToNot(HaveOccurred())
+			cmdRunner.ClearCommandHistory()
+		})
+
+		It("thaws the store directory and then runs the post-snapshot scripts of all jobs", func() {
+			parallelScripts["post-snapshot"].RunStub = func() error {
+				Expect(cmdRunner.RunCommands).To(HaveLen(1))
+				return nil
+			}
+
+			result, err := thawDiskAction.Run("vol-123")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(map[string]string{}))
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"fsfreeze", "--unfreeze", "/var/vcap/store"}}))
+			Expect(parallelScripts["post-snapshot"].RunCallCount()).To(Equal(1))
+
+			_, err = freezeDiskAction.Run("vol-123")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("keeps the disk frozen when the file system cannot be thawed", func() {
+			cmdRunner.AddCmdResult("fsfreeze --unfreeze /var/vcap/store", fakesys.FakeCmdResult{Error: errors.New("fake-thaw-error")})
+
+			_, err := thawDiskAction.Run("vol-123")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-thaw-error"))
+			Expect(parallelScripts["post-snapshot"].RunCallCount()).To(Equal(0))
+
+			_, err = thawDiskAction.Run("vol-123")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parallelScripts["post-snapshot"].RunCallCount()).To(Equal(1))
+		})
+
+		It("returns an error when a post-snapshot script fails", func() {
+			parallelScripts["post-snapshot"].RunReturns(errors.New("fake-script-error"))
+
+			_, err := thawDiskAction.Run("vol-123")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-script-error"))
+		})
+	})
+
+	It("returns an error when the disk is not frozen", func() {
+		_, err := thawDiskAction.Run("vol-123")
+		Expect(err).To(MatchError("Persistent disk 'vol-123' is not frozen, it may have been thawed automatically"))
+		Expect(cmdRunner.RunCommands).To(BeEmpty())
+	})
+})
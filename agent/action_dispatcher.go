@@ -1,6 +1,13 @@
 package agent
 
 import (
+	"encoding/json"
+	"regexp"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
@@ -20,23 +27,29 @@ type concreteActionDispatcher struct {
 	logger        boshlog.Logger
 	taskService   boshtask.Service
 	taskManager   boshtask.Manager
+	taskHistory   boshtask.History
 	actionFactory boshaction.Factory
 	actionRunner  boshaction.Runner
+	timeService   clock.Clock
 }
 
 func NewActionDispatcher(
 	logger boshlog.Logger,
 	taskService boshtask.Service,
 	taskManager boshtask.Manager,
+	taskHistory boshtask.History,
 	actionFactory boshaction.Factory,
 	actionRunner boshaction.Runner,
+	timeService clock.Clock,
 ) (dispatcher ActionDispatcher) {
 	return concreteActionDispatcher{
 		logger:        logger,
 		taskService:   taskService,
 		taskManager:   taskManager,
+		taskHistory:   taskHistory,
 		actionFactory: actionFactory,
 		actionRunner:  actionRunner,
+		timeService:   timeService,
 	}
 }
 
@@ -64,13 +77,17 @@ func (dispatcher concreteActionDispatcher) ResumePreviouslyDispatchedTasks() {
 		taskID := taskInfo.TaskID
 		payload := taskInfo.Payload
 
-		task := dispatcher.taskService.CreateTaskWithID(
-			taskID,
+		runTask, cancelTask, endTask, progressFunc := dispatcher.recordTask(
+			action,
+			taskInfo.Method,
+			payload,
+			true,
 			func() (interface{}, error) { return dispatcher.actionRunner.Resume(action, payload) },
-			func(_ boshtask.Task) error { return action.Cancel() },
-			dispatcher.removeInfo,
 		)
 
+		task := dispatcher.taskService.CreateTaskWithID(taskID, runTask, cancelTask, endTask)
+		task.ProgressFunc = progressFunc
+
 		dispatcher.taskService.StartTask(task)
 	}
 }
@@ -103,18 +120,22 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 	var task boshtask.Task
 	var err error
 
-	runTask := func() (interface{}, error) {
-		return dispatcher.actionRunner.Run(action, req.GetPayload(), boshaction.ProtocolVersion(req.ProtocolVersion))
-	}
-
-	cancelTask := func(_ boshtask.Task) error { return action.Cancel() }
+	runTask, cancelTask, endTask, progressFunc := dispatcher.recordTask(
+		action,
+		req.Method,
+		req.GetPayload(),
+		action.IsPersistent(),
+		func() (interface{}, error) {
+			return dispatcher.actionRunner.Run(action, req.GetPayload(), boshaction.ProtocolVersion(req.ProtocolVersion))
+		},
+	)
 
 	// Certain long-running tasks (e.g. configure_networks) must be resumed
 	// after agent restart so that API consumers do not need to know
 	// if agent is restarted midway through the task.
 	if action.IsPersistent() {
 		dispatcher.logger.Info(actionDispatcherLogTag, "Running persistent action %s", req.Method)
-		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, dispatcher.removeInfo)
+		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, endTask)
 		if err != nil {
 			err = bosherr.WrapErrorf(err, "Create Task Failed %s", req.Method)
 			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
@@ -134,7 +155,7 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 			return boshhandler.NewExceptionResponse(err)
 		}
 	} else {
-		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, nil)
+		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, endTask)
 		if err != nil {
 			err = bosherr.WrapErrorf(err, "Create Task Failed %s", req.Method)
 			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
@@ -142,6 +163,8 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 		}
 	}
 
+	task.ProgressFunc = progressFunc
+
 	dispatcher.taskService.StartTask(task)
 
 	return boshhandler.NewValueResponse(boshtask.StateValue{
@@ -174,3 +197,168 @@ func (dispatcher concreteActionDispatcher) removeInfo(task boshtask.Task) {
 		dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
 	}
 }
+
+// recordTask wraps the func of an asynchronous task so that it notes when
+// the task starts running and builds its cancel func, its progress func for
+// actions that report progress and its end func, which records the task
+// outcome in the task history and, for persistent actions, forgets the task
+// so that it is not resumed again.
+func (dispatcher concreteActionDispatcher) recordTask(
+	action boshaction.Action,
+	method string,
+	payload []byte,
+	persistent bool,
+	run boshtask.Func,
+) (boshtask.Func, boshtask.CancelFunc, boshtask.EndFunc, boshtask.ProgressFunc) {
+	var arguments json.RawMessage
+	if action.IsLoggable() {
+		arguments = sanitizeArguments(dispatcher.extractArguments(payload))
+	}
+
+	// Tasks may be queued for a while, so the start time is taken once the task
+	// runs; the task service runs a task and its end func on the same goroutine
+	var startedAt time.Time
+
+	// Every task has its own tracker. Actions are shared by all of their tasks
+	// and busy with the running one, so a task cancelled while it is queued is
+	// only marked as cancelled on its tracker and then not run at all.
+	tracker := boshtask.NewProgressTracker()
+	reporter, reportsProgress := action.(boshaction.ProgressReporter)
+
+	var startLock sync.Mutex
+	var started bool
+
+	runTask := func() (interface{}, error) {
+		startedAt = dispatcher.timeService.Now()
+
+		startLock.Lock()
+		started = true
+		startLock.Unlock()
+
+		if tracker.Cancelled() {
+			return nil, bosherr.Error("Task was cancelled before it started")
+		}
+
+		if reportsProgress {
+			untrack := reporter.TrackProgress(tracker)
+			defer untrack()
+		}
+
+		return run()
+	}
+
+	cancelTask := func(_ boshtask.Task) error {
+		tracker.Cancel()
+
+		startLock.Lock()
+		running := started
+		startLock.Unlock()
+
+		if !running {
+			return nil
+		}
+
+		return action.Cancel()
+	}
+
+	var progressFunc boshtask.ProgressFunc
+	if reportsProgress {
+		progressFunc = tracker.Progress
+	}
+
+	return runTask, cancelTask, func(task boshtask.Task) {
+		if persistent {
+			dispatcher.removeInfo(task)
+		}
+
+		finishedAt := dispatcher.timeService.Now()
+		if startedAt.IsZero() {
+			startedAt = finishedAt
+		}
+
+		entry := boshtask.HistoryEntry{
+			TaskID:     task.ID,
+			Method:     method,
+			Arguments:  arguments,
+			State:      task.State,
+			StartedAt:  startedAt,
+			FinishedAt: finishedAt,
+		}
+
+		if task.Error != nil {
+			entry.Error = task.Error.Error()
+		} else if task.Value != nil {
+			value, err := json.Marshal(task.Value)
+			if err != nil {
+				dispatcher.logger.Warn(actionDispatcherLogTag, "Failed to marshal result of task %s: %s", task.ID, err.Error())
+			} else {
+				entry.Value = value
+			}
+		}
+
+		err := dispatcher.taskHistory.Record(entry)
+		if err != nil {
+			dispatcher.logger.Error(actionDispatcherLogTag, "Failed to record task history: %s", err.Error())
+		}
+	}, progressFunc
+}
+
+func (dispatcher concreteActionDispatcher) extractArguments(payload []byte) json.RawMessage {
+	var parsedPayload struct {
+		Arguments json.RawMessage `json:"arguments"`
+	}
+
+	err := json.Unmarshal(payload, &parsedPayload)
+	if err != nil {
+		return nil
+	}
+
+	return parsedPayload.Arguments
+}
+
+// sensitiveArgumentKey matches object keys in action arguments whose values
+// may hold credentials, such as settings, blobstore options, keys and signed URLs
+var sensitiveArgumentKey = regexp.MustCompile(`(?i)password|secret|token|credential|key|cert|settings|env|properties|headers|url`)
+
+const redactedArgument = "<redacted>"
+
+// sanitizeArguments redacts the values of sensitive keys in action arguments
+// before they are stored in the task history
+func sanitizeArguments(arguments json.RawMessage) json.RawMessage {
+	if arguments == nil {
+		return nil
+	}
+
+	var parsed interface{}
+
+	err := json.Unmarshal(arguments, &parsed)
+	if err != nil {
+		return nil
+	}
+
+	sanitized, err := json.Marshal(sanitizeArgument(parsed))
+	if err != nil {
+		return nil
+	}
+
+	return sanitized
+}
+
+func sanitizeArgument(argument interface{}) interface{} {
+	switch typed := argument.(type) {
+	case map[string]interface{}:
+		for key, value := range typed {
+			if sensitiveArgumentKey.MatchString(key) {
+				typed[key] = redactedArgument
+			} else {
+				typed[key] = sanitizeArgument(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range typed {
+			typed[i] = sanitizeArgument(value)
+		}
+	}
+
+	return argument
+}
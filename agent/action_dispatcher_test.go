@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -25,6 +28,8 @@ func init() { //nolint:funlen,gochecknoinits
 			logger        *fakes.FakeLogger
 			taskService   *faketask.FakeService
 			taskManager   *faketask.FakeManager
+			taskHistory   *faketask.FakeHistory
+			timeService   *fakeclock.FakeClock
 			actionFactory *fakeaction.FakeFactory
 			actionRunner  *fakeaction.FakeRunner
 			dispatcher    agent.ActionDispatcher
@@ -34,9 +39,11 @@ func init() { //nolint:funlen,gochecknoinits
 			logger = &fakes.FakeLogger{}
 			taskService = faketask.NewFakeService()
 			taskManager = faketask.NewFakeManager()
+			taskHistory = faketask.NewFakeHistory()
+			timeService = fakeclock.NewFakeClock(time.Unix(1000, 0))
 			actionFactory = fakeaction.NewFakeFactory()
 			actionRunner = &fakeaction.FakeRunner{}
-			dispatcher = agent.NewActionDispatcher(logger, taskService, taskManager, actionFactory, actionRunner)
+			dispatcher = agent.NewActionDispatcher(logger, taskService, taskManager, taskHistory, actionFactory, actionRunner, timeService)
 		})
 
 		It("responds with exception when the method is unknown", func() {
@@ -192,20 +199,44 @@ func init() { //nolint:funlen,gochecknoinits
 			})
 
 			ItAllowsToCancelTask := func() {
-				It("allows task to be cancelled", func() {
+				It("allows running task to be cancelled", func() {
 					dispatcher.Dispatch(req)
 
-					err := taskService.StartedTasks["fake-generated-task-id"].Cancel()
+					task := taskService.StartedTasks["fake-generated-task-id"]
+					_, err := task.Func()
 					Expect(err).ToNot(HaveOccurred())
 
+					Expect(task.Cancel()).To(Succeed())
 					Expect(action.Canceled).To(BeTrue())
 				})
 
+				It("does not run a task that was cancelled before it started", func() {
+					dispatcher.Dispatch(req)
+
+					task := taskService.StartedTasks["fake-generated-task-id"]
+					Expect(task.Cancel()).To(Succeed())
+
+					_, err := task.Func()
+					Expect(err).To(MatchError(ContainSubstring("Task was cancelled before it started")))
+					Expect(actionRunner.RunAction).To(BeNil())
+				})
+
+				It("does not cancel the action, which is busy with another task, for a queued task", func() {
+					dispatcher.Dispatch(req)
+
+					Expect(taskService.StartedTasks["fake-generated-task-id"].Cancel()).To(Succeed())
+					Expect(action.Canceled).To(BeFalse())
+				})
+
 				It("returns error from cancelling task if canceling task fails", func() {
 					action.CancelErr = errors.New("fake-cancel-err")
 					dispatcher.Dispatch(req)
 
-					err := taskService.StartedTasks["fake-generated-task-id"].Cancel()
+					task := taskService.StartedTasks["fake-generated-task-id"]
+					_, err := task.Func()
+					Expect(err).ToNot(HaveOccurred())
+
+					err = task.Cancel()
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-cancel-err"))
 				})
@@ -228,6 +259,44 @@ func init() { //nolint:funlen,gochecknoinits
 					Expect(taskService.StartedTasks["fake-generated-task-id"]).ToNot(BeNil())
 				})
 
+				Context("when action reports progress", func() {
+					var progressAction *fakeaction.TestProgressAction
+
+					BeforeEach(func() {
+						progressAction = &fakeaction.TestProgressAction{TestAction: fakeaction.TestAction{Asynchronous: true}}
+						actionFactory.RegisterAction("fake-progress-action", progressAction)
+					})
+
+					It("reports progress of the task to its own tracker while the task runs", func() {
+						dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "fake-progress-action", []byte("fake-payload"), 0))
+
+						task := taskService.StartedTasks["fake-generated-task-id"]
+						_, err := task.Func()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(progressAction.Untracked).To(BeTrue())
+
+						progressAction.Tracker.AddTotal(2)
+						progressAction.Tracker.StepDone()
+
+						progress, reported := task.ProgressFunc()
+						Expect(reported).To(BeTrue())
+						Expect(progress).To(Equal(boshtask.Progress{Done: 1, Total: 2}))
+					})
+
+					It("does not report progress of a queued task", func() {
+						dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "fake-progress-action", []byte("fake-payload"), 0))
+
+						_, reported := taskService.StartedTasks["fake-generated-task-id"].ProgressFunc()
+						Expect(reported).To(BeFalse())
+						Expect(progressAction.Tracker).To(BeNil())
+					})
+				})
+
+				It("does not report progress of other actions", func() {
+					dispatcher.Dispatch(req)
+					Expect(taskService.StartedTasks["fake-generated-task-id"].ProgressFunc).To(BeNil())
+				})
+
 				It("returns create task error", func() {
 					taskService.CreateTaskErr = errors.New("fake-create-task-error")
 					resp := dispatcher.Dispatch(req)
@@ -269,9 +338,90 @@ func init() { //nolint:funlen,gochecknoinits
 					Expect(taskInfos).To(BeEmpty())
 				})
 
-				It("does not do anything after task finishes", func() {
+				It("records the task in the task history after task finishes", func() {
+					dispatcher.Dispatch(req)
+
+					// the task waits in the queue before it runs
+					timeService.Increment(3 * time.Second)
+					_, err := taskService.StartedTasks["fake-generated-task-id"].Func()
+					Expect(err).ToNot(HaveOccurred())
+
+					timeService.Increment(5 * time.Second)
+					taskService.StartedTasks["fake-generated-task-id"].EndFunc(boshtask.Task{
+						ID:    "fake-generated-task-id",
+						State: boshtask.StateDone,
+						Value: map[string]string{"fake-key": "fake-value"},
+					})
+
+					Expect(taskHistory.RecordedEntries).To(Equal([]boshtask.HistoryEntry{
+						{
+							TaskID:     "fake-generated-task-id",
+							Method:     "fake-action",
+							State:      boshtask.StateDone,
+							StartedAt:  time.Unix(1003, 0),
+							FinishedAt: time.Unix(1008, 0),
+							Value:      json.RawMessage(`{"fake-key":"fake-value"}`),
+						},
+					}))
+				})
+
+				It("records the task error in the task history", func() {
 					dispatcher.Dispatch(req)
-					Expect(taskService.StartedTasks["fake-generated-task-id"].EndFunc).To(BeNil())
+
+					taskService.StartedTasks["fake-generated-task-id"].EndFunc(boshtask.Task{
+						ID:    "fake-generated-task-id",
+						State: boshtask.StateFailed,
+						Error: errors.New("fake-task-error"),
+					})
+
+					Expect(taskHistory.RecordedEntries).To(HaveLen(1))
+					Expect(taskHistory.RecordedEntries[0].State).To(Equal(boshtask.StateFailed))
+					Expect(taskHistory.RecordedEntries[0].Error).To(Equal("fake-task-error"))
+					Expect(taskHistory.RecordedEntries[0].Value).To(BeNil())
+				})
+
+				Context("when action is loggable", func() {
+					BeforeEach(func() {
+						action.Loggable = true
+						req = boshhandler.NewRequest("fake-reply", "fake-action", []byte(`{"arguments":["fake-arg"]}`), 0)
+					})
+
+					It("records the task arguments in the task history", func() {
+						dispatcher.Dispatch(req)
+						taskService.StartedTasks["fake-generated-task-id"].EndFunc(boshtask.Task{ID: "fake-generated-task-id"})
+
+						Expect(taskHistory.RecordedEntries).To(HaveLen(1))
+						Expect(string(taskHistory.RecordedEntries[0].Arguments)).To(Equal(`["fake-arg"]`))
+					})
+
+					It("redacts sensitive arguments in the task history", func() {
+						req = boshhandler.NewRequest("fake-reply", "fake-action", []byte(`{"arguments":[`+
+							`{"settings":{"agent_id":"fake-id"},"blobstore_id":"fake-blob-id","nested":[{"private_key":"fake-key","name":"fake-name"}]},`+
+							`"fake-arg"]}`), 0)
+
+						dispatcher.Dispatch(req)
+						taskService.StartedTasks["fake-generated-task-id"].EndFunc(boshtask.Task{ID: "fake-generated-task-id"})
+
+						Expect(taskHistory.RecordedEntries).To(HaveLen(1))
+						Expect(taskHistory.RecordedEntries[0].Arguments).To(MatchJSON(`[` +
+							`{"settings":"<redacted>","blobstore_id":"fake-blob-id","nested":[{"private_key":"<redacted>","name":"fake-name"}]},` +
+							`"fake-arg"]`))
+					})
+				})
+
+				Context("when action is not loggable", func() {
+					BeforeEach(func() {
+						action.Loggable = false
+						req = boshhandler.NewRequest("fake-reply", "fake-action", []byte(`{"arguments":["fake-secret"]}`), 0)
+					})
+
+					It("does not record the task arguments in the task history", func() {
+						dispatcher.Dispatch(req)
+						taskService.StartedTasks["fake-generated-task-id"].EndFunc(boshtask.Task{ID: "fake-generated-task-id"})
+
+						Expect(taskHistory.RecordedEntries).To(HaveLen(1))
+						Expect(taskHistory.RecordedEntries[0].Arguments).To(BeNil())
+					})
 				})
 			})
 
@@ -423,6 +573,20 @@ func init() { //nolint:funlen,gochecknoinits
 				Expect(taskInfos).To(BeEmpty())
 			})
 
+			It("records resumed tasks in the task history after each task finishes", func() {
+				actionFactory.RegisterAction("fake-action-1", firstAction)
+				actionFactory.RegisterAction("fake-action-2", secondAction)
+
+				dispatcher.ResumePreviouslyDispatchedTasks()
+
+				taskService.StartedTasks["fake-task-id-1"].EndFunc(boshtask.Task{ID: "fake-task-id-1", State: boshtask.StateDone})
+
+				Expect(taskHistory.RecordedEntries).To(HaveLen(1))
+				Expect(taskHistory.RecordedEntries[0].TaskID).To(Equal("fake-task-id-1"))
+				Expect(taskHistory.RecordedEntries[0].Method).To(Equal("fake-action-1"))
+				Expect(taskHistory.RecordedEntries[0].State).To(Equal(boshtask.StateDone))
+			})
+
 			It("return resume error to each task", func() {
 				actionFactory.RegisterAction("fake-action-1", firstAction)
 				actionFactory.RegisterAction("fake-action-2", secondAction)
@@ -484,7 +648,12 @@ func init() { //nolint:funlen,gochecknoinits
 
 				dispatcher.ResumePreviouslyDispatchedTasks()
 
-				err := taskService.StartedTasks["fake-task-id-1"].Cancel()
+				_, err := taskService.StartedTasks["fake-task-id-1"].Func()
+				Expect(err).ToNot(HaveOccurred())
+				_, err = taskService.StartedTasks["fake-task-id-2"].Func()
+				Expect(err).ToNot(HaveOccurred())
+
+				err = taskService.StartedTasks["fake-task-id-1"].Cancel()
 				Expect(err).ToNot(HaveOccurred())
 				Expect(firstAction.Canceled).To(BeTrue())
 				Expect(secondAction.Canceled).To(BeFalse())
@@ -503,7 +672,12 @@ func init() { //nolint:funlen,gochecknoinits
 				firstAction.CancelErr = errors.New("fake-cancel-err-1")
 				secondAction.CancelErr = errors.New("fake-cancel-err-2")
 
-				err := taskService.StartedTasks["fake-task-id-1"].Cancel()
+				_, err := taskService.StartedTasks["fake-task-id-1"].Func()
+				Expect(err).ToNot(HaveOccurred())
+				_, err = taskService.StartedTasks["fake-task-id-2"].Func()
+				Expect(err).ToNot(HaveOccurred())
+
+				err = taskService.StartedTasks["fake-task-id-1"].Cancel()
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-cancel-err-1"))
 
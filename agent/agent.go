@@ -21,11 +21,18 @@ import (
 const (
 	agentLogTag         = "agent"
 	heartbeatMaxRetries = 60
+
+	// alertReceiverQueueSize bounds the alerts waiting for a receiver that is slow or unreachable
+	alertReceiverQueueSize = 100
 )
 
 var (
 	// HeartbeatRetryInterval TODO: remove 'nolint:gochecknoglobals' - should be passed in rather than re-defined in agent_test.go
 	HeartbeatRetryInterval = 1 * time.Second //nolint:gochecknoglobals
+
+	// AlertReceiverMinBackoff and AlertReceiverMaxBackoff bound the time between attempts to send an alert to a receiver
+	AlertReceiverMinBackoff = 1 * time.Second  //nolint:gochecknoglobals
+	AlertReceiverMaxBackoff = 60 * time.Second //nolint:gochecknoglobals
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . StartManager
@@ -47,6 +54,9 @@ type Agent struct {
 	uuidGenerator     boshuuid.Generator
 	timeService       clock.Clock
 	startManager      StartManager
+	heartbeatSinks    []HeartbeatSink
+	alertPipeline     boshalert.Pipeline
+	alertReceivers    []boshalert.Receiver
 }
 
 func New(
@@ -61,6 +71,9 @@ func New(
 	uuidGenerator boshuuid.Generator,
 	timeService clock.Clock,
 	startManager StartManager,
+	heartbeatSinks []HeartbeatSink,
+	alertPipeline boshalert.Pipeline,
+	alertReceivers []boshalert.Receiver,
 ) Agent {
 	return Agent{
 		logger:            logger,
@@ -74,6 +87,9 @@ func New(
 		uuidGenerator:     uuidGenerator,
 		timeService:       timeService,
 		startManager:      startManager,
+		heartbeatSinks:    heartbeatSinks,
+		alertPipeline:     alertPipeline,
+		alertReceivers:    alertReceivers,
 	}
 }
 
@@ -93,6 +109,12 @@ func (a Agent) Run() error {
 
 	go a.generateHeartbeats(errCh)
 
+	for _, sink := range a.heartbeatSinks {
+		go a.generateSinkHeartbeats(sink)
+	}
+
+	go a.forwardAlerts(a.startAlertReceivers())
+
 	go func() {
 		err := a.jobSupervisor.MonitorJobFailures(a.handleJobFailure(errCh))
 		if err != nil {
@@ -169,6 +191,34 @@ func (a Agent) sendAndRecordHeartbeat(errCh chan error, retry bool) {
 	}
 }
 
+// generateSinkHeartbeats sends heartbeats to an additional sink on its own
+// interval; failures are only logged so that they do not affect the agent
+func (a Agent) generateSinkHeartbeats(sink HeartbeatSink) {
+	defer a.logger.HandlePanic("Agent Generate Sink Heartbeats")
+
+	a.sendSinkHeartbeat(sink)
+
+	ticker := a.timeService.NewTicker(sink.Interval())
+	defer ticker.Stop()
+
+	for range ticker.C() {
+		a.sendSinkHeartbeat(sink)
+	}
+}
+
+func (a Agent) sendSinkHeartbeat(sink HeartbeatSink) {
+	heartbeat, err := a.getHeartbeat(a.jobSupervisor.Status())
+	if err != nil {
+		a.logger.Error(agentLogTag, "Building heartbeat for %s: %s", sink.Name(), err.Error())
+		return
+	}
+
+	err = sink.Send(heartbeat)
+	if err != nil {
+		a.logger.Error(agentLogTag, "Sending heartbeat to %s: %s", sink.Name(), err.Error())
+	}
+}
+
 func (a Agent) getHeartbeat(status string) (Heartbeat, error) {
 	a.logger.Debug(agentLogTag, "Building heartbeat")
 	vitalsService := a.platform.GetVitalsService()
@@ -221,11 +271,83 @@ func (a Agent) handleJobFailure(errCh chan error) boshjobsuper.JobFailureHandler
 			errCh <- bosherr.WrapError(err, "Adapting monit alert")
 		}
 
-		err = a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+		err = a.alertPipeline.Add(monitAlert.Service, monitAlert.Event, alert)
 		if err != nil {
-			errCh <- bosherr.WrapError(err, "Sending monit alert")
+			a.logger.Error(agentLogTag, "Queueing monit alert: %s", err.Error())
 		}
 
 		return nil
 	}
 }
+
+// forwardAlerts hands alerts that passed deduplication and rate limiting to the
+// receivers as soon as the pipeline accepts them and sends them to the health monitor.
+// The pipeline retries alerts that could not be sent until the message bus is back,
+// while receivers retry on their own, so they get every alert once either way.
+func (a Agent) forwardAlerts(receiverQueues []chan boshalert.Alert) {
+	defer a.logger.HandlePanic("Agent Forward Alerts")
+
+	a.alertPipeline.Run(
+		func(alert boshalert.Alert) {
+			a.queueAlertForReceivers(receiverQueues, alert)
+		},
+		func(alert boshalert.Alert) error {
+			return a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+		},
+		nil,
+	)
+}
+
+// startAlertReceivers sends the alerts queued for each receiver one after another
+func (a Agent) startAlertReceivers() []chan boshalert.Alert {
+	queues := make([]chan boshalert.Alert, 0, len(a.alertReceivers))
+
+	for _, receiver := range a.alertReceivers {
+		queue := make(chan boshalert.Alert, alertReceiverQueueSize)
+		queues = append(queues, queue)
+
+		go func() {
+			for alert := range queue {
+				a.sendAlertToReceiver(receiver, alert)
+			}
+		}()
+	}
+
+	return queues
+}
+
+// queueAlertForReceivers does not block the pipeline; receivers that fall too far behind miss alerts
+func (a Agent) queueAlertForReceivers(receiverQueues []chan boshalert.Alert, alert boshalert.Alert) {
+	for i, queue := range receiverQueues {
+		select {
+		case queue <- alert:
+		default:
+			a.logger.Error(agentLogTag, "Dropping alert '%s' for %s: too many alerts are waiting", alert.ID, a.alertReceivers[i].Name())
+		}
+	}
+}
+
+// sendAlertToReceiver retries with backoff independently of the message bus;
+// failures are only logged so that they do not affect the agent
+func (a Agent) sendAlertToReceiver(receiver boshalert.Receiver, alert boshalert.Alert) {
+	defer a.logger.HandlePanic("Agent Send Alert To Receiver")
+
+	alertRetryable := boshretry.NewRetryable(func() (bool, error) {
+		err := receiver.Send(alert)
+		if err != nil {
+			return true, bosherr.WrapErrorf(err, "Sending alert to %s", receiver.Name())
+		}
+		return false, nil
+	})
+
+	err := boshretry.NewBackoffWithJitterRetryStrategy(
+		receiver.MaxAttempts(),
+		AlertReceiverMinBackoff,
+		AlertReceiverMaxBackoff,
+		alertRetryable,
+		a.logger,
+	).Try()
+	if err != nil {
+		a.logger.Error(agentLogTag, "Dropping alert '%s': %s", alert.ID, err.Error())
+	}
+}
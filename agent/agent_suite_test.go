@@ -17,4 +17,6 @@ func TestAgent(t *testing.T) {
 
 var _ = BeforeSuite(func() {
 	agent.HeartbeatRetryInterval = 1 * time.Millisecond
+	agent.AlertReceiverMinBackoff = 1 * time.Millisecond
+	agent.AlertReceiverMaxBackoff = 1 * time.Millisecond
 })
@@ -14,6 +14,7 @@ import (
 	"github.com/cloudfoundry/bosh-agent/v2/agent"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/agentfakes"
 	boshalert "github.com/cloudfoundry/bosh-agent/v2/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/alert/alertfakes"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
 	fakeagent "github.com/cloudfoundry/bosh-agent/v2/agent/fakes"
@@ -41,6 +42,9 @@ func init() { //nolint:funlen,gochecknoinits
 			timeService      *fakeclock.FakeClock
 			vitalService     *vitalsfakes.FakeService
 			startManager     *agentfakes.FakeStartManager
+			heartbeatSinks   []agent.HeartbeatSink
+			alertPipeline    *alertfakes.FakePipeline
+			alertReceivers   []boshalert.Receiver
 
 			boshAgent agent.Agent
 		)
@@ -58,6 +62,9 @@ func init() { //nolint:funlen,gochecknoinits
 			vitalService = &vitalsfakes.FakeService{}
 			startManager = &agentfakes.FakeStartManager{}
 			startManager.CanStartReturns(true)
+			heartbeatSinks = nil
+			alertPipeline = &alertfakes.FakePipeline{}
+			alertReceivers = nil
 
 			platform.GetVitalsServiceReturns(vitalService)
 
@@ -73,6 +80,9 @@ func init() { //nolint:funlen,gochecknoinits
 				uuidGenerator,
 				timeService,
 				startManager,
+				heartbeatSinks,
+				alertPipeline,
+				alertReceivers,
 			)
 		})
 
@@ -166,6 +176,9 @@ func init() { //nolint:funlen,gochecknoinits
 						uuidGenerator,
 						timeService,
 						startManager,
+						heartbeatSinks,
+						alertPipeline,
+						alertReceivers,
 					)
 
 					// Immediately exit after sending initial heartbeat
@@ -217,6 +230,66 @@ func init() { //nolint:funlen,gochecknoinits
 					Expect(jobSupervisor.GetHealthRecorded()).To(BeNumerically(">=", 3))
 				})
 
+				Context("when additional heartbeat sinks are configured", func() {
+					var (
+						healthySink *agentfakes.FakeHeartbeatSink
+						failingSink *agentfakes.FakeHeartbeatSink
+					)
+
+					BeforeEach(func() {
+						healthySink = &agentfakes.FakeHeartbeatSink{}
+						healthySink.IntervalReturns(time.Minute)
+
+						failingSink = &agentfakes.FakeHeartbeatSink{}
+						failingSink.IntervalReturns(time.Hour)
+						failingSink.SendReturns(errors.New("fake-sink-error"))
+
+						heartbeatSinks = []agent.HeartbeatSink{healthySink, failingSink}
+
+						boshAgent = agent.New(
+							logger,
+							handler,
+							platform,
+							actionDispatcher,
+							jobSupervisor,
+							specService,
+							5*time.Millisecond,
+							settingsService,
+							uuidGenerator,
+							timeService,
+							startManager,
+							heartbeatSinks,
+							alertPipeline,
+							alertReceivers,
+						)
+					})
+
+					It("sends heartbeats to each sink on its own interval without affecting the message bus", func() {
+						// Keep the message bus heartbeats going until the healthy sink
+						// has been sent its periodic heartbeat
+						handler.SendCallback = func(_ fakembus.SendInput) {
+							if healthySink.SendCallCount() >= 2 {
+								handler.SendErr = errors.New("stop")
+							}
+						}
+
+						go func() {
+							defer GinkgoRecover()
+							Eventually(timeService.WatcherCount).Should(Equal(2))
+							timeService.Increment(time.Minute)
+						}()
+
+						err := boshAgent.Run()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("stop"))
+
+						Eventually(healthySink.SendCallCount).Should(Equal(2))
+						Expect(healthySink.SendArgsForCall(0)).To(Equal(expectedHb))
+						Expect(failingSink.SendCallCount()).To(Equal(1))
+						Expect(failingSink.SendArgsForCall(0)).To(Equal(expectedHb))
+					})
+				})
+
 				Context("when the boshAgent may not be rebooted", func() {
 					BeforeEach(func() {
 						startManager.CanStartReturns(false)
@@ -304,7 +377,7 @@ func init() { //nolint:funlen,gochecknoinits
 				})
 			})
 
-			It("sends job monitoring alerts to health manager", func() {
+			It("queues job monitoring alerts for the health manager", func() {
 				handler.KeepOnRunning()
 
 				monitAlert := boshalert.MonitAlert{
@@ -317,11 +390,10 @@ func init() { //nolint:funlen,gochecknoinits
 				}
 				jobSupervisor.JobFailureAlert = &monitAlert
 
-				// Fail the first time handler.Send is called for an alert (ignore heartbeats)
-				handler.SendCallback = func(input fakembus.SendInput) {
-					if input.Topic == boshhandler.Alert {
-						handler.SendErr = errors.New("stop")
-					}
+				// Stop the agent once the alert was queued
+				alertPipeline.AddStub = func(string, string, boshalert.Alert) error {
+					handler.SendErr = errors.New("stop")
+					return nil
 				}
 
 				err := boshAgent.Run()
@@ -336,10 +408,142 @@ func init() { //nolint:funlen,gochecknoinits
 					CreatedAt: int64(1306076861),
 				}
 
+				Expect(alertPipeline.AddCallCount()).To(Equal(1))
+				service, event, alert := alertPipeline.AddArgsForCall(0)
+				Expect(service).To(Equal("fake-service"))
+				Expect(event).To(Equal("fake-event"))
+				Expect(alert).To(Equal(expectedAlert))
+			})
+
+			It("sends forwarded alerts to additional receivers once, retrying failed attempts", func() {
+				handler.KeepOnRunning()
+
+				expectedAlert := boshalert.Alert{ID: "fake-alert", Title: "fake-title"}
+				alertPipeline.RunStub = func(accept func(boshalert.Alert), forward func(boshalert.Alert) error, _ <-chan struct{}) {
+					// The pipeline forwards alerts again until the health monitor got them
+					accept(expectedAlert)
+					_ = forward(expectedAlert)
+					_ = forward(expectedAlert)
+				}
+
+				healthyReceiver := &alertfakes.FakeReceiver{}
+				healthyReceiver.MaxAttemptsReturns(3)
+
+				flakyReceiver := &alertfakes.FakeReceiver{}
+				flakyReceiver.MaxAttemptsReturns(3)
+				flakyReceiver.SendReturnsOnCall(0, errors.New("fake-send-error"))
+
+				failingReceiver := &alertfakes.FakeReceiver{}
+				failingReceiver.MaxAttemptsReturns(2)
+				failingReceiver.SendReturns(errors.New("fake-send-error"))
+
+				alertReceivers = []boshalert.Receiver{healthyReceiver, flakyReceiver, failingReceiver}
+
+				boshAgent = agent.New(
+					logger,
+					handler,
+					platform,
+					actionDispatcher,
+					jobSupervisor,
+					specService,
+					5*time.Millisecond,
+					settingsService,
+					uuidGenerator,
+					timeService,
+					startManager,
+					heartbeatSinks,
+					alertPipeline,
+					alertReceivers,
+				)
+
+				// Keep the agent running until the failing receiver gave up
+				handler.SendCallback = func(_ fakembus.SendInput) {
+					if failingReceiver.SendCallCount() >= 2 {
+						handler.SendErr = errors.New("stop")
+					}
+				}
+
+				err := boshAgent.Run()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("stop"))
+
+				Eventually(healthyReceiver.SendCallCount).Should(Equal(1))
+				Consistently(healthyReceiver.SendCallCount).Should(Equal(1))
+				Expect(healthyReceiver.SendArgsForCall(0)).To(Equal(expectedAlert))
+				Eventually(flakyReceiver.SendCallCount).Should(Equal(2))
+				Expect(flakyReceiver.SendArgsForCall(1)).To(Equal(expectedAlert))
+				Eventually(failingReceiver.SendCallCount).Should(Equal(2))
+				Consistently(failingReceiver.SendCallCount).Should(Equal(2))
+			})
+
+			It("sends every accepted alert to additional receivers while the message bus is down", func() {
+				handler.KeepOnRunning()
+
+				alerts := []boshalert.Alert{
+					{ID: "fake-alert-1", Title: "fake-title-1"},
+					{ID: "fake-alert-2", Title: "fake-title-2"},
+					{ID: "fake-alert-3", Title: "fake-title-3"},
+				}
+				alertPipeline.RunStub = func(accept func(boshalert.Alert), forward func(boshalert.Alert) error, _ <-chan struct{}) {
+					handler.SendErr = errors.New("fake-nats-down")
+
+					for _, alert := range alerts {
+						accept(alert)
+					}
+
+					// The pipeline retries the oldest alert until the message bus is back
+					_ = forward(alerts[0])
+					_ = forward(alerts[0])
+				}
+
+				receiver := &alertfakes.FakeReceiver{}
+				receiver.MaxAttemptsReturns(1)
+
+				boshAgent = agent.New(
+					logger,
+					handler,
+					platform,
+					actionDispatcher,
+					jobSupervisor,
+					specService,
+					5*time.Millisecond,
+					settingsService,
+					uuidGenerator,
+					timeService,
+					startManager,
+					heartbeatSinks,
+					alertPipeline,
+					[]boshalert.Receiver{receiver},
+				)
+
+				err := boshAgent.Run()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-nats-down"))
+
+				Eventually(receiver.SendCallCount).Should(Equal(3))
+				Consistently(receiver.SendCallCount).Should(Equal(3))
+				for i, alert := range alerts {
+					Expect(receiver.SendArgsForCall(i)).To(Equal(alert))
+				}
+			})
+
+			It("forwards queued alerts to the health manager", func() {
+				handler.KeepOnRunning()
+
+				alert := boshalert.Alert{ID: "fake-alert", Title: "fake-title"}
+				alertPipeline.RunStub = func(_ func(boshalert.Alert), forward func(boshalert.Alert) error, _ <-chan struct{}) {
+					_ = forward(alert)
+					handler.SendErr = errors.New("stop")
+				}
+
+				err := boshAgent.Run()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("stop"))
+
 				Expect(handler.SendInputs()).To(ContainElement(fakembus.SendInput{
 					Target:  boshhandler.HealthMonitor,
 					Topic:   boshhandler.Alert,
-					Message: expectedAlert,
+					Message: alert,
 				}))
 			})
 		})
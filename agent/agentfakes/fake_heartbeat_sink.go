@@ -0,0 +1,236 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package agentfakes
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent"
+)
+
+type FakeHeartbeatSink struct {
+	IntervalStub        func() time.Duration
+	intervalMutex       sync.RWMutex
+	intervalArgsForCall []struct {
+	}
+	intervalReturns struct {
+		result1 time.Duration
+	}
+	intervalReturnsOnCall map[int]struct {
+		result1 time.Duration
+	}
+	NameStub        func() string
+	nameMutex       sync.RWMutex
+	nameArgsForCall []struct {
+	}
+	nameReturns struct {
+		result1 string
+	}
+	nameReturnsOnCall map[int]struct {
+		result1 string
+	}
+	SendStub        func(agent.Heartbeat) error
+	sendMutex       sync.RWMutex
+	sendArgsForCall []struct {
+		arg1 agent.Heartbeat
+	}
+	sendReturns struct {
+		result1 error
+	}
+	sendReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeHeartbeatSink) Interval() time.Duration {
+	fake.intervalMutex.Lock()
+	ret, specificReturn := fake.intervalReturnsOnCall[len(fake.intervalArgsForCall)]
+	fake.intervalArgsForCall = append(fake.intervalArgsForCall, struct {
+	}{})
+	stub := fake.IntervalStub
+	fakeReturns := fake.intervalReturns
+	fake.recordInvocation("Interval", []interface{}{})
+	fake.intervalMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHeartbeatSink) IntervalCallCount() int {
+	fake.intervalMutex.RLock()
+	defer fake.intervalMutex.RUnlock()
+	return len(fake.intervalArgsForCall)
+}
+
+func (fake *FakeHeartbeatSink) IntervalCalls(stub func() time.Duration) {
+	fake.intervalMutex.Lock()
+	defer fake.intervalMutex.Unlock()
+	fake.IntervalStub = stub
+}
+
+func (fake *FakeHeartbeatSink) IntervalReturns(result1 time.Duration) {
+	fake.intervalMutex.Lock()
+	defer fake.intervalMutex.Unlock()
+	fake.IntervalStub = nil
+	fake.intervalReturns = struct {
+		result1 time.Duration
+	}{result1}
+}
+
+func (fake *FakeHeartbeatSink) IntervalReturnsOnCall(i int, result1 time.Duration) {
+	fake.intervalMutex.Lock()
+	defer fake.intervalMutex.Unlock()
+	fake.IntervalStub = nil
+	if fake.intervalReturnsOnCall == nil {
+		fake.intervalReturnsOnCall = make(map[int]struct {
+			result1 time.Duration
+		})
+	}
+	fake.intervalReturnsOnCall[i] = struct {
+		result1 time.Duration
+	}{result1}
+}
+
+func (fake *FakeHeartbeatSink) Name() string {
+	fake.nameMutex.Lock()
+	ret, specificReturn := fake.nameReturnsOnCall[len(fake.nameArgsForCall)]
+	fake.nameArgsForCall = append(fake.nameArgsForCall, struct {
+	}{})
+	stub := fake.NameStub
+	fakeReturns := fake.nameReturns
+	fake.recordInvocation("Name", []interface{}{})
+	fake.nameMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHeartbeatSink) NameCallCount() int {
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
+	return len(fake.nameArgsForCall)
+}
+
+func (fake *FakeHeartbeatSink) NameCalls(stub func() string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = stub
+}
+
+func (fake *FakeHeartbeatSink) NameReturns(result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	fake.nameReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeHeartbeatSink) NameReturnsOnCall(i int, result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	if fake.nameReturnsOnCall == nil {
+		fake.nameReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.nameReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeHeartbeatSink) Send(arg1 agent.Heartbeat) error {
+	fake.sendMutex.Lock()
+	ret, specificReturn := fake.sendReturnsOnCall[len(fake.sendArgsForCall)]
+	fake.sendArgsForCall = append(fake.sendArgsForCall, struct {
+		arg1 agent.Heartbeat
+	}{arg1})
+	stub := fake.SendStub
+	fakeReturns := fake.sendReturns
+	fake.recordInvocation("Send", []interface{}{arg1})
+	fake.sendMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHeartbeatSink) SendCallCount() int {
+	fake.sendMutex.RLock()
+	defer fake.sendMutex.RUnlock()
+	return len(fake.sendArgsForCall)
+}
+
+func (fake *FakeHeartbeatSink) SendCalls(stub func(agent.Heartbeat) error) {
+	fake.sendMutex.Lock()
+	defer fake.sendMutex.Unlock()
+	fake.SendStub = stub
+}
+
+func (fake *FakeHeartbeatSink) SendArgsForCall(i int) agent.Heartbeat {
+	fake.sendMutex.RLock()
+	defer fake.sendMutex.RUnlock()
+	argsForCall := fake.sendArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeHeartbeatSink) SendReturns(result1 error) {
+	fake.sendMutex.Lock()
+	defer fake.sendMutex.Unlock()
+	fake.SendStub = nil
+	fake.sendReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHeartbeatSink) SendReturnsOnCall(i int, result1 error) {
+	fake.sendMutex.Lock()
+	defer fake.sendMutex.Unlock()
+	fake.SendStub = nil
+	if fake.sendReturnsOnCall == nil {
+		fake.sendReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.sendReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHeartbeatSink) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeHeartbeatSink) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ agent.HeartbeatSink = new(FakeHeartbeatSink)
@@ -0,0 +1,235 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package alertfakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/alert"
+)
+
+type FakeReceiver struct {
+	MaxAttemptsStub        func() int
+	maxAttemptsMutex       sync.RWMutex
+	maxAttemptsArgsForCall []struct {
+	}
+	maxAttemptsReturns struct {
+		result1 int
+	}
+	maxAttemptsReturnsOnCall map[int]struct {
+		result1 int
+	}
+	NameStub        func() string
+	nameMutex       sync.RWMutex
+	nameArgsForCall []struct {
+	}
+	nameReturns struct {
+		result1 string
+	}
+	nameReturnsOnCall map[int]struct {
+		result1 string
+	}
+	SendStub        func(alert.Alert) error
+	sendMutex       sync.RWMutex
+	sendArgsForCall []struct {
+		arg1 alert.Alert
+	}
+	sendReturns struct {
+		result1 error
+	}
+	sendReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeReceiver) MaxAttempts() int {
+	fake.maxAttemptsMutex.Lock()
+	ret, specificReturn := fake.maxAttemptsReturnsOnCall[len(fake.maxAttemptsArgsForCall)]
+	fake.maxAttemptsArgsForCall = append(fake.maxAttemptsArgsForCall, struct {
+	}{})
+	stub := fake.MaxAttemptsStub
+	fakeReturns := fake.maxAttemptsReturns
+	fake.recordInvocation("MaxAttempts", []interface{}{})
+	fake.maxAttemptsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeReceiver) MaxAttemptsCallCount() int {
+	fake.maxAttemptsMutex.RLock()
+	defer fake.maxAttemptsMutex.RUnlock()
+	return len(fake.maxAttemptsArgsForCall)
+}
+
+func (fake *FakeReceiver) MaxAttemptsCalls(stub func() int) {
+	fake.maxAttemptsMutex.Lock()
+	defer fake.maxAttemptsMutex.Unlock()
+	fake.MaxAttemptsStub = stub
+}
+
+func (fake *FakeReceiver) MaxAttemptsReturns(result1 int) {
+	fake.maxAttemptsMutex.Lock()
+	defer fake.maxAttemptsMutex.Unlock()
+	fake.MaxAttemptsStub = nil
+	fake.maxAttemptsReturns = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *FakeReceiver) MaxAttemptsReturnsOnCall(i int, result1 int) {
+	fake.maxAttemptsMutex.Lock()
+	defer fake.maxAttemptsMutex.Unlock()
+	fake.MaxAttemptsStub = nil
+	if fake.maxAttemptsReturnsOnCall == nil {
+		fake.maxAttemptsReturnsOnCall = make(map[int]struct {
+			result1 int
+		})
+	}
+	fake.maxAttemptsReturnsOnCall[i] = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *FakeReceiver) Name() string {
+	fake.nameMutex.Lock()
+	ret, specificReturn := fake.nameReturnsOnCall[len(fake.nameArgsForCall)]
+	fake.nameArgsForCall = append(fake.nameArgsForCall, struct {
+	}{})
+	stub := fake.NameStub
+	fakeReturns := fake.nameReturns
+	fake.recordInvocation("Name", []interface{}{})
+	fake.nameMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeReceiver) NameCallCount() int {
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
+	return len(fake.nameArgsForCall)
+}
+
+func (fake *FakeReceiver) NameCalls(stub func() string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = stub
+}
+
+func (fake *FakeReceiver) NameReturns(result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	fake.nameReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeReceiver) NameReturnsOnCall(i int, result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	if fake.nameReturnsOnCall == nil {
+		fake.nameReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.nameReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeReceiver) Send(arg1 alert.Alert) error {
+	fake.sendMutex.Lock()
+	ret, specificReturn := fake.sendReturnsOnCall[len(fake.sendArgsForCall)]
+	fake.sendArgsForCall = append(fake.sendArgsForCall, struct {
+		arg1 alert.Alert
+	}{arg1})
+	stub := fake.SendStub
+	fakeReturns := fake.sendReturns
+	fake.recordInvocation("Send", []interface{}{arg1})
+	fake.sendMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeReceiver) SendCallCount() int {
+	fake.sendMutex.RLock()
+	defer fake.sendMutex.RUnlock()
+	return len(fake.sendArgsForCall)
+}
+
+func (fake *FakeReceiver) SendCalls(stub func(alert.Alert) error) {
+	fake.sendMutex.Lock()
+	defer fake.sendMutex.Unlock()
+	fake.SendStub = stub
+}
+
+func (fake *FakeReceiver) SendArgsForCall(i int) alert.Alert {
+	fake.sendMutex.RLock()
+	defer fake.sendMutex.RUnlock()
+	argsForCall := fake.sendArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeReceiver) SendReturns(result1 error) {
+	fake.sendMutex.Lock()
+	defer fake.sendMutex.Unlock()
+	fake.SendStub = nil
+	fake.sendReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeReceiver) SendReturnsOnCall(i int, result1 error) {
+	fake.sendMutex.Lock()
+	defer fake.sendMutex.Unlock()
+	fake.SendStub = nil
+	if fake.sendReturnsOnCall == nil {
+		fake.sendReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.sendReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeReceiver) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeReceiver) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ alert.Receiver = new(FakeReceiver)
@@ -0,0 +1,392 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+const (
+	DefaultPipelineDedupWindowSeconds     = 60
+	DefaultPipelineRateLimitPerService    = 10
+	DefaultPipelineRateLimitWindowSeconds = 60
+	DefaultPipelineMaxPending             = 1000
+
+	pipelineLogTag        = "alertPipeline"
+	pipelineFlushInterval = time.Second
+	pipelineRetryInterval = 5 * time.Second
+)
+
+type PipelineOptions struct {
+	// Seconds during which alerts for the same service and event are forwarded only once;
+	// defaults to DefaultPipelineDedupWindowSeconds. A negative value disables deduplication.
+	DedupWindowSeconds int
+
+	// Maximum number of alerts forwarded per service within RateLimitWindowSeconds;
+	// defaults to DefaultPipelineRateLimitPerService. A negative value disables rate limiting.
+	RateLimitPerService int
+
+	// Defaults to DefaultPipelineRateLimitWindowSeconds
+	RateLimitWindowSeconds int
+
+	// Maximum number of alerts waiting to be forwarded; the oldest alerts are dropped
+	// beyond it. Defaults to DefaultPipelineMaxPending.
+	MaxPending int
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Pipeline
+
+// Pipeline sits between monit and the health monitor so that flapping
+// processes do not flood it with identical alerts
+type Pipeline interface {
+	// Add queues the alert unless an alert for the same service and event was queued
+	// within the deduplication window or the service exceeded its rate limit.
+	// Suppressed alerts are reported in a summary alert once the windows passed.
+	Add(service, event string, alert Alert) error
+
+	// Run forwards queued alerts until stop is closed. Alerts that could not be forwarded
+	// stay queued, also across restarts, and are retried. Each newly queued alert is also
+	// passed to accept once, whether or not forwarding it succeeds; accept must not block.
+	Run(accept func(Alert), forward func(Alert) error, stop <-chan struct{})
+}
+
+type pendingAlert struct {
+	Seq   uint64 `json:"seq"`
+	Alert Alert  `json:"alert"`
+}
+
+type alertKey struct {
+	service string
+	event   string
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+type suppression struct {
+	title    string
+	count    int
+	severity SeverityLevel
+	first    time.Time
+	last     time.Time
+}
+
+type pipeline struct {
+	logger      boshlog.Logger
+	fs          boshsys.FileSystem
+	statePath   string
+	timeService clock.Clock
+	uuidGen     boshuuid.Generator
+
+	dedupWindow     time.Duration
+	rateLimit       int
+	rateLimitWindow time.Duration
+	maxPending      int
+
+	queued chan struct{}
+
+	lock        sync.Mutex
+	loaded      bool
+	pending     []pendingAlert
+	accepted    []Alert
+	nextSeq     uint64
+	retryAt     time.Time
+	lastQueued  map[alertKey]time.Time
+	rateWindows map[string]*rateWindow
+	suppressed  map[alertKey]*suppression
+}
+
+func NewPipeline(
+	logger boshlog.Logger,
+	fs boshsys.FileSystem,
+	dir string,
+	options PipelineOptions,
+	timeService clock.Clock,
+	uuidGen boshuuid.Generator,
+) Pipeline {
+	if options.DedupWindowSeconds == 0 {
+		options.DedupWindowSeconds = DefaultPipelineDedupWindowSeconds
+	}
+	if options.RateLimitPerService == 0 {
+		options.RateLimitPerService = DefaultPipelineRateLimitPerService
+	}
+	if options.RateLimitWindowSeconds <= 0 {
+		options.RateLimitWindowSeconds = DefaultPipelineRateLimitWindowSeconds
+	}
+	if options.MaxPending <= 0 {
+		options.MaxPending = DefaultPipelineMaxPending
+	}
+
+	return &pipeline{
+		logger:      logger,
+		fs:          fs,
+		statePath:   path.Join(dir, "pending_alerts.json"),
+		timeService: timeService,
+		uuidGen:     uuidGen,
+
+		dedupWindow:     time.Duration(options.DedupWindowSeconds) * time.Second,
+		rateLimit:       options.RateLimitPerService,
+		rateLimitWindow: time.Duration(options.RateLimitWindowSeconds) * time.Second,
+		maxPending:      options.MaxPending,
+
+		queued:      make(chan struct{}, 1),
+		lastQueued:  map[alertKey]time.Time{},
+		rateWindows: map[string]*rateWindow{},
+		suppressed:  map[alertKey]*suppression{},
+	}
+}
+
+func (p *pipeline) Add(service, event string, alert Alert) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.load()
+
+	now := p.timeService.Now()
+	key := alertKey{service: service, event: event}
+
+	if last, found := p.lastQueued[key]; found && p.dedupWindow > 0 && now.Sub(last) < p.dedupWindow {
+		p.logger.Debug(pipelineLogTag, "Suppressing duplicate alert '%s' for service '%s'", event, service)
+		p.suppress(key, alert, now)
+		return nil
+	}
+
+	if p.rateLimit > 0 {
+		window, found := p.rateWindows[service]
+		if !found || now.Sub(window.start) >= p.rateLimitWindow {
+			window = &rateWindow{start: now}
+			p.rateWindows[service] = window
+		}
+		if window.count >= p.rateLimit {
+			p.logger.Debug(pipelineLogTag, "Suppressing alert '%s' for service '%s' over the rate limit", event, service)
+			p.suppress(key, alert, now)
+			return nil
+		}
+		window.count++
+	}
+
+	p.lastQueued[key] = now
+	err := p.enqueue(alert)
+
+	select {
+	case p.queued <- struct{}{}:
+	default:
+	}
+
+	return err
+}
+
+func (p *pipeline) Run(accept func(Alert), forward func(Alert) error, stop <-chan struct{}) {
+	ticker := p.timeService.NewTicker(pipelineFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		p.queueSummaries()
+		p.announce(accept)
+		p.flush(forward)
+
+		select {
+		case <-stop:
+			return
+		case <-p.queued:
+		case <-ticker.C():
+		}
+	}
+}
+
+// queueSummaries queues summaries of suppressed alerts whose windows passed
+func (p *pipeline) queueSummaries() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.load()
+
+	now := p.timeService.Now()
+
+	for key, s := range p.suppressed {
+		if now.Sub(s.first) < p.dedupWindow || now.Sub(s.first) < p.rateLimitWindow {
+			continue
+		}
+		delete(p.suppressed, key)
+
+		err := p.enqueue(p.summary(key, s, now))
+		if err != nil {
+			p.logger.Warn(pipelineLogTag, "Queueing summary of suppressed alerts: %s", err.Error())
+		}
+	}
+}
+
+// announce passes alerts queued since the last call to accept, independently
+// of forwarding them, which may be held up by retries
+func (p *pipeline) announce(accept func(Alert)) {
+	p.lock.Lock()
+	accepted := p.accepted
+	p.accepted = nil
+	p.lock.Unlock()
+
+	for _, alert := range accepted {
+		accept(alert)
+	}
+}
+
+// flush forwards queued alerts in order until one fails
+func (p *pipeline) flush(forward func(Alert) error) {
+	p.lock.Lock()
+	p.load()
+
+	now := p.timeService.Now()
+
+	if now.Before(p.retryAt) || len(p.pending) == 0 {
+		p.lock.Unlock()
+		return
+	}
+
+	pending := make([]pendingAlert, len(p.pending))
+	copy(pending, p.pending)
+	p.lock.Unlock()
+
+	// Forwarding happens outside the lock so that a slow message bus does not block monit
+	var forwardedSeq uint64
+	var forwardErr error
+
+	for _, a := range pending {
+		forwardErr = forward(a.Alert)
+		if forwardErr != nil {
+			break
+		}
+		forwardedSeq = a.Seq
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if forwardErr != nil {
+		p.logger.Warn(pipelineLogTag, "Forwarding alerts, retrying in %s: %s", pipelineRetryInterval, forwardErr.Error())
+		p.retryAt = now.Add(pipelineRetryInterval)
+	}
+
+	if forwardedSeq == 0 {
+		return
+	}
+
+	remaining := p.pending[:0]
+	for _, a := range p.pending {
+		if a.Seq > forwardedSeq {
+			remaining = append(remaining, a)
+		}
+	}
+	p.pending = remaining
+
+	err := p.persist()
+	if err != nil {
+		p.logger.Warn(pipelineLogTag, "Persisting pending alerts: %s", err.Error())
+	}
+}
+
+func (p *pipeline) suppress(key alertKey, alert Alert, now time.Time) {
+	s, found := p.suppressed[key]
+	if !found {
+		s = &suppression{first: now, severity: alert.Severity}
+		p.suppressed[key] = s
+	}
+
+	s.title = alert.Title
+	s.count++
+	s.last = now
+
+	// Lower levels are more severe
+	if alert.Severity > 0 && (s.severity <= 0 || alert.Severity < s.severity) {
+		s.severity = alert.Severity
+	}
+}
+
+func (p *pipeline) summary(key alertKey, s *suppression, now time.Time) Alert {
+	id, err := p.uuidGen.Generate()
+	if err != nil {
+		id = fmt.Sprintf("suppressed-%s-%s-%d", key.service, key.event, now.Unix())
+	}
+
+	return Alert{
+		ID:       id,
+		Severity: s.severity,
+		Title:    fmt.Sprintf("%s (%d similar alerts suppressed)", s.title, s.count),
+		Summary: fmt.Sprintf(
+			"Suppressed %d alerts for service '%s' and event '%s' between %s and %s",
+			s.count, key.service, key.event, s.first.UTC().Format(time.RFC3339), s.last.UTC().Format(time.RFC3339),
+		),
+		CreatedAt: now.Unix(),
+	}
+}
+
+// enqueue keeps the alert in memory even when it cannot be persisted
+func (p *pipeline) enqueue(alert Alert) error {
+	p.nextSeq++
+	p.pending = append(p.pending, pendingAlert{Seq: p.nextSeq, Alert: alert})
+	p.accepted = append(p.accepted, alert)
+
+	if dropped := len(p.pending) - p.maxPending; dropped > 0 {
+		p.logger.Warn(pipelineLogTag, "Dropping %d oldest pending alerts", dropped)
+		p.pending = p.pending[dropped:]
+	}
+
+	if dropped := len(p.accepted) - p.maxPending; dropped > 0 {
+		p.accepted = p.accepted[dropped:]
+	}
+
+	return p.persist()
+}
+
+func (p *pipeline) persist() error {
+	bytes, err := json.Marshal(p.pending)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling pending alerts")
+	}
+
+	err = p.fs.WriteFile(p.statePath, bytes)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing pending alerts")
+	}
+
+	return nil
+}
+
+// load restores alerts that were pending when the agent stopped
+func (p *pipeline) load() {
+	if p.loaded {
+		return
+	}
+	p.loaded = true
+
+	if !p.fs.FileExists(p.statePath) {
+		return
+	}
+
+	bytes, err := p.fs.ReadFile(p.statePath)
+	if err == nil {
+		err = json.Unmarshal(bytes, &p.pending)
+	}
+	if err != nil {
+		p.logger.Warn(pipelineLogTag, "Discarding unreadable pending alerts: %s", err.Error())
+		p.pending = nil
+		return
+	}
+
+	for _, a := range p.pending {
+		if a.Seq > p.nextSeq {
+			p.nextSeq = a.Seq
+		}
+	}
+
+	p.logger.Debug(pipelineLogTag, "Restored %d pending alerts", len(p.pending))
+}
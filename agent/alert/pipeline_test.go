@@ -0,0 +1,247 @@
+package alert_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent/alert"
+)
+
+var _ = Describe("Pipeline", func() {
+	var (
+		fs          *fakesys.FakeFileSystem
+		timeService *fakeclock.FakeClock
+		uuidGen     *fakeuuid.FakeGenerator
+		options     PipelineOptions
+		accepted    []Alert
+		forwarded   []Alert
+		forwardErr  error
+		stopped     chan struct{}
+	)
+
+	newPipeline := func() Pipeline {
+		return NewPipeline(boshlog.NewLogger(boshlog.LevelNone), fs, "/fake-bosh-dir", options, timeService, uuidGen)
+	}
+
+	accept := func(alert Alert) {
+		accepted = append(accepted, alert)
+	}
+
+	forward := func(alert Alert) error {
+		if forwardErr != nil {
+			return forwardErr
+		}
+		forwarded = append(forwarded, alert)
+		return nil
+	}
+
+	// Run flushes once before it notices that it was stopped
+	flush := func(pipeline Pipeline) {
+		pipeline.Run(accept, forward, stopped)
+	}
+
+	buildAlert := func(id string, severity SeverityLevel) Alert {
+		return Alert{ID: id, Severity: severity, Title: "nats - does not exist - restart", CreatedAt: 1306076861}
+	}
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		timeService = fakeclock.NewFakeClock(time.Unix(1306076861, 0))
+		uuidGen = &fakeuuid.FakeGenerator{GeneratedUUID: "fake-summary-id"}
+		options = PipelineOptions{}
+		accepted = nil
+		forwarded = nil
+		forwardErr = nil
+		stopped = make(chan struct{})
+		close(stopped)
+	})
+
+	It("forwards queued alerts in order", func() {
+		pipeline := newPipeline()
+
+		Expect(pipeline.Add("nats", "does not exist", buildAlert("1", SeverityCritical))).To(Succeed())
+		Expect(pipeline.Add("nats", "restart", buildAlert("2", SeverityWarning))).To(Succeed())
+		flush(pipeline)
+
+		Expect(forwarded).To(Equal([]Alert{buildAlert("1", SeverityCritical), buildAlert("2", SeverityWarning)}))
+	})
+
+	It("deduplicates alerts for the same service and event within the window", func() {
+		pipeline := newPipeline()
+
+		Expect(pipeline.Add("nats", "does not exist", buildAlert("1", SeverityWarning))).To(Succeed())
+		Expect(pipeline.Add("nats", "does not exist", buildAlert("2", SeverityCritical))).To(Succeed())
+		Expect(pipeline.Add("nats", "does not exist", buildAlert("3", SeverityError))).To(Succeed())
+		Expect(pipeline.Add("director", "does not exist", buildAlert("4", SeverityWarning))).To(Succeed())
+		flush(pipeline)
+
+		Expect(forwarded).To(HaveLen(2))
+		Expect(forwarded[0].ID).To(Equal("1"))
+		Expect(forwarded[1].ID).To(Equal("4"))
+
+		timeService.Increment(DefaultPipelineDedupWindowSeconds * time.Second)
+		flush(pipeline)
+
+		Expect(forwarded).To(HaveLen(3))
+		Expect(forwarded[2]).To(Equal(Alert{
+			ID:        "fake-summary-id",
+			Severity:  SeverityCritical,
+			Title:     "nats - does not exist - restart (2 similar alerts suppressed)",
+			Summary:   "Suppressed 2 alerts for service 'nats' and event 'does not exist' between 2011-05-22T15:07:41Z and 2011-05-22T15:07:41Z",
+			CreatedAt: 1306076921,
+		}))
+
+		Expect(pipeline.Add("nats", "does not exist", buildAlert("5", SeverityWarning))).To(Succeed())
+		flush(pipeline)
+
+		Expect(forwarded).To(HaveLen(4))
+		Expect(forwarded[3].ID).To(Equal("5"))
+	})
+
+	It("does not deduplicate when the window is disabled", func() {
+		options.DedupWindowSeconds = -1
+		pipeline := newPipeline()
+
+		Expect(pipeline.Add("nats", "does not exist", buildAlert("1", SeverityWarning))).To(Succeed())
+		Expect(pipeline.Add("nats", "does not exist", buildAlert("2", SeverityWarning))).To(Succeed())
+		flush(pipeline)
+
+		Expect(forwarded).To(HaveLen(2))
+	})
+
+	It("rate limits alerts per service", func() {
+		options.RateLimitPerService = 2
+		pipeline := newPipeline()
+
+		Expect(pipeline.Add("nats", "does not exist", buildAlert("1", SeverityWarning))).To(Succeed())
+		Expect(pipeline.Add("nats", "restart", buildAlert("2", SeverityWarning))).To(Succeed())
+		Expect(pipeline.Add("nats", "exec failed", buildAlert("3", SeverityWarning))).To(Succeed())
+		Expect(pipeline.Add("director", "exec failed", buildAlert("4", SeverityWarning))).To(Succeed())
+		flush(pipeline)
+
+		Expect(forwarded).To(HaveLen(3))
+		Expect([]string{forwarded[0].ID, forwarded[1].ID, forwarded[2].ID}).To(Equal([]string{"1", "2", "4"}))
+
+		timeService.Increment(DefaultPipelineRateLimitWindowSeconds * time.Second)
+		Expect(pipeline.Add("nats", "checksum failed", buildAlert("5", SeverityWarning))).To(Succeed())
+		flush(pipeline)
+
+		Expect(forwarded).To(HaveLen(5))
+		Expect(forwarded[3].ID).To(Equal("5"))
+		Expect(forwarded[4].Title).To(Equal("nats - does not exist - restart (1 similar alerts suppressed)"))
+	})
+
+	It("keeps alerts that could not be forwarded and retries them later", func() {
+		pipeline := newPipeline()
+
+		Expect(pipeline.Add("nats", "does not exist", buildAlert("1", SeverityWarning))).To(Succeed())
+		forwardErr = errors.New("fake-disconnected")
+		flush(pipeline)
+		Expect(forwarded).To(BeEmpty())
+
+		forwardErr = nil
+		flush(pipeline)
+		Expect(forwarded).To(BeEmpty())
+
+		timeService.Increment(5 * time.Second)
+		flush(pipeline)
+		Expect(forwarded).To(HaveLen(1))
+		Expect(forwarded[0].ID).To(Equal("1"))
+	})
+
+	It("accepts every queued alert once while forwarding is retried", func() {
+		pipeline := newPipeline()
+
+		Expect(pipeline.Add("nats", "does not exist", buildAlert("1", SeverityWarning))).To(Succeed())
+		Expect(pipeline.Add("nats", "restart", buildAlert("2", SeverityWarning))).To(Succeed())
+		forwardErr = errors.New("fake-disconnected")
+		flush(pipeline)
+
+		Expect(pipeline.Add("postgres", "does not exist", buildAlert("3", SeverityWarning))).To(Succeed())
+		timeService.Increment(5 * time.Second)
+		flush(pipeline)
+
+		Expect(forwarded).To(BeEmpty())
+		Expect(accepted).To(Equal([]Alert{
+			buildAlert("1", SeverityWarning),
+			buildAlert("2", SeverityWarning),
+			buildAlert("3", SeverityWarning),
+		}))
+
+		forwardErr = nil
+		timeService.Increment(5 * time.Second)
+		flush(pipeline)
+
+		Expect(forwarded).To(HaveLen(3))
+		Expect(accepted).To(HaveLen(3))
+	})
+
+	It("does not accept restored alerts again after a restart", func() {
+		Expect(newPipeline().Add("nats", "does not exist", buildAlert("1", SeverityWarning))).To(Succeed())
+
+		flush(newPipeline())
+
+		Expect(forwarded).To(HaveLen(1))
+		Expect(accepted).To(BeEmpty())
+	})
+
+	It("restores pending alerts after a restart", func() {
+		Expect(newPipeline().Add("nats", "does not exist", buildAlert("1", SeverityWarning))).To(Succeed())
+		Expect(fs.FileExists("/fake-bosh-dir/pending_alerts.json")).To(BeTrue())
+
+		pipeline := newPipeline()
+		Expect(pipeline.Add("nats", "restart", buildAlert("2", SeverityWarning))).To(Succeed())
+		flush(pipeline)
+
+		Expect(forwarded).To(HaveLen(2))
+		Expect(forwarded[0].ID).To(Equal("1"))
+		Expect(forwarded[1].ID).To(Equal("2"))
+
+		contents, err := fs.ReadFileString("/fake-bosh-dir/pending_alerts.json")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(contents).To(Equal("[]"))
+	})
+
+	It("discards pending alerts that cannot be read", func() {
+		Expect(fs.WriteFileString("/fake-bosh-dir/pending_alerts.json", "not-json")).To(Succeed())
+
+		pipeline := newPipeline()
+		flush(pipeline)
+
+		Expect(forwarded).To(BeEmpty())
+	})
+
+	It("drops the oldest alerts beyond the maximum number of pending alerts", func() {
+		options.MaxPending = 2
+		pipeline := newPipeline()
+
+		Expect(pipeline.Add("nats", "does not exist", buildAlert("1", SeverityWarning))).To(Succeed())
+		Expect(pipeline.Add("nats", "restart", buildAlert("2", SeverityWarning))).To(Succeed())
+		Expect(pipeline.Add("nats", "exec failed", buildAlert("3", SeverityWarning))).To(Succeed())
+		flush(pipeline)
+
+		Expect(forwarded).To(HaveLen(2))
+		Expect(forwarded[0].ID).To(Equal("2"))
+		Expect(forwarded[1].ID).To(Equal("3"))
+	})
+
+	It("returns an error when pending alerts cannot be persisted but still forwards them", func() {
+		fs.WriteFileError = errors.New("fake-write-error")
+		pipeline := newPipeline()
+
+		err := pipeline.Add("nats", "does not exist", buildAlert("1", SeverityWarning))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+
+		flush(pipeline)
+		Expect(forwarded).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,90 @@
+package alert
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	ReceiverTypeWebhook = "webhook"
+	ReceiverTypeSyslog  = "syslog"
+
+	DefaultReceiverMaxAttempts    = 5
+	DefaultReceiverTimeoutSeconds = 10
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Receiver
+
+// Receiver gets job failure alerts directly from the VM in addition to the
+// message bus, so that it is reached even when the message bus is disconnected
+type Receiver interface {
+	Name() string
+
+	// MaxAttempts is the number of times sending an alert is attempted before it is dropped
+	MaxAttempts() int
+
+	Send(Alert) error
+}
+
+type ReceiversOptions struct {
+	Receivers []ReceiverOptions
+}
+
+type ReceiverOptions struct {
+	// Possible values: webhook, syslog
+	Type string
+
+	// Defaults to DefaultReceiverMaxAttempts
+	MaxAttempts int
+
+	// URL alerts are posted to as JSON (webhook)
+	URL string
+
+	// Key of the HMAC-SHA256 signature of the request body sent
+	// in the X-Bosh-Signature header; not signed when empty (webhook)
+	Secret string
+
+	// Defaults to DefaultReceiverTimeoutSeconds (webhook)
+	TimeoutSeconds int
+
+	// Network ("udp" or "tcp", defaults to "udp") and host:port of the collector (syslog)
+	Network string
+	Address string
+}
+
+func NewReceivers(options ReceiversOptions, timeService clock.Clock) ([]Receiver, error) {
+	receivers := make([]Receiver, 0, len(options.Receivers))
+
+	for _, receiverOptions := range options.Receivers {
+		maxAttempts := receiverOptions.MaxAttempts
+		if maxAttempts <= 0 {
+			maxAttempts = DefaultReceiverMaxAttempts
+		}
+
+		switch receiverOptions.Type {
+		case ReceiverTypeWebhook:
+			if receiverOptions.URL == "" {
+				return nil, bosherr.Error("Alert webhook receiver requires a URL")
+			}
+			timeout := time.Duration(DefaultReceiverTimeoutSeconds) * time.Second
+			if receiverOptions.TimeoutSeconds > 0 {
+				timeout = time.Duration(receiverOptions.TimeoutSeconds) * time.Second
+			}
+			receivers = append(receivers, NewWebhookReceiver(receiverOptions.URL, receiverOptions.Secret, timeout, maxAttempts))
+
+		case ReceiverTypeSyslog:
+			if receiverOptions.Address == "" {
+				return nil, bosherr.Error("Alert syslog receiver requires an address")
+			}
+			receivers = append(receivers, NewSyslogReceiver(receiverOptions.Network, receiverOptions.Address, maxAttempts, timeService))
+
+		default:
+			return nil, bosherr.Errorf("Unknown alert receiver type '%s'", receiverOptions.Type)
+		}
+	}
+
+	return receivers, nil
+}
@@ -0,0 +1,55 @@
+package alert
+
+import (
+	"encoding/json"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshsyslog "github.com/cloudfoundry/bosh-agent/v2/agent/syslog"
+)
+
+type syslogReceiver struct {
+	address     string
+	maxAttempts int
+	writer      *boshsyslog.Writer
+}
+
+// NewSyslogReceiver sends each alert as JSON in an RFC 5424 message
+// with a syslog severity that matches the alert severity
+func NewSyslogReceiver(network, address string, maxAttempts int, timeService clock.Clock) Receiver {
+	return syslogReceiver{
+		address:     address,
+		maxAttempts: maxAttempts,
+		writer:      boshsyslog.NewWriter(network, address, "bosh-agent", timeService),
+	}
+}
+
+func (r syslogReceiver) Name() string { return ReceiverTypeSyslog + ":" + r.address }
+
+func (r syslogReceiver) MaxAttempts() int { return r.maxAttempts }
+
+func (r syslogReceiver) Send(alert Alert) error {
+	msg, err := json.Marshal(alert)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling alert")
+	}
+
+	return r.writer.Write(syslogSeverity(alert.Severity), "alert", string(msg))
+}
+
+func syslogSeverity(severity SeverityLevel) boshsyslog.Severity {
+	switch severity {
+	case SeverityAlert:
+		return boshsyslog.SeverityAlert
+	case SeverityCritical:
+		return boshsyslog.SeverityCritical
+	case SeverityError:
+		return boshsyslog.SeverityError
+	case SeverityWarning:
+		return boshsyslog.SeverityWarning
+	default:
+		return boshsyslog.SeverityNotice
+	}
+}
@@ -0,0 +1,91 @@
+package alert
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// WebhookSignatureHeader holds "sha256=" followed by the hex encoded HMAC-SHA256 of the request body
+const WebhookSignatureHeader = "X-Bosh-Signature"
+
+type webhookReceiver struct {
+	url         string
+	secret      string
+	maxAttempts int
+	client      *http.Client
+}
+
+// NewWebhookReceiver posts each alert as JSON to the URL
+func NewWebhookReceiver(url, secret string, timeout time.Duration, maxAttempts int) Receiver {
+	return webhookReceiver{
+		url:         url,
+		secret:      secret,
+		maxAttempts: maxAttempts,
+		client:      &http.Client{Timeout: timeout},
+	}
+}
+
+// Name leaves out credentials and tokens that may be part of the user info or query of the URL
+func (r webhookReceiver) Name() string {
+	parsedURL, err := url.Parse(r.url)
+	if err != nil {
+		return ReceiverTypeWebhook
+	}
+
+	parsedURL.User = nil
+	parsedURL.RawQuery = ""
+	parsedURL.Fragment = ""
+
+	return ReceiverTypeWebhook + ":" + parsedURL.String()
+}
+
+func (r webhookReceiver) MaxAttempts() int { return r.maxAttempts }
+
+func (r webhookReceiver) Send(alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling alert")
+	}
+
+	request, err := http.NewRequest(http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return bosherr.WrapError(err, "Building webhook request")
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	if r.secret != "" {
+		mac := hmac.New(sha256.New, []byte(r.secret))
+		mac.Write(body) //nolint:errcheck
+		request.Header.Set(WebhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		// The error of the client repeats the URL, which is only logged redacted
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return bosherr.WrapErrorf(err, "Posting alert to %s", r.Name())
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	_, _ = io.Copy(io.Discard, response.Body) //nolint:errcheck
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return bosherr.Errorf("Posting alert: unexpected status %d", response.StatusCode)
+	}
+
+	return nil
+}
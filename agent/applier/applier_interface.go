@@ -8,4 +8,11 @@ type Applier interface {
 	Prepare(desiredApplySpec boshas.ApplySpec) error
 	ConfigureJobs(desiredApplySpec boshas.ApplySpec) error
 	Apply(desiredApplySpec boshas.ApplySpec) error
+	Rollback(previousApplySpec boshas.ApplySpec) error
+
+	// KeepOnly removes installed bundles that none of the apply specs use
+	KeepOnly(applySpecs ...boshas.ApplySpec) error
+
+	// Plan reports what Apply would change, only reading the file system
+	Plan(currentApplySpec, desiredApplySpec boshas.ApplySpec) (Plan, error)
 }
@@ -10,50 +10,112 @@ import (
 )
 
 type concreteV1Service struct {
-	fs           boshsys.FileSystem
-	specFilePath string
+	fs                   boshsys.FileSystem
+	specFilePath         string
+	previousSpecFilePath string
 }
 
 func NewConcreteV1Service(fs boshsys.FileSystem, specFilePath string) V1Service {
-	return concreteV1Service{fs: fs, specFilePath: specFilePath}
+	return concreteV1Service{
+		fs:                   fs,
+		specFilePath:         specFilePath,
+		previousSpecFilePath: specFilePath + ".previous",
+	}
 }
 
 // Get reads and marshals the file contents.
 func (s concreteV1Service) Get() (V1ApplySpec, error) {
-	var spec V1ApplySpec
+	return s.read(s.specFilePath)
+}
 
-	if !s.fs.FileExists(s.specFilePath) {
-		return spec, nil
+// Set unmarshals and writes to the file. The replaced spec is kept
+// as the previous one unless an uncommitted previous spec exists.
+func (s concreteV1Service) Set(spec V1ApplySpec) error {
+	specBytes, err := json.Marshal(spec)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling apply spec")
 	}
 
-	contents, err := s.fs.ReadFile(s.specFilePath)
+	if !s.fs.FileExists(s.previousSpecFilePath) {
+		currentSpec, err := s.Get()
+		if err != nil {
+			return err
+		}
+
+		currentSpecBytes, err := json.Marshal(currentSpec)
+		if err != nil {
+			return bosherr.WrapError(err, "Marshalling current apply spec")
+		}
+
+		err = s.fs.WriteFile(s.previousSpecFilePath, currentSpecBytes)
+		if err != nil {
+			return bosherr.WrapError(err, "Writing previous spec to disk")
+		}
+	}
+
+	err = s.fs.WriteFile(s.specFilePath, specBytes)
 	if err != nil {
-		return spec, bosherr.WrapError(err, "Reading json spec file")
+		return bosherr.WrapError(err, "Writing spec to disk")
 	}
 
-	err = json.Unmarshal(contents, &spec)
+	return nil
+}
+
+func (s concreteV1Service) GetPrevious() (V1ApplySpec, bool, error) {
+	if !s.fs.FileExists(s.previousSpecFilePath) {
+		return V1ApplySpec{}, false, nil
+	}
+
+	spec, err := s.read(s.previousSpecFilePath)
 	if err != nil {
-		return spec, bosherr.WrapError(err, "Unmarshalling json spec file")
+		return spec, false, err
 	}
 
-	return spec, nil
+	return spec, true, nil
 }
 
-// Set unmarshals and writes to the file.
-func (s concreteV1Service) Set(spec V1ApplySpec) error {
-	specBytes, err := json.Marshal(spec)
+func (s concreteV1Service) Commit() error {
+	err := s.fs.RemoveAll(s.previousSpecFilePath)
 	if err != nil {
-		return bosherr.WrapError(err, "Marshalling apply spec")
+		return bosherr.WrapError(err, "Removing previous spec")
 	}
 
-	err = s.fs.WriteFile(s.specFilePath, specBytes)
+	return nil
+}
+
+func (s concreteV1Service) Rollback() error {
+	if !s.fs.FileExists(s.previousSpecFilePath) {
+		return bosherr.Error("No previous spec to roll back to")
+	}
+
+	err := s.fs.Rename(s.previousSpecFilePath, s.specFilePath)
 	if err != nil {
-		return bosherr.WrapError(err, "Writing spec to disk")
+		return bosherr.WrapError(err, "Restoring previous spec")
 	}
 
 	return nil
 }
 
+func (s concreteV1Service) read(path string) (V1ApplySpec, error) {
+	var spec V1ApplySpec
+
+	if !s.fs.FileExists(path) {
+		return spec, nil
+	}
+
+	contents, err := s.fs.ReadFile(path)
+	if err != nil {
+		return spec, bosherr.WrapError(err, "Reading json spec file")
+	}
+
+	err = json.Unmarshal(contents, &spec)
+	if err != nil {
+		return spec, bosherr.WrapError(err, "Unmarshalling json spec file")
+	}
+
+	return spec, nil
+}
+
 func (s concreteV1Service) PopulateDHCPNetworks(spec V1ApplySpec, settings boshsettings.Settings) (V1ApplySpec, error) {
 	for networkName, networkSpec := range spec.NetworkSpecs {
 		// Skip 'local' network since for vsphere/vcloud networks
@@ -79,6 +79,71 @@ func init() { //nolint:gochecknoinits
 			})
 		})
 
+		Describe("GetPrevious, Commit and Rollback", func() {
+			startedSpec := V1ApplySpec{Deployment: "fake-started-deployment"}
+			appliedSpec := V1ApplySpec{Deployment: "fake-applied-deployment"}
+
+			BeforeEach(func() {
+				Expect(service.Set(startedSpec)).To(Succeed())
+				Expect(service.Commit()).To(Succeed())
+			})
+
+			It("does not have a previous spec after committing", func() {
+				_, found, err := service.GetPrevious()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeFalse())
+			})
+
+			It("keeps the replaced spec as the previous one", func() {
+				Expect(service.Set(appliedSpec)).To(Succeed())
+
+				previous, found, err := service.GetPrevious()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(previous).To(Equal(startedSpec))
+			})
+
+			It("keeps the last committed spec as the previous one when setting specs repeatedly", func() {
+				Expect(service.Set(appliedSpec)).To(Succeed())
+				Expect(service.Set(V1ApplySpec{Deployment: "fake-other-deployment"})).To(Succeed())
+
+				previous, _, err := service.GetPrevious()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(previous).To(Equal(startedSpec))
+			})
+
+			It("restores the previous spec on rollback", func() {
+				Expect(service.Set(appliedSpec)).To(Succeed())
+				Expect(service.Rollback()).To(Succeed())
+
+				spec, err := service.Get()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(spec).To(Equal(startedSpec))
+
+				_, found, err := service.GetPrevious()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeFalse())
+			})
+
+			It("returns error on rollback when there is no previous spec", func() {
+				err := service.Rollback()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("No previous spec"))
+			})
+
+			It("returns error if writing the previous spec errs", func() {
+				fs.WriteFileErrors["/spec.json.previous"] = errors.New("fake-write-error")
+
+				err := service.Set(appliedSpec)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+
+				spec, err := service.Get()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(spec).To(Equal(startedSpec))
+			})
+		})
+
 		Describe("PopulateDHCPNetworks", func() {
 			var settings boshsettings.Settings
 			var unresolvedSpec V1ApplySpec
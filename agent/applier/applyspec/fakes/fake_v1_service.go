@@ -16,6 +16,13 @@ type FakeV1Service struct {
 	PopulateDHCPNetworksSettings   boshsettings.Settings
 	PopulateDHCPNetworksResultSpec boshas.V1ApplySpec
 	PopulateDHCPNetworksErr        error
+
+	PreviousSpec   boshas.V1ApplySpec
+	PreviousFound  bool
+	GetPreviousErr error
+
+	CommitErr   error
+	RollbackErr error
 }
 
 func NewFakeV1Service() *FakeV1Service {
@@ -29,8 +36,15 @@ func (s *FakeV1Service) Get() (boshas.V1ApplySpec, error) {
 
 func (s *FakeV1Service) Set(spec boshas.V1ApplySpec) error {
 	s.ActionsCalled = append(s.ActionsCalled, "Set")
+	if s.SetErr != nil {
+		return s.SetErr
+	}
+	if !s.PreviousFound {
+		s.PreviousSpec = s.Spec
+		s.PreviousFound = true
+	}
 	s.Spec = spec
-	return s.SetErr
+	return nil
 }
 
 func (s *FakeV1Service) PopulateDHCPNetworks(spec boshas.V1ApplySpec, settings boshsettings.Settings) (boshas.V1ApplySpec, error) {
@@ -39,3 +53,25 @@ func (s *FakeV1Service) PopulateDHCPNetworks(spec boshas.V1ApplySpec, settings b
 	s.PopulateDHCPNetworksSettings = settings
 	return s.PopulateDHCPNetworksResultSpec, s.PopulateDHCPNetworksErr
 }
+
+func (s *FakeV1Service) GetPrevious() (boshas.V1ApplySpec, bool, error) {
+	s.ActionsCalled = append(s.ActionsCalled, "GetPrevious")
+	return s.PreviousSpec, s.PreviousFound, s.GetPreviousErr
+}
+
+func (s *FakeV1Service) Commit() error {
+	s.ActionsCalled = append(s.ActionsCalled, "Commit")
+	if s.CommitErr == nil {
+		s.PreviousFound = false
+	}
+	return s.CommitErr
+}
+
+func (s *FakeV1Service) Rollback() error {
+	s.ActionsCalled = append(s.ActionsCalled, "Rollback")
+	if s.RollbackErr == nil {
+		s.Spec = s.PreviousSpec
+		s.PreviousFound = false
+	}
+	return s.RollbackErr
+}
@@ -5,13 +5,34 @@ import (
 )
 
 type JobTemplateSpec struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
+	Name           string              `json:"name"`
+	Version        string              `json:"version"`
+	ResourceLimits *ResourceLimitsSpec `json:"resource_limits,omitempty"`
+}
+
+type ResourceLimitsSpec struct {
+	CPUWeight  uint64 `json:"cpu_weight,omitempty"`
+	MemoryMax  string `json:"memory_max,omitempty"`
+	MemoryHigh string `json:"memory_high,omitempty"`
+	PidsMax    uint64 `json:"pids_max,omitempty"`
+	IOWeight   uint64 `json:"io_weight,omitempty"`
 }
 
 func (s *JobTemplateSpec) AsJob() models.Job {
-	return models.Job{
+	job := models.Job{
 		Name:    s.Name,
 		Version: s.Version,
 	}
+
+	if s.ResourceLimits != nil {
+		job.ResourceLimits = &models.ResourceLimits{
+			CPUWeight:  s.ResourceLimits.CPUWeight,
+			MemoryMax:  s.ResourceLimits.MemoryMax,
+			MemoryHigh: s.ResourceLimits.MemoryHigh,
+			PidsMax:    s.ResourceLimits.PidsMax,
+			IOWeight:   s.ResourceLimits.IOWeight,
+		}
+	}
+
+	return job
 }
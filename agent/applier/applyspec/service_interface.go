@@ -8,4 +8,14 @@ type V1Service interface {
 	Get() (V1ApplySpec, error)
 	Set(V1ApplySpec) error
 	PopulateDHCPNetworks(V1ApplySpec, boshsettings.Settings) (V1ApplySpec, error)
+
+	// GetPrevious returns the spec that was current before the first Set
+	// since the last Commit; found is false when there is nothing to roll back to
+	GetPrevious() (spec V1ApplySpec, found bool, err error)
+
+	// Commit forgets the previous spec once the current one is running
+	Commit() error
+
+	// Rollback restores the previous spec as the current one
+	Rollback() error
 }
@@ -51,6 +51,12 @@ type NetworkSpec struct {
 	Fields map[string]interface{}
 }
 
+// IsDeployed is false for specs without a configuration or jobs, such as the
+// initial empty spec of a new VM; there is nothing to roll back to for them
+func (s V1ApplySpec) IsDeployed() bool {
+	return s.ConfigurationHash != "" && len(s.JobSpec.JobTemplateSpecs) > 0
+}
+
 // Jobs returns a list of pre-rendered job templates
 // extracted from a single tarball provided by BOSH director.
 func (s V1ApplySpec) Jobs() []models.Job {
@@ -179,6 +179,39 @@ var _ = Describe("V1ApplySpec", func() {
 	})
 
 	Describe("Jobs", func() {
+		It("returns resource limits declared on job templates", func() {
+			specJSON := `{
+				"job": {
+					"templates": [
+						{"name": "limited", "version": "0.1", "resource_limits": {
+							"cpu_weight": 200, "memory_max": "1G", "memory_high": "768M", "pids_max": 512, "io_weight": 50
+						}},
+						{"name": "unlimited", "version": "0.2"}
+					]
+				},
+				"rendered_templates_archive": {"sha1": "archivesha1", "blobstore_id": "archive-blob-id"}
+			}`
+
+			spec := V1ApplySpec{}
+			err := json.Unmarshal([]byte(specJSON), &spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			jobs := spec.Jobs()
+			Expect(jobs).To(HaveLen(2))
+			Expect(jobs[0].ResourceLimits).To(Equal(&models.ResourceLimits{
+				CPUWeight:  200,
+				MemoryMax:  "1G",
+				MemoryHigh: "768M",
+				PidsMax:    512,
+				IOWeight:   50,
+			}))
+			Expect(jobs[1].ResourceLimits).To(BeNil())
+
+			specBytes, err := json.Marshal(spec.JobSpec.JobTemplateSpecs[1])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(specBytes)).To(Equal(`{"name":"unlimited","version":"0.2"}`))
+		})
+
 		It("returns jobs specified in job specs", func() {
 			jobName := "fake-job-legacy-name"
 			sha1 := crypto.MustParseMultipleDigest("sha1:fakerenderedtemplatesarchivesha1")
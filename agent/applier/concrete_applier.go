@@ -1,12 +1,16 @@
 package applier
 
 import (
+	"sort"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	"github.com/cloudfoundry/bosh-utils/work"
 
 	as "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/jobs"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/packages"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
@@ -19,6 +23,7 @@ type concreteApplier struct {
 	jobSupervisor     boshjobsuper.JobSupervisor
 	dirProvider       boshdirs.Provider
 	settings          boshsettings.Settings
+	progress          *boshtask.RunningProgress
 }
 
 func NewConcreteApplier(
@@ -28,6 +33,7 @@ func NewConcreteApplier(
 	jobSupervisor boshjobsuper.JobSupervisor,
 	dirProvider boshdirs.Provider,
 	settings boshsettings.Settings,
+	progress *boshtask.RunningProgress,
 ) Applier {
 	return &concreteApplier{
 		jobApplier:        jobApplier,
@@ -36,93 +42,213 @@ func NewConcreteApplier(
 		jobSupervisor:     jobSupervisor,
 		dirProvider:       dirProvider,
 		settings:          settings,
+		progress:          progress,
 	}
 }
 
 func (a *concreteApplier) Prepare(desiredApplySpec as.ApplySpec) error {
-	tasks := make([]func() error, 0, len(desiredApplySpec.Jobs())+len(desiredApplySpec.Packages()))
+	err := a.download(desiredApplySpec)
+	if err != nil {
+		return err
+	}
+
+	err = a.jobApplier.DeleteSourceBlobs(desiredApplySpec.Jobs())
+	if err != nil {
+		return bosherr.WrapError(err, "Failed removing job source blobs")
+	}
+
+	return nil
+}
+
+// Apply enables the desired jobs and packages. Bundles that are no longer needed
+// are left installed so that Rollback can restore them until KeepOnly is called.
+func (a *concreteApplier) Apply(desiredApplySpec as.ApplySpec) error {
+	// Install everything up front so that enabling does not download sequentially
+	err := a.download(desiredApplySpec)
+	if err != nil {
+		return err
+	}
+
+	err = a.enable(desiredApplySpec)
+	if err != nil {
+		return err
+	}
+
+	err = a.jobApplier.DeleteSourceBlobs(desiredApplySpec.Jobs())
+	if err != nil {
+		return bosherr.WrapError(err, "Failed removing job source blobs")
+	}
+
+	err = a.jobSupervisor.Reload()
+	if err != nil {
+		return bosherr.WrapError(err, "Reloading jobSupervisor")
+	}
+
+	return a.setUpLogrotate(desiredApplySpec)
+}
+
+// Rollback re-enables the bundles of a previously applied spec
+// and restores its job supervisor configuration
+func (a *concreteApplier) Rollback(previousApplySpec as.ApplySpec) error {
+	err := a.enable(previousApplySpec)
+	if err != nil {
+		return err
+	}
+
+	err = a.ConfigureJobs(previousApplySpec)
+	if err != nil {
+		return err
+	}
+
+	return a.setUpLogrotate(previousApplySpec)
+}
+
+func (a *concreteApplier) KeepOnly(applySpecs ...as.ApplySpec) error {
+	var jobs []models.Job
+	var pkgs []models.Package
+
+	for _, applySpec := range applySpecs {
+		jobs = append(jobs, applySpec.Jobs()...)
+		pkgs = append(pkgs, applySpec.Packages()...)
+	}
+
+	err := a.jobApplier.KeepOnly(jobs)
+	if err != nil {
+		return bosherr.WrapError(err, "Keeping only needed jobs")
+	}
+
+	err = a.packageApplier.KeepOnly(pkgs)
+	if err != nil {
+		return bosherr.WrapError(err, "Keeping only needed packages")
+	}
+
+	return nil
+}
+
+// download installs jobs and packages that are not installed yet, running at most
+// Env.Bosh.Parallel downloads at a time. Once the task is cancelled no further
+// downloads are started; downloads in flight are allowed to finish.
+func (a *concreteApplier) download(applySpec as.ApplySpec) error {
+	tasks := make([]func() error, 0, len(applySpec.Jobs())+len(applySpec.Packages()))
 
 	pool := work.Pool{
 		Count: *a.settings.Env.GetParallel(),
 	}
 
-	for _, job := range desiredApplySpec.Jobs() {
+	for _, job := range applySpec.Jobs() {
 		job := job
-		tasks = append(tasks, func() error {
+		tasks = append(tasks, a.downloadStep(func() error {
 			jobErr := a.jobApplier.Prepare(job)
 			if jobErr != nil {
 				return bosherr.WrapErrorf(jobErr, "Preparing job %s", job.Name)
 			}
 			return nil
-		})
+		}))
 	}
 
-	for _, pkg := range desiredApplySpec.Packages() {
+	for _, pkg := range applySpec.Packages() {
 		pkg := pkg
-		tasks = append(tasks, func() error {
+		tasks = append(tasks, a.downloadStep(func() error {
 			pkgErr := a.packageApplier.Prepare(pkg)
 			if pkgErr != nil {
 				return bosherr.WrapErrorf(pkgErr, "Preparing package %s", pkg.Name)
 			}
 			return nil
-		})
+		}))
 	}
 
+	a.progress.AddTotal(len(tasks))
+
 	err := pool.ParallelDo(tasks...)
 	if err != nil {
+		if a.progress.Cancelled() {
+			return bosherr.Error("Cancelled downloading jobs and packages")
+		}
 		return err
 	}
 
-	err = a.jobApplier.DeleteSourceBlobs(desiredApplySpec.Jobs())
-	if err != nil {
-		return bosherr.WrapError(err, "Failed removing job source blobs")
-	}
-
 	return nil
 }
 
-func (a *concreteApplier) Apply(desiredApplySpec as.ApplySpec) error {
+func (a *concreteApplier) downloadStep(step func() error) func() error {
+	return func() error {
+		if a.progress.Cancelled() {
+			return bosherr.Error("Cancelled")
+		}
+
+		err := step()
+		if err != nil {
+			return err
+		}
+
+		a.progress.StepDone()
+
+		return nil
+	}
+}
+
+func (a *concreteApplier) enable(applySpec as.ApplySpec) error {
 	err := a.jobSupervisor.RemoveAllJobs()
 	if err != nil {
 		return bosherr.WrapError(err, "Removing all jobs")
 	}
 
-	jobs := desiredApplySpec.Jobs()
-	for _, job := range jobs {
+	for _, job := range applySpec.Jobs() {
 		err = a.jobApplier.Apply(job)
 		if err != nil {
 			return bosherr.WrapErrorf(err, "Applying job %s", job.Name)
 		}
 	}
 
-	err = a.jobApplier.DeleteSourceBlobs(desiredApplySpec.Jobs())
-	if err != nil {
-		return bosherr.WrapError(err, "Failed removing job source blobs")
+	for _, pkg := range applySpec.Packages() {
+		err = a.packageApplier.Apply(pkg)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Applying package %s", pkg.Name)
+		}
+	}
+
+	return nil
+}
+
+func (a *concreteApplier) Plan(currentApplySpec, desiredApplySpec as.ApplySpec) (Plan, error) {
+	plan := Plan{
+		JobsToDownload:         []string{},
+		PackagesToDownload:     []string{},
+		JobBundlesToDelete:     []string{},
+		PackageBundlesToDelete: []string{},
 	}
 
-	err = a.jobApplier.KeepOnly(desiredApplySpec.Jobs())
+	jobsToDownload, jobBundlesToDelete, err := a.jobApplier.Plan(desiredApplySpec.Jobs())
 	if err != nil {
-		return bosherr.WrapError(err, "Keeping only needed jobs")
+		return Plan{}, bosherr.WrapError(err, "Planning jobs")
 	}
 
-	for _, pkg := range desiredApplySpec.Packages() {
-		err = a.packageApplier.Apply(pkg)
-		if err != nil {
-			return bosherr.WrapErrorf(err, "Applying package %s", pkg.Name)
-		}
+	for _, job := range jobsToDownload {
+		plan.JobsToDownload = append(plan.JobsToDownload, job.Name+"/"+job.Version)
 	}
+	plan.JobBundlesToDelete = append(plan.JobBundlesToDelete, jobBundlesToDelete...)
 
-	err = a.packageApplier.KeepOnly(desiredApplySpec.Packages())
+	pkgsToDownload, pkgBundlesToDelete, err := a.packageApplier.Plan(desiredApplySpec.Packages())
 	if err != nil {
-		return bosherr.WrapError(err, "Keeping only needed packages")
+		return Plan{}, bosherr.WrapError(err, "Planning packages")
 	}
 
-	err = a.jobSupervisor.Reload()
+	for _, pkg := range pkgsToDownload {
+		plan.PackagesToDownload = append(plan.PackagesToDownload, pkg.Name+"/"+pkg.Version)
+	}
+	plan.PackageBundlesToDelete = append(plan.PackageBundlesToDelete, pkgBundlesToDelete...)
+
+	sort.Strings(plan.JobsToDownload)
+	sort.Strings(plan.PackagesToDownload)
+	sort.Strings(plan.JobBundlesToDelete)
+	sort.Strings(plan.PackageBundlesToDelete)
+
+	plan.MonitConfigs, err = a.diffMonitConfigs(currentApplySpec.Jobs(), desiredApplySpec.Jobs())
 	if err != nil {
-		return bosherr.WrapError(err, "Reloading jobSupervisor")
+		return Plan{}, bosherr.WrapError(err, "Planning monit configurations")
 	}
 
-	return a.setUpLogrotate(desiredApplySpec)
+	return plan, nil
 }
 
 func (a *concreteApplier) ConfigureJobs(desiredApplySpec as.ApplySpec) error {
@@ -3,6 +3,8 @@ package applier_test
 import (
 	"errors"
 	"path/filepath"
+	"sync"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -15,6 +17,7 @@ import (
 	fakejobs "github.com/cloudfoundry/bosh-agent/v2/agent/applier/jobs/jobsfakes"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	fakepackages "github.com/cloudfoundry/bosh-agent/v2/agent/applier/packages/fakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/fakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
@@ -59,6 +62,7 @@ var _ = Describe("concreteApplier", func() {
 		jobSupervisor     *fakejobsuper.FakeJobSupervisor
 		agentApplier      applier.Applier
 		settingsService   boshsettings.Service
+		progress          *boshtask.RunningProgress
 	)
 
 	BeforeEach(func() {
@@ -67,6 +71,7 @@ var _ = Describe("concreteApplier", func() {
 		logRotateDelegate = &FakeLogRotateDelegate{}
 		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
 		settingsService = &fakesettings.FakeSettingsService{}
+		progress = boshtask.NewRunningProgress()
 		agentApplier = applier.NewConcreteApplier(
 			jobApplier,
 			packageApplier,
@@ -74,6 +79,7 @@ var _ = Describe("concreteApplier", func() {
 			jobSupervisor,
 			boshdirs.NewProvider("/fake-base-dir"),
 			settingsService.GetSettings(),
+			progress,
 		)
 	})
 
@@ -146,6 +152,94 @@ var _ = Describe("concreteApplier", func() {
 		})
 	})
 
+	Describe("downloading jobs and packages", func() {
+		It("reports each downloaded job and package as progress", func() {
+			err := agentApplier.Prepare(&fakeas.FakeApplySpec{
+				JobResults:     []models.Job{buildJob()},
+				PackageResults: []models.Package{buildPackage(), buildPackage()},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			reported, found := progress.Progress()
+			Expect(found).To(BeTrue())
+			Expect(reported).To(Equal(boshtask.Progress{Done: 3, Total: 3}))
+		})
+
+		It("downloads jobs and packages before enabling them when applying", func() {
+			job := buildJob()
+			pkg := buildPackage()
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}, PackageResults: []models.Package{pkg}})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobApplier.PrepareCallCount()).To(Equal(1))
+			Expect(packageApplier.ActionsCalled).To(Equal([]string{"Prepare", "Apply"}))
+
+			reported, _ := progress.Progress()
+			Expect(reported).To(Equal(boshtask.Progress{Done: 2, Total: 2}))
+		})
+
+		It("runs at most Env.Bosh.Parallel downloads at a time", func() {
+			parallel := 2
+			settings := boshsettings.Settings{Env: boshsettings.Env{Bosh: boshsettings.BoshEnv{Parallel: &parallel}}}
+			agentApplier = applier.NewConcreteApplier(
+				jobApplier,
+				packageApplier,
+				logRotateDelegate,
+				jobSupervisor,
+				boshdirs.NewProvider("/fake-base-dir"),
+				settings,
+				progress,
+			)
+
+			var lock sync.Mutex
+			running, maxRunning := 0, 0
+			packageApplier.PrepareStub = func(_ models.Package) error {
+				lock.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				lock.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+
+				lock.Lock()
+				running--
+				lock.Unlock()
+				return nil
+			}
+
+			err := agentApplier.Prepare(&fakeas.FakeApplySpec{
+				PackageResults: []models.Package{buildPackage(), buildPackage(), buildPackage(), buildPackage(), buildPackage()},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(maxRunning).To(Equal(2))
+		})
+
+		It("stops starting downloads once the task is cancelled", func() {
+			progress.Cancel()
+
+			err := agentApplier.Prepare(&fakeas.FakeApplySpec{PackageResults: []models.Package{buildPackage()}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Cancelled"))
+
+			Expect(packageApplier.PreparedPackages).To(BeEmpty())
+			Expect(jobApplier.DeleteSourceBlobsCallCount()).To(Equal(0))
+		})
+
+		It("does not enable anything when the apply is cancelled", func() {
+			progress.Cancel()
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{buildJob()}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Cancelled"))
+
+			Expect(jobApplier.ApplyCallCount()).To(Equal(0))
+			Expect(jobSupervisor.RemovedAllJobs).To(BeFalse())
+		})
+	})
+
 	Describe("Configure jobs", func() {
 		It("reloads job supervisor", func() {
 			job1 := models.Job{Name: "fake-job-name-1", Version: "fake-version-name-1"}
@@ -222,27 +316,6 @@ var _ = Describe("concreteApplier", func() {
 			Expect(err.Error()).To(ContainSubstring("fake-apply-job-error"))
 		})
 
-		It("asked jobApplier to keep only the jobs in the desired specs", func() {
-			desiredJob := buildJob()
-
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{desiredJob}})
-
-			Expect(err).ToNot(HaveOccurred())
-
-			Expect(jobApplier.KeepOnlyCallCount()).To(Equal(1))
-			Expect(jobApplier.KeepOnlyArgsForCall(0)).To(Equal([]models.Job{desiredJob}))
-		})
-
-		It("returns error when jobApplier fails to keep only the jobs in the desired specs", func() {
-			jobApplier.KeepOnlyReturns(errors.New("fake-keep-only-error"))
-
-			desiredJob := buildJob()
-
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{desiredJob}})
-			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("fake-keep-only-error"))
-		})
-
 		It("apply applies packages", func() {
 			pkg1 := buildPackage()
 			pkg2 := buildPackage()
@@ -262,22 +335,12 @@ var _ = Describe("concreteApplier", func() {
 			Expect(err.Error()).To(ContainSubstring("fake-apply-package-error"))
 		})
 
-		It("asked packageApplier to keep only the packages in the desired specs", func() {
-			desiredPkg := buildPackage()
-
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{PackageResults: []models.Package{desiredPkg}})
+		It("apply does not remove bundles that are not in the desired specs", func() {
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{buildJob()}, PackageResults: []models.Package{buildPackage()}})
 			Expect(err).ToNot(HaveOccurred())
-			Expect(packageApplier.KeptOnlyPackages).To(Equal([]models.Package{desiredPkg}))
-		})
-
-		It("returns error when packageApplier fails to keep only the packages in the desired specs", func() {
-			packageApplier.KeepOnlyErr = errors.New("fake-keep-only-error")
 
-			desiredPkg := buildPackage()
-
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{PackageResults: []models.Package{desiredPkg}})
-			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("fake-keep-only-error"))
+			Expect(jobApplier.KeepOnlyCallCount()).To(Equal(0))
+			Expect(packageApplier.ActionsCalled).ToNot(ContainElement("KeepOnly"))
 		})
 
 		It("apply does not configure jobs", func() {
@@ -342,4 +405,205 @@ var _ = Describe("concreteApplier", func() {
 			Expect(jobApplier.DeleteSourceBlobsArgsForCall(0)).To(Equal([]models.Job{job}))
 		})
 	})
+
+	Describe("Rollback", func() {
+		It("re-enables jobs and packages of the previous spec and configures its jobs", func() {
+			job1 := buildJob()
+			job2 := buildJob()
+			pkg := buildPackage()
+
+			previous := &fakeas.FakeApplySpec{JobResults: []models.Job{job1, job2}, PackageResults: []models.Package{pkg}, MaxLogFileSizeResult: "fake-size"}
+
+			err := agentApplier.Rollback(previous)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobSupervisor.RemovedAllJobs).To(BeTrue())
+			Expect(jobApplier.ApplyCallCount()).To(Equal(2))
+			Expect(jobApplier.ApplyArgsForCall(0)).To(Equal(job1))
+			Expect(jobApplier.ApplyArgsForCall(1)).To(Equal(job2))
+			Expect(packageApplier.AppliedPackages).To(Equal([]models.Package{pkg}))
+
+			Expect(jobApplier.ConfigureCallCount()).To(Equal(2))
+			Expect(jobSupervisor.Reloaded).To(BeTrue())
+			Expect(logRotateDelegate.SetupLogrotateArgs.Size).To(Equal("fake-size"))
+		})
+
+		It("does not delete source blobs since they were removed when the spec was first applied", func() {
+			err := agentApplier.Rollback(&fakeas.FakeApplySpec{JobResults: []models.Job{buildJob()}})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobApplier.DeleteSourceBlobsCallCount()).To(Equal(0))
+		})
+
+		It("returns error when re-enabling a job fails", func() {
+			jobApplier.ApplyReturns(errors.New("fake-apply-job-error"))
+
+			err := agentApplier.Rollback(&fakeas.FakeApplySpec{JobResults: []models.Job{buildJob()}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-apply-job-error"))
+			Expect(jobApplier.ConfigureCallCount()).To(Equal(0))
+		})
+
+		It("returns error when configuring jobs fails", func() {
+			jobApplier.ConfigureReturns(errors.New("fake-configure-job-error"))
+
+			err := agentApplier.Rollback(&fakeas.FakeApplySpec{JobResults: []models.Job{buildJob()}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-configure-job-error"))
+		})
+	})
+
+	Describe("KeepOnly", func() {
+		It("keeps jobs and packages of all given specs", func() {
+			job1 := buildJob()
+			job2 := buildJob()
+			pkg1 := buildPackage()
+			pkg2 := buildPackage()
+
+			err := agentApplier.KeepOnly(
+				&fakeas.FakeApplySpec{JobResults: []models.Job{job1}, PackageResults: []models.Package{pkg1}},
+				&fakeas.FakeApplySpec{JobResults: []models.Job{job2}, PackageResults: []models.Package{pkg2}},
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobApplier.KeepOnlyCallCount()).To(Equal(1))
+			Expect(jobApplier.KeepOnlyArgsForCall(0)).To(Equal([]models.Job{job1, job2}))
+			Expect(packageApplier.KeptOnlyPackages).To(Equal([]models.Package{pkg1, pkg2}))
+		})
+
+		It("returns error when jobApplier fails to keep only the jobs", func() {
+			jobApplier.KeepOnlyReturns(errors.New("fake-keep-only-error"))
+
+			err := agentApplier.KeepOnly(&fakeas.FakeApplySpec{JobResults: []models.Job{buildJob()}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-keep-only-error"))
+		})
+
+		It("returns error when packageApplier fails to keep only the packages", func() {
+			packageApplier.KeepOnlyErr = errors.New("fake-keep-only-error")
+
+			err := agentApplier.KeepOnly(&fakeas.FakeApplySpec{PackageResults: []models.Package{buildPackage()}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-keep-only-error"))
+		})
+	})
+
+	Describe("Plan", func() {
+		It("reports jobs and packages to download and bundles to delete", func() {
+			job := buildJob()
+			pkg := buildPackage()
+
+			jobApplier.PlanReturns([]models.Job{job}, []string{"/fake-jobs/old-job"}, nil)
+			packageApplier.PlanDownload = []models.Package{pkg}
+			packageApplier.PlanRemove = []string{"/fake-packages/old-pkg"}
+
+			desired := &fakeas.FakeApplySpec{JobResults: []models.Job{job}, PackageResults: []models.Package{pkg}}
+
+			plan, err := agentApplier.Plan(&fakeas.FakeApplySpec{}, desired)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.JobsToDownload).To(Equal([]string{job.Name + "/fake-version-name"}))
+			Expect(plan.PackagesToDownload).To(Equal([]string{pkg.Name + "/fake-package-name"}))
+			Expect(plan.JobBundlesToDelete).To(Equal([]string{"/fake-jobs/old-job"}))
+			Expect(plan.PackageBundlesToDelete).To(Equal([]string{"/fake-packages/old-pkg"}))
+
+			Expect(jobApplier.PlanArgsForCall(0)).To(Equal([]models.Job{job}))
+			Expect(packageApplier.PlannedPackages).To(Equal([]models.Package{pkg}))
+		})
+
+		It("does not change jobs, packages or the job supervisor", func() {
+			_, err := agentApplier.Plan(&fakeas.FakeApplySpec{}, &fakeas.FakeApplySpec{JobResults: []models.Job{buildJob()}})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobApplier.ApplyCallCount()).To(Equal(0))
+			Expect(jobApplier.KeepOnlyCallCount()).To(Equal(0))
+			Expect(jobApplier.DeleteSourceBlobsCallCount()).To(Equal(0))
+			Expect(packageApplier.ActionsCalled).To(BeEmpty())
+			Expect(jobSupervisor.RemovedAllJobs).To(BeFalse())
+			Expect(jobSupervisor.Reloaded).To(BeFalse())
+		})
+
+		It("reports added, removed and changed monit configurations", func() {
+			unchanged := models.Job{Name: "unchanged", Version: "v1"}
+			removed := models.Job{Name: "removed", Version: "v1"}
+			changed := models.Job{Name: "changed", Version: "v1"}
+			limited := models.Job{Name: "limited", Version: "v1"}
+			added := models.Job{Name: "added", Version: "v1"}
+
+			templatesOnly := models.Job{Name: "templates-only", Version: "v1"}
+
+			changedV2 := changed
+			changedV2.Version = "v2"
+			limitedWithLimits := limited
+			limitedWithLimits.ResourceLimits = &models.ResourceLimits{PidsMax: 100}
+			templatesOnlyV2 := templatesOnly
+			templatesOnlyV2.Version = "v2"
+
+			jobApplier.MonitConfigsStub = func(job models.Job) (map[string]string, bool, error) {
+				if job.Name == "changed" {
+					return map[string]string{"monit": "check process " + job.Version}, true, nil
+				}
+				return map[string]string{"monit": "check process"}, true, nil
+			}
+
+			plan, err := agentApplier.Plan(
+				&fakeas.FakeApplySpec{JobResults: []models.Job{unchanged, removed, changed, limited, templatesOnly}},
+				&fakeas.FakeApplySpec{JobResults: []models.Job{unchanged, changedV2, limitedWithLimits, templatesOnlyV2, added}},
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.MonitConfigs).To(Equal(applier.MonitConfigChanges{
+				Added:   []string{"added"},
+				Removed: []string{"removed"},
+				Changed: []string{"changed", "limited"},
+			}))
+
+			// Jobs with the same templates are not read
+			Expect(jobApplier.MonitConfigsCallCount()).To(Equal(4))
+		})
+
+		It("reports jobs that are not installed yet as changed", func() {
+			current := models.Job{Name: "job", Version: "v1"}
+			desired := models.Job{Name: "job", Version: "v2"}
+			jobApplier.MonitConfigsStub = func(job models.Job) (map[string]string, bool, error) {
+				return map[string]string{"monit": "check process"}, job.Version == "v1", nil
+			}
+
+			plan, err := agentApplier.Plan(
+				&fakeas.FakeApplySpec{JobResults: []models.Job{current}},
+				&fakeas.FakeApplySpec{JobResults: []models.Job{desired}},
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.MonitConfigs.Changed).To(Equal([]string{"job"}))
+		})
+
+		It("returns error when reading monit configurations fails", func() {
+			current := models.Job{Name: "job", Version: "v1"}
+			desired := models.Job{Name: "job", Version: "v2"}
+			jobApplier.MonitConfigsReturns(nil, false, errors.New("fake-monit-configs-error"))
+
+			_, err := agentApplier.Plan(
+				&fakeas.FakeApplySpec{JobResults: []models.Job{current}},
+				&fakeas.FakeApplySpec{JobResults: []models.Job{desired}},
+			)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-monit-configs-error"))
+		})
+
+		It("returns error when planning jobs fails", func() {
+			jobApplier.PlanReturns(nil, nil, errors.New("fake-plan-jobs-error"))
+
+			_, err := agentApplier.Plan(&fakeas.FakeApplySpec{}, &fakeas.FakeApplySpec{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-plan-jobs-error"))
+		})
+
+		It("returns error when planning packages fails", func() {
+			packageApplier.PlanErr = errors.New("fake-plan-packages-error")
+
+			_, err := agentApplier.Plan(&fakeas.FakeApplySpec{}, &fakeas.FakeApplySpec{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-plan-packages-error"))
+		})
+	})
 })
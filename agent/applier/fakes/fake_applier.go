@@ -1,6 +1,7 @@
 package fakes
 
 import (
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 )
@@ -18,6 +19,20 @@ type FakeApplier struct {
 	ConfiguredDesiredApplySpec boshas.ApplySpec
 	ConfiguredJobs             []models.Job
 	ConfiguredError            error
+
+	RolledBack                bool
+	RollbackPreviousApplySpec boshas.ApplySpec
+	RollbackError             error
+
+	KeptOnly           bool
+	KeepOnlyApplySpecs []boshas.ApplySpec
+	KeepOnlyError      error
+
+	Planned              bool
+	PlanCurrentApplySpec boshas.ApplySpec
+	PlanDesiredApplySpec boshas.ApplySpec
+	PlanResult           applier.Plan
+	PlanError            error
 }
 
 func NewFakeApplier() *FakeApplier {
@@ -41,3 +56,22 @@ func (s *FakeApplier) Apply(desiredApplySpec boshas.ApplySpec) error {
 	s.ApplyDesiredApplySpec = desiredApplySpec
 	return s.ApplyError
 }
+
+func (s *FakeApplier) Plan(currentApplySpec, desiredApplySpec boshas.ApplySpec) (applier.Plan, error) {
+	s.Planned = true
+	s.PlanCurrentApplySpec = currentApplySpec
+	s.PlanDesiredApplySpec = desiredApplySpec
+	return s.PlanResult, s.PlanError
+}
+
+func (s *FakeApplier) Rollback(previousApplySpec boshas.ApplySpec) error {
+	s.RolledBack = true
+	s.RollbackPreviousApplySpec = previousApplySpec
+	return s.RollbackError
+}
+
+func (s *FakeApplier) KeepOnly(applySpecs ...boshas.ApplySpec) error {
+	s.KeptOnly = true
+	s.KeepOnlyApplySpecs = applySpecs
+	return s.KeepOnlyError
+}
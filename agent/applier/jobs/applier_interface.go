@@ -12,4 +12,13 @@ type Applier interface {
 	Configure(job models.Job, jobIndex int) error
 	KeepOnly(jobs []models.Job) error
 	DeleteSourceBlobs(jobs []models.Job) error
+
+	// Plan reports which jobs are not installed yet and the install paths
+	// of bundles KeepOnly would remove, without changing anything
+	Plan(jobs []models.Job) (download []models.Job, remove []string, err error)
+
+	// MonitConfigs returns the rendered monit files of an installed job keyed by
+	// file name; found is false when the job is not installed, since its files
+	// are only known once it is downloaded
+	MonitConfigs(job models.Job) (configs map[string]string, found bool, err error)
 }
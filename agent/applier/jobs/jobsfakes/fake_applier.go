@@ -54,6 +54,36 @@ type FakeApplier struct {
 	keepOnlyReturnsOnCall map[int]struct {
 		result1 error
 	}
+	MonitConfigsStub        func(models.Job) (map[string]string, bool, error)
+	monitConfigsMutex       sync.RWMutex
+	monitConfigsArgsForCall []struct {
+		arg1 models.Job
+	}
+	monitConfigsReturns struct {
+		result1 map[string]string
+		result2 bool
+		result3 error
+	}
+	monitConfigsReturnsOnCall map[int]struct {
+		result1 map[string]string
+		result2 bool
+		result3 error
+	}
+	PlanStub        func([]models.Job) ([]models.Job, []string, error)
+	planMutex       sync.RWMutex
+	planArgsForCall []struct {
+		arg1 []models.Job
+	}
+	planReturns struct {
+		result1 []models.Job
+		result2 []string
+		result3 error
+	}
+	planReturnsOnCall map[int]struct {
+		result1 []models.Job
+		result2 []string
+		result3 error
+	}
 	PrepareStub        func(models.Job) error
 	prepareMutex       sync.RWMutex
 	prepareArgsForCall []struct {
@@ -324,6 +354,145 @@ func (fake *FakeApplier) KeepOnlyReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeApplier) MonitConfigs(arg1 models.Job) (map[string]string, bool, error) {
+	fake.monitConfigsMutex.Lock()
+	ret, specificReturn := fake.monitConfigsReturnsOnCall[len(fake.monitConfigsArgsForCall)]
+	fake.monitConfigsArgsForCall = append(fake.monitConfigsArgsForCall, struct {
+		arg1 models.Job
+	}{arg1})
+	stub := fake.MonitConfigsStub
+	fakeReturns := fake.monitConfigsReturns
+	fake.recordInvocation("MonitConfigs", []interface{}{arg1})
+	fake.monitConfigsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeApplier) MonitConfigsCallCount() int {
+	fake.monitConfigsMutex.RLock()
+	defer fake.monitConfigsMutex.RUnlock()
+	return len(fake.monitConfigsArgsForCall)
+}
+
+func (fake *FakeApplier) MonitConfigsCalls(stub func(models.Job) (map[string]string, bool, error)) {
+	fake.monitConfigsMutex.Lock()
+	defer fake.monitConfigsMutex.Unlock()
+	fake.MonitConfigsStub = stub
+}
+
+func (fake *FakeApplier) MonitConfigsArgsForCall(i int) models.Job {
+	fake.monitConfigsMutex.RLock()
+	defer fake.monitConfigsMutex.RUnlock()
+	argsForCall := fake.monitConfigsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeApplier) MonitConfigsReturns(result1 map[string]string, result2 bool, result3 error) {
+	fake.monitConfigsMutex.Lock()
+	defer fake.monitConfigsMutex.Unlock()
+	fake.MonitConfigsStub = nil
+	fake.monitConfigsReturns = struct {
+		result1 map[string]string
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeApplier) MonitConfigsReturnsOnCall(i int, result1 map[string]string, result2 bool, result3 error) {
+	fake.monitConfigsMutex.Lock()
+	defer fake.monitConfigsMutex.Unlock()
+	fake.MonitConfigsStub = nil
+	if fake.monitConfigsReturnsOnCall == nil {
+		fake.monitConfigsReturnsOnCall = make(map[int]struct {
+			result1 map[string]string
+			result2 bool
+			result3 error
+		})
+	}
+	fake.monitConfigsReturnsOnCall[i] = struct {
+		result1 map[string]string
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeApplier) Plan(arg1 []models.Job) ([]models.Job, []string, error) {
+	var arg1Copy []models.Job
+	if arg1 != nil {
+		arg1Copy = make([]models.Job, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.planMutex.Lock()
+	ret, specificReturn := fake.planReturnsOnCall[len(fake.planArgsForCall)]
+	fake.planArgsForCall = append(fake.planArgsForCall, struct {
+		arg1 []models.Job
+	}{arg1Copy})
+	stub := fake.PlanStub
+	fakeReturns := fake.planReturns
+	fake.recordInvocation("Plan", []interface{}{arg1Copy})
+	fake.planMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeApplier) PlanCallCount() int {
+	fake.planMutex.RLock()
+	defer fake.planMutex.RUnlock()
+	return len(fake.planArgsForCall)
+}
+
+func (fake *FakeApplier) PlanCalls(stub func([]models.Job) ([]models.Job, []string, error)) {
+	fake.planMutex.Lock()
+	defer fake.planMutex.Unlock()
+	fake.PlanStub = stub
+}
+
+func (fake *FakeApplier) PlanArgsForCall(i int) []models.Job {
+	fake.planMutex.RLock()
+	defer fake.planMutex.RUnlock()
+	argsForCall := fake.planArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeApplier) PlanReturns(result1 []models.Job, result2 []string, result3 error) {
+	fake.planMutex.Lock()
+	defer fake.planMutex.Unlock()
+	fake.PlanStub = nil
+	fake.planReturns = struct {
+		result1 []models.Job
+		result2 []string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeApplier) PlanReturnsOnCall(i int, result1 []models.Job, result2 []string, result3 error) {
+	fake.planMutex.Lock()
+	defer fake.planMutex.Unlock()
+	fake.PlanStub = nil
+	if fake.planReturnsOnCall == nil {
+		fake.planReturnsOnCall = make(map[int]struct {
+			result1 []models.Job
+			result2 []string
+			result3 error
+		})
+	}
+	fake.planReturnsOnCall[i] = struct {
+		result1 []models.Job
+		result2 []string
+		result3 error
+	}{result1, result2, result3}
+}
+
 func (fake *FakeApplier) Prepare(arg1 models.Job) error {
 	fake.prepareMutex.Lock()
 	ret, specificReturn := fake.prepareReturnsOnCall[len(fake.prepareArgsForCall)]
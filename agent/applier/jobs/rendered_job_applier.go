@@ -2,12 +2,15 @@ package jobs
 
 import (
 	"fmt"
+	"os"
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/packages"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	boshdisk "github.com/cloudfoundry/bosh-agent/v2/platform/disk"
 	"github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
@@ -32,6 +35,7 @@ type renderedJobApplier struct {
 	jobsBc                 boshbc.BundleCollection
 	logger                 boshlog.Logger
 	packageApplierProvider packages.ApplierProvider
+	projectQuota           boshdisk.ProjectQuota
 }
 
 func NewRenderedJobApplier(
@@ -40,6 +44,7 @@ func NewRenderedJobApplier(
 	jobsBc boshbc.BundleCollection,
 	jobSupervisor boshjobsuper.JobSupervisor,
 	packageApplierProvider packages.ApplierProvider,
+	projectQuota boshdisk.ProjectQuota,
 	fixPermissions FixPermissionsFunc,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
@@ -53,6 +58,7 @@ func NewRenderedJobApplier(
 		jobsBc:                 jobsBc,
 		logger:                 logger,
 		packageApplierProvider: packageApplierProvider,
+		projectQuota:           projectQuota,
 	}
 }
 
@@ -169,9 +175,23 @@ func (s *renderedJobApplier) Configure(job models.Job, jobIndex int) (err error)
 		return
 	}
 
+	quotaMB, err := s.persistentDiskQuotaMB(jobDir)
+	if err != nil {
+		err = bosherr.WrapErrorf(err, "Reading persistent disk quota of job %s", job.Name)
+		return
+	}
+
+	if quotaMB > 0 {
+		err = s.limitStoreDir(job, quotaMB)
+		if err != nil {
+			err = bosherr.WrapErrorf(err, "Configuring persistent disk quota for job %s", job.Name)
+			return
+		}
+	}
+
 	monitFilePath := path.Join(jobDir, "monit")
 	if s.fs.FileExists(monitFilePath) {
-		err = s.jobSupervisor.AddJob(job.Name, jobIndex, monitFilePath)
+		err = s.addJob(job, job.Name, jobIndex, monitFilePath)
 		if err != nil {
 			err = bosherr.WrapError(err, "Adding monit configuration")
 			return
@@ -188,7 +208,7 @@ func (s *renderedJobApplier) Configure(job models.Job, jobIndex int) (err error)
 		label := strings.Replace(path.Base(monitFilePath), ".monit", "", 1)
 		subJobName := fmt.Sprintf("%s_%s", job.Name, label)
 
-		err = s.jobSupervisor.AddJob(subJobName, jobIndex, monitFilePath)
+		err = s.addJob(job, subJobName, jobIndex, monitFilePath)
 		if err != nil {
 			err = bosherr.WrapErrorf(err, "Adding additional monit configuration %s", label)
 			return
@@ -198,6 +218,83 @@ func (s *renderedJobApplier) Configure(job models.Job, jobIndex int) (err error)
 	return nil
 }
 
+// persistentDiskQuotaMB reads the quota a job declares for its directory on the persistent disk.
+// Like the monit file, the persistent_disk_quota_mb file is rendered from the templates of the job's
+// release, so jobs can make the quota configurable through their properties. Jobs without the file,
+// or rendering it empty, are not limited.
+func (s *renderedJobApplier) persistentDiskQuotaMB(jobDir string) (uint64, error) {
+	quotaFilePath := path.Join(jobDir, "persistent_disk_quota_mb")
+	if !s.fs.FileExists(quotaFilePath) {
+		return 0, nil
+	}
+
+	contents, err := s.fs.ReadFileString(quotaFilePath)
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Reading quota file")
+	}
+
+	contents = strings.TrimSpace(contents)
+	if contents == "" {
+		return 0, nil
+	}
+
+	quotaMB, err := strconv.ParseUint(contents, 10, 64)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Parsing quota '%s'", contents)
+	}
+
+	return quotaMB, nil
+}
+
+// limitStoreDir creates the job's directory on the persistent disk and
+// limits it through a project quota of the file system mounted at the store dir.
+// The quota is skipped when there is no persistent disk or it has no project quotas.
+func (s *renderedJobApplier) limitStoreDir(job models.Job, quotaMB uint64) error {
+	storeDir := s.dirProvider.StoreDir()
+	jobStoreDir := path.Join(storeDir, job.Name)
+
+	supported, err := s.projectQuota.IsSupported(storeDir)
+	if err != nil {
+		return bosherr.WrapError(err, "Checking for project quotas")
+	}
+
+	if !supported {
+		s.logger.Warn(logTag, "Not limiting the persistent disk usage of job %s since '%s' is not a persistent disk with project quotas", job.Name, storeDir)
+		return nil
+	}
+
+	if !s.fs.FileExists(jobStoreDir) {
+		mode := os.FileMode(0770)
+
+		err := s.fs.MkdirAll(jobStoreDir, mode)
+		if err != nil {
+			return bosherr.WrapError(err, "Creating job store dir")
+		}
+
+		err = s.fs.Chmod(jobStoreDir, mode)
+		if err != nil {
+			return bosherr.WrapError(err, "Chmoding job store dir")
+		}
+
+		err = s.fs.Chown(jobStoreDir, "root:vcap")
+		if err != nil {
+			return bosherr.WrapError(err, "Chowning job store dir")
+		}
+	}
+
+	return s.projectQuota.SetDirectoryQuota(storeDir, jobStoreDir, boshdisk.ConvertFromMbToBytes(quotaMB))
+}
+
+// addJob hands a monit file to the job supervisor, which constrains
+// the processes of jobs with resource limits
+func (s *renderedJobApplier) addJob(job models.Job, jobName string, jobIndex int, monitFilePath string) error {
+	if job.ResourceLimits == nil {
+		return s.jobSupervisor.AddJob(jobName, jobIndex, monitFilePath)
+	}
+
+	return s.jobSupervisor.AddJobWithResourceLimits(jobName, jobIndex, monitFilePath, job.Name, *job.ResourceLimits)
+}
+
 func (s *renderedJobApplier) KeepOnly(jobs []models.Job) error {
 	s.logger.Debug(logTag, "Keeping only jobs %v", jobs)
 
@@ -240,6 +337,94 @@ func (s *renderedJobApplier) KeepOnly(jobs []models.Job) error {
 	return nil
 }
 
+func (s *renderedJobApplier) Plan(jobs []models.Job) ([]models.Job, []string, error) {
+	var download []models.Job
+
+	keep := map[boshbc.Bundle]bool{}
+
+	for _, job := range jobs {
+		jobBundle, err := s.jobsBc.Get(job)
+		if err != nil {
+			return nil, nil, bosherr.WrapError(err, "Getting job bundle")
+		}
+
+		jobInstalled, err := jobBundle.IsInstalled()
+		if err != nil {
+			return nil, nil, bosherr.WrapError(err, "Checking if job is installed")
+		}
+
+		if !jobInstalled {
+			download = append(download, job)
+		}
+
+		keep[jobBundle] = true
+	}
+
+	installedBundles, err := s.jobsBc.List()
+	if err != nil {
+		return nil, nil, bosherr.WrapError(err, "Retrieving installed bundles")
+	}
+
+	var remove []string
+
+	for _, installedBundle := range installedBundles {
+		if keep[installedBundle] {
+			continue
+		}
+
+		installPath, err := installedBundle.GetInstallPath()
+		if err != nil {
+			return nil, nil, bosherr.WrapError(err, "Getting the install path")
+		}
+
+		remove = append(remove, installPath)
+	}
+
+	return download, remove, nil
+}
+
+func (s *renderedJobApplier) MonitConfigs(job models.Job) (map[string]string, bool, error) {
+	jobBundle, err := s.jobsBc.Get(job)
+	if err != nil {
+		return nil, false, bosherr.WrapError(err, "Getting job bundle")
+	}
+
+	jobInstalled, err := jobBundle.IsInstalled()
+	if err != nil {
+		return nil, false, bosherr.WrapError(err, "Checking if job is installed")
+	}
+
+	if !jobInstalled {
+		return nil, false, nil
+	}
+
+	jobDir, err := jobBundle.GetInstallPath()
+	if err != nil {
+		return nil, false, bosherr.WrapError(err, "Looking up job directory")
+	}
+
+	monitFilePaths, err := s.fs.Glob(path.Join(jobDir, "*.monit"))
+	if err != nil {
+		return nil, false, bosherr.WrapError(err, "Looking for additional monit files")
+	}
+
+	monitFilePath := path.Join(jobDir, "monit")
+	if s.fs.FileExists(monitFilePath) {
+		monitFilePaths = append(monitFilePaths, monitFilePath)
+	}
+
+	configs := map[string]string{}
+
+	for _, monitFilePath := range monitFilePaths {
+		configs[path.Base(monitFilePath)], err = s.fs.ReadFileString(monitFilePath)
+		if err != nil {
+			return nil, false, bosherr.WrapErrorf(err, "Reading monit file %s", monitFilePath)
+		}
+	}
+
+	return configs, true, nil
+}
+
 func (s *renderedJobApplier) DeleteSourceBlobs(jobs []models.Job) error {
 	deletedBlobs := map[string]bool{}
 
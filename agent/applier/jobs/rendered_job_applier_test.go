@@ -24,6 +24,7 @@ import (
 	fakepackages "github.com/cloudfoundry/bosh-agent/v2/agent/applier/packages/fakes"
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/platform/disk/diskfakes"
 )
 
 var _ = Describe("renderedJobApplier", func() {
@@ -35,6 +36,7 @@ var _ = Describe("renderedJobApplier", func() {
 		fs                     *fakesys.FakeFileSystem
 		applier                jobs.Applier
 		fixPermissions         *fakeFixer
+		projectQuota           *diskfakes.FakeProjectQuota
 	)
 
 	BeforeEach(func() {
@@ -46,6 +48,7 @@ var _ = Describe("renderedJobApplier", func() {
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		dirProvider := directories.NewProvider("/fakebasedir")
 		fixPermissions = &fakeFixer{}
+		projectQuota = &diskfakes.FakeProjectQuota{}
 
 		applier = jobs.NewRenderedJobApplier(
 			blobstore,
@@ -53,6 +56,7 @@ var _ = Describe("renderedJobApplier", func() {
 			jobsBc,
 			jobSupervisor,
 			packageApplierProvider,
+			projectQuota,
 			fixPermissions.Fix,
 			fs,
 			logger,
@@ -410,6 +414,138 @@ var _ = Describe("renderedJobApplier", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(len(jobSupervisor.AddJobArgs)).To(Equal(0))
 		})
+
+		It("adds jobs without resource limits unconstrained", func() {
+			job, bundle := buildJob(jobsBc)
+			bundle.GetDirPath = "/path/to/job"
+			Expect(fs.WriteFileString("/path/to/job/monit", "some conf")).To(Succeed())
+
+			err := applier.Configure(job, 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(jobSupervisor.AddJobArgs).To(HaveLen(1))
+			Expect(jobSupervisor.AddJobLimits).To(BeEmpty())
+		})
+
+		It("adds all monit files of jobs with resource limits with the limits of the job", func() {
+			job, bundle := buildJob(jobsBc)
+			job.ResourceLimits = &models.ResourceLimits{CPUWeight: 50, MemoryMax: "1G"}
+			bundle.GetDirPath = "/path/to/job"
+
+			Expect(fs.WriteFileString("/path/to/job/monit", "some conf")).To(Succeed())
+			Expect(fs.WriteFileString("/path/to/job/subjob.monit", "some subjob conf")).To(Succeed())
+			fs.SetGlob("/path/to/job/*.monit", []string{"/path/to/job/subjob.monit"})
+
+			err := applier.Configure(job, 0)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobSupervisor.AddJobArgs).To(Equal([]fakejobsuper.AddJobArgs{
+				{Name: job.Name, Index: 0, ConfigPath: "/path/to/job/monit"},
+				{Name: job.Name + "_subjob", Index: 0, ConfigPath: "/path/to/job/subjob.monit"},
+			}))
+			limits := fakejobsuper.AddJobLimitsArgs{
+				LimitsName: job.Name,
+				Limits:     models.ResourceLimits{CPUWeight: 50, MemoryMax: "1G"},
+			}
+			Expect(jobSupervisor.AddJobLimits).To(Equal(map[string]fakejobsuper.AddJobLimitsArgs{
+				job.Name:             limits,
+				job.Name + "_subjob": limits,
+			}))
+		})
+
+		It("does not set a project quota for jobs without a persistent disk quota", func() {
+			job, bundle := buildJob(jobsBc)
+			bundle.GetDirPath = "/path/to/job"
+
+			err := applier.Configure(job, 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(projectQuota.SetDirectoryQuotaCallCount()).To(Equal(0))
+			Expect(fs.FileExists("/fakebasedir/store/" + job.Name)).To(BeFalse())
+		})
+
+		Context("when the job has a persistent disk quota", func() {
+			var job models.Job
+
+			BeforeEach(func() {
+				var bundle *fakebc.FakeBundle
+				job, bundle = buildJob(jobsBc)
+				bundle.GetDirPath = "/path/to/job"
+				Expect(fs.WriteFileString("/path/to/job/persistent_disk_quota_mb", "512\n")).To(Succeed())
+				projectQuota.IsSupportedReturns(true, nil)
+			})
+
+			It("creates the job store dir and limits it with a project quota", func() {
+				err := applier.Configure(job, 0)
+				Expect(err).ToNot(HaveOccurred())
+
+				jobStoreDir := fs.GetFileTestStat("/fakebasedir/store/" + job.Name)
+				Expect(jobStoreDir).ToNot(BeNil())
+				Expect(jobStoreDir.FileType).To(Equal(fakesys.FakeFileTypeDir))
+				Expect(jobStoreDir.FileMode).To(Equal(os.FileMode(0770)))
+				Expect(jobStoreDir.Username).To(Equal("root"))
+				Expect(jobStoreDir.Groupname).To(Equal("vcap"))
+
+				Expect(projectQuota.SetDirectoryQuotaCallCount()).To(Equal(1))
+				mountPoint, directory, limit := projectQuota.SetDirectoryQuotaArgsForCall(0)
+				Expect(mountPoint).To(Equal("/fakebasedir/store"))
+				Expect(directory).To(Equal("/fakebasedir/store/" + job.Name))
+				Expect(limit).To(Equal(uint64(512 * 1024 * 1024)))
+			})
+
+			It("keeps an existing job store dir as is", func() {
+				err := fs.MkdirAll("/fakebasedir/store/"+job.Name, os.FileMode(0750))
+				Expect(err).ToNot(HaveOccurred())
+
+				err = applier.Configure(job, 0)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fs.GetFileTestStat("/fakebasedir/store/" + job.Name).FileMode).To(Equal(os.FileMode(0750)))
+				Expect(projectQuota.SetDirectoryQuotaCallCount()).To(Equal(1))
+			})
+
+			It("skips the quota when the store dir does not support project quotas", func() {
+				projectQuota.IsSupportedReturns(false, nil)
+
+				err := applier.Configure(job, 0)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(projectQuota.IsSupportedArgsForCall(0)).To(Equal("/fakebasedir/store"))
+				Expect(projectQuota.SetDirectoryQuotaCallCount()).To(Equal(0))
+				Expect(fs.FileExists("/fakebasedir/store/" + job.Name)).To(BeFalse())
+			})
+
+			It("returns an error when project quota support cannot be checked", func() {
+				projectQuota.IsSupportedReturns(false, errors.New("fake-is-supported-err"))
+
+				err := applier.Configure(job, 0)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-is-supported-err"))
+			})
+
+			It("does not set a project quota when the job renders an empty quota", func() {
+				Expect(fs.WriteFileString("/path/to/job/persistent_disk_quota_mb", "\n")).To(Succeed())
+
+				err := applier.Configure(job, 0)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(projectQuota.SetDirectoryQuotaCallCount()).To(Equal(0))
+			})
+
+			It("returns an error when the quota is not a number of megabytes", func() {
+				Expect(fs.WriteFileString("/path/to/job/persistent_disk_quota_mb", "1G")).To(Succeed())
+
+				err := applier.Configure(job, 0)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Parsing quota '1G'"))
+				Expect(projectQuota.SetDirectoryQuotaCallCount()).To(Equal(0))
+			})
+
+			It("returns an error when the project quota cannot be set", func() {
+				projectQuota.SetDirectoryQuotaReturns(errors.New("fake-quota-err"))
+
+				err := applier.Configure(job, 0)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-quota-err"))
+			})
+		})
 	})
 
 	Describe("KeepOnly", func() {
@@ -472,6 +608,75 @@ var _ = Describe("renderedJobApplier", func() {
 		})
 	})
 
+	Describe("Plan", func() {
+		It("reports jobs that are not installed and bundles that are not kept", func() {
+			job1, bundle1 := buildJob(jobsBc)
+			job2, bundle2 := buildJob(jobsBc)
+			_, bundle3 := buildJob(jobsBc)
+
+			bundle1.Installed = true
+			bundle3.GetDirPath = "/fake-jobs/job3/fake-version"
+			jobsBc.ListBundles = []boshbc.Bundle{bundle1, bundle3}
+
+			download, remove, err := applier.Plan([]models.Job{job1, job2})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(download).To(Equal([]models.Job{job2}))
+			Expect(remove).To(Equal([]string{"/fake-jobs/job3/fake-version"}))
+
+			Expect(bundle1.ActionsCalled).To(BeEmpty())
+			Expect(bundle2.ActionsCalled).To(BeEmpty())
+			Expect(bundle3.ActionsCalled).To(BeEmpty())
+			Expect(blobstore.GetCallCount()).To(Equal(0))
+		})
+
+		It("returns error when bundle collection cannot retrieve bundle for job", func() {
+			job1, _ := buildJob(jobsBc)
+			jobsBc.GetErr = errors.New("fake-bc-get-error")
+
+			_, _, err := applier.Plan([]models.Job{job1})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-bc-get-error"))
+		})
+
+		It("returns error when bundle collection fails to return list of installed bundles", func() {
+			jobsBc.ListErr = errors.New("fake-bc-list-error")
+
+			_, _, err := applier.Plan([]models.Job{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-bc-list-error"))
+		})
+	})
+
+	Describe("MonitConfigs", func() {
+		It("reads the monit files of installed jobs", func() {
+			job, bundle := buildJob(jobsBc)
+			bundle.Installed = true
+			bundle.GetDirPath = "/path/to/job"
+
+			Expect(fs.WriteFileString("/path/to/job/monit", "some conf")).To(Succeed())
+			Expect(fs.WriteFileString("/path/to/job/subjob.monit", "some subjob conf")).To(Succeed())
+			fs.SetGlob("/path/to/job/*.monit", []string{"/path/to/job/subjob.monit"})
+
+			configs, found, err := applier.MonitConfigs(job)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(configs).To(Equal(map[string]string{"monit": "some conf", "subjob.monit": "some subjob conf"}))
+			Expect(blobstore.GetCallCount()).To(Equal(0))
+		})
+
+		It("reports jobs that are not installed as not found without downloading them", func() {
+			job, bundle := buildJob(jobsBc)
+
+			configs, found, err := applier.MonitConfigs(job)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+			Expect(configs).To(BeNil())
+
+			Expect(bundle.ActionsCalled).To(BeEmpty())
+			Expect(blobstore.GetCallCount()).To(Equal(0))
+		})
+	})
+
 	Describe("DeleteSourceBlobs", func() {
 		var jobOne, jobTwo, jobThree models.Job
 
@@ -14,6 +14,9 @@ type Job struct {
 	// Packages that this job depends on; however,
 	// currently it will contain packages from all jobs
 	Packages []Package
+
+	// Optional; jobs without limits are not placed in a dedicated cgroup
+	ResourceLimits *ResourceLimits
 }
 
 func (s Job) BundleName() string {
@@ -0,0 +1,17 @@
+package models
+
+// ResourceLimits constrain the processes of a job through its cgroup.
+// Zero values leave the corresponding kernel default in place.
+type ResourceLimits struct {
+	// Relative CPU weight in the range 1-10000 (kernel default 100)
+	CPUWeight uint64
+
+	// Memory limits in bytes, optionally suffixed with K, M or G
+	MemoryMax  string
+	MemoryHigh string
+
+	PidsMax uint64
+
+	// Relative IO weight in the range 1-10000 (kernel default 100)
+	IOWeight uint64
+}
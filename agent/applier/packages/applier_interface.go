@@ -8,4 +8,8 @@ type Applier interface {
 	Prepare(pkg models.Package) error
 	Apply(pkg models.Package) error
 	KeepOnly(pkgs []models.Package) error
+
+	// Plan reports which packages are not installed yet and the install paths
+	// of bundles KeepOnly would disable, without changing anything
+	Plan(pkgs []models.Package) (download []models.Package, remove []string, err error)
 }
@@ -146,3 +146,49 @@ func (s *compiledPackageApplier) KeepOnly(pkgs []models.Package) error {
 
 	return nil
 }
+
+func (s *compiledPackageApplier) Plan(pkgs []models.Package) ([]models.Package, []string, error) {
+	var download []models.Package
+
+	keep := map[bc.Bundle]bool{}
+
+	for _, pkg := range pkgs {
+		pkgBundle, err := s.packagesBc.Get(pkg)
+		if err != nil {
+			return nil, nil, bosherr.WrapError(err, "Getting package bundle")
+		}
+
+		pkgInstalled, err := pkgBundle.IsInstalled()
+		if err != nil {
+			return nil, nil, bosherr.WrapError(err, "Checking if package is installed")
+		}
+
+		if !pkgInstalled {
+			download = append(download, pkg)
+		}
+
+		keep[pkgBundle] = true
+	}
+
+	installedBundles, err := s.packagesBc.List()
+	if err != nil {
+		return nil, nil, bosherr.WrapError(err, "Retrieving installed bundles")
+	}
+
+	var remove []string
+
+	for _, installedBundle := range installedBundles {
+		if keep[installedBundle] {
+			continue
+		}
+
+		installPath, err := installedBundle.GetInstallPath()
+		if err != nil {
+			return nil, nil, bosherr.WrapError(err, "Getting the install path")
+		}
+
+		remove = append(remove, installPath)
+	}
+
+	return download, remove, nil
+}
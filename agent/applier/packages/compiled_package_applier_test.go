@@ -352,5 +352,44 @@ func init() { //nolint:funlen,gochecknoinits
 				ItReturnsErrors()
 			})
 		})
+
+		Describe("Plan", func() {
+			It("reports packages that are not installed and bundles that are not kept", func() {
+				pkg1, bundle1 := buildPkg(packagesBc)
+				pkg2, bundle2 := buildPkg(packagesBc)
+				_, bundle3 := buildPkg(packagesBc)
+
+				bundle1.Installed = true
+				bundle3.GetDirPath = "/fake-packages/pkg3/fake-version"
+				packagesBc.ListBundles = []boshbc.Bundle{bundle1, bundle3}
+
+				download, remove, err := applier.Plan([]models.Package{pkg1, pkg2})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(download).To(Equal([]models.Package{pkg2}))
+				Expect(remove).To(Equal([]string{"/fake-packages/pkg3/fake-version"}))
+
+				Expect(bundle1.ActionsCalled).To(BeEmpty())
+				Expect(bundle2.ActionsCalled).To(BeEmpty())
+				Expect(bundle3.ActionsCalled).To(BeEmpty())
+				Expect(blobstore.GetCallCount()).To(Equal(0))
+			})
+
+			It("returns error when bundle collection fails to return list of installed bundles", func() {
+				packagesBc.ListErr = errors.New("fake-bc-list-error")
+
+				_, _, err := applier.Plan([]models.Package{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-bc-list-error"))
+			})
+
+			It("returns error when checking if a package is installed fails", func() {
+				pkg1, bundle1 := buildPkg(packagesBc)
+				bundle1.IsInstalledErr = errors.New("fake-is-installed-error")
+
+				_, _, err := applier.Plan([]models.Package{pkg1})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-is-installed-error"))
+			})
+		})
 	})
 }
@@ -17,8 +17,14 @@ type FakeApplier struct {
 
 	KeptOnlyPackages []models.Package
 	KeepOnlyErr      error
-	applyMutex       sync.Mutex
-	PrepareStub      func(pkg models.Package) error
+
+	PlannedPackages []models.Package
+	PlanDownload    []models.Package
+	PlanRemove      []string
+	PlanErr         error
+
+	applyMutex  sync.Mutex
+	PrepareStub func(pkg models.Package) error
 }
 
 func NewFakeApplier() *FakeApplier {
@@ -49,3 +55,8 @@ func (s *FakeApplier) KeepOnly(pkgs []models.Package) error {
 	s.KeptOnlyPackages = pkgs
 	return s.KeepOnlyErr
 }
+
+func (s *FakeApplier) Plan(pkgs []models.Package) ([]models.Package, []string, error) {
+	s.PlannedPackages = pkgs
+	return s.PlanDownload, s.PlanRemove, s.PlanErr
+}
@@ -0,0 +1,111 @@
+package applier
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
+// Plan lists the changes Apply would make to reach a desired apply spec
+type Plan struct {
+	JobsToDownload     []string `json:"jobs_to_download"`
+	PackagesToDownload []string `json:"packages_to_download"`
+
+	JobBundlesToDelete     []string `json:"job_bundles_to_delete"`
+	PackageBundlesToDelete []string `json:"package_bundles_to_delete"`
+
+	MonitConfigs MonitConfigChanges `json:"monit_configs"`
+}
+
+// MonitConfigChanges lists jobs by name. A job's monit configuration is considered
+// changed when its rendered monit files or resource limits differ.
+type MonitConfigChanges struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+}
+
+func (a *concreteApplier) diffMonitConfigs(currentJobs, desiredJobs []models.Job) (MonitConfigChanges, error) {
+	changes := MonitConfigChanges{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []string{},
+	}
+
+	current := map[string]models.Job{}
+	for _, job := range currentJobs {
+		current[job.Name] = job
+	}
+
+	desired := map[string]bool{}
+	for _, job := range desiredJobs {
+		desired[job.Name] = true
+
+		currentJob, found := current[job.Name]
+		if !found {
+			changes.Added = append(changes.Added, job.Name)
+			continue
+		}
+
+		changed, err := a.monitConfigChanged(currentJob, job)
+		if err != nil {
+			return MonitConfigChanges{}, bosherr.WrapErrorf(err, "Comparing monit configuration of job %s", job.Name)
+		}
+
+		if changed {
+			changes.Changed = append(changes.Changed, job.Name)
+		}
+	}
+
+	for _, job := range currentJobs {
+		if !desired[job.Name] {
+			changes.Removed = append(changes.Removed, job.Name)
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Changed)
+
+	return changes, nil
+}
+
+// monitConfigChanged compares the rendered monit files of jobs whose
+// templates differ, since most template changes do not touch them. Planning
+// does not download jobs, so jobs that are not installed yet count as changed.
+func (a *concreteApplier) monitConfigChanged(currentJob, desiredJob models.Job) (bool, error) {
+	if !reflect.DeepEqual(currentJob.ResourceLimits, desiredJob.ResourceLimits) {
+		return true, nil
+	}
+
+	if currentJob.Version == desiredJob.Version && digestString(currentJob.Source.Sha1) == digestString(desiredJob.Source.Sha1) {
+		return false, nil
+	}
+
+	currentConfigs, found, err := a.jobApplier.MonitConfigs(currentJob)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Reading installed monit files")
+	}
+
+	if !found {
+		return true, nil
+	}
+
+	desiredConfigs, found, err := a.jobApplier.MonitConfigs(desiredJob)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Reading desired monit files")
+	}
+
+	return !found || !reflect.DeepEqual(currentConfigs, desiredConfigs), nil
+}
+
+func digestString(digest crypto.Digest) string {
+	if digest == nil {
+		return ""
+	}
+	return digest.String()
+}
@@ -0,0 +1,346 @@
+package blobstore
+
+import (
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	DefaultBlobCacheMaxSizeMB = 1024
+
+	blobCacheLogTag    = "blobCache"
+	blobCacheTmpMarker = ".tmp-"
+)
+
+type BlobCacheOptions struct {
+	// Maximum total size of cached blobs in megabytes;
+	// defaults to DefaultBlobCacheMaxSizeMB when not set.
+	// A negative value disables the cache.
+	MaxSizeMB int
+}
+
+type BlobCacheStats struct {
+	Hits         uint64 `json:"hits"`
+	Misses       uint64 `json:"misses"`
+	Evictions    uint64 `json:"evictions"`
+	Entries      int    `json:"entries"`
+	SizeBytes    int64  `json:"size_bytes"`
+	MaxSizeBytes int64  `json:"max_size_bytes"`
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . BlobCache
+
+type BlobCache interface {
+	// Get copies the blob cached under digest to a temporary file owned by the caller.
+	// Entries that fail digest verification are removed and reported as not found.
+	Get(digest boshcrypto.Digest) (filePath string, found bool, err error)
+
+	// Put stores a copy of the file under digest, evicting least recently used blobs
+	// to stay within the size limit. Blobs larger than the limit are not cached.
+	Put(digest boshcrypto.Digest, filePath string) error
+
+	Stats() BlobCacheStats
+}
+
+type blobCacheEntry struct {
+	size     int64
+	lastUsed uint64
+}
+
+// blobCache keeps blobs on the ephemeral disk keyed by their strongest digest
+// so that recreates, repairs and compilations do not download them again
+type blobCache struct {
+	fs      boshsys.FileSystem
+	dir     string
+	maxSize int64
+	logger  boshlog.Logger
+
+	lock     sync.Mutex
+	loaded   bool
+	entries  map[string]*blobCacheEntry
+	size     int64
+	useCount uint64
+	tmpCount uint64
+	stats    BlobCacheStats
+}
+
+func NewBlobCache(fs boshsys.FileSystem, dir string, options BlobCacheOptions, logger boshlog.Logger) BlobCache {
+	if options.MaxSizeMB == 0 {
+		options.MaxSizeMB = DefaultBlobCacheMaxSizeMB
+	}
+
+	return &blobCache{
+		fs:      fs,
+		dir:     dir,
+		maxSize: int64(options.MaxSizeMB) << 20,
+		logger:  logger,
+		entries: map[string]*blobCacheEntry{},
+	}
+}
+
+func (c *blobCache) Get(digest boshcrypto.Digest) (string, bool, error) {
+	key, ok := blobCacheKey(digest)
+	if !ok || !c.enabled() {
+		return "", false, nil
+	}
+
+	c.lock.Lock()
+	err := c.load()
+	if err != nil {
+		c.lock.Unlock()
+		return "", false, err
+	}
+
+	entry, found := c.entries[key]
+	if !found {
+		c.stats.Misses++
+		c.lock.Unlock()
+		return "", false, nil
+	}
+
+	// Marking the entry as used first keeps it from being evicted while it is copied
+	c.useCount++
+	entry.lastUsed = c.useCount
+	c.lock.Unlock()
+
+	// Copying happens outside the lock so that parallel downloads are not serialized
+	filePath, err := c.copyOut(key, digest)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err != nil {
+		// The entry may have been evicted and cached again in the meantime
+		if c.entries[key] == entry {
+			c.logger.Warn(blobCacheLogTag, "Removing cached blob '%s': %s", key, err.Error())
+			c.remove(key)
+		}
+		c.stats.Misses++
+		return "", false, nil
+	}
+
+	c.stats.Hits++
+
+	c.logger.Debug(blobCacheLogTag, "Found blob '%s' in cache", key)
+
+	return filePath, true, nil
+}
+
+func (c *blobCache) Put(digest boshcrypto.Digest, filePath string) error {
+	key, ok := blobCacheKey(digest)
+	if !ok || !c.enabled() {
+		return nil
+	}
+
+	info, err := c.fs.Stat(filePath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Checking size of blob '%s'", filePath)
+	}
+
+	if info.Size() > c.maxSize {
+		c.logger.Debug(blobCacheLogTag, "Not caching blob '%s' larger than the cache", key)
+		return nil
+	}
+
+	c.lock.Lock()
+	err = c.load()
+	if _, found := c.entries[key]; found || err != nil {
+		c.lock.Unlock()
+		return err
+	}
+	c.tmpCount++
+	tmpPath := path.Join(c.dir, key+blobCacheTmpMarker+strconv.FormatUint(c.tmpCount, 10))
+	c.lock.Unlock()
+
+	// Copying happens outside the lock so that parallel downloads are not serialized
+	err = c.fs.CopyFile(filePath, tmpPath)
+	if err != nil {
+		_ = c.fs.RemoveAll(tmpPath)
+		return bosherr.WrapErrorf(err, "Copying blob '%s' to cache", key)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, found := c.entries[key]; found {
+		return c.fs.RemoveAll(tmpPath)
+	}
+
+	c.evict(info.Size())
+
+	err = c.fs.Rename(tmpPath, path.Join(c.dir, key))
+	if err != nil {
+		_ = c.fs.RemoveAll(tmpPath)
+		return bosherr.WrapErrorf(err, "Adding blob '%s' to cache", key)
+	}
+
+	c.useCount++
+	c.entries[key] = &blobCacheEntry{size: info.Size(), lastUsed: c.useCount}
+	c.size += info.Size()
+
+	return nil
+}
+
+func (c *blobCache) Stats() BlobCacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	stats.SizeBytes = c.size
+
+	if c.enabled() {
+		stats.MaxSizeBytes = c.maxSize
+	}
+
+	return stats
+}
+
+func (c *blobCache) enabled() bool {
+	return c.maxSize > 0
+}
+
+// load indexes blobs cached before the agent started; their modification
+// time stands in for the last use which is otherwise only tracked in memory
+func (c *blobCache) load() error {
+	if c.loaded {
+		return nil
+	}
+
+	err := c.fs.MkdirAll(c.dir, 0700)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating blob cache directory")
+	}
+
+	matches, err := c.fs.Glob(path.Join(c.dir, "*"))
+	if err != nil {
+		return bosherr.WrapError(err, "Listing cached blobs")
+	}
+
+	type cachedBlob struct {
+		key  string
+		info os.FileInfo
+	}
+
+	var blobs []cachedBlob
+
+	for _, match := range matches {
+		if strings.Contains(path.Base(match), blobCacheTmpMarker) {
+			_ = c.fs.RemoveAll(match)
+			continue
+		}
+
+		info, err := c.fs.Stat(match)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Checking cached blob '%s'", match)
+		}
+
+		blobs = append(blobs, cachedBlob{key: path.Base(match), info: info})
+	}
+
+	sort.Slice(blobs, func(i, j int) bool {
+		return blobs[i].info.ModTime().Before(blobs[j].info.ModTime())
+	})
+
+	for _, blob := range blobs {
+		c.useCount++
+		c.entries[blob.key] = &blobCacheEntry{size: blob.info.Size(), lastUsed: c.useCount}
+		c.size += blob.info.Size()
+	}
+
+	c.loaded = true
+
+	return nil
+}
+
+// copyOut verifies the cached blob while copying it to a temporary file
+func (c *blobCache) copyOut(key string, digest boshcrypto.Digest) (string, error) {
+	src, err := c.fs.OpenFile(path.Join(c.dir, key), os.O_RDONLY, 0)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening cached blob")
+	}
+	defer src.Close() //nolint:errcheck
+
+	dst, err := c.fs.TempFile("bosh-blob-cache")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating temporary file")
+	}
+	defer dst.Close() //nolint:errcheck
+
+	err = digest.Verify(io.TeeReader(src, dst))
+	if err != nil {
+		_ = c.fs.RemoveAll(dst.Name())
+		return "", bosherr.WrapError(err, "Verifying cached blob")
+	}
+
+	return dst.Name(), nil
+}
+
+func (c *blobCache) evict(incoming int64) {
+	for c.size+incoming > c.maxSize && len(c.entries) > 0 {
+		var oldestKey string
+		var oldest *blobCacheEntry
+
+		for key, entry := range c.entries {
+			if oldest == nil || entry.lastUsed < oldest.lastUsed {
+				oldestKey, oldest = key, entry
+			}
+		}
+
+		c.logger.Debug(blobCacheLogTag, "Evicting blob '%s' from cache", oldestKey)
+		c.remove(oldestKey)
+		c.stats.Evictions++
+	}
+}
+
+func (c *blobCache) remove(key string) {
+	err := c.fs.RemoveAll(path.Join(c.dir, key))
+	if err != nil {
+		c.logger.Warn(blobCacheLogTag, "Removing cached blob '%s': %s", key, err.Error())
+	}
+
+	if entry, found := c.entries[key]; found {
+		c.size -= entry.size
+		delete(c.entries, key)
+	}
+}
+
+// blobCacheKey names entries after the strongest digest so that the same
+// content referenced with different sets of digests shares an entry
+// whenever the strongest algorithm matches
+func blobCacheKey(digest boshcrypto.Digest) (string, bool) {
+	if digest == nil {
+		return "", false
+	}
+
+	if multiDigest, ok := digest.(boshcrypto.MultipleDigest); ok {
+		if multiDigest.String() == "" {
+			return "", false
+		}
+
+		strongest, err := multiDigest.DigestFor(multiDigest.Algorithm())
+		if err != nil {
+			return "", false
+		}
+		digest = strongest
+	}
+
+	algorithm := digest.Algorithm().Name()
+	value := strings.TrimPrefix(digest.String(), algorithm+":")
+
+	if value == "" || strings.ContainsAny(value, `/\.`) {
+		return "", false
+	}
+
+	return algorithm + "-" + value, true
+}
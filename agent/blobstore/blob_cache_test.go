@@ -0,0 +1,209 @@
+package blobstore_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshagentblobstore "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore"
+)
+
+var _ = Describe("BlobCache", func() {
+	var (
+		fs       boshsys.FileSystem
+		cacheDir string
+		srcDir   string
+		options  boshagentblobstore.BlobCacheOptions
+
+		blobCache boshagentblobstore.BlobCache
+	)
+
+	digestOf := func(content string) boshcrypto.Digest {
+		digest, err := boshcrypto.DigestAlgorithmSHA256.CreateDigest(strings.NewReader(content))
+		Expect(err).ToNot(HaveOccurred())
+		return digest
+	}
+
+	writeBlob := func(name, content string) string {
+		blobPath := filepath.Join(srcDir, name)
+		Expect(os.WriteFile(blobPath, []byte(content), 0600)).To(Succeed())
+		return blobPath
+	}
+
+	readBlob := func(blobPath string) string {
+		content, err := os.ReadFile(blobPath)
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+
+		cacheDir = filepath.Join(GinkgoT().TempDir(), "blob_cache")
+		srcDir = GinkgoT().TempDir()
+		options = boshagentblobstore.BlobCacheOptions{}
+	})
+
+	JustBeforeEach(func() {
+		blobCache = boshagentblobstore.NewBlobCache(fs, cacheDir, options, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	It("returns a copy of a cached blob", func() {
+		digest := digestOf("fake-content")
+
+		_, found, err := blobCache.Get(digest)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+
+		err = blobCache.Put(digest, writeBlob("blob", "fake-content"))
+		Expect(err).ToNot(HaveOccurred())
+
+		blobPath, found, err := blobCache.Get(digest)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(readBlob(blobPath)).To(Equal("fake-content"))
+		Expect(filepath.Dir(blobPath)).ToNot(Equal(cacheDir))
+
+		Expect(os.Remove(blobPath)).To(Succeed())
+		Expect(blobCache.Stats()).To(Equal(boshagentblobstore.BlobCacheStats{
+			Hits:         1,
+			Misses:       1,
+			Entries:      1,
+			SizeBytes:    int64(len("fake-content")),
+			MaxSizeBytes: boshagentblobstore.DefaultBlobCacheMaxSizeMB << 20,
+		}))
+	})
+
+	It("finds blobs by the strongest of multiple digests", func() {
+		sha1, err := boshcrypto.DigestAlgorithmSHA1.CreateDigest(strings.NewReader("fake-content"))
+		Expect(err).ToNot(HaveOccurred())
+
+		err = blobCache.Put(digestOf("fake-content"), writeBlob("blob", "fake-content"))
+		Expect(err).ToNot(HaveOccurred())
+
+		blobPath, found, err := blobCache.Get(boshcrypto.MustNewMultipleDigest(sha1, digestOf("fake-content")))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(readBlob(blobPath)).To(Equal("fake-content"))
+	})
+
+	It("removes cached blobs that do not match their digest", func() {
+		digest := digestOf("fake-content")
+
+		err := blobCache.Put(digest, writeBlob("blob", "fake-content"))
+		Expect(err).ToNot(HaveOccurred())
+
+		entries, err := filepath.Glob(filepath.Join(cacheDir, "*"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(os.WriteFile(entries[0], []byte("corrupted"), 0600)).To(Succeed())
+
+		_, found, err := blobCache.Get(digest)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+		Expect(entries[0]).ToNot(BeAnExistingFile())
+
+		stats := blobCache.Stats()
+		Expect(stats.Entries).To(Equal(0))
+		Expect(stats.Misses).To(Equal(uint64(1)))
+	})
+
+	It("does not cache blobs without a digest", func() {
+		err := blobCache.Put(boshcrypto.MultipleDigest{}, writeBlob("blob", "fake-content"))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, found, err := blobCache.Get(nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+
+		Expect(blobCache.Stats().Entries).To(Equal(0))
+	})
+
+	It("indexes blobs cached before it was created", func() {
+		err := blobCache.Put(digestOf("fake-content"), writeBlob("blob", "fake-content"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(cacheDir, "sha256-abc.tmp-1"), []byte("partial"), 0600)).To(Succeed())
+
+		blobCache = boshagentblobstore.NewBlobCache(fs, cacheDir, options, boshlog.NewLogger(boshlog.LevelNone))
+
+		_, found, err := blobCache.Get(digestOf("fake-content"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+
+		Expect(blobCache.Stats().Entries).To(Equal(1))
+		Expect(filepath.Join(cacheDir, "sha256-abc.tmp-1")).ToNot(BeAnExistingFile())
+	})
+
+	Context("when the cache is full", func() {
+		var blobA, blobB, blobC string
+
+		BeforeEach(func() {
+			options.MaxSizeMB = 1
+
+			blobA = strings.Repeat("a", 400*1024)
+			blobB = strings.Repeat("b", 400*1024)
+			blobC = strings.Repeat("c", 400*1024)
+		})
+
+		It("evicts the least recently used blobs", func() {
+			Expect(blobCache.Put(digestOf(blobA), writeBlob("a", blobA))).To(Succeed())
+			Expect(blobCache.Put(digestOf(blobB), writeBlob("b", blobB))).To(Succeed())
+
+			_, found, err := blobCache.Get(digestOf(blobA))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			Expect(blobCache.Put(digestOf(blobC), writeBlob("c", blobC))).To(Succeed())
+
+			_, found, err = blobCache.Get(digestOf(blobB))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+
+			for _, content := range []string{blobA, blobC} {
+				_, found, err = blobCache.Get(digestOf(content))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+			}
+
+			stats := blobCache.Stats()
+			Expect(stats.Evictions).To(Equal(uint64(1)))
+			Expect(stats.Entries).To(Equal(2))
+			Expect(stats.SizeBytes).To(Equal(int64(800 * 1024)))
+		})
+
+		It("does not cache blobs larger than the cache", func() {
+			blob := strings.Repeat("d", 2<<20)
+
+			Expect(blobCache.Put(digestOf(blob), writeBlob("d", blob))).To(Succeed())
+
+			Expect(blobCache.Stats().Entries).To(Equal(0))
+		})
+	})
+
+	Context("when the cache is disabled", func() {
+		BeforeEach(func() {
+			options.MaxSizeMB = -1
+		})
+
+		It("neither stores nor finds blobs", func() {
+			digest := digestOf("fake-content")
+
+			Expect(blobCache.Put(digest, writeBlob("blob", "fake-content"))).To(Succeed())
+
+			_, found, err := blobCache.Get(digest)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+
+			Expect(cacheDir).ToNot(BeADirectory())
+			Expect(blobCache.Stats()).To(Equal(boshagentblobstore.BlobCacheStats{}))
+		})
+	})
+})
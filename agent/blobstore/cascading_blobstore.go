@@ -11,17 +11,20 @@ const logTag = "cascadingBlobstore"
 type cascadingBlobstore struct {
 	innerBlobstore utilblobstore.DigestBlobstore
 	blobManagers   []BlobManagerInterface
+	blobCache      BlobCache
 	logger         boshlog.Logger
 }
 
 func NewCascadingBlobstore(
 	innerBlobstore utilblobstore.DigestBlobstore,
 	blobManagers []BlobManagerInterface,
+	blobCache BlobCache,
 	logger boshlog.Logger,
 ) utilblobstore.DigestBlobstore {
 	return cascadingBlobstore{
 		innerBlobstore: innerBlobstore,
 		blobManagers:   blobManagers,
+		blobCache:      blobCache,
 		logger:         logger,
 	}
 }
@@ -40,7 +43,24 @@ func (b cascadingBlobstore) Get(blobID string, digest boshcrypto.Digest) (string
 		}
 	}
 
-	return b.innerBlobstore.Get(blobID, digest)
+	blobPath, found, err := b.blobCache.Get(digest)
+	if err != nil {
+		b.logger.Warn(logTag, "Looking up blob in cache: %s", err.Error())
+	} else if found {
+		return blobPath, nil
+	}
+
+	blobPath, err = b.innerBlobstore.Get(blobID, digest)
+	if err != nil {
+		return "", err
+	}
+
+	err = b.blobCache.Put(digest, blobPath)
+	if err != nil {
+		b.logger.Warn(logTag, "Adding blob to cache: %s", err.Error())
+	}
+
+	return blobPath, nil
 }
 
 func (b cascadingBlobstore) CleanUp(fileName string) error {
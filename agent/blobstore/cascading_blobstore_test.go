@@ -19,11 +19,13 @@ import (
 var _ = Describe("cascadingBlobstore", func() {
 	var (
 		innerBlobstore     *fakeblob.FakeDigestBlobstore
+		blobCache          *fakeagentblob.FakeBlobCache
 		cascadingBlobstore boshblob.DigestBlobstore
 	)
 
 	BeforeEach(func() {
 		innerBlobstore = &fakeblob.FakeDigestBlobstore{}
+		blobCache = &fakeagentblob.FakeBlobCache{}
 	})
 
 	Context("when there's a single blobManager", func() {
@@ -36,7 +38,7 @@ var _ = Describe("cascadingBlobstore", func() {
 			blobManager = &fakeagentblob.FakeBlobManagerInterface{}
 			logger := boshlog.NewLogger(boshlog.LevelNone)
 
-			cascadingBlobstore = blobstore.NewCascadingBlobstore(innerBlobstore, []blobstore.BlobManagerInterface{blobManager}, logger)
+			cascadingBlobstore = blobstore.NewCascadingBlobstore(innerBlobstore, []blobstore.BlobManagerInterface{blobManager}, blobCache, logger)
 		})
 
 		Describe("Get", func() {
@@ -111,6 +113,43 @@ var _ = Describe("cascadingBlobstore", func() {
 					Expect(receivedDigest).To(Equal(digest))
 				})
 
+				It("adds the downloaded blob to the cache", func() {
+					digest := boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "smurf-4-sha")
+					innerBlobstore.GetReturns("/smurf-file/path", nil)
+
+					_, err := cascadingBlobstore.Get("smurf-4", digest)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(blobCache.GetCallCount()).To(Equal(1))
+					Expect(blobCache.GetArgsForCall(0)).To(Equal(digest))
+
+					Expect(blobCache.PutCallCount()).To(Equal(1))
+					cachedDigest, cachedPath := blobCache.PutArgsForCall(0)
+					Expect(cachedDigest).To(Equal(digest))
+					Expect(cachedPath).To(Equal("/smurf-file/path"))
+				})
+
+				It("returns the cached blob without asking the inner blobstore", func() {
+					blobCache.GetReturns("/cached/smurf-file", true, nil)
+
+					filename, err := cascadingBlobstore.Get("smurf-4", boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "smurf-4-sha"))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(filename).To(Equal("/cached/smurf-file"))
+
+					Expect(innerBlobstore.GetCallCount()).To(Equal(0))
+					Expect(blobCache.PutCallCount()).To(Equal(0))
+				})
+
+				It("falls back to the inner blobstore when the cache fails", func() {
+					blobCache.GetReturns("", false, errors.New("fake-cache-error"))
+					blobCache.PutReturns(errors.New("fake-cache-error"))
+					innerBlobstore.GetReturns("/smurf-file/path", nil)
+
+					filename, err := cascadingBlobstore.Get("smurf-4", boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "smurf-4-sha"))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(filename).To(Equal("/smurf-file/path"))
+				})
+
 				Context("when inner blobstore returns an error", func() {
 
 					It("returns that error to the caller", func() {
@@ -262,6 +301,7 @@ var _ = Describe("cascadingBlobstore", func() {
 			cascadingBlobstore = blobstore.NewCascadingBlobstore(
 				innerBlobstore,
 				bmis,
+				blobCache,
 				logger,
 			)
 		})
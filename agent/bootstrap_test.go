@@ -1107,11 +1107,11 @@ var _ = Describe("bootstrap", func() {
 
 				compressor := boshcmd.NewTarballCompressor(runner, fs)
 				copier := boshcmd.NewGenericCpCopier(fs, logger)
-				logsTarProvider := boshlogstarprovider.NewLogsTarProvider(compressor, copier, dirProvider)
+				logsTarProvider := boshlogstarprovider.NewLogsTarProvider(compressor, copier, dirProvider, fs, runner, logger)
 
 				sigarCollector := boshsigar.NewSigarStatsCollector(&sigar.ConcreteSigar{})
 
-				vitalsService := boshvitals.NewService(sigarCollector, dirProvider, mounter)
+				vitalsService := boshvitals.NewService(sigarCollector, dirProvider, mounter, logger)
 
 				ipResolver := boship.NewResolver(boship.NetworkInterfaceToAddrsFunc)
 
@@ -1126,7 +1126,7 @@ var _ = Describe("bootstrap", func() {
 
 				dnsResolver := boshdnsresolver.NewResolveConfResolver(fs, runner)
 
-				ubuntuNetManager := boshnet.NewUbuntuNetManager(fs, runner, ipResolver, fakeMACAddressDetector, interfaceConfigurationCreator, interfaceAddrsProvider, dnsResolver, arping, kernelIPv6, logger)
+				ubuntuNetManager := boshnet.NewUbuntuNetManager(fs, runner, ipResolver, fakeMACAddressDetector, interfaceConfigurationCreator, interfaceAddrsProvider, dnsResolver, arping, kernelIPv6, boshnet.NewSystemdNetworkdRenderer(fs, runner), logger)
 				ubuntuCertManager := boshcert.NewUbuntuCertManager(fs, runner, 1, logger)
 
 				monitRetryable := boshplatform.NewMonitRetryable(&serviceManager)
@@ -12,6 +12,9 @@ type FakeFileLoggingCmdRunner struct {
 	RunCommandTaskName string
 	RunCommandResult   *boshcmdrunner.CmdResult
 	RunCommandErr      error
+
+	// Written to the command's stdout when it has one
+	RunCommandOutput string
 }
 
 func NewFakeFileLoggingCmdRunner() *FakeFileLoggingCmdRunner {
@@ -22,5 +25,10 @@ func (f *FakeFileLoggingCmdRunner) RunCommand(jobName, taskName string, cmd bosh
 	f.RunCommandJobName = jobName
 	f.RunCommandTaskName = taskName
 	f.RunCommands = append(f.RunCommands, cmd)
+
+	if cmd.Stdout != nil && f.RunCommandOutput != "" {
+		_, _ = cmd.Stdout.Write([]byte(f.RunCommandOutput))
+	}
+
 	return f.RunCommandResult, f.RunCommandErr
 }
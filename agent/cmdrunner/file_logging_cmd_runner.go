@@ -3,6 +3,7 @@ package cmdrunner
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"unicode/utf8"
@@ -85,7 +86,7 @@ func (f FileLoggingCmdRunner) RunCommand(jobName string, taskName string, cmd bo
 		_ = stdoutFile.Close() //nolint:errcheck
 	}()
 
-	cmd.Stdout = stdoutFile
+	cmd.Stdout = teeOutput(stdoutFile, cmd.Stdout)
 
 	stderrFile, err := f.fs.OpenFile(stderrPath, fileOpenFlag, fileOpenPerm)
 	if err != nil {
@@ -95,7 +96,7 @@ func (f FileLoggingCmdRunner) RunCommand(jobName string, taskName string, cmd bo
 		_ = stderrFile.Close() //nolint:errcheck
 	}()
 
-	cmd.Stderr = stderrFile
+	cmd.Stderr = teeOutput(stderrFile, cmd.Stderr)
 
 	// Stdout/stderr are redirected to the files
 	_, _, exitStatus, runErr := f.cmdRunner.RunComplexCommand(cmd)
@@ -127,6 +128,15 @@ func (f FileLoggingCmdRunner) RunCommand(jobName string, taskName string, cmd bo
 	return result, nil
 }
 
+// teeOutput also sends output to a writer set on the command by the caller
+// so that it can follow the output while the command runs
+func teeOutput(file boshsys.File, writer io.Writer) io.Writer {
+	if writer == nil {
+		return file
+	}
+	return io.MultiWriter(file, writer)
+}
+
 func (f FileLoggingCmdRunner) getTruncatedOutput(file boshsys.File, truncateLength int64) ([]byte, bool, error) {
 	isTruncated := false
 
@@ -1,6 +1,7 @@
 package cmdrunner_test
 
 import (
+	"bytes"
 	"errors"
 	"os"
 
@@ -130,6 +131,21 @@ var _ = Describe("FileLoggingCmdRunner", func() {
 				Expect(err).ToNot(HaveOccurred())
 				Expect(stdout).To(Equal("fake-stderr"))
 			})
+
+			It("also writes output to writers set on the command", func() {
+				output := &bytes.Buffer{}
+				cmd.Stdout = output
+				cmd.Stderr = output
+
+				_, err := runner.RunCommand("fake-log-dir-name", "fake-log-file-name", cmd)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(output.String()).To(Equal("fake-stdoutfake-stderr"))
+
+				stdout, err := fs.ReadFileString("/fake-base-dir/fake-log-dir-name/fake-log-file-name.stdout.log")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(stdout).To(Equal("fake-stdout"))
+			})
 		})
 
 		Context("when comamnd fails", func() {
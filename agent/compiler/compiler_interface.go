@@ -7,7 +7,16 @@ import (
 )
 
 type Compiler interface {
-	Compile(pkg Package, deps []boshmodels.Package) (blobID string, digest boshcrypto.Digest, err error)
+	// Compile returns where the packaging script's output log was uploaded alongside
+	// the compiled package, including when compilation fails after running the script
+	Compile(pkg Package, deps []boshmodels.Package) (blobID string, digest boshcrypto.Digest, log CompileLog, err error)
+}
+
+// CompileLog tells whether the packaging script's output log was uploaded.
+// Logs uploaded to a signed URL have no blob ID.
+type CompileLog struct {
+	BlobID   string
+	Uploaded bool
 }
 
 type Package struct {
@@ -15,6 +24,7 @@ type Package struct {
 	Name                string
 	PackageGetSignedURL string            `json:"package_get_signed_url"`
 	UploadSignedURL     string            `json:"upload_signed_url"`
+	LogUploadSignedURL  string            `json:"log_upload_signed_url"`
 	BlobstoreHeaders    map[string]string `json:"blobstore_headers"`
 	Sha1                boshcrypto.MultipleDigest
 	Version             string
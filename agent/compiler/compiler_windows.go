@@ -2,12 +2,13 @@ package compiler
 
 import (
 	"fmt"
+	"io"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
-func (c concreteCompiler) runPackagingCommand(compilePath, enablePath string, pkg Package) error {
+func (c concreteCompiler) runPackagingCommand(compilePath, enablePath string, pkg Package, output io.Writer) error {
 	command := boshsys.Command{
 		Name: "powershell",
 		Args: []string{"-command", fmt.Sprintf("iex (get-content -raw %s)", PackagingScriptName)},
@@ -18,6 +19,8 @@ func (c concreteCompiler) runPackagingCommand(compilePath, enablePath string, pk
 			"BOSH_PACKAGE_VERSION": pkg.Version,
 		},
 		WorkingDir: compilePath,
+		Stdout:     output,
+		Stderr:     output,
 	}
 
 	_, err := c.runner.RunCommand("compilation", PackagingScriptName, command)
@@ -2,14 +2,17 @@ package compiler
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path"
+	"sync"
 
 	"code.cloudfoundry.org/clock"
 
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	boshbc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection"
@@ -17,10 +20,13 @@ import (
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/packages"
 	boshcmdrunner "github.com/cloudfoundry/bosh-agent/v2/agent/cmdrunner"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
 const PackagingScriptName = "packaging"
 
+const compilerLogTag = "concreteCompiler"
+
 type CompileDirProvider interface {
 	CompileDir() string
 }
@@ -34,6 +40,8 @@ type concreteCompiler struct {
 	packageApplier     packages.Applier
 	packagesBc         boshbc.BundleCollection
 	timeProvider       clock.Clock
+	progress           *boshtask.RunningProgress
+	logger             boshlog.Logger
 }
 
 func NewConcreteCompiler(
@@ -45,6 +53,8 @@ func NewConcreteCompiler(
 	packageApplier packages.Applier,
 	packagesBc boshbc.BundleCollection,
 	timeProvider clock.Clock,
+	progress *boshtask.RunningProgress,
+	logger boshlog.Logger,
 ) Compiler {
 	return concreteCompiler{
 		compressor:         compressor,
@@ -55,32 +65,39 @@ func NewConcreteCompiler(
 		packageApplier:     packageApplier,
 		packagesBc:         packagesBc,
 		timeProvider:       timeProvider,
+		progress:           progress,
+		logger:             logger,
 	}
 }
 
-func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobID string, digest boshcrypto.Digest, err error) {
+func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobID string, digest boshcrypto.Digest, log CompileLog, err error) {
+	// Installing dependencies, fetching the source, packaging, compressing and uploading
+	c.progress.AddTotal(len(deps) + 4)
+
 	err = c.packageApplier.KeepOnly([]boshmodels.Package{})
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Removing packages")
+		return "", nil, CompileLog{}, bosherr.WrapError(err, "Removing packages")
 	}
 
 	for _, dep := range deps {
 		err := c.packageApplier.Apply(dep)
 		if err != nil {
-			return "", nil, bosherr.WrapErrorf(err, "Installing dependent package: '%s'", dep.Name)
+			return "", nil, CompileLog{}, bosherr.WrapErrorf(err, "Installing dependent package: '%s'", dep.Name)
 		}
+		c.progress.StepDone()
 	}
 
 	compilePath := path.Join(c.compileDirProvider.CompileDir(), pkg.Name)
 
 	srcPkgArchiveFile, err := c.fetchPackageSrcArchive(pkg)
 	if err != nil {
-		return "", nil, bosherr.WrapErrorf(err, "Fetching package %s", pkg.Name)
+		return "", nil, CompileLog{}, bosherr.WrapErrorf(err, "Fetching package %s", pkg.Name)
 	}
+	c.progress.StepDone()
 
 	err = c.atomicDecompress(srcPkgArchiveFile, compilePath)
 	if err != nil {
-		return "", nil, bosherr.WrapErrorf(err, "Uncompressing package %s", pkg.Name)
+		return "", nil, CompileLog{}, bosherr.WrapErrorf(err, "Uncompressing package %s", pkg.Name)
 	}
 
 	defer func() {
@@ -97,33 +114,36 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 
 	compiledPkgBundle, err := c.packagesBc.Get(compiledPkg)
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Getting bundle for new package")
+		return "", nil, CompileLog{}, bosherr.WrapError(err, "Getting bundle for new package")
 	}
 
 	installPath, err := compiledPkgBundle.InstallWithoutContents()
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Setting up new package bundle")
+		return "", nil, CompileLog{}, bosherr.WrapError(err, "Setting up new package bundle")
 	}
 
 	enablePath, err := compiledPkgBundle.Enable()
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Enabling new package bundle")
+		return "", nil, CompileLog{}, bosherr.WrapError(err, "Enabling new package bundle")
 	}
 
 	scriptPath := path.Join(compilePath, PackagingScriptName)
 
 	if c.fs.FileExists(scriptPath) {
-		if err := c.runPackagingCommand(compilePath, enablePath, pkg); err != nil {
-			return "", nil, bosherr.WrapError(err, "Running packaging script")
+		log, err = c.runPackagingScript(compilePath, enablePath, pkg)
+		if err != nil {
+			return "", nil, log, err
 		}
 	}
+	c.progress.StepDone()
 
 	tmpPackageTar, err :=
 		c.compressor.CompressFilesInDir(installPath,
 			boshcmd.CompressorOptions{NoCompression: c.compressor.IsNonCompressedTarball(srcPkgArchiveFile)})
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Compressing compiled package")
+		return "", nil, log, bosherr.WrapError(err, "Compressing compiled package")
 	}
+	c.progress.StepDone()
 
 	defer func() {
 		_ = c.compressor.CleanUp(tmpPackageTar) //nolint:errcheck
@@ -131,25 +151,62 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 
 	uploadedBlobID, digest, err := c.blobstore.Write(pkg.UploadSignedURL, tmpPackageTar, pkg.BlobstoreHeaders)
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Uploading compiled package")
+		return "", nil, log, bosherr.WrapError(err, "Uploading compiled package")
 	}
+	c.progress.StepDone()
 
 	err = compiledPkgBundle.Disable()
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Disabling compiled package")
+		return "", nil, log, bosherr.WrapError(err, "Disabling compiled package")
 	}
 
 	err = compiledPkgBundle.Uninstall()
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Uninstalling compiled package")
+		return "", nil, log, bosherr.WrapError(err, "Uninstalling compiled package")
 	}
 
 	err = c.packageApplier.KeepOnly([]boshmodels.Package{})
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Removing packages")
+		return "", nil, log, bosherr.WrapError(err, "Removing packages")
 	}
 
-	return uploadedBlobID, digest, nil
+	return uploadedBlobID, digest, log, nil
+}
+
+// runPackagingScript captures the script's combined output in a log that is
+// uploaded whether or not the script succeeds. The log is not uploaded when the
+// package is uploaded to a signed URL and no signed URL was given for the log.
+// Failing to upload the log does not fail the compilation.
+func (c concreteCompiler) runPackagingScript(compilePath, enablePath string, pkg Package) (CompileLog, error) {
+	logFile, err := c.fs.TempFile("bosh-agent-compile-log")
+	if err != nil {
+		return CompileLog{}, bosherr.WrapError(err, "Creating compile log")
+	}
+
+	defer func() {
+		_ = c.fs.RemoveAll(logFile.Name())
+	}()
+
+	output := &lockedWriter{writer: io.MultiWriter(logFile, c.progress)}
+
+	err = c.runPackagingCommand(compilePath, enablePath, pkg, output)
+	if err != nil {
+		err = bosherr.WrapError(err, "Running packaging script")
+	}
+
+	_ = logFile.Close()
+
+	if pkg.UploadSignedURL != "" && pkg.LogUploadSignedURL == "" {
+		return CompileLog{}, err
+	}
+
+	logBlobID, _, uploadErr := c.blobstore.Write(pkg.LogUploadSignedURL, logFile.Name(), pkg.BlobstoreHeaders)
+	if uploadErr != nil {
+		c.logger.Warn(compilerLogTag, "Failed to upload compile log of package %s: %s", pkg.Name, uploadErr.Error())
+		return CompileLog{}, err
+	}
+
+	return CompileLog{BlobID: logBlobID, Uploaded: true}, err
 }
 
 func (c concreteCompiler) fetchPackageSrcArchive(pkg Package) (string, error) {
@@ -204,3 +261,16 @@ func (c concreteCompiler) atomicDecompress(archivePath string, finalDir string)
 
 	return c.moveTmpDir(tmpInstallPath, finalDir)
 }
+
+// lockedWriter serializes writes of stdout and stderr to the same log
+type lockedWriter struct {
+	lock   sync.Mutex
+	writer io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.writer.Write(p)
+}
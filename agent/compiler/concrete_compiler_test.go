@@ -13,6 +13,7 @@ import (
 
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 
@@ -21,6 +22,7 @@ import (
 	fakepackages "github.com/cloudfoundry/bosh-agent/v2/agent/applier/packages/fakes"
 	fakecmdrunner "github.com/cloudfoundry/bosh-agent/v2/agent/cmdrunner/fakes"
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
 type FakeCompileDirProvider struct {
@@ -75,6 +77,7 @@ func init() { //nolint:funlen,gochecknoinits
 			runner         *fakecmdrunner.FakeFileLoggingCmdRunner
 			packageApplier *fakepackages.FakeApplier
 			packagesBc     *fakebc.FakeBundleCollection
+			progress       *boshtask.RunningProgress
 		)
 
 		BeforeEach(func() {
@@ -84,6 +87,7 @@ func init() { //nolint:funlen,gochecknoinits
 			runner = fakecmdrunner.NewFakeFileLoggingCmdRunner()
 			packageApplier = fakepackages.NewFakeApplier()
 			packagesBc = fakebc.NewFakeBundleCollection()
+			progress = boshtask.NewRunningProgress()
 
 			compiler = NewConcreteCompiler(
 				compressor,
@@ -94,6 +98,8 @@ func init() { //nolint:funlen,gochecknoinits
 				packageApplier,
 				packagesBc,
 				new(fakebc.FakeClock),
+				progress,
+				boshlog.NewLogger(boshlog.LevelNone),
 			)
 
 			err := fs.MkdirAll("/real-compile-dir", os.ModePerm)
@@ -132,7 +138,7 @@ func init() { //nolint:funlen,gochecknoinits
 					),
 				), nil)
 
-				blobID, digest, err := compiler.Compile(pkg, pkgDeps)
+				blobID, digest, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(blobID).To(Equal("fake-blob-id"))
@@ -150,7 +156,7 @@ func init() { //nolint:funlen,gochecknoinits
 				// Currently algo of source package is used for compilation pkg algo
 				pkg.Sha1 = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA256, "fakesha"))
 
-				_, digest, err := compiler.Compile(pkg, pkgDeps)
+				_, digest, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				// echo -n fake-contents|shasum -a 256
 				Expect(digest.String()).To(Equal("sha256:d12d3a3ee8dcdc9e7ea3416fd618298ea50abde2cf434313c6c3edb213f441cd"))
@@ -164,7 +170,7 @@ func init() { //nolint:funlen,gochecknoinits
 			})
 
 			It("cleans up all packages before and after applying dependent packages", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(packageApplier.ActionsCalled).To(Equal([]string{"KeepOnly", "Apply", "Apply", "KeepOnly"}))
 				Expect(packageApplier.KeptOnlyPackages).To(BeEmpty())
@@ -173,7 +179,7 @@ func init() { //nolint:funlen,gochecknoinits
 			It("returns an error if cleaning up packages fails", func() {
 				packageApplier.KeepOnlyErr = errors.New("fake-keep-only-error")
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-keep-only-error"))
 			})
@@ -186,7 +192,7 @@ func init() { //nolint:funlen,gochecknoinits
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-remove-error"))
 			})
@@ -199,7 +205,7 @@ func init() { //nolint:funlen,gochecknoinits
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-mkdir-error"))
 			})
@@ -212,7 +218,7 @@ func init() { //nolint:funlen,gochecknoinits
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-remove-error"))
 			})
@@ -220,7 +226,7 @@ func init() { //nolint:funlen,gochecknoinits
 			It("returns an error if creating temporary compile target directory during uncompression fails", func() {
 				fs.RegisterMkdirAllError("/fake-compile-dir/pkg_name-bosh-agent-unpack", errors.New("fake-mkdir-error"))
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-mkdir-error"))
 			})
@@ -229,25 +235,25 @@ func init() { //nolint:funlen,gochecknoinits
 				pkg.BlobstoreID = ""
 				pkg.PackageGetSignedURL = ""
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("No blobstore reference for package '%s'", pkg.Name))
 			})
 
 			It("installs dependent packages", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(packageApplier.AppliedPackages).To(Equal(pkgDeps))
 			})
 
 			It("cleans up the compile directory", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(fs.FileExists("/fake-compile-dir/pkg_name")).To(BeFalse())
 			})
 
 			It("installs, enables and later cleans up bundle", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(bundle.ActionsCalled).To(Equal([]string{
 					"InstallWithoutContents",
@@ -269,7 +275,7 @@ func init() { //nolint:funlen,gochecknoinits
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-remove-error"))
 			})
@@ -285,7 +291,7 @@ func init() { //nolint:funlen,gochecknoinits
 				})
 
 				It("runs packaging script ", func() {
-					_, _, err := compiler.Compile(pkg, pkgDeps)
+					_, _, _, err := compiler.Compile(pkg, pkgDeps)
 					Expect(err).ToNot(HaveOccurred())
 
 					expectedCmd := boshsys.Command{
@@ -299,6 +305,10 @@ func init() { //nolint:funlen,gochecknoinits
 					}
 
 					cmd := runner.RunCommands[0]
+					Expect(cmd.Stdout).ToNot(BeNil())
+					Expect(cmd.Stderr).To(BeIdenticalTo(cmd.Stdout))
+					cmd.Stdout, cmd.Stderr = nil, nil
+
 					if runtime.GOOS == "windows" {
 						expectedCmd.Name = "powershell"
 						expectedCmd.Args = []string{"-command", fmt.Sprintf("iex (get-content -raw %s)", PackagingScriptName)}
@@ -316,20 +326,135 @@ func init() { //nolint:funlen,gochecknoinits
 				It("propagates the error from packaging script", func() {
 					runner.RunCommandErr = errors.New("fake-packaging-error")
 
-					_, _, err := compiler.Compile(pkg, pkgDeps)
+					_, _, _, err := compiler.Compile(pkg, pkgDeps)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-packaging-error"))
 				})
+
+				Context("when capturing the output of the packaging script", func() {
+					var logContents string
+
+					BeforeEach(func() {
+						fs.ReturnTempFile = fakesys.NewFakeFile("/tmp/compile-log", fs)
+						runner.RunCommandOutput = "fake-packaging-output"
+
+						blobstore.WriteStub = func(signedURL, fileName string, headers map[string]string) (string, boshcrypto.MultipleDigest, error) {
+							if fileName == "/tmp/compile-log" {
+								contents, err := fs.ReadFileString(fileName)
+								Expect(err).ToNot(HaveOccurred())
+								logContents = contents
+								return "fake-log-blob-id", boshcrypto.MultipleDigest{}, nil
+							}
+							return "fake-blob-id", boshcrypto.MultipleDigest{}, nil
+						}
+					})
+
+					It("uploads the output and returns its blob id", func() {
+						blobID, _, log, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(blobID).To(Equal("fake-blob-id"))
+						Expect(log).To(Equal(CompileLog{BlobID: "fake-log-blob-id", Uploaded: true}))
+						Expect(logContents).To(Equal("fake-packaging-output"))
+
+						Expect(blobstore.WriteCallCount()).To(Equal(2))
+						signedURL, _, headers := blobstore.WriteArgsForCall(0)
+						Expect(signedURL).To(BeEmpty())
+						Expect(headers).To(Equal(map[string]string{"key": "value"}))
+
+						Expect(fs.FileExists("/tmp/compile-log")).To(BeFalse())
+					})
+
+					It("uploads the output when the packaging script fails", func() {
+						runner.RunCommandErr = errors.New("fake-packaging-error")
+
+						_, _, log, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("fake-packaging-error"))
+
+						Expect(log.BlobID).To(Equal("fake-log-blob-id"))
+						Expect(logContents).To(Equal("fake-packaging-output"))
+						Expect(blobstore.WriteCallCount()).To(Equal(1))
+					})
+
+					It("returns the compiled package when uploading the output fails", func() {
+						blobstore.WriteStub = func(signedURL, fileName string, headers map[string]string) (string, boshcrypto.MultipleDigest, error) {
+							if fileName == "/tmp/compile-log" {
+								return "", boshcrypto.MultipleDigest{}, errors.New("fake-write-error")
+							}
+							return "fake-blob-id", boshcrypto.MultipleDigest{}, nil
+						}
+
+						blobID, _, log, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(blobID).To(Equal("fake-blob-id"))
+						Expect(log).To(Equal(CompileLog{}))
+					})
+
+					It("returns only the packaging error when uploading the output of a failed script fails", func() {
+						runner.RunCommandErr = errors.New("fake-packaging-error")
+						blobstore.WriteStub = nil
+						blobstore.WriteReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-write-error"))
+
+						_, _, _, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("fake-packaging-error"))
+						Expect(err.Error()).ToNot(ContainSubstring("fake-write-error"))
+					})
+
+					It("uploads the output to its own signed URL and reports it as uploaded", func() {
+						pkg.UploadSignedURL = "/upload/signed/url"
+						pkg.LogUploadSignedURL = "/log/upload/signed/url"
+						blobstore.WriteStub = nil
+						blobstore.WriteReturns("", boshcrypto.MultipleDigest{}, nil)
+
+						_, _, log, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(log).To(Equal(CompileLog{Uploaded: true}))
+
+						signedURL, fileName, _ := blobstore.WriteArgsForCall(0)
+						Expect(signedURL).To(Equal("/log/upload/signed/url"))
+						Expect(fileName).To(Equal("/tmp/compile-log"))
+					})
+
+					It("does not upload the output when only the package has a signed URL", func() {
+						pkg.UploadSignedURL = "/upload/signed/url"
+
+						_, _, log, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(log).To(Equal(CompileLog{}))
+						Expect(blobstore.WriteCallCount()).To(Equal(1))
+					})
+
+					It("reports the output as progress", func() {
+						_, _, _, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+
+						value, _ := progress.Progress()
+						Expect(value.Output).To(Equal("fake-packaging-output"))
+					})
+				})
+			})
+
+			It("reports a step for each dependency and stage of the compilation", func() {
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).ToNot(HaveOccurred())
+
+				value, reported := progress.Progress()
+				Expect(reported).To(BeTrue())
+				Expect(value.Done).To(Equal(6))
+				Expect(value.Total).To(Equal(6))
 			})
 
 			It("does not run packaging script when script does not exist", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(runner.RunCommands).To(BeEmpty())
 			})
 
 			It("compresses compiled package", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 
 				// archive was downloaded from the blobstore and decompress to this temp dir
@@ -349,7 +474,7 @@ func init() { //nolint:funlen,gochecknoinits
 			It("uploads compressed package to blobstore", func() {
 				compressor.CompressFilesInDirTarballPath = "/tmp/compressed-compiled-package"
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 
 				_, filePathArg, headers := blobstore.WriteArgsForCall(0)
@@ -360,7 +485,7 @@ func init() { //nolint:funlen,gochecknoinits
 			It("returs error if uploading compressed package fails", func() {
 				blobstore.WriteReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-create-err"))
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-create-err"))
 			})
@@ -373,7 +498,7 @@ func init() { //nolint:funlen,gochecknoinits
 					return "my-blob-id", boshcrypto.MultipleDigest{}, nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 
 				// Compressed package is not cleaned up before blobstore upload
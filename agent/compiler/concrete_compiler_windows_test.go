@@ -14,8 +14,10 @@ import (
 	fakecmdrunner "github.com/cloudfoundry/bosh-agent/v2/agent/cmdrunner/fakes"
 	. "github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 
 	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
 
@@ -50,6 +52,8 @@ func init() { //nolint:gochecknoinits
 				packageApplier,
 				packagesBc,
 				fakeClock,
+				boshtask.NewRunningProgress(),
+				boshlog.NewLogger(boshlog.LevelNone),
 			)
 
 			err := fs.MkdirAll("/fake-compile-dir", os.ModePerm)
@@ -93,7 +97,7 @@ func init() { //nolint:gochecknoinits
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(fs.RenameOldPaths[0]).To(Equal("/fake-compile-dir/pkg_name-bosh-agent-unpack"))
@@ -117,7 +121,7 @@ func init() { //nolint:gochecknoinits
 				fakeClock.NowReturns(startTime)
 				fakeClock.SinceReturns(CompileTimeout + time.Second)
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(MatchError(ContainSubstring("can't perform filesystem rename")))
 
 				Expect(fakeClock.SinceCallCount()).To(Equal(1))
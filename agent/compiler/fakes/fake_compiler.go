@@ -12,6 +12,7 @@ type FakeCompiler struct {
 	CompileDeps   []boshmodels.Package
 	CompileBlobID string
 	CompileDigest boshcrypto.Digest
+	CompileLog    boshcomp.CompileLog
 	CompileErr    error
 }
 
@@ -20,11 +21,12 @@ func NewFakeCompiler() (c *FakeCompiler) {
 	return
 }
 
-func (c *FakeCompiler) Compile(pkg boshcomp.Package, deps []boshmodels.Package) (blobID string, digest boshcrypto.Digest, err error) {
+func (c *FakeCompiler) Compile(pkg boshcomp.Package, deps []boshmodels.Package) (blobID string, digest boshcrypto.Digest, log boshcomp.CompileLog, err error) {
 	c.CompilePkg = pkg
 	c.CompileDeps = deps
 	blobID = c.CompileBlobID
 	digest = c.CompileDigest
+	log = c.CompileLog
 	err = c.CompileErr
 	return
 }
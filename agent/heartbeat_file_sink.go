@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type fileHeartbeatSink struct {
+	path     string
+	interval time.Duration
+	fs       boshsys.FileSystem
+}
+
+// NewFileHeartbeatSink appends each heartbeat as a single JSON line to path
+func NewFileHeartbeatSink(path string, interval time.Duration, fs boshsys.FileSystem) HeartbeatSink {
+	return fileHeartbeatSink{path: path, interval: interval, fs: fs}
+}
+
+func (s fileHeartbeatSink) Name() string { return HeartbeatSinkTypeFile + ":" + s.path }
+
+func (s fileHeartbeatSink) Interval() time.Duration { return s.interval }
+
+func (s fileHeartbeatSink) Send(heartbeat Heartbeat) error {
+	line, err := json.Marshal(heartbeat)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling heartbeat")
+	}
+
+	file, err := s.fs.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening heartbeat file '%s'", s.path)
+	}
+	defer file.Close() //nolint:errcheck
+
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing heartbeat file '%s'", s.path)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	HeartbeatSinkTypeFile   = "file"
+	HeartbeatSinkTypeSyslog = "syslog"
+	HeartbeatSinkTypeStatsD = "statsd"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . HeartbeatSink
+
+// HeartbeatSink receives heartbeats in addition to the message bus.
+// Each sink is driven on its own interval and its failures do not
+// affect the message bus or other sinks.
+type HeartbeatSink interface {
+	Name() string
+	Interval() time.Duration
+	Send(Heartbeat) error
+}
+
+type HeartbeatOptions struct {
+	Sinks []HeartbeatSinkOptions
+}
+
+type HeartbeatSinkOptions struct {
+	// Possible values: file, syslog, statsd
+	Type string
+
+	// Seconds between heartbeats sent to this sink;
+	// defaults to the message bus heartbeat interval
+	IntervalSeconds int
+
+	// Path of the JSON lines file heartbeats are appended to (file)
+	Path string
+
+	// Network ("udp" or "tcp", defaults to "udp") and host:port
+	// of the collector (syslog, statsd)
+	Network string
+	Address string
+
+	// Prefix of metric names; defaults to "bosh.agent" (statsd)
+	Prefix string
+}
+
+func NewHeartbeatSinks(
+	options HeartbeatOptions,
+	defaultInterval time.Duration,
+	fs boshsys.FileSystem,
+	timeService clock.Clock,
+) ([]HeartbeatSink, error) {
+	sinks := make([]HeartbeatSink, 0, len(options.Sinks))
+
+	for _, sinkOptions := range options.Sinks {
+		interval := defaultInterval
+		if sinkOptions.IntervalSeconds > 0 {
+			interval = time.Duration(sinkOptions.IntervalSeconds) * time.Second
+		}
+
+		switch sinkOptions.Type {
+		case HeartbeatSinkTypeFile:
+			if sinkOptions.Path == "" {
+				return nil, bosherr.Error("Heartbeat file sink requires a path")
+			}
+			sinks = append(sinks, NewFileHeartbeatSink(sinkOptions.Path, interval, fs))
+
+		case HeartbeatSinkTypeSyslog:
+			if sinkOptions.Address == "" {
+				return nil, bosherr.Error("Heartbeat syslog sink requires an address")
+			}
+			sinks = append(sinks, NewSyslogHeartbeatSink(sinkOptions.Network, sinkOptions.Address, interval, timeService))
+
+		case HeartbeatSinkTypeStatsD:
+			if sinkOptions.Address == "" {
+				return nil, bosherr.Error("Heartbeat statsd sink requires an address")
+			}
+			sinks = append(sinks, NewStatsDHeartbeatSink(sinkOptions.Network, sinkOptions.Address, sinkOptions.Prefix, interval))
+
+		default:
+			return nil, bosherr.Errorf("Unknown heartbeat sink type '%s'", sinkOptions.Type)
+		}
+	}
+
+	return sinks, nil
+}
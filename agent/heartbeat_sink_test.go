@@ -0,0 +1,180 @@
+package agent_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent"
+	boshvitals "github.com/cloudfoundry/bosh-agent/v2/platform/vitals"
+)
+
+var _ = Describe("HeartbeatSinks", func() {
+	var (
+		fs          boshsys.FileSystem
+		timeService *fakeclock.FakeClock
+		heartbeat   agent.Heartbeat
+	)
+
+	BeforeEach(func() {
+		fs = boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		timeService = fakeclock.NewFakeClock(time.Now())
+
+		jobName := "fake-job"
+		index := 0
+		processes := 2
+		heartbeat = agent.Heartbeat{
+			Deployment: "fake-deployment",
+			Job:        &jobName,
+			Index:      &index,
+			JobState:   "running",
+			Vitals: boshvitals.Vitals{
+				CPU:  boshvitals.CPUVitals{User: "56.0", Sys: "10.0", Wait: "1.0"},
+				Load: []string{"0.20", "4.55", "1.12"},
+				Mem:  boshvitals.MemoryVitals{Kb: "700", Percent: "70"},
+				Disk: boshvitals.DiskVitals{
+					"system": boshvitals.SpecificDiskVitals{Percent: "50", InodePercent: "10"},
+				},
+				Uptime: boshvitals.UptimeVitals{Secs: 3600},
+			},
+			NumberOfProcesses: &processes,
+		}
+	})
+
+	Describe("NewHeartbeatSinks", func() {
+		It("builds the configured sinks with their intervals", func() {
+			sinks, err := agent.NewHeartbeatSinks(agent.HeartbeatOptions{
+				Sinks: []agent.HeartbeatSinkOptions{
+					{Type: "file", Path: "/fake-path"},
+					{Type: "syslog", Address: "127.0.0.1:514", IntervalSeconds: 60},
+					{Type: "statsd", Address: "127.0.0.1:8125", IntervalSeconds: 10},
+				},
+			}, 30*time.Second, fs, timeService)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sinks).To(HaveLen(3))
+
+			Expect(sinks[0].Name()).To(Equal("file:/fake-path"))
+			Expect(sinks[0].Interval()).To(Equal(30 * time.Second))
+			Expect(sinks[1].Name()).To(Equal("syslog:127.0.0.1:514"))
+			Expect(sinks[1].Interval()).To(Equal(60 * time.Second))
+			Expect(sinks[2].Name()).To(Equal("statsd:127.0.0.1:8125"))
+			Expect(sinks[2].Interval()).To(Equal(10 * time.Second))
+		})
+
+		It("returns an error for an unknown sink type", func() {
+			_, err := agent.NewHeartbeatSinks(agent.HeartbeatOptions{
+				Sinks: []agent.HeartbeatSinkOptions{{Type: "fake-type"}},
+			}, 30*time.Second, fs, timeService)
+			Expect(err).To(MatchError("Unknown heartbeat sink type 'fake-type'"))
+		})
+
+		It("returns an error when a sink is missing its destination", func() {
+			_, err := agent.NewHeartbeatSinks(agent.HeartbeatOptions{
+				Sinks: []agent.HeartbeatSinkOptions{{Type: "file"}},
+			}, 30*time.Second, fs, timeService)
+			Expect(err).To(MatchError("Heartbeat file sink requires a path"))
+
+			_, err = agent.NewHeartbeatSinks(agent.HeartbeatOptions{
+				Sinks: []agent.HeartbeatSinkOptions{{Type: "statsd"}},
+			}, 30*time.Second, fs, timeService)
+			Expect(err).To(MatchError("Heartbeat statsd sink requires an address"))
+		})
+	})
+
+	Describe("file sink", func() {
+		var fakeFs *fakesys.FakeFileSystem
+
+		BeforeEach(func() {
+			fakeFs = fakesys.NewFakeFileSystem()
+		})
+
+		It("appends heartbeats as JSON lines", func() {
+			sink := agent.NewFileHeartbeatSink("/fake-dir/heartbeats.log", time.Minute, fakeFs)
+
+			Expect(sink.Send(heartbeat)).To(Succeed())
+
+			stats := fakeFs.GetFileTestStat("/fake-dir/heartbeats.log")
+			Expect(stats.Flags).To(Equal(os.O_WRONLY | os.O_APPEND | os.O_CREATE))
+			Expect(string(stats.Content)).To(HaveSuffix("\n"))
+
+			var decoded agent.Heartbeat
+			Expect(json.Unmarshal(stats.Content, &decoded)).To(Succeed())
+			Expect(decoded).To(Equal(heartbeat))
+		})
+
+		It("returns an error when the file cannot be opened", func() {
+			fakeFs.OpenFileErr = errors.New("fake-open-error")
+			sink := agent.NewFileHeartbeatSink("/fake-dir/heartbeats.log", time.Minute, fakeFs)
+
+			err := sink.Send(heartbeat)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-open-error"))
+		})
+	})
+
+	Describe("statsd sink", func() {
+		It("sends vitals as gauges", func() {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			defer conn.Close() //nolint:errcheck
+
+			sink := agent.NewStatsDHeartbeatSink("udp", conn.LocalAddr().String(), "fake.prefix", time.Minute)
+			Expect(sink.Send(heartbeat)).To(Succeed())
+
+			buf := make([]byte, 4096)
+			Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+			n, _, err := conn.ReadFrom(buf)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(string(buf[:n])).To(Equal(strings.Join([]string{
+				"fake.prefix.job_healthy:1|g",
+				"fake.prefix.number_of_processes:2|g",
+				"fake.prefix.cpu.user:56.0|g",
+				"fake.prefix.cpu.sys:10.0|g",
+				"fake.prefix.cpu.wait:1.0|g",
+				"fake.prefix.load.1m:0.20|g",
+				"fake.prefix.load.5m:4.55|g",
+				"fake.prefix.load.15m:1.12|g",
+				"fake.prefix.mem.percent:70|g",
+				"fake.prefix.mem.kb:700|g",
+				"fake.prefix.disk.system.percent:50|g",
+				"fake.prefix.disk.system.inode_percent:10|g",
+				"fake.prefix.uptime.secs:3600|g",
+			}, "\n") + "\n"))
+		})
+	})
+
+	Describe("syslog sink", func() {
+		It("sends heartbeats as JSON syslog messages", func() {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			defer conn.Close() //nolint:errcheck
+
+			sink := agent.NewSyslogHeartbeatSink("udp", conn.LocalAddr().String(), time.Minute, timeService)
+			Expect(sink.Send(heartbeat)).To(Succeed())
+
+			buf := make([]byte, 4096)
+			Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+			n, _, err := conn.ReadFrom(buf)
+			Expect(err).ToNot(HaveOccurred())
+
+			expectedJSON, err := json.Marshal(heartbeat)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(string(buf[:n])).To(HavePrefix("<14>1 "))
+			Expect(string(buf[:n])).To(HaveSuffix(" bosh-agent " + strconv.Itoa(os.Getpid()) + " heartbeat - " + string(expectedJSON)))
+		})
+	})
+})
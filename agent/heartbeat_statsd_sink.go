@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const defaultStatsDPrefix = "bosh.agent"
+
+type statsDHeartbeatSink struct {
+	network  string
+	address  string
+	prefix   string
+	interval time.Duration
+}
+
+// NewStatsDHeartbeatSink sends heartbeat vitals as StatsD gauges
+func NewStatsDHeartbeatSink(network, address, prefix string, interval time.Duration) HeartbeatSink {
+	if network == "" {
+		network = "udp"
+	}
+	if prefix == "" {
+		prefix = defaultStatsDPrefix
+	}
+
+	return statsDHeartbeatSink{
+		network:  network,
+		address:  address,
+		prefix:   prefix,
+		interval: interval,
+	}
+}
+
+func (s statsDHeartbeatSink) Name() string { return HeartbeatSinkTypeStatsD + ":" + s.address }
+
+func (s statsDHeartbeatSink) Interval() time.Duration { return s.interval }
+
+func (s statsDHeartbeatSink) Send(heartbeat Heartbeat) error {
+	conn, err := net.DialTimeout(s.network, s.address, 5*time.Second)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Connecting to statsd '%s'", s.address)
+	}
+	defer conn.Close() //nolint:errcheck
+
+	_, err = conn.Write(s.Gauges(heartbeat))
+	if err != nil {
+		return bosherr.WrapError(err, "Writing statsd gauges")
+	}
+
+	return nil
+}
+
+// Gauges renders the heartbeat as newline separated StatsD gauges;
+// vitals that cannot be parsed as numbers are skipped
+func (s statsDHeartbeatSink) Gauges(heartbeat Heartbeat) []byte {
+	var buf bytes.Buffer
+
+	gauge := func(name, value string) {
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return
+		}
+		fmt.Fprintf(&buf, "%s.%s:%s|g\n", s.prefix, name, value)
+	}
+
+	healthy := "0"
+	if heartbeat.JobState == "running" {
+		healthy = "1"
+	}
+	gauge("job_healthy", healthy)
+
+	if heartbeat.NumberOfProcesses != nil {
+		gauge("number_of_processes", strconv.Itoa(*heartbeat.NumberOfProcesses))
+	}
+
+	vitals := heartbeat.Vitals
+
+	gauge("cpu.user", vitals.CPU.User)
+	gauge("cpu.sys", vitals.CPU.Sys)
+	gauge("cpu.wait", vitals.CPU.Wait)
+
+	for i, period := range []string{"1m", "5m", "15m"} {
+		if i < len(vitals.Load) {
+			gauge("load."+period, vitals.Load[i])
+		}
+	}
+
+	gauge("mem.percent", vitals.Mem.Percent)
+	gauge("mem.kb", vitals.Mem.Kb)
+	gauge("swap.percent", vitals.Swap.Percent)
+	gauge("swap.kb", vitals.Swap.Kb)
+
+	diskNames := make([]string, 0, len(vitals.Disk))
+	for name := range vitals.Disk {
+		diskNames = append(diskNames, name)
+	}
+	sort.Strings(diskNames)
+
+	for _, name := range diskNames {
+		gauge("disk."+name+".percent", vitals.Disk[name].Percent)
+		gauge("disk."+name+".inode_percent", vitals.Disk[name].InodePercent)
+	}
+
+	gauge("uptime.secs", strconv.FormatUint(vitals.Uptime.Secs, 10))
+
+	return buf.Bytes()
+}
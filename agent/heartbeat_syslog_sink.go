@@ -0,0 +1,40 @@
+package agent
+
+import (
+	"encoding/json"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshsyslog "github.com/cloudfoundry/bosh-agent/v2/agent/syslog"
+)
+
+type syslogHeartbeatSink struct {
+	address  string
+	interval time.Duration
+	writer   *boshsyslog.Writer
+}
+
+// NewSyslogHeartbeatSink sends each heartbeat as JSON in an RFC 5424 message
+func NewSyslogHeartbeatSink(network, address string, interval time.Duration, timeService clock.Clock) HeartbeatSink {
+	return syslogHeartbeatSink{
+		address:  address,
+		interval: interval,
+		writer:   boshsyslog.NewWriter(network, address, "bosh-agent", timeService),
+	}
+}
+
+func (s syslogHeartbeatSink) Name() string { return HeartbeatSinkTypeSyslog + ":" + s.address }
+
+func (s syslogHeartbeatSink) Interval() time.Duration { return s.interval }
+
+func (s syslogHeartbeatSink) Send(heartbeat Heartbeat) error {
+	msg, err := json.Marshal(heartbeat)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling heartbeat")
+	}
+
+	return s.writer.Write(boshsyslog.SeverityInfo, "heartbeat", string(msg))
+}
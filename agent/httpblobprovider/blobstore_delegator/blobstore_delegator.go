@@ -11,19 +11,26 @@ import (
 	"github.com/cloudfoundry/bosh-utils/blobstore"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 
+	boshagentblobstore "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
 )
 
+const logTag = "blobstoreDelegator"
+
+// BlobstoreDelegatorImpl consults the blob cache before downloading from signed URLs;
+// blobs fetched by ID are cached by the underlying cascading blobstore
 type BlobstoreDelegatorImpl struct {
 	h      httpblobprovider.HTTPBlobProvider
 	b      blobstore.DigestBlobstore
+	c      boshagentblobstore.BlobCache
 	logger boshlog.Logger
 }
 
-func NewBlobstoreDelegator(hp httpblobprovider.HTTPBlobProvider, bp blobstore.DigestBlobstore, logger boshlog.Logger) *BlobstoreDelegatorImpl {
+func NewBlobstoreDelegator(hp httpblobprovider.HTTPBlobProvider, bp blobstore.DigestBlobstore, bc boshagentblobstore.BlobCache, logger boshlog.Logger) *BlobstoreDelegatorImpl {
 	return &BlobstoreDelegatorImpl{
 		h:      hp,
 		b:      bp,
+		c:      bc,
 		logger: logger,
 	}
 }
@@ -36,6 +43,13 @@ func (b *BlobstoreDelegatorImpl) Get(digest boshcrypto.Digest, signedURL, blobID
 		return b.b.Get(blobID, digest)
 	}
 
+	cachedFileName, found, err := b.c.Get(digest)
+	if err != nil {
+		b.logger.Warn(logTag, "Looking up blob in cache: %s", err.Error())
+	} else if found {
+		return cachedFileName, nil
+	}
+
 	getBlobRetryable := boshretry.NewRetryable(func() (bool, error) {
 		fileName, err = b.h.Get(signedURL, digest, headers)
 		if err != nil {
@@ -50,6 +64,11 @@ func (b *BlobstoreDelegatorImpl) Get(digest boshcrypto.Digest, signedURL, blobID
 		return "", err
 	}
 
+	cacheErr := b.c.Put(digest, fileName)
+	if cacheErr != nil {
+		b.logger.Warn(logTag, "Adding blob to cache: %s", cacheErr.Error())
+	}
+
 	return fileName, nil
 }
 
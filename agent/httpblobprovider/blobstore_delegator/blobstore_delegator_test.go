@@ -9,6 +9,7 @@ import (
 
 	fakeblobstore "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
 
+	fakeagentblobstore "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore/blobstorefakes"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
 	fakeblobprovider "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/httpblobproviderfakes"
 
@@ -20,6 +21,7 @@ var _ = Describe("BlobstoreDelegator", func() {
 		blobstoreDelegator   blobstore_delegator.BlobstoreDelegator
 		fakeHTTPBlobProvider *fakeblobprovider.FakeHTTPBlobProvider
 		fakeBlobManager      *fakeblobstore.FakeDigestBlobstore
+		fakeBlobCache        *fakeagentblobstore.FakeBlobCache
 		logger               boshlog.Logger
 
 		digest = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "some-digest"))
@@ -28,9 +30,10 @@ var _ = Describe("BlobstoreDelegator", func() {
 	BeforeEach(func() {
 		fakeHTTPBlobProvider = &fakeblobprovider.FakeHTTPBlobProvider{}
 		fakeBlobManager = &fakeblobstore.FakeDigestBlobstore{}
+		fakeBlobCache = &fakeagentblobstore.FakeBlobCache{}
 		logger = boshlog.NewLogger(boshlog.LevelNone)
 
-		blobstoreDelegator = blobstore_delegator.NewBlobstoreDelegator(fakeHTTPBlobProvider, fakeBlobManager, logger)
+		blobstoreDelegator = blobstore_delegator.NewBlobstoreDelegator(fakeHTTPBlobProvider, fakeBlobManager, fakeBlobCache, logger)
 	})
 
 	Context("Get", func() {
@@ -76,6 +79,39 @@ var _ = Describe("BlobstoreDelegator", func() {
 				Expect(fakeBlobManager.GetCallCount()).To(Equal(0))
 				Expect(fakeHTTPBlobProvider.GetCallCount()).To(Equal(2))
 			})
+
+			It("adds the downloaded blob to the cache", func() {
+				fakeHTTPBlobProvider.GetReturns("/some/path/to/a/file", nil)
+
+				_, err := blobstoreDelegator.Get(digest, "some-signed-url", "", nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeBlobCache.PutCallCount()).To(Equal(1))
+				digestArg, pathArg := fakeBlobCache.PutArgsForCall(0)
+				Expect(digestArg).To(Equal(digest))
+				Expect(pathArg).To(Equal("/some/path/to/a/file"))
+			})
+
+			It("returns the cached blob without downloading it", func() {
+				fakeBlobCache.GetReturns("/cached/file", true, nil)
+
+				getResponse, err := blobstoreDelegator.Get(digest, "some-signed-url", "", nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(getResponse).To(Equal("/cached/file"))
+
+				Expect(fakeBlobCache.GetArgsForCall(0)).To(Equal(digest))
+				Expect(fakeHTTPBlobProvider.GetCallCount()).To(Equal(0))
+			})
+
+			It("downloads the blob when the cache fails", func() {
+				fakeBlobCache.GetReturns("", false, errors.New("fake-cache-error"))
+				fakeBlobCache.PutReturns(errors.New("fake-cache-error"))
+				fakeHTTPBlobProvider.GetReturns("/some/path/to/a/file", nil)
+
+				getResponse, err := blobstoreDelegator.Get(digest, "some-signed-url", "", nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(getResponse).To(Equal("/some/path/to/a/file"))
+			})
 		})
 
 		Context("when there is no signed URL provided", func() {
@@ -89,6 +125,7 @@ var _ = Describe("BlobstoreDelegator", func() {
 
 				Expect(fakeBlobManager.GetCallCount()).To(Equal(1))
 				Expect(fakeHTTPBlobProvider.GetCallCount()).To(Equal(0))
+				Expect(fakeBlobCache.GetCallCount()).To(Equal(0))
 
 				fetchedBlobID, digestArg := fakeBlobManager.GetArgsForCall(0)
 				Expect(fetchedBlobID).To(Equal("1234"))
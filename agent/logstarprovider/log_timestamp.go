@@ -0,0 +1,58 @@
+package logstarprovider
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// 2006-01-02T15:04:05.000Z, 2006-01-02 15:04:05,000 +0000, [2006-01-02T15:04:05+00:00] ...
+	isoTimestampPrefix = regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2})[T ](\d{2}:\d{2}:\d{2}(?:[.,]\d+)?)\s?(Z|[+-]\d{2}:?\d{2})?`)
+
+	// 2006/01/02 15:04:05, also behind the "[tag] " of bosh-utils loggers
+	slashTimestampPrefix = regexp.MustCompile(`^(?:\[[^\]]*\] )?(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2})`)
+
+	// Jan  2 15:04:05 as written by syslog
+	syslogTimestampPrefix = regexp.MustCompile(`^([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2})`)
+)
+
+// parseTimestampPrefix recognizes the timestamps log lines commonly start with.
+// Timestamps without a zone are in local time; syslog timestamps lack a year,
+// so the year of reference (the modification time of the file) is assumed.
+func parseTimestampPrefix(line string, reference time.Time) (time.Time, bool) {
+	if match := isoTimestampPrefix.FindStringSubmatch(line); match != nil {
+		value := match[1] + "T" + strings.Replace(match[2], ",", ".", 1)
+
+		if match[3] == "" {
+			t, err := time.ParseInLocation("2006-01-02T15:04:05.999999999", value, time.Local)
+			return t, err == nil
+		}
+
+		zone := strings.Replace(match[3], ":", "", 1)
+		t, err := time.Parse("2006-01-02T15:04:05.999999999Z0700", value+zone)
+		return t, err == nil
+	}
+
+	if match := slashTimestampPrefix.FindStringSubmatch(line); match != nil {
+		t, err := time.ParseInLocation("2006/01/02 15:04:05", match[1], time.Local)
+		return t, err == nil
+	}
+
+	if match := syslogTimestampPrefix.FindStringSubmatch(line); match != nil {
+		t, err := time.ParseInLocation("Jan _2 15:04:05", match[1], time.Local)
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		t = t.AddDate(reference.Year()-t.Year(), 0, 0)
+		if t.After(reference.Add(24 * time.Hour)) {
+			// written in the previous year, e.g. December lines of a file last modified in January
+			t = t.AddDate(-1, 0, 0)
+		}
+
+		return t, true
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,35 @@
+package logstarprovider
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("parseTimestampPrefix", func() {
+	reference := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+
+	DescribeTable("recognizes common timestamp prefixes",
+		func(line string, expected time.Time) {
+			t, found := parseTimestampPrefix(line, reference)
+			Expect(found).To(BeTrue())
+			Expect(t).To(BeTemporally("==", expected))
+		},
+		Entry("RFC 3339", "2024-05-01T10:30:00Z fake-message", time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)),
+		Entry("RFC 3339 with fraction and offset", "2024-05-01T10:30:00.123456+02:00 fake-message", time.Date(2024, 5, 1, 8, 30, 0, 123456000, time.UTC)),
+		Entry("bracketed with space and offset", "[2024-05-01 10:30:00+0000] fake-message", time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)),
+		Entry("comma fraction without zone", "2024-05-01 10:30:00,250 INFO fake-message", time.Date(2024, 5, 1, 10, 30, 0, 250000000, time.Local)),
+		Entry("bosh-utils logger", "[File System] 2024/05/01 10:30:00 DEBUG - fake-message", time.Date(2024, 5, 1, 10, 30, 0, 0, time.Local)),
+		Entry("syslog", "May  1 10:30:00 fake-host fake-message", time.Date(2024, 5, 1, 10, 30, 0, 0, time.Local)),
+		Entry("syslog of the previous year", "Dec 31 23:59:00 fake-host fake-message", time.Date(2023, 12, 31, 23, 59, 0, 0, time.Local)),
+	)
+
+	It("does not recognize lines without a timestamp prefix", func() {
+		_, found := parseTimestampPrefix("  at fake.Method(Fake.java:1)", reference)
+		Expect(found).To(BeFalse())
+
+		_, found = parseTimestampPrefix("fake-message 2024-05-01T10:30:00Z", reference)
+		Expect(found).To(BeFalse())
+	})
+})
@@ -0,0 +1,386 @@
+package logstarprovider
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	logTag = "logsTarProvider"
+
+	journalFileName = "journal/journal.log"
+
+	skippedBeforeTimeWindow  = "not modified since the start of the time window"
+	skippedOutsideTimeWindow = "no lines within the time window"
+	skippedMaxBytes          = "bundle size limit reached"
+	skippedNoJournal         = "journald is not available on this system"
+)
+
+type bundledLog struct {
+	relPath string
+	srcPath string
+	size    int64
+	modTime time.Time
+	trimmed bool
+
+	// copied is set once the log was written to the bundle directory
+	copied bool
+}
+
+// narrowedCopyToTemp copies the logs of the dirs that match the filters and
+// fall within the options to a temporary directory. Logs are trimmed to the
+// time window while they are copied and skipped logs are not copied at all.
+func (l logsTarProvider) narrowedCopyToTemp(dirs []boshcmd.DirToCopy, filters []string, options Options) (string, error) {
+	tmpDir, err := l.fs.TempDir("bosh-agent-logs")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating temporary directory")
+	}
+
+	err = l.fs.Chmod(tmpDir, os.FileMode(0755))
+	if err == nil {
+		err = l.narrowDown(tmpDir, dirs, filters, options)
+	}
+
+	if err != nil {
+		_ = l.fs.RemoveAll(tmpDir)
+		return "", err
+	}
+
+	return tmpDir, nil
+}
+
+// narrowDown copies the logs that fall within the options to tmpDir and
+// records what was included or skipped in a manifest at the root of tmpDir
+func (l logsTarProvider) narrowDown(tmpDir string, dirs []boshcmd.DirToCopy, filters []string, options Options) error {
+	manifest := Manifest{
+		MaxBytes: options.MaxBytes,
+		Included: []ManifestEntry{},
+		Skipped:  []ManifestEntry{},
+	}
+
+	if !options.Since.IsZero() {
+		manifest.Since = &options.Since
+	}
+
+	if !options.Until.IsZero() {
+		manifest.Until = &options.Until
+	}
+
+	sources, err := l.sourceLogs(dirs, filters)
+	if err != nil {
+		return bosherr.WrapError(err, "Listing logs")
+	}
+
+	var relPaths []string
+	for relPath := range sources {
+		relPaths = append(relPaths, relPath)
+	}
+	sort.Strings(relPaths)
+
+	var logs []bundledLog
+
+	for _, relPath := range relPaths {
+		log := sources[relPath]
+
+		if !options.Since.IsZero() && log.modTime.Before(options.Since) {
+			err = l.skip(tmpDir, log, skippedBeforeTimeWindow, &manifest)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Rotated logs are compressed and kept or skipped as a whole
+		if options.hasTimeWindow() && !strings.HasSuffix(relPath, ".gz") {
+			size, trimmed, empty, err := l.copyTrimmedToTimeWindow(log.srcPath, filepath.Join(tmpDir, relPath), log.modTime, options)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Trimming log '%s' to the time window", relPath)
+			}
+
+			if empty {
+				err = l.skip(tmpDir, log, skippedOutsideTimeWindow, &manifest)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			log.size, log.trimmed, log.copied = size, trimmed, true
+		}
+
+		logs = append(logs, log)
+	}
+
+	if options.IncludeJournal {
+		journal, found, err := l.writeJournal(tmpDir, options)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			logs = append(logs, journal)
+		} else {
+			manifest.Skipped = append(manifest.Skipped, ManifestEntry{Path: journalFileName, Reason: skippedNoJournal})
+		}
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].modTime.After(logs[j].modTime)
+	})
+
+	var totalBytes uint64
+	limitReached := false
+
+	for _, log := range logs {
+		if options.MaxBytes > 0 && (limitReached || totalBytes+uint64(log.size) > options.MaxBytes) {
+			// Newer logs take precedence; once one does not fit, no older log is included either
+			limitReached = true
+
+			err = l.skip(tmpDir, log, skippedMaxBytes, &manifest)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !log.copied {
+			err = l.copyLog(log.srcPath, filepath.Join(tmpDir, log.relPath))
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Copying log '%s'", log.relPath)
+			}
+		}
+
+		totalBytes += uint64(log.size)
+		manifest.Included = append(manifest.Included, ManifestEntry{Path: log.relPath, Size: log.size, Trimmed: log.trimmed})
+	}
+
+	sort.Slice(manifest.Included, func(i, j int) bool { return manifest.Included[i].Path < manifest.Included[j].Path })
+	sort.Slice(manifest.Skipped, func(i, j int) bool { return manifest.Skipped[i].Path < manifest.Skipped[j].Path })
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling logs manifest")
+	}
+
+	err = l.fs.WriteFile(filepath.Join(tmpDir, ManifestFileName), manifestBytes)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing logs manifest")
+	}
+
+	return nil
+}
+
+// sourceLogs finds the files of the dirs that match the filters, the same way
+// the copier does, keyed by their path in the bundle. Files of later dirs take
+// the place of files of earlier dirs with the same path.
+func (l logsTarProvider) sourceLogs(dirs []boshcmd.DirToCopy, filters []string) (map[string]bundledLog, error) {
+	logs := map[string]bundledLog{}
+
+	for _, dir := range dirs {
+		for _, filter := range filters {
+			pattern := filepath.Join(dir.Dir, filter)
+
+			if l.fs.FileExists(pattern) {
+				info, err := l.fs.Stat(pattern)
+				if err == nil && info.IsDir() {
+					pattern = filepath.Join(pattern, "**", "*")
+				}
+			}
+
+			matches, err := doublestar.Glob(pattern)
+			if err != nil {
+				return nil, bosherr.WrapError(err, "Finding files matching filters")
+			}
+
+			for _, match := range matches {
+				info, err := l.fs.Stat(match)
+				if err != nil {
+					return nil, bosherr.WrapErrorf(err, "Checking log '%s'", match)
+				}
+
+				if info.IsDir() {
+					continue
+				}
+
+				relPath, err := filepath.Rel(dir.Dir, match)
+				if err != nil {
+					return nil, err
+				}
+
+				relPath = strings.TrimPrefix(filepath.ToSlash(filepath.Join(dir.Prefix, relPath)), "/")
+
+				logs[relPath] = bundledLog{relPath: relPath, srcPath: match, size: info.Size(), modTime: info.ModTime()}
+			}
+		}
+	}
+
+	return logs, nil
+}
+
+func (l logsTarProvider) skip(tmpDir string, log bundledLog, reason string, manifest *Manifest) error {
+	l.logger.Debug(logTag, "Skipping log '%s': %s", log.relPath, reason)
+
+	if log.copied {
+		err := l.fs.RemoveAll(filepath.Join(tmpDir, log.relPath))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing skipped log '%s'", log.relPath)
+		}
+	}
+
+	manifest.Skipped = append(manifest.Skipped, ManifestEntry{Path: log.relPath, Size: log.size, Reason: reason})
+
+	return nil
+}
+
+func (l logsTarProvider) copyLog(srcPath, dstPath string) error {
+	err := l.fs.MkdirAll(filepath.Dir(dstPath), os.ModePerm)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating log directory")
+	}
+
+	return l.fs.CopyFile(srcPath, dstPath)
+}
+
+// copyTrimmedToTimeWindow copies the lines of a log whose timestamp lies within
+// the time window. Lines without a timestamp, such as stack traces, share the
+// fate of the closest timestamped line above them. Nothing is left at dstPath
+// when every line lies outside of the time window.
+func (l logsTarProvider) copyTrimmedToTimeWindow(srcPath, dstPath string, modTime time.Time, options Options) (size int64, trimmed bool, empty bool, err error) {
+	err = l.fs.MkdirAll(filepath.Dir(dstPath), os.ModePerm)
+	if err != nil {
+		return 0, false, false, bosherr.WrapError(err, "Creating log directory")
+	}
+
+	src, err := l.fs.OpenFile(srcPath, os.O_RDONLY, 0)
+	if err != nil {
+		return 0, false, false, bosherr.WrapError(err, "Opening log")
+	}
+
+	dst, err := l.fs.OpenFile(dstPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(0644))
+	if err != nil {
+		_ = src.Close()
+		return 0, false, false, bosherr.WrapError(err, "Creating trimmed log")
+	}
+
+	kept, dropped, err := filterLines(src, dst, modTime, options)
+
+	_ = src.Close()
+	if closeErr := dst.Close(); err == nil && closeErr != nil {
+		err = bosherr.WrapError(closeErr, "Closing trimmed log")
+	}
+
+	if err != nil {
+		_ = l.fs.RemoveAll(dstPath)
+		return 0, false, false, err
+	}
+
+	if kept == 0 && dropped > 0 {
+		return 0, true, true, l.fs.RemoveAll(dstPath)
+	}
+
+	info, err := l.fs.Stat(dstPath)
+	if err != nil {
+		return 0, false, false, bosherr.WrapError(err, "Checking trimmed log")
+	}
+
+	return info.Size(), dropped > 0, false, nil
+}
+
+func filterLines(src io.Reader, dst io.Writer, modTime time.Time, options Options) (kept int, dropped int, err error) {
+	reader := bufio.NewReader(src)
+	writer := bufio.NewWriter(dst)
+
+	// Lines above the first timestamp cannot be placed and are kept
+	include := true
+
+	for {
+		line, readErr := reader.ReadString('\n')
+
+		if len(line) > 0 {
+			if t, found := parseTimestampPrefix(line, modTime); found {
+				include = options.inTimeWindow(t)
+			}
+
+			if include {
+				_, err = writer.WriteString(line)
+				if err != nil {
+					return kept, dropped, bosherr.WrapError(err, "Writing trimmed log")
+				}
+				kept++
+			} else {
+				dropped++
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+
+		if readErr != nil {
+			return kept, dropped, bosherr.WrapError(readErr, "Reading log")
+		}
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return kept, dropped, bosherr.WrapError(err, "Writing trimmed log")
+	}
+
+	return kept, dropped, nil
+}
+
+// writeJournal saves the journald output of the time window; found is
+// false on systems without journald
+func (l logsTarProvider) writeJournal(tmpDir string, options Options) (journal bundledLog, found bool, err error) {
+	if runtime.GOOS != "linux" || !l.cmdRunner.CommandExists("journalctl") {
+		return bundledLog{}, false, nil
+	}
+
+	journalPath := filepath.Join(tmpDir, journalFileName)
+
+	err = l.fs.MkdirAll(filepath.Dir(journalPath), os.FileMode(0755))
+	if err != nil {
+		return bundledLog{}, false, bosherr.WrapError(err, "Creating journal directory")
+	}
+
+	file, err := l.fs.OpenFile(journalPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(0644))
+	if err != nil {
+		return bundledLog{}, false, bosherr.WrapError(err, "Creating journal log")
+	}
+
+	args := []string{"--no-pager", "--quiet", "--output", "short-iso-precise"}
+
+	if !options.Since.IsZero() {
+		args = append(args, "--since", fmt.Sprintf("@%d", options.Since.Unix()))
+	}
+
+	if !options.Until.IsZero() {
+		args = append(args, "--until", fmt.Sprintf("@%d", options.Until.Unix()))
+	}
+
+	_, _, _, err = l.cmdRunner.RunComplexCommand(boshsys.Command{Name: "journalctl", Args: args, Stdout: file})
+	_ = file.Close()
+	if err != nil {
+		return bundledLog{}, false, bosherr.WrapError(err, "Running journalctl")
+	}
+
+	info, err := l.fs.Stat(journalPath)
+	if err != nil {
+		return bundledLog{}, false, bosherr.WrapError(err, "Checking journal log")
+	}
+
+	return bundledLog{relPath: journalFileName, size: info.Size(), modTime: info.ModTime(), copied: true}, true, nil
+}
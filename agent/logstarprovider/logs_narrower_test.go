@@ -0,0 +1,287 @@
+package logstarprovider
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+// recordingFileSystem records which files are read or copied
+type recordingFileSystem struct {
+	boshsys.FileSystem
+
+	readPaths   []string
+	copiedPaths []string
+}
+
+func (fs *recordingFileSystem) OpenFile(path string, flag int, perm os.FileMode) (boshsys.File, error) {
+	if flag == os.O_RDONLY {
+		fs.readPaths = append(fs.readPaths, path)
+	}
+
+	return fs.FileSystem.OpenFile(path, flag, perm)
+}
+
+func (fs *recordingFileSystem) CopyFile(srcPath, dstPath string) error {
+	fs.copiedPaths = append(fs.copiedPaths, srcPath)
+
+	return fs.FileSystem.CopyFile(srcPath, dstPath)
+}
+
+var _ = Describe("LogsTarProvider with options", func() {
+	var (
+		baseDir     string
+		dirProvider boshdirs.Provider
+		compressor  *fakecmd.FakeCompressor
+		cmdRunner   *fakesys.FakeCmdRunner
+		fs          *recordingFileSystem
+		bundled     map[string]string
+
+		provider LogsTarProvider
+	)
+
+	writeLog := func(relPath, contents string, modTime time.Time) {
+		logPath := filepath.Join(dirProvider.LogsDir(), relPath)
+		Expect(os.MkdirAll(filepath.Dir(logPath), 0755)).To(Succeed())
+		Expect(os.WriteFile(logPath, []byte(contents), 0644)).To(Succeed())
+		Expect(os.Chtimes(logPath, modTime, modTime)).To(Succeed())
+	}
+
+	readManifest := func() Manifest {
+		Expect(bundled).To(HaveKey(ManifestFileName))
+
+		var manifest Manifest
+		Expect(json.Unmarshal([]byte(bundled[ManifestFileName]), &manifest)).To(Succeed())
+		return manifest
+	}
+
+	BeforeEach(func() {
+		baseDir = GinkgoT().TempDir()
+		dirProvider = boshdirs.NewProvider(baseDir)
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = &recordingFileSystem{FileSystem: boshsys.NewOsFileSystem(logger)}
+		cmdRunner = fakesys.NewFakeCmdRunner()
+
+		bundled = map[string]string{}
+		compressor = fakecmd.NewFakeCompressor()
+		compressor.CompressFilesInDirCallBack = func() {
+			dir := compressor.CompressFilesInDirDir
+			err := filepath.Walk(dir, func(filePath string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+
+				contents, err := os.ReadFile(filePath)
+				if err != nil {
+					return err
+				}
+
+				relPath, err := filepath.Rel(dir, filePath)
+				bundled[filepath.ToSlash(relPath)] = string(contents)
+				return err
+			})
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		provider = NewLogsTarProvider(compressor, boshcmd.NewGenericCpCopier(fs, logger), dirProvider, fs, cmdRunner, logger)
+	})
+
+	It("does not add a manifest without options", func() {
+		writeLog("job-a/job-a.log", "fake-line\n", time.Now())
+
+		_, err := provider.Get("job", []string{}, Options{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(bundled).To(Equal(map[string]string{"job-a/job-a.log": "fake-line\n"}))
+	})
+
+	Context("with a time window", func() {
+		var options Options
+
+		BeforeEach(func() {
+			options = Options{
+				Since: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+				Until: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+			}
+		})
+
+		It("keeps the lines within the window together with their continuation lines", func() {
+			writeLog("job-a/job-a.log", ""+
+				"2024-05-01T09:00:00Z too early\n"+
+				"  continued too early\n"+
+				"2024-05-01T10:30:00.123Z within\n"+
+				"  continued within\n"+
+				"[2024-05-01 11:00:00+0000] within as well\n"+
+				"2024-05-01T12:30:00Z too late\n",
+				time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC))
+
+			_, err := provider.Get("job", []string{}, options)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(bundled["job-a/job-a.log"]).To(Equal("" +
+				"2024-05-01T10:30:00.123Z within\n" +
+				"  continued within\n" +
+				"[2024-05-01 11:00:00+0000] within as well\n"))
+
+			manifest := readManifest()
+			Expect(*manifest.Since).To(BeTemporally("==", options.Since))
+			Expect(*manifest.Until).To(BeTemporally("==", options.Until))
+			Expect(manifest.Included).To(Equal([]ManifestEntry{
+				{Path: "job-a/job-a.log", Size: int64(len(bundled["job-a/job-a.log"])), Trimmed: true},
+			}))
+			Expect(manifest.Skipped).To(BeEmpty())
+		})
+
+		It("keeps logs without timestamps that were modified within the window as a whole", func() {
+			writeLog("job-a/job-a.stdout.log", "no timestamps here\n", time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC))
+
+			_, err := provider.Get("job", []string{}, options)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(bundled["job-a/job-a.stdout.log"]).To(Equal("no timestamps here\n"))
+			Expect(readManifest().Included).To(Equal([]ManifestEntry{{Path: "job-a/job-a.stdout.log", Size: 19}}))
+		})
+
+		It("skips logs that were not modified since the start of the window", func() {
+			writeLog("job-a/old.log", "2024-05-01T11:00:00Z fake-line\n", time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC))
+
+			_, err := provider.Get("job", []string{}, options)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(bundled).ToNot(HaveKey("job-a/old.log"))
+			Expect(readManifest().Skipped).To(Equal([]ManifestEntry{
+				{Path: "job-a/old.log", Size: 31, Reason: "not modified since the start of the time window"},
+			}))
+		})
+
+		It("skips logs without lines within the window", func() {
+			writeLog("job-a/later.log", "2024-05-01T13:00:00Z fake-line\n", time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC))
+
+			_, err := provider.Get("job", []string{}, options)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(bundled).ToNot(HaveKey("job-a/later.log"))
+			Expect(readManifest().Skipped).To(Equal([]ManifestEntry{
+				{Path: "job-a/later.log", Size: 31, Reason: "no lines within the time window"},
+			}))
+		})
+	})
+
+	It("keeps the newest logs that fit into the maximum bundle size", func() {
+		now := time.Now()
+		writeLog("job-a/newest.log", "0123456789", now)
+		writeLog("job-a/newer.log", "0123456789", now.Add(-time.Hour))
+		writeLog("job-a/small-but-old.log", "0", now.Add(-3*time.Hour))
+		writeLog("job-b/older.log", "0123456789", now.Add(-2*time.Hour))
+
+		_, err := provider.Get("job", []string{}, Options{MaxBytes: 25})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(bundled).To(HaveKey("job-a/newest.log"))
+		Expect(bundled).To(HaveKey("job-a/newer.log"))
+		Expect(bundled).ToNot(HaveKey("job-b/older.log"))
+		Expect(bundled).ToNot(HaveKey("job-a/small-but-old.log"))
+
+		manifest := readManifest()
+		Expect(manifest.MaxBytes).To(Equal(uint64(25)))
+		Expect(manifest.Included).To(Equal([]ManifestEntry{
+			{Path: "job-a/newer.log", Size: 10},
+			{Path: "job-a/newest.log", Size: 10},
+		}))
+		Expect(manifest.Skipped).To(Equal([]ManifestEntry{
+			{Path: "job-a/small-but-old.log", Size: 1, Reason: "bundle size limit reached"},
+			{Path: "job-b/older.log", Size: 10, Reason: "bundle size limit reached"},
+		}))
+	})
+
+	It("does not copy logs that are skipped", func() {
+		now := time.Now()
+		writeLog("job-a/new.log", "0123456789", now)
+		writeLog("job-a/too-big.log", "0123456789", now.Add(-time.Hour))
+		writeLog("job-a/too-old.log", "0123456789", now.Add(-48*time.Hour))
+
+		_, err := provider.Get("job", []string{}, Options{Since: now.Add(-24 * time.Hour), MaxBytes: 15})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fs.readPaths).To(ContainElement(filepath.Join(dirProvider.LogsDir(), "job-a/new.log")))
+		Expect(fs.readPaths).ToNot(ContainElement(filepath.Join(dirProvider.LogsDir(), "job-a/too-old.log")))
+		Expect(fs.copiedPaths).To(BeEmpty())
+	})
+
+	It("copies the logs that are not trimmed only once they fit into the maximum bundle size", func() {
+		now := time.Now()
+		writeLog("job-a/new.log", "0123456789", now)
+		writeLog("job-a/too-big.log", "0123456789", now.Add(-time.Hour))
+
+		_, err := provider.Get("job", []string{}, Options{MaxBytes: 15})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fs.copiedPaths).To(Equal([]string{filepath.Join(dirProvider.LogsDir(), "job-a/new.log")}))
+		Expect(fs.readPaths).To(BeEmpty())
+	})
+
+	It("bundles the logs of the selected jobs under their names", func() {
+		writeLog("job-a/job-a.log", "a\n", time.Now())
+		writeLog("job-b/job-b.log", "b\n", time.Now())
+		Expect(os.MkdirAll(dirProvider.AgentLogsDir(), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dirProvider.AgentLogsDir(), "current"), []byte("agent\n"), 0644)).To(Succeed())
+
+		_, err := provider.Get("job,agent", []string{}, Options{Jobs: []string{"job-b"}})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(bundled).To(HaveKey("job-b/job-b.log"))
+		Expect(bundled).To(HaveKey("current"))
+		Expect(bundled).ToNot(HaveKey("job-a/job-a.log"))
+		Expect(readManifest().Included).To(Equal([]ManifestEntry{{Path: "current", Size: 6}, {Path: "job-b/job-b.log", Size: 2}}))
+	})
+
+	Context("when journald output is requested", func() {
+		It("adds the journal of the time window", func() {
+			if runtime.GOOS != "linux" {
+				Skip("journald is only available on linux")
+			}
+
+			cmdRunner.CommandExistsValue = true
+			cmdRunner.AddCmdResult(
+				"journalctl --no-pager --quiet --output short-iso-precise --since @1714557600 --until @1714564800",
+				fakesys.FakeCmdResult{Stdout: "fake-journal\n"},
+			)
+
+			_, err := provider.Get("job", []string{}, Options{
+				Since:          time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+				Until:          time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+				IncludeJournal: true,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(bundled["journal/journal.log"]).To(Equal("fake-journal\n"))
+			Expect(readManifest().Included).To(Equal([]ManifestEntry{{Path: "journal/journal.log", Size: 13}}))
+		})
+
+		It("records the journal as skipped when journald is not available", func() {
+			cmdRunner.CommandExistsValue = false
+
+			_, err := provider.Get("job", []string{}, Options{IncludeJournal: true})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(bundled).ToNot(HaveKey("journal/journal.log"))
+			Expect(readManifest().Skipped).To(Equal([]ManifestEntry{
+				{Path: "journal/journal.log", Reason: "journald is not available on this system"},
+			}))
+		})
+	})
+})
@@ -1,11 +1,14 @@
 package logstarprovider
 
 import (
+	"path"
 	"runtime"
 	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 )
@@ -14,20 +17,30 @@ type logsTarProvider struct {
 	compressor  boshcmd.Compressor
 	copier      boshcmd.Copier
 	settingsDir boshdirs.Provider
+	fs          boshsys.FileSystem
+	cmdRunner   boshsys.CmdRunner
+	logger      boshlog.Logger
 }
 
 func NewLogsTarProvider(
 	compressor boshcmd.Compressor,
 	copier boshcmd.Copier,
-	settingsDir boshdirs.Provider) LogsTarProvider {
+	settingsDir boshdirs.Provider,
+	fs boshsys.FileSystem,
+	cmdRunner boshsys.CmdRunner,
+	logger boshlog.Logger,
+) LogsTarProvider {
 	return logsTarProvider{
 		compressor:  compressor,
 		copier:      copier,
 		settingsDir: settingsDir,
+		fs:          fs,
+		cmdRunner:   cmdRunner,
+		logger:      logger,
 	}
 }
 
-func (l logsTarProvider) Get(logTypes string, filters []string) (string, error) {
+func (l logsTarProvider) Get(logTypes string, filters []string, options Options) (string, error) {
 	var directoriesAndPrefixes []boshcmd.DirToCopy
 	var err error
 
@@ -37,15 +50,29 @@ func (l logsTarProvider) Get(logTypes string, filters []string) (string, error)
 
 	for _, logType := range strings.Split(logTypes, ",") {
 		if logType == "job" {
-			directoriesAndPrefixes = append(directoriesAndPrefixes,
-				boshcmd.DirToCopy{Dir: l.settingsDir.LogsDir(), Prefix: ""})
+			if len(options.Jobs) == 0 {
+				directoriesAndPrefixes = append(directoriesAndPrefixes,
+					boshcmd.DirToCopy{Dir: l.settingsDir.LogsDir(), Prefix: ""})
+				continue
+			}
+
+			for _, job := range options.Jobs {
+				if job == "" || job == "." || job == ".." || strings.ContainsAny(job, `/\`) {
+					return "", bosherr.Errorf("Invalid job name '%s'", job)
+				}
+
+				directoriesAndPrefixes = append(directoriesAndPrefixes,
+					boshcmd.DirToCopy{Dir: path.Join(l.settingsDir.LogsDir(), job), Prefix: job})
+			}
 			continue
 		}
+
 		if logType == "agent" {
 			directoriesAndPrefixes = append(directoriesAndPrefixes,
 				boshcmd.DirToCopy{Dir: l.settingsDir.AgentLogsDir(), Prefix: ""})
 			continue
 		}
+
 		if logType == "system" {
 			if runtime.GOOS == "linux" {
 				directoriesAndPrefixes = append(directoriesAndPrefixes,
@@ -53,16 +80,28 @@ func (l logsTarProvider) Get(logTypes string, filters []string) (string, error)
 			}
 			continue
 		}
+
 		err = bosherr.Error("Invalid log type")
 		return "", err
 	}
 
-	tmpDir, err := l.copier.FilteredMultiCopyToTemp(directoriesAndPrefixes, filters)
-	if err != nil {
-		return "", bosherr.WrapError(err, "Copying filtered files to temp directory")
-	}
+	var tmpDir string
+
+	if options.IsZero() {
+		tmpDir, err = l.copier.FilteredMultiCopyToTemp(directoriesAndPrefixes, filters)
+		if err != nil {
+			return "", bosherr.WrapError(err, "Copying filtered files to temp directory")
+		}
+
+		defer l.copier.CleanUp(tmpDir)
+	} else {
+		tmpDir, err = l.narrowedCopyToTemp(directoriesAndPrefixes, filters, options)
+		if err != nil {
+			return "", bosherr.WrapError(err, "Narrowing down logs")
+		}
 
-	defer l.copier.CleanUp(tmpDir)
+		defer l.fs.RemoveAll(tmpDir) //nolint:errcheck
+	}
 
 	tarball, err := l.compressor.CompressFilesInDir(tmpDir, boshcmd.CompressorOptions{})
 	if err != nil {
@@ -1,8 +1,66 @@
 package logstarprovider
 
+import (
+	"time"
+)
+
 //go:generate counterfeiter . LogsTarProvider
 
 type LogsTarProvider interface {
-	Get(logType string, filters []string) (string, error)
+	Get(logType string, filters []string, options Options) (string, error)
 	CleanUp(path string) error
 }
+
+// Options narrow down the logs in a bundle; the zero value bundles
+// every file matching the filters, just like plain fetch_logs
+type Options struct {
+	// Only logs written within [Since, Until] are included; zero values leave the window open
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+
+	// Oldest files are left out once the bundle would grow beyond MaxBytes; zero means unlimited
+	MaxBytes uint64 `json:"max_bytes"`
+
+	// Limits job logs to the log directories of these jobs
+	Jobs []string `json:"jobs"`
+
+	// Adds journald output for the same time window
+	IncludeJournal bool `json:"include_journal"`
+}
+
+func (o Options) IsZero() bool {
+	return o.Since.IsZero() && o.Until.IsZero() && o.MaxBytes == 0 && len(o.Jobs) == 0 && !o.IncludeJournal
+}
+
+func (o Options) hasTimeWindow() bool {
+	return !o.Since.IsZero() || !o.Until.IsZero()
+}
+
+func (o Options) inTimeWindow(t time.Time) bool {
+	if !o.Since.IsZero() && t.Before(o.Since) {
+		return false
+	}
+
+	return o.Until.IsZero() || !t.After(o.Until)
+}
+
+// ManifestFileName is written to the root of bundles created with non-zero Options
+const ManifestFileName = "bosh-logs-manifest.json"
+
+type Manifest struct {
+	Since    *time.Time `json:"since,omitempty"`
+	Until    *time.Time `json:"until,omitempty"`
+	MaxBytes uint64     `json:"max_bytes,omitempty"`
+
+	Included []ManifestEntry `json:"included"`
+	Skipped  []ManifestEntry `json:"skipped"`
+}
+
+type ManifestEntry struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+
+	// Trimmed is set when lines outside of the time window were dropped
+	Trimmed bool   `json:"trimmed,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
@@ -5,10 +5,12 @@ import (
 	"runtime"
 
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 
 	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -28,7 +30,7 @@ var _ = Describe("LogsTarProvider", func() {
 		dirProvider = boshdirs.NewProvider("/fake/dir")
 		copier = fakecmd.NewFakeCopier()
 
-		provider = NewLogsTarProvider(compressor, copier, dirProvider)
+		provider = NewLogsTarProvider(compressor, copier, dirProvider, fakesys.NewFakeFileSystem(), fakesys.NewFakeCmdRunner(), boshlog.NewLogger(boshlog.LevelNone))
 	})
 
 	Describe("Get", func() {
@@ -39,7 +41,7 @@ var _ = Describe("LogsTarProvider", func() {
 
 			Context("job logs", func() {
 				It("uses the correct logs dir", func() {
-					_, err := provider.Get("job", []string{})
+					_, err := provider.Get("job", []string{}, Options{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempDirs[0].Dir).To(boshassert.MatchPath(dirProvider.LogsDir()))
@@ -48,7 +50,7 @@ var _ = Describe("LogsTarProvider", func() {
 
 			Context("agent logs", func() {
 				It("uses the correct logs dir", func() {
-					_, err := provider.Get("agent", []string{})
+					_, err := provider.Get("agent", []string{}, Options{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempDirs[0].Dir).To(boshassert.MatchPath(dirProvider.AgentLogsDir()))
@@ -57,7 +59,7 @@ var _ = Describe("LogsTarProvider", func() {
 
 			Context("system logs", func() {
 				It("uses the correct logs dir", func() {
-					_, err := provider.Get("system", []string{})
+					_, err := provider.Get("system", []string{}, Options{})
 					Expect(err).NotTo(HaveOccurred())
 
 					if runtime.GOOS == "linux" {
@@ -70,7 +72,7 @@ var _ = Describe("LogsTarProvider", func() {
 
 			Context("multiple logs", func() {
 				It("uses the correct logs dirs", func() {
-					_, err := provider.Get("job,agent,system", []string{})
+					_, err := provider.Get("job,agent,system", []string{}, Options{})
 					Expect(err).NotTo(HaveOccurred())
 
 					if runtime.GOOS == "linux" {
@@ -96,14 +98,14 @@ var _ = Describe("LogsTarProvider", func() {
 
 			Context("job logs", func() {
 				It("uses the filters provided", func() {
-					_, err := provider.Get("job", []string{"foo", "bar"})
+					_, err := provider.Get("job", []string{"foo", "bar"}, Options{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempFilters).To(ConsistOf("foo", "bar"))
 				})
 
 				It("uses the default filters when none are provided", func() {
-					_, err := provider.Get("job", []string{})
+					_, err := provider.Get("job", []string{}, Options{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempFilters).To(ConsistOf("**/*"))
@@ -112,14 +114,14 @@ var _ = Describe("LogsTarProvider", func() {
 
 			Context("agent logs", func() {
 				It("uses the filters provided", func() {
-					_, err := provider.Get("agent", []string{"foo", "bar"})
+					_, err := provider.Get("agent", []string{"foo", "bar"}, Options{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempFilters).To(ConsistOf("foo", "bar"))
 				})
 
 				It("uses the default filters when none are provided", func() {
-					_, err := provider.Get("agent", []string{})
+					_, err := provider.Get("agent", []string{}, Options{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempFilters).To(ConsistOf("**/*"))
@@ -128,14 +130,14 @@ var _ = Describe("LogsTarProvider", func() {
 
 			Context("system logs", func() {
 				It("uses the filters provided", func() {
-					_, err := provider.Get("system", []string{"foo", "bar"})
+					_, err := provider.Get("system", []string{"foo", "bar"}, Options{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempFilters).To(ConsistOf("foo", "bar"))
 				})
 
 				It("uses the default filters when none are provided", func() {
-					_, err := provider.Get("system", []string{})
+					_, err := provider.Get("system", []string{}, Options{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempFilters).To(ConsistOf("**/*"))
@@ -144,23 +146,38 @@ var _ = Describe("LogsTarProvider", func() {
 
 			Context("multiple log types", func() {
 				It("uses the filters provided, just as it does with one log type", func() {
-					_, err := provider.Get("system,agent,job", []string{"foo", "bar"})
+					_, err := provider.Get("system,agent,job", []string{"foo", "bar"}, Options{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempFilters).To(ConsistOf("foo", "bar"))
 				})
 
 				It("uses the default filters when none are provided", func() {
-					_, err := provider.Get("agent,system,job", []string{})
+					_, err := provider.Get("agent,system,job", []string{}, Options{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempFilters).To(ConsistOf("**/*"))
 				})
 			})
 
+			Context("selected jobs", func() {
+				It("narrows down the logs without the copier", func() {
+					_, err := provider.Get("job,agent", []string{}, Options{Jobs: []string{"job-1", "job-2"}})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(copier.FilteredMultiCopyToTempDirs).To(BeEmpty())
+					Expect(compressor.CompressFilesInDirDir).ToNot(BeEmpty())
+				})
+
+				It("returns an error for job names that leave the logs dir", func() {
+					_, err := provider.Get("job", []string{}, Options{Jobs: []string{"../bosh"}})
+					Expect(err).To(MatchError("Invalid job name '../bosh'"))
+					Expect(copier.FilteredMultiCopyToTempDirs).To(BeEmpty())
+				})
+			})
+
 			Context("invalid log types", func() {
 				It("returns an error", func() {
-					_, err := provider.Get("lincoln", []string{})
+					_, err := provider.Get("lincoln", []string{}, Options{})
 					Expect(err).To(MatchError("Invalid log type"))
 				})
 			})
@@ -172,7 +189,7 @@ var _ = Describe("LogsTarProvider", func() {
 					})
 
 					It("returns an error if the copier returns an error", func() {
-						_, err := provider.Get("job", []string{})
+						_, err := provider.Get("job", []string{}, Options{})
 						Expect(err).To(MatchError(ContainSubstring("Copying filtered files to temp directory")))
 						Expect(err).To(MatchError(ContainSubstring("plagiarization")))
 					})
@@ -182,7 +199,7 @@ var _ = Describe("LogsTarProvider", func() {
 					copier.FilteredMultiCopyToTempDir = "/tmp/dir"
 					Expect(copier.CleanUpTempDir).To(BeZero())
 
-					_, err := provider.Get("job", []string{})
+					_, err := provider.Get("job", []string{}, Options{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.CleanUpTempDir).To(Equal("/tmp/dir"))
@@ -196,7 +213,7 @@ var _ = Describe("LogsTarProvider", func() {
 					})
 
 					It("returns an error if the compressor returns an error", func() {
-						_, err := provider.Get("job", []string{})
+						_, err := provider.Get("job", []string{}, Options{})
 						Expect(err).To(MatchError(ContainSubstring("Making logs tarball")))
 						Expect(err).To(MatchError(ContainSubstring("squish")))
 					})
@@ -205,7 +222,7 @@ var _ = Describe("LogsTarProvider", func() {
 				It("returns the tarball path", func() {
 					compressor.CompressFilesInDirTarballPath = "/tmp/logs.tar"
 
-					tarballPath, err := provider.Get("job", []string{})
+					tarballPath, err := provider.Get("job", []string{}, Options{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(tarballPath).To(Equal("/tmp/logs.tar"))
@@ -19,11 +19,12 @@ type FakeLogsTarProvider struct {
 	cleanUpReturnsOnCall map[int]struct {
 		result1 error
 	}
-	GetStub        func(string, []string) (string, error)
+	GetStub        func(string, []string, logstarprovider.Options) (string, error)
 	getMutex       sync.RWMutex
 	getArgsForCall []struct {
 		arg1 string
 		arg2 []string
+		arg3 logstarprovider.Options
 	}
 	getReturns struct {
 		result1 string
@@ -43,15 +44,16 @@ func (fake *FakeLogsTarProvider) CleanUp(arg1 string) error {
 	fake.cleanUpArgsForCall = append(fake.cleanUpArgsForCall, struct {
 		arg1 string
 	}{arg1})
+	stub := fake.CleanUpStub
+	fakeReturns := fake.cleanUpReturns
 	fake.recordInvocation("CleanUp", []interface{}{arg1})
 	fake.cleanUpMutex.Unlock()
-	if fake.CleanUpStub != nil {
-		return fake.CleanUpStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.cleanUpReturns
 	return fakeReturns.result1
 }
 
@@ -97,7 +99,7 @@ func (fake *FakeLogsTarProvider) CleanUpReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeLogsTarProvider) Get(arg1 string, arg2 []string) (string, error) {
+func (fake *FakeLogsTarProvider) Get(arg1 string, arg2 []string, arg3 logstarprovider.Options) (string, error) {
 	var arg2Copy []string
 	if arg2 != nil {
 		arg2Copy = make([]string, len(arg2))
@@ -108,16 +110,18 @@ func (fake *FakeLogsTarProvider) Get(arg1 string, arg2 []string) (string, error)
 	fake.getArgsForCall = append(fake.getArgsForCall, struct {
 		arg1 string
 		arg2 []string
-	}{arg1, arg2Copy})
-	fake.recordInvocation("Get", []interface{}{arg1, arg2Copy})
+		arg3 logstarprovider.Options
+	}{arg1, arg2Copy, arg3})
+	stub := fake.GetStub
+	fakeReturns := fake.getReturns
+	fake.recordInvocation("Get", []interface{}{arg1, arg2Copy, arg3})
 	fake.getMutex.Unlock()
-	if fake.GetStub != nil {
-		return fake.GetStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.getReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -127,17 +131,17 @@ func (fake *FakeLogsTarProvider) GetCallCount() int {
 	return len(fake.getArgsForCall)
 }
 
-func (fake *FakeLogsTarProvider) GetCalls(stub func(string, []string) (string, error)) {
+func (fake *FakeLogsTarProvider) GetCalls(stub func(string, []string, logstarprovider.Options) (string, error)) {
 	fake.getMutex.Lock()
 	defer fake.getMutex.Unlock()
 	fake.GetStub = stub
 }
 
-func (fake *FakeLogsTarProvider) GetArgsForCall(i int) (string, []string) {
+func (fake *FakeLogsTarProvider) GetArgsForCall(i int) (string, []string, logstarprovider.Options) {
 	fake.getMutex.RLock()
 	defer fake.getMutex.RUnlock()
 	argsForCall := fake.getArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeLogsTarProvider) GetReturns(result1 string, result2 error) {
@@ -169,10 +173,6 @@ func (fake *FakeLogsTarProvider) GetReturnsOnCall(i int, result1 string, result2
 func (fake *FakeLogsTarProvider) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.cleanUpMutex.RLock()
-	defer fake.cleanUpMutex.RUnlock()
-	fake.getMutex.RLock()
-	defer fake.getMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
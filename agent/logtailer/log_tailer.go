@@ -0,0 +1,330 @@
+package logtailer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmatcuk/doublestar"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	logTag = "logTailer"
+
+	DefaultPollInterval = 250 * time.Millisecond
+
+	// Lines without a line break within this many bytes are emitted in pieces
+	maxLineBytes = 64 * 1024
+
+	defaultFilter = "**/*.log"
+)
+
+// rotatedLogPattern matches logs rotated away by logrotate, such as job.log.1,
+// job.log.2.gz or job.log-20240101; their lines were emitted from the live log
+var rotatedLogPattern = regexp.MustCompile(`\.(\d+|gz|bz2|xz|zst)$|-\d{8,10}$`)
+
+type logTailer struct {
+	fs           boshsys.FileSystem
+	cmdRunner    boshsys.CmdRunner
+	logsDir      string
+	pollInterval time.Duration
+	logger       boshlog.Logger
+}
+
+// NewLogTailer follows logs by polling them, so that rotated and newly
+// created logs are picked up without depending on inotify
+func NewLogTailer(
+	fs boshsys.FileSystem,
+	cmdRunner boshsys.CmdRunner,
+	logsDir string,
+	pollInterval time.Duration,
+	logger boshlog.Logger,
+) LogTailer {
+	return logTailer{
+		fs:           fs,
+		cmdRunner:    cmdRunner,
+		logsDir:      logsDir,
+		pollInterval: pollInterval,
+		logger:       logger,
+	}
+}
+
+func (t logTailer) Tail(ctx context.Context, request Request, emit func(Line) error) error {
+	err := request.Validate()
+	if err != nil {
+		return err
+	}
+
+	filters := request.Filters
+	if len(filters) == 0 {
+		filters = []string{defaultFilter}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Lines of the journal are emitted from another goroutine
+	var emitLock sync.Mutex
+	syncedEmit := func(line Line) error {
+		emitLock.Lock()
+		defer emitLock.Unlock()
+
+		return emit(line)
+	}
+
+	var journalDone <-chan error
+
+	if request.Journal {
+		journalDone, err = t.followJournal(ctx, syncedEmit)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = t.followLogs(ctx, filters, request.FromOffset, syncedEmit, journalDone)
+
+	if journalDone != nil {
+		cancel()
+
+		journalErr := <-journalDone
+		if err == nil {
+			err = journalErr
+		}
+	}
+
+	return err
+}
+
+func (t logTailer) followLogs(ctx context.Context, filters []string, fromOffset *int64, emit func(Line) error, journalDone <-chan error) error {
+	ticker := time.NewTicker(t.pollInterval)
+	defer ticker.Stop()
+
+	offsets := map[string]int64{}
+	infos := map[string]os.FileInfo{}
+	firstPoll := true
+
+	for {
+		logPaths, err := t.glob(filters)
+		if err != nil {
+			return err
+		}
+
+		for _, logPath := range logPaths {
+			info, err := t.fs.Stat(logPath)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+
+			offset, found := offsets[logPath]
+
+			switch {
+			case !found && firstPoll:
+				offset = startOffset(fromOffset, info.Size())
+			case !found:
+				offset = 0
+			case !os.SameFile(infos[logPath], info):
+				t.logger.Debug(logTag, "Log '%s' was rotated, following the new log from its beginning", logPath)
+				offset = 0
+			case info.Size() < offset:
+				t.logger.Debug(logTag, "Log '%s' was truncated, following it from its beginning", logPath)
+				offset = 0
+			}
+
+			infos[logPath] = info
+
+			offsets[logPath], err = t.emitLines(logPath, offset, info.Size(), emit)
+			if err != nil {
+				return err
+			}
+		}
+
+		firstPoll = false
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-journalDone:
+			// journalDone is nil and blocks forever unless the journal is followed
+			if err == nil {
+				err = bosherr.Error("Following journald stopped unexpectedly")
+			}
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t logTailer) glob(filters []string) ([]string, error) {
+	var logPaths []string
+
+	for _, filter := range filters {
+		matches, err := doublestar.Glob(filepath.Join(t.logsDir, filter))
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Finding logs matching '%s'", filter)
+		}
+
+		for _, match := range matches {
+			if !rotatedLogPattern.MatchString(match) {
+				logPaths = append(logPaths, match)
+			}
+		}
+	}
+
+	slices.Sort(logPaths)
+
+	return slices.Compact(logPaths), nil
+}
+
+func startOffset(fromOffset *int64, size int64) int64 {
+	switch {
+	case fromOffset == nil:
+		return size
+	case *fromOffset < 0:
+		return max(size+*fromOffset, 0)
+	default:
+		return min(*fromOffset, size)
+	}
+}
+
+// emitLines emits the complete lines between offset and size and returns the
+// offset after the last emitted line; an incomplete last line is emitted once
+// its line break was written
+func (t logTailer) emitLines(logPath string, offset, size int64, emit func(Line) error) (int64, error) {
+	if offset >= size {
+		return offset, nil
+	}
+
+	relPath, err := filepath.Rel(t.logsDir, logPath)
+	if err != nil {
+		return offset, bosherr.WrapErrorf(err, "Finding relative path of log '%s'", logPath)
+	}
+
+	file, err := t.fs.OpenFile(logPath, os.O_RDONLY, 0)
+	if err != nil {
+		// the log may have been rotated away in the meantime
+		t.logger.Debug(logTag, "Skipping log '%s': %s", logPath, err.Error())
+		return offset, nil
+	}
+
+	defer func() {
+		_ = file.Close() //nolint:errcheck
+	}()
+
+	buf := make([]byte, min(size-offset, maxLineBytes))
+
+	for offset < size {
+		n, err := file.ReadAt(buf[:min(int64(len(buf)), size-offset)], offset)
+		if n == 0 {
+			if err != nil && err != io.EOF {
+				return offset, bosherr.WrapErrorf(err, "Reading log '%s'", logPath)
+			}
+			return offset, nil
+		}
+
+		chunk := buf[:n]
+
+		lineEnd := bytes.LastIndexByte(chunk, '\n')
+		if lineEnd < 0 {
+			if n < maxLineBytes {
+				return offset, nil
+			}
+
+			lineEnd = n - 1
+		}
+
+		for _, text := range strings.SplitAfter(string(chunk[:lineEnd+1]), "\n") {
+			if text == "" {
+				continue
+			}
+
+			offset += int64(len(text))
+
+			err = emit(Line{Path: filepath.ToSlash(relPath), Offset: offset, Text: strings.TrimSuffix(text, "\n")})
+			if err != nil {
+				return offset, err
+			}
+		}
+	}
+
+	return offset, nil
+}
+
+// followJournal emits journal entries written from now on; the returned
+// channel receives the outcome once journalctl exits or ctx is done
+func (t logTailer) followJournal(ctx context.Context, emit func(Line) error) (<-chan error, error) {
+	if runtime.GOOS != "linux" || !t.cmdRunner.CommandExists("journalctl") {
+		return nil, bosherr.Error("journald is not available on this system")
+	}
+
+	reader, writer := io.Pipe()
+
+	process, err := t.cmdRunner.RunComplexCommandAsync(boshsys.Command{
+		Name:   "journalctl",
+		Args:   []string{"--follow", "--lines", "0", "--no-pager", "--quiet", "--output", "short-iso-precise"},
+		Stdout: writer,
+	})
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Starting journalctl")
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		scanned := make(chan struct{})
+
+		go func() {
+			defer close(scanned)
+
+			lines := bufio.NewReader(reader)
+			for {
+				text, err := lines.ReadString('\n')
+				if text != "" {
+					err = emit(Line{Path: JournalPath, Text: strings.TrimSuffix(text, "\n")})
+				}
+
+				if err != nil {
+					// keeps journalctl from blocking on a full pipe
+					_, _ = io.Copy(io.Discard, reader) //nolint:errcheck
+					return
+				}
+			}
+		}()
+
+		var err error
+
+		resultCh := process.Wait()
+
+		select {
+		case <-ctx.Done():
+			err = process.TerminateNicely(1 * time.Second)
+			<-resultCh
+		case result := <-resultCh:
+			if result.Error != nil {
+				err = bosherr.WrapError(result.Error, "Following journald")
+			} else {
+				err = bosherr.Error("journalctl exited")
+			}
+		}
+
+		_ = writer.Close()
+		<-scanned
+
+		// closing lets later receives return right away
+		done <- err
+		close(done)
+	}()
+
+	return done, nil
+}
@@ -0,0 +1,57 @@
+package logtailer
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . LogTailer
+
+type LogTailer interface {
+	// Tail emits the lines appended to the selected logs until ctx is done
+	Tail(ctx context.Context, request Request, emit func(Line) error) error
+}
+
+type Request struct {
+	// Globs relative to the job log dir; defaults to all *.log files. Rotated
+	// and compressed logs, such as job.log.1 or job.log.2.gz, are never followed.
+	Filters []string
+
+	// Byte offset to start each log at; negative offsets count from the end
+	// of the log and nil starts at the end. The offset applies to every log,
+	// so resuming from a line's offset only works for a filter matching its
+	// log alone. Logs that show up or are rotated while tailing are always
+	// followed from their beginning.
+	FromOffset *int64
+
+	// Also follows journald
+	Journal bool
+}
+
+// Validate rejects filters that reach outside of the job log dir
+func (r Request) Validate() error {
+	for _, filter := range r.Filters {
+		if filepath.IsAbs(filter) || slices.Contains(strings.Split(filepath.ToSlash(filter), "/"), "..") {
+			return bosherr.Errorf("Invalid log filter '%s'", filter)
+		}
+	}
+
+	return nil
+}
+
+type Line struct {
+	// Log path relative to the job log dir, or JournalPath
+	Path string `json:"path"`
+
+	// Offset right after the line within its log; pass it as FromOffset
+	// with a filter matching only this log to resume it
+	Offset int64 `json:"offset,omitempty"`
+
+	Text string `json:"line"`
+}
+
+const JournalPath = "journal"
@@ -0,0 +1,308 @@
+package logtailer_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent/logtailer"
+)
+
+var _ = Describe("LogTailer", func() {
+	var (
+		logsDir   string
+		cmdRunner *fakesys.FakeCmdRunner
+
+		linesLock sync.Mutex
+		lines     []Line
+
+		cancel  context.CancelFunc
+		tailErr chan error
+
+		logTailer LogTailer
+	)
+
+	writeLog := func(relPath, contents string) {
+		logPath := filepath.Join(logsDir, relPath)
+		Expect(os.MkdirAll(filepath.Dir(logPath), 0755)).To(Succeed())
+		Expect(os.WriteFile(logPath, []byte(contents), 0644)).To(Succeed())
+	}
+
+	appendLog := func(relPath, contents string) {
+		file, err := os.OpenFile(filepath.Join(logsDir, relPath), os.O_APPEND|os.O_WRONLY, 0644)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = file.WriteString(contents)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(file.Close()).To(Succeed())
+	}
+
+	emittedLines := func() []Line {
+		linesLock.Lock()
+		defer linesLock.Unlock()
+
+		return append([]Line{}, lines...)
+	}
+
+	startTail := func(request Request) {
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+
+		tailErr = make(chan error, 1)
+
+		go func() {
+			defer GinkgoRecover()
+
+			tailErr <- logTailer.Tail(ctx, request, func(line Line) error {
+				linesLock.Lock()
+				defer linesLock.Unlock()
+
+				lines = append(lines, line)
+				return nil
+			})
+		}()
+
+		// gives the first poll the chance to pick its start offsets
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	stopTail := func() error {
+		cancel()
+
+		var err error
+		Eventually(tailErr).Should(Receive(&err))
+		return err
+	}
+
+	BeforeEach(func() {
+		logsDir = GinkgoT().TempDir()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+
+		linesLock.Lock()
+		lines = nil
+		linesLock.Unlock()
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		logTailer = NewLogTailer(boshsys.NewOsFileSystem(logger), cmdRunner, logsDir, 10*time.Millisecond, logger)
+	})
+
+	It("follows the lines appended to existing logs from their end", func() {
+		writeLog("job-a/job-a.log", "fake-old-line\n")
+
+		startTail(Request{})
+		appendLog("job-a/job-a.log", "fake-new-line\n")
+
+		Eventually(emittedLines).Should(Equal([]Line{
+			{Path: "job-a/job-a.log", Offset: 28, Text: "fake-new-line"},
+		}))
+
+		Expect(stopTail()).To(Succeed())
+	})
+
+	It("follows logs created while tailing from their beginning", func() {
+		startTail(Request{})
+		writeLog("job-b/job-b.log", "fake-line-1\nfake-line-2\n")
+
+		Eventually(emittedLines).Should(Equal([]Line{
+			{Path: "job-b/job-b.log", Offset: 12, Text: "fake-line-1"},
+			{Path: "job-b/job-b.log", Offset: 24, Text: "fake-line-2"},
+		}))
+
+		Expect(stopTail()).To(Succeed())
+	})
+
+	It("starts existing logs at the given offset", func() {
+		writeLog("job-a/job-a.log", "fake-line-1\nfake-line-2\n")
+
+		fromOffset := int64(12)
+		startTail(Request{FromOffset: &fromOffset})
+
+		Eventually(emittedLines).Should(Equal([]Line{
+			{Path: "job-a/job-a.log", Offset: 24, Text: "fake-line-2"},
+		}))
+
+		Expect(stopTail()).To(Succeed())
+	})
+
+	It("counts negative offsets from the end of existing logs", func() {
+		writeLog("job-a/job-a.log", "fake-line-1\nfake-line-2\n")
+		writeLog("job-a/short.log", "fake-line\n")
+
+		fromOffset := int64(-12)
+		startTail(Request{FromOffset: &fromOffset})
+
+		Eventually(emittedLines).Should(ConsistOf(
+			Line{Path: "job-a/job-a.log", Offset: 24, Text: "fake-line-2"},
+			Line{Path: "job-a/short.log", Offset: 10, Text: "fake-line"},
+		))
+
+		Expect(stopTail()).To(Succeed())
+	})
+
+	It("only follows the logs matching the filters", func() {
+		startTail(Request{Filters: []string{"job-a/*.log"}})
+		writeLog("job-a/job-a.log", "fake-line-a\n")
+		writeLog("job-a/job-a.txt", "fake-line-txt\n")
+		writeLog("job-b/job-b.log", "fake-line-b\n")
+
+		Eventually(emittedLines).Should(Equal([]Line{
+			{Path: "job-a/job-a.log", Offset: 12, Text: "fake-line-a"},
+		}))
+		Consistently(emittedLines, 50*time.Millisecond).Should(HaveLen(1))
+
+		Expect(stopTail()).To(Succeed())
+	})
+
+	It("holds back lines until their line break was written", func() {
+		writeLog("job-a/job-a.log", "")
+
+		startTail(Request{})
+		appendLog("job-a/job-a.log", "fake-")
+		Consistently(emittedLines, 50*time.Millisecond).Should(BeEmpty())
+
+		appendLog("job-a/job-a.log", "line\n")
+		Eventually(emittedLines).Should(Equal([]Line{
+			{Path: "job-a/job-a.log", Offset: 10, Text: "fake-line"},
+		}))
+
+		Expect(stopTail()).To(Succeed())
+	})
+
+	It("follows truncated logs from their beginning", func() {
+		writeLog("job-a/job-a.log", "fake-old-line\n")
+
+		startTail(Request{})
+		writeLog("job-a/job-a.log", "new\n")
+
+		Eventually(emittedLines).Should(Equal([]Line{
+			{Path: "job-a/job-a.log", Offset: 4, Text: "new"},
+		}))
+
+		Expect(stopTail()).To(Succeed())
+	})
+
+	It("follows the new log from its beginning when a log was rotated", func() {
+		writeLog("job-a/job-a.log", "fake-old-line\n")
+
+		startTail(Request{})
+		Expect(os.Rename(filepath.Join(logsDir, "job-a/job-a.log"), filepath.Join(logsDir, "job-a/job-a.log.1"))).To(Succeed())
+		writeLog("job-a/job-a.log", "fake-new-line-1\nfake-new-line-2\n")
+		writeLog("job-a/job-a.log.2.gz", "fake-compressed\n")
+
+		Eventually(emittedLines).Should(Equal([]Line{
+			{Path: "job-a/job-a.log", Offset: 16, Text: "fake-new-line-1"},
+			{Path: "job-a/job-a.log", Offset: 32, Text: "fake-new-line-2"},
+		}))
+		Consistently(emittedLines, 50*time.Millisecond).Should(HaveLen(2))
+
+		Expect(stopTail()).To(Succeed())
+	})
+
+	It("does not follow rotated logs even when the filters match them", func() {
+		startTail(Request{Filters: []string{"job-a/*"}})
+		writeLog("job-a/job-a.log.1", "fake-rotated-line\n")
+		writeLog("job-a/job-a.log-20240101", "fake-dated-line\n")
+		writeLog("job-a/job-a.out", "fake-line\n")
+
+		Eventually(emittedLines).Should(Equal([]Line{
+			{Path: "job-a/job-a.out", Offset: 10, Text: "fake-line"},
+		}))
+		Consistently(emittedLines, 50*time.Millisecond).Should(HaveLen(1))
+
+		Expect(stopTail()).To(Succeed())
+	})
+
+	It("only follows *.log files by default", func() {
+		startTail(Request{})
+		writeLog("job-a/job-a.txt", "fake-line-txt\n")
+		writeLog("job-a/job-a.log", "fake-line\n")
+
+		Eventually(emittedLines).Should(Equal([]Line{
+			{Path: "job-a/job-a.log", Offset: 10, Text: "fake-line"},
+		}))
+		Consistently(emittedLines, 50*time.Millisecond).Should(HaveLen(1))
+
+		Expect(stopTail()).To(Succeed())
+	})
+
+	It("returns an error when emitting a line fails", func() {
+		writeLog("job-a/job-a.log", "fake-line\n")
+
+		fromOffset := int64(0)
+		err := logTailer.Tail(context.Background(), Request{FromOffset: &fromOffset}, func(Line) error {
+			return errors.New("fake-emit-err")
+		})
+		Expect(err).To(MatchError("fake-emit-err"))
+	})
+
+	It("rejects filters outside of the log dir", func() {
+		for _, filter := range []string{"../*", "job-a/../../*", filepath.Join(logsDir, "*")} {
+			err := logTailer.Tail(context.Background(), Request{Filters: []string{filter}}, func(Line) error { return nil })
+			Expect(err).To(MatchError(ContainSubstring("Invalid log filter")))
+		}
+	})
+
+	Context("when journald is followed", func() {
+		var process *fakesys.FakeProcess
+
+		BeforeEach(func() {
+			if runtime.GOOS != "linux" {
+				Skip("journald is only available on linux")
+			}
+
+			cmdRunner.CommandExistsValue = true
+
+			process = &fakesys.FakeProcess{
+				TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
+					p.WaitCh <- boshsys.Result{}
+				},
+			}
+			cmdRunner.AddProcess("journalctl --follow --lines 0 --no-pager --quiet --output short-iso-precise", process)
+		})
+
+		It("emits journal entries until the context is done", func() {
+			startTail(Request{Journal: true})
+
+			// journalctl is started before logs are polled, so its stdout is set once a log line arrives
+			writeLog("job-a/job-a.log", "fake-line\n")
+			Eventually(emittedLines).Should(HaveLen(1))
+
+			_, err := io.WriteString(process.Stdout, "fake-entry\n")
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(emittedLines).Should(Equal([]Line{
+				{Path: "job-a/job-a.log", Offset: 10, Text: "fake-line"},
+				{Path: JournalPath, Text: "fake-entry"},
+			}))
+
+			Expect(stopTail()).To(Succeed())
+			Expect(process.TerminateNicelyKillGracePeriod).To(Equal(1 * time.Second))
+		})
+
+		It("returns an error when journalctl exits by itself", func() {
+			process.TerminatedNicelyCallBack = nil
+			process.WaitResult = boshsys.Result{Error: errors.New("fake-journalctl-err")}
+
+			err := logTailer.Tail(context.Background(), Request{Journal: true}, func(Line) error { return nil })
+			Expect(err).To(MatchError(ContainSubstring("fake-journalctl-err")))
+		})
+	})
+
+	It("returns an error when journald is not available", func() {
+		cmdRunner.CommandExistsValue = false
+
+		err := logTailer.Tail(context.Background(), Request{Journal: true}, func(Line) error { return nil })
+		Expect(err).To(MatchError("journald is not available on this system"))
+	})
+})
@@ -0,0 +1,13 @@
+package logtailer_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLogTailer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LogTailer Suite")
+}
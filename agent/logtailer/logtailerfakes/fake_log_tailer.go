@@ -0,0 +1,114 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package logtailerfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/logtailer"
+)
+
+type FakeLogTailer struct {
+	TailStub        func(context.Context, logtailer.Request, func(logtailer.Line) error) error
+	tailMutex       sync.RWMutex
+	tailArgsForCall []struct {
+		arg1 context.Context
+		arg2 logtailer.Request
+		arg3 func(logtailer.Line) error
+	}
+	tailReturns struct {
+		result1 error
+	}
+	tailReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeLogTailer) Tail(arg1 context.Context, arg2 logtailer.Request, arg3 func(logtailer.Line) error) error {
+	fake.tailMutex.Lock()
+	ret, specificReturn := fake.tailReturnsOnCall[len(fake.tailArgsForCall)]
+	fake.tailArgsForCall = append(fake.tailArgsForCall, struct {
+		arg1 context.Context
+		arg2 logtailer.Request
+		arg3 func(logtailer.Line) error
+	}{arg1, arg2, arg3})
+	stub := fake.TailStub
+	fakeReturns := fake.tailReturns
+	fake.recordInvocation("Tail", []interface{}{arg1, arg2, arg3})
+	fake.tailMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLogTailer) TailCallCount() int {
+	fake.tailMutex.RLock()
+	defer fake.tailMutex.RUnlock()
+	return len(fake.tailArgsForCall)
+}
+
+func (fake *FakeLogTailer) TailCalls(stub func(context.Context, logtailer.Request, func(logtailer.Line) error) error) {
+	fake.tailMutex.Lock()
+	defer fake.tailMutex.Unlock()
+	fake.TailStub = stub
+}
+
+func (fake *FakeLogTailer) TailArgsForCall(i int) (context.Context, logtailer.Request, func(logtailer.Line) error) {
+	fake.tailMutex.RLock()
+	defer fake.tailMutex.RUnlock()
+	argsForCall := fake.tailArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeLogTailer) TailReturns(result1 error) {
+	fake.tailMutex.Lock()
+	defer fake.tailMutex.Unlock()
+	fake.TailStub = nil
+	fake.tailReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLogTailer) TailReturnsOnCall(i int, result1 error) {
+	fake.tailMutex.Lock()
+	defer fake.tailMutex.Unlock()
+	fake.TailStub = nil
+	if fake.tailReturnsOnCall == nil {
+		fake.tailReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.tailReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLogTailer) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeLogTailer) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ logtailer.LogTailer = new(FakeLogTailer)
@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
+	boshvitals "github.com/cloudfoundry/bosh-agent/v2/platform/vitals"
+)
+
+const (
+	OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+	metricPrefix = "bosh_agent_"
+)
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	labels []label
+	value  float64
+}
+
+type family struct {
+	name    string
+	help    string
+	unit    string
+	samples []sample
+}
+
+// WriteOpenMetrics renders the given vitals and monit processes in the
+// OpenMetrics text exposition format. Vitals values that are missing or
+// cannot be parsed are omitted rather than reported as zero.
+func WriteOpenMetrics(w io.Writer, vitals boshvitals.Vitals, processes []boshjobsuper.Process) error {
+	buf := bufio.NewWriter(w)
+
+	for _, f := range vitalsFamilies(vitals) {
+		writeFamily(buf, f)
+	}
+
+	for _, f := range processFamilies(processes) {
+		writeFamily(buf, f)
+	}
+
+	_, _ = buf.WriteString("# EOF\n") //nolint:errcheck
+
+	return buf.Flush()
+}
+
+func vitalsFamilies(vitals boshvitals.Vitals) []family {
+	cpu := family{name: "cpu_usage_percent", help: "CPU usage percentage by mode."}
+	cpu.add(vitals.CPU.User, label{"mode", "user"})
+	cpu.add(vitals.CPU.Sys, label{"mode", "sys"})
+	cpu.add(vitals.CPU.Wait, label{"mode", "wait"})
+
+	load := family{name: "load_average", help: "System load average."}
+	for i, period := range []string{"1m", "5m", "15m"} {
+		if i < len(vitals.Load) {
+			load.add(vitals.Load[i], label{"period", period})
+		}
+	}
+
+	memBytes := family{name: "memory_used_bytes", help: "Used memory in bytes.", unit: "bytes"}
+	memBytes.addKb(vitals.Mem.Kb)
+	memPercent := family{name: "memory_used_percent", help: "Used memory percentage."}
+	memPercent.add(vitals.Mem.Percent)
+
+	swapBytes := family{name: "swap_used_bytes", help: "Used swap in bytes.", unit: "bytes"}
+	swapBytes.addKb(vitals.Swap.Kb)
+	swapPercent := family{name: "swap_used_percent", help: "Used swap percentage."}
+	swapPercent.add(vitals.Swap.Percent)
+
+	diskNames := make([]string, 0, len(vitals.Disk))
+	for name := range vitals.Disk {
+		diskNames = append(diskNames, name)
+	}
+	sort.Strings(diskNames)
+
+	diskPercent := family{name: "disk_used_percent", help: "Used disk space percentage by mount."}
+	inodePercent := family{name: "disk_inode_used_percent", help: "Used inode percentage by mount."}
+	for _, name := range diskNames {
+		diskPercent.add(vitals.Disk[name].Percent, label{"disk", name})
+		inodePercent.add(vitals.Disk[name].InodePercent, label{"disk", name})
+	}
+
+	uptime := family{name: "uptime_seconds", help: "System uptime in seconds.", unit: "seconds"}
+	uptime.samples = append(uptime.samples, sample{value: float64(vitals.Uptime.Secs)})
+
+	return []family{cpu, load, memBytes, memPercent, swapBytes, swapPercent, diskPercent, inodePercent, uptime}
+}
+
+func processFamilies(processes []boshjobsuper.Process) []family {
+	state := family{name: "process_state", help: "Current monit state of the process; the value is always 1."}
+	uptime := family{name: "process_uptime_seconds", help: "Process uptime in seconds.", unit: "seconds"}
+	memBytes := family{name: "process_memory_bytes", help: "Process memory usage in bytes.", unit: "bytes"}
+	memPercent := family{name: "process_memory_percent", help: "Process memory usage percentage."}
+	cpuPercent := family{name: "process_cpu_percent", help: "Process total CPU usage percentage."}
+
+	for _, p := range processes {
+		name := label{"process", p.Name}
+		state.samples = append(state.samples, sample{labels: []label{name, {"state", p.State}}, value: 1})
+		uptime.samples = append(uptime.samples, sample{labels: []label{name}, value: float64(p.Uptime.Secs)})
+		memBytes.samples = append(memBytes.samples, sample{labels: []label{name}, value: float64(p.Memory.Kb) * 1024})
+		memPercent.samples = append(memPercent.samples, sample{labels: []label{name}, value: p.Memory.Percent})
+		cpuPercent.samples = append(cpuPercent.samples, sample{labels: []label{name}, value: p.CPU.Total})
+	}
+
+	return []family{state, uptime, memBytes, memPercent, cpuPercent}
+}
+
+func (f *family) add(value string, labels ...label) {
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+	f.samples = append(f.samples, sample{labels: labels, value: parsed})
+}
+
+func (f *family) addKb(value string) {
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+	f.samples = append(f.samples, sample{value: parsed * 1024})
+}
+
+func writeFamily(w *bufio.Writer, f family) {
+	if len(f.samples) == 0 {
+		return
+	}
+
+	name := metricPrefix + f.name
+
+	fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+	if f.unit != "" {
+		fmt.Fprintf(w, "# UNIT %s %s\n", name, f.unit)
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n", name, f.help)
+
+	for _, s := range f.samples {
+		_, _ = w.WriteString(name) //nolint:errcheck
+		writeLabels(w, s.labels)
+		fmt.Fprintf(w, " %s\n", strconv.FormatFloat(s.value, 'f', -1, 64))
+	}
+}
+
+func writeLabels(w *bufio.Writer, labels []label) {
+	if len(labels) == 0 {
+		return
+	}
+
+	pairs := make([]string, len(labels))
+	for i, l := range labels {
+		pairs[i] = fmt.Sprintf("%s=\"%s\"", l.name, escapeLabelValue(l.value))
+	}
+
+	fmt.Fprintf(w, "{%s}", strings.Join(pairs, ","))
+}
+
+func escapeLabelValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return strings.ReplaceAll(value, `"`, `\"`)
+}
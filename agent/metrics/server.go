@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/tlsconfig"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
+	boshvitals "github.com/cloudfoundry/bosh-agent/v2/platform/vitals"
+)
+
+const metricsServerLogTag = "MetricsServer"
+
+type Options struct {
+	// Address the metrics listener binds to, e.g. "127.0.0.1:9323";
+	// the listener is disabled when empty
+	Address string
+
+	// Server certificate, key and client CA used to require mutual TLS.
+	// All three must be set to bind to a non-loopback address.
+	CertificatePath   string
+	PrivateKeyPath    string
+	CACertificatePath string
+}
+
+func (o Options) Enabled() bool {
+	return o.Address != ""
+}
+
+func (o Options) mutualTLS() bool {
+	return o.CertificatePath != "" || o.PrivateKeyPath != "" || o.CACertificatePath != ""
+}
+
+type Server struct {
+	options       Options
+	vitalsService boshvitals.Service
+	jobSupervisor boshjobsuper.JobSupervisor
+	logger        boshlog.Logger
+
+	httpServer *http.Server
+}
+
+func NewServer(
+	options Options,
+	vitalsService boshvitals.Service,
+	jobSupervisor boshjobsuper.JobSupervisor,
+	logger boshlog.Logger,
+) *Server {
+	s := &Server{
+		options:       options,
+		vitalsService: vitalsService,
+		jobSupervisor: jobSupervisor,
+		logger:        logger,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.ServeMetrics)
+
+	s.httpServer = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return s
+}
+
+func (s *Server) Validate() error {
+	if s.options.mutualTLS() {
+		if s.options.CertificatePath == "" || s.options.PrivateKeyPath == "" || s.options.CACertificatePath == "" {
+			return bosherr.Error("Metrics mutual TLS requires a certificate, private key and CA certificate")
+		}
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(s.options.Address)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing metrics address '%s'", s.options.Address)
+	}
+
+	if host == "localhost" {
+		return nil
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !ip.IsLoopback() {
+		return bosherr.Errorf("Metrics address '%s' must be a loopback address unless mutual TLS is configured", s.options.Address)
+	}
+
+	return nil
+}
+
+func (s *Server) Start() error {
+	err := s.Validate()
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", s.options.Address)
+	if err != nil {
+		return bosherr.WrapError(err, "Starting metrics listener")
+	}
+
+	if s.options.mutualTLS() {
+		tlsConfig, err := tlsconfig.Build(
+			tlsconfig.WithInternalServiceDefaults(),
+			tlsconfig.WithIdentityFromFile(s.options.CertificatePath, s.options.PrivateKeyPath),
+		).Server(tlsconfig.WithClientAuthenticationFromFile(s.options.CACertificatePath))
+		if err != nil {
+			_ = listener.Close() //nolint:errcheck
+			return bosherr.WrapError(err, "Building metrics TLS config")
+		}
+		tlsConfig.NextProtos = []string{"http/1.1"}
+
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	s.logger.Info(metricsServerLogTag, "Serving metrics on %s", s.options.Address)
+
+	err = s.httpServer.Serve(listener)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+
+	return err
+}
+
+func (s *Server) Stop() {
+	_ = s.httpServer.Close() //nolint:errcheck
+}
+
+func (s *Server) ServeMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	vitals, err := s.vitalsService.Get()
+	if err != nil {
+		s.logger.Error(metricsServerLogTag, "Getting vitals: %s", err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	processes, err := s.jobSupervisor.Processes()
+	if err != nil {
+		// Vitals are still useful when monit is unavailable
+		s.logger.Debug(metricsServerLogTag, "Getting processes: %s", err.Error())
+		processes = nil
+	}
+
+	var body bytes.Buffer
+
+	err = WriteOpenMetrics(&body, vitals, processes)
+	if err != nil {
+		s.logger.Error(metricsServerLogTag, "Rendering metrics: %s", err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", OpenMetricsContentType)
+
+	_, err = w.Write(body.Bytes())
+	if err != nil {
+		s.logger.Error(metricsServerLogTag, "Writing response: %s", err.Error())
+	}
+}
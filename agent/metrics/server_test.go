@@ -0,0 +1,152 @@
+package metrics_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent/metrics"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
+	fakejobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/fakes"
+	boshvitals "github.com/cloudfoundry/bosh-agent/v2/platform/vitals"
+	"github.com/cloudfoundry/bosh-agent/v2/platform/vitals/vitalsfakes"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		options       Options
+		vitalsService *vitalsfakes.FakeService
+		jobSupervisor *fakejobsuper.FakeJobSupervisor
+		server        *Server
+	)
+
+	BeforeEach(func() {
+		options = Options{Address: "127.0.0.1:9323"}
+		vitalsService = &vitalsfakes.FakeService{}
+		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+	})
+
+	JustBeforeEach(func() {
+		server = NewServer(options, vitalsService, jobSupervisor, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("Validate", func() {
+		It("accepts loopback addresses", func() {
+			Expect(server.Validate()).To(Succeed())
+		})
+
+		Context("when bound to a non-loopback address", func() {
+			BeforeEach(func() {
+				options.Address = "0.0.0.0:9323"
+			})
+
+			It("returns an error", func() {
+				Expect(server.Validate()).To(MatchError(ContainSubstring("must be a loopback address")))
+			})
+
+			Context("when mutual TLS is configured", func() {
+				BeforeEach(func() {
+					options.CertificatePath = "/fake-cert"
+					options.PrivateKeyPath = "/fake-key"
+					options.CACertificatePath = "/fake-ca"
+				})
+
+				It("accepts the address", func() {
+					Expect(server.Validate()).To(Succeed())
+				})
+			})
+		})
+
+		Context("when mutual TLS is partially configured", func() {
+			BeforeEach(func() {
+				options.CertificatePath = "/fake-cert"
+			})
+
+			It("returns an error", func() {
+				Expect(server.Validate()).To(MatchError(ContainSubstring("requires a certificate, private key and CA certificate")))
+			})
+		})
+	})
+
+	Describe("ServeMetrics", func() {
+		var recorder *httptest.ResponseRecorder
+
+		BeforeEach(func() {
+			recorder = httptest.NewRecorder()
+			vitalsService.GetReturns(boshvitals.Vitals{
+				CPU:  boshvitals.CPUVitals{User: "56.0", Sys: "10.0", Wait: "1.0"},
+				Load: []string{"0.20", "4.55", "1.12"},
+				Mem:  boshvitals.MemoryVitals{Kb: "700", Percent: "70"},
+				Swap: boshvitals.MemoryVitals{Kb: "600", Percent: "60"},
+				Disk: boshvitals.DiskVitals{
+					"system":    boshvitals.SpecificDiskVitals{Percent: "50", InodePercent: "10"},
+					"ephemeral": boshvitals.SpecificDiskVitals{Percent: "25", InodePercent: "5"},
+				},
+				Uptime: boshvitals.UptimeVitals{Secs: 3600},
+			}, nil)
+			jobSupervisor.ProcessesStatus = []boshjobsuper.Process{
+				{
+					Name:   "fake-process",
+					State:  "running",
+					Uptime: boshjobsuper.UptimeVitals{Secs: 42},
+					Memory: boshjobsuper.MemoryVitals{Kb: 2, Percent: 0.5},
+					CPU:    boshjobsuper.CPUVitals{Total: 1.5},
+				},
+			}
+		})
+
+		It("renders vitals and processes in OpenMetrics format", func() {
+			server.ServeMetrics(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Header().Get("Content-Type")).To(Equal(OpenMetricsContentType))
+
+			body := recorder.Body.String()
+			Expect(body).To(ContainSubstring("# TYPE bosh_agent_cpu_usage_percent gauge\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_cpu_usage_percent{mode="user"} 56` + "\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_load_average{period="5m"} 4.55` + "\n"))
+			Expect(body).To(ContainSubstring("# UNIT bosh_agent_memory_used_bytes bytes\n"))
+			Expect(body).To(ContainSubstring("bosh_agent_memory_used_bytes 716800\n"))
+			Expect(body).To(ContainSubstring("bosh_agent_swap_used_percent 60\n"))
+			Expect(body).To(ContainSubstring(
+				`bosh_agent_disk_used_percent{disk="ephemeral"} 25` + "\n" +
+					`bosh_agent_disk_used_percent{disk="system"} 50` + "\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_disk_inode_used_percent{disk="system"} 10` + "\n"))
+			Expect(body).To(ContainSubstring("bosh_agent_uptime_seconds 3600\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_process_state{process="fake-process",state="running"} 1` + "\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_process_uptime_seconds{process="fake-process"} 42` + "\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_process_memory_bytes{process="fake-process"} 2048` + "\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_process_cpu_percent{process="fake-process"} 1.5` + "\n"))
+			Expect(body).To(HaveSuffix("# EOF\n"))
+		})
+
+		It("still renders vitals when processes cannot be fetched", func() {
+			jobSupervisor.ProcessesError = errors.New("fake-processes-error")
+
+			server.ServeMetrics(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(ContainSubstring("bosh_agent_uptime_seconds 3600\n"))
+			Expect(recorder.Body.String()).ToNot(ContainSubstring("bosh_agent_process_state"))
+		})
+
+		It("returns an error status when vitals cannot be fetched", func() {
+			vitalsService.GetReturns(boshvitals.Vitals{}, errors.New("fake-vitals-error"))
+
+			server.ServeMetrics(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+			Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+		})
+
+		It("rejects non-GET requests", func() {
+			server.ServeMetrics(recorder, httptest.NewRequest("POST", "/metrics", nil))
+
+			Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+})
@@ -0,0 +1,148 @@
+package syslog
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type Severity int
+
+const (
+	SeverityEmergency Severity = iota
+	SeverityAlert
+	SeverityCritical
+	SeverityError
+	SeverityWarning
+	SeverityNotice
+	SeverityInfo
+	SeverityDebug
+)
+
+// facilityUser is the syslog "user-level messages" facility
+const facilityUser = 1
+
+const dialTimeout = 5 * time.Second
+
+// Writer sends RFC 5424 formatted messages to a remote syslog collector.
+// Messages sent over TCP are framed using octet counting (RFC 6587).
+type Writer struct {
+	network     string
+	address     string
+	appName     string
+	hostname    string
+	timeService clock.Clock
+
+	conn net.Conn
+	lock sync.Mutex
+}
+
+func NewWriter(network, address, appName string, timeService clock.Clock) *Writer {
+	if network == "" {
+		network = "udp"
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	return &Writer{
+		network:     network,
+		address:     address,
+		appName:     appName,
+		hostname:    hostname,
+		timeService: timeService,
+	}
+}
+
+func (w *Writer) Write(severity Severity, msgID, msg string) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	line := Format(severity, w.timeService.Now(), w.hostname, w.appName, os.Getpid(), msgID, msg)
+	if w.network == "tcp" {
+		line = fmt.Sprintf("%d %s", len(line), line)
+	}
+
+	err := w.send(line)
+	if err == nil {
+		return nil
+	}
+
+	// Connection may have been dropped by the collector; retry once on a new one
+	w.closeConn()
+
+	return w.send(line)
+}
+
+func (w *Writer) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.closeConn()
+}
+
+func (w *Writer) send(line string) error {
+	if w.conn == nil {
+		conn, err := net.DialTimeout(w.network, w.address, dialTimeout)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Connecting to syslog collector '%s'", w.address)
+		}
+		w.conn = conn
+	}
+
+	_, err := w.conn.Write([]byte(line))
+	if err != nil {
+		return bosherr.WrapError(err, "Writing syslog message")
+	}
+
+	return nil
+}
+
+func (w *Writer) closeConn() error {
+	if w.conn == nil {
+		return nil
+	}
+
+	err := w.conn.Close()
+	w.conn = nil
+
+	return err
+}
+
+// Format renders a single RFC 5424 message without structured data.
+func Format(severity Severity, timestamp time.Time, hostname, appName string, procID int, msgID, msg string) string {
+	return fmt.Sprintf(
+		"<%d>1 %s %s %s %d %s - %s",
+		facilityUser*8+int(severity),
+		timestamp.UTC().Format(time.RFC3339Nano),
+		headerField(hostname),
+		headerField(appName),
+		procID,
+		headerField(msgID),
+		msg,
+	)
+}
+
+// headerField replaces characters not allowed in RFC 5424 header fields
+// and uses the nil value for empty fields
+func headerField(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, value)
+}
@@ -0,0 +1,97 @@
+package syslog_test
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/syslog"
+)
+
+var _ = Describe("Format", func() {
+	It("renders an RFC 5424 message", func() {
+		timestamp := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		line := syslog.Format(syslog.SeverityWarning, timestamp, "fake-host", "bosh-agent", 123, "heartbeat", "fake message")
+		Expect(line).To(Equal("<12>1 2020-01-02T03:04:05Z fake-host bosh-agent 123 heartbeat - fake message"))
+	})
+
+	It("uses the nil value for empty header fields and escapes spaces", func() {
+		timestamp := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		line := syslog.Format(syslog.SeverityInfo, timestamp, "", "bosh agent", 1, "", "msg")
+		Expect(line).To(Equal("<14>1 2020-01-02T03:04:05Z - bosh_agent 1 - - msg"))
+	})
+})
+
+var _ = Describe("Writer", func() {
+	var (
+		timeService *fakeclock.FakeClock
+	)
+
+	BeforeEach(func() {
+		timeService = fakeclock.NewFakeClock(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	})
+
+	It("sends messages over UDP", func() {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close() //nolint:errcheck
+
+		writer := syslog.NewWriter("udp", conn.LocalAddr().String(), "bosh-agent", timeService)
+		defer writer.Close() //nolint:errcheck
+
+		Expect(writer.Write(syslog.SeverityInfo, "heartbeat", "fake message")).To(Succeed())
+
+		buf := make([]byte, 1024)
+		Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+		n, _, err := conn.ReadFrom(buf)
+		Expect(err).ToNot(HaveOccurred())
+
+		hostname, _ := os.Hostname() //nolint:errcheck
+		Expect(string(buf[:n])).To(Equal(fmt.Sprintf(
+			"<14>1 2020-01-02T03:04:05Z %s bosh-agent %d heartbeat - fake message", hostname, os.Getpid())))
+	})
+
+	It("frames messages sent over TCP with their length", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		defer listener.Close() //nolint:errcheck
+
+		received := make(chan string, 1)
+		go func() {
+			defer GinkgoRecover()
+			conn, err := listener.Accept()
+			Expect(err).ToNot(HaveOccurred())
+			defer conn.Close() //nolint:errcheck
+
+			line, _ := bufio.NewReader(conn).ReadString('Z') //nolint:errcheck
+			received <- line
+		}()
+
+		writer := syslog.NewWriter("tcp", listener.Addr().String(), "bosh-agent", timeService)
+		defer writer.Close() //nolint:errcheck
+
+		Expect(writer.Write(syslog.SeverityInfo, "heartbeat", "fake message")).To(Succeed())
+		Eventually(received).Should(Receive(MatchRegexp(`^\d+ <14>1 2020-01-02T03:04:05Z$`)))
+	})
+
+	It("returns an error when the collector cannot be reached", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		address := listener.Addr().String()
+		Expect(listener.Close()).To(Succeed())
+
+		writer := syslog.NewWriter("tcp", address, "bosh-agent", timeService)
+
+		err = writer.Write(syslog.SeverityInfo, "heartbeat", "fake message")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Connecting to syslog collector"))
+	})
+})
@@ -0,0 +1,38 @@
+package fakes
+
+import (
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+type FakeHistory struct {
+	RecordedEntries []boshtask.HistoryEntry
+	RecordErr       error
+
+	EntriesErr error
+}
+
+func NewFakeHistory() *FakeHistory {
+	return &FakeHistory{}
+}
+
+func (h *FakeHistory) Record(entry boshtask.HistoryEntry) error {
+	h.RecordedEntries = append(h.RecordedEntries, entry)
+	return h.RecordErr
+}
+
+func (h *FakeHistory) Entries() ([]boshtask.HistoryEntry, error) {
+	entries := make([]boshtask.HistoryEntry, 0, len(h.RecordedEntries))
+	for i := len(h.RecordedEntries) - 1; i >= 0; i-- {
+		entries = append(entries, h.RecordedEntries[i])
+	}
+	return entries, h.EntriesErr
+}
+
+func (h *FakeHistory) Find(taskID string) (boshtask.HistoryEntry, bool, error) {
+	for _, entry := range h.RecordedEntries {
+		if entry.TaskID == taskID {
+			return entry, true, h.EntriesErr
+		}
+	}
+	return boshtask.HistoryEntry{}, false, h.EntriesErr
+}
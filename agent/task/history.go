@@ -0,0 +1,181 @@
+package task
+
+import (
+	"encoding/json"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const DefaultHistoryMaxEntries = 100
+
+type HistoryOptions struct {
+	// Maximum number of completed tasks kept in the journal;
+	// defaults to DefaultHistoryMaxEntries when not set
+	MaxEntries int
+
+	// Completed tasks older than this many seconds are dropped from the journal;
+	// entries are kept regardless of age when not set
+	MaxAgeSeconds int
+}
+
+type HistoryEntry struct {
+	TaskID     string          `json:"agent_task_id"`
+	Method     string          `json:"method"`
+	Arguments  json.RawMessage `json:"arguments,omitempty"`
+	State      State           `json:"state"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt time.Time       `json:"finished_at"`
+	Value      json.RawMessage `json:"value,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+type History interface {
+	Record(entry HistoryEntry) error
+
+	// Entries returns completed tasks, most recently finished first
+	Entries() ([]HistoryEntry, error)
+	Find(taskID string) (HistoryEntry, bool, error)
+}
+
+type concreteHistory struct {
+	logger      boshlog.Logger
+	fs          boshsys.FileSystem
+	historyPath string
+	options     HistoryOptions
+	timeService clock.Clock
+
+	lock sync.Mutex
+}
+
+func NewHistory(
+	logger boshlog.Logger,
+	fs boshsys.FileSystem,
+	dir string,
+	options HistoryOptions,
+	timeService clock.Clock,
+) History {
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = DefaultHistoryMaxEntries
+	}
+
+	return &concreteHistory{
+		logger:      logger,
+		fs:          fs,
+		historyPath: path.Join(dir, "task_history.json"),
+		options:     options,
+		timeService: timeService,
+	}
+}
+
+func (h *concreteHistory) Record(entry HistoryEntry) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	entries, err := h.readEntries()
+	if err != nil {
+		// A corrupted journal should not prevent recording new tasks
+		h.logger.Warn("Task History", "Discarding unreadable task history: %s", err.Error())
+		entries = nil
+	}
+
+	retained := []HistoryEntry{entry}
+	for _, e := range entries {
+		if e.TaskID != entry.TaskID {
+			retained = append(retained, e)
+		}
+	}
+
+	return h.writeEntries(h.prune(retained))
+}
+
+func (h *concreteHistory) Entries() ([]HistoryEntry, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	entries, err := h.readEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	return h.prune(entries), nil
+}
+
+func (h *concreteHistory) Find(taskID string) (HistoryEntry, bool, error) {
+	entries, err := h.Entries()
+	if err != nil {
+		return HistoryEntry{}, false, err
+	}
+
+	for _, entry := range entries {
+		if entry.TaskID == taskID {
+			return entry, true, nil
+		}
+	}
+
+	return HistoryEntry{}, false, nil
+}
+
+func (h *concreteHistory) prune(entries []HistoryEntry) []HistoryEntry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].FinishedAt.After(entries[j].FinishedAt)
+	})
+
+	if h.options.MaxAgeSeconds > 0 {
+		cutoff := h.timeService.Now().Add(-time.Duration(h.options.MaxAgeSeconds) * time.Second)
+
+		for i, entry := range entries {
+			if entry.FinishedAt.Before(cutoff) {
+				entries = entries[:i]
+				break
+			}
+		}
+	}
+
+	if len(entries) > h.options.MaxEntries {
+		entries = entries[:h.options.MaxEntries]
+	}
+
+	return entries
+}
+
+func (h *concreteHistory) readEntries() ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+
+	if !h.fs.FileExists(h.historyPath) {
+		return entries, nil
+	}
+
+	historyJSON, err := h.fs.ReadFile(h.historyPath)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading task history json")
+	}
+
+	err = json.Unmarshal(historyJSON, &entries)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshaling task history json")
+	}
+
+	return entries, nil
+}
+
+func (h *concreteHistory) writeEntries(entries []HistoryEntry) error {
+	historyJSON, err := json.Marshal(entries)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling task history json")
+	}
+
+	err = h.fs.WriteFile(h.historyPath, historyJSON)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing task history json")
+	}
+
+	return nil
+}
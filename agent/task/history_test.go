@@ -0,0 +1,157 @@
+package task_test
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+var _ = Describe("concreteHistory", func() {
+	var (
+		logger      boshlog.Logger
+		fs          *fakesys.FakeFileSystem
+		timeService *fakeclock.FakeClock
+		options     boshtask.HistoryOptions
+		history     boshtask.History
+	)
+
+	entryFinishedAt := func(taskID string, finishedAt time.Time) boshtask.HistoryEntry {
+		return boshtask.HistoryEntry{
+			TaskID:     taskID,
+			Method:     "fake-method",
+			State:      boshtask.StateDone,
+			StartedAt:  finishedAt.Add(-time.Second),
+			FinishedAt: finishedAt,
+		}
+	}
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = fakesys.NewFakeFileSystem()
+		timeService = fakeclock.NewFakeClock(time.Unix(10000, 0).UTC())
+		options = boshtask.HistoryOptions{}
+	})
+
+	JustBeforeEach(func() {
+		history = boshtask.NewHistory(logger, fs, "/dir/path", options, timeService)
+	})
+
+	Describe("Record", func() {
+		It("persists entries to task_history.json so that they survive restarts", func() {
+			entry := entryFinishedAt("fake-task-id", time.Unix(9000, 0).UTC())
+			entry.Arguments = json.RawMessage(`["fake-arg"]`)
+			entry.Value = json.RawMessage(`"fake-value"`)
+
+			err := history.Record(entry)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.FileExists("/dir/path/task_history.json")).To(BeTrue())
+
+			reloadedHistory := boshtask.NewHistory(logger, fs, "/dir/path", options, timeService)
+			entries, err := reloadedHistory.Entries()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(Equal([]boshtask.HistoryEntry{entry}))
+		})
+
+		It("replaces an existing entry with the same task id", func() {
+			Expect(history.Record(entryFinishedAt("fake-task-id", time.Unix(9000, 0).UTC()))).To(Succeed())
+
+			replacement := entryFinishedAt("fake-task-id", time.Unix(9500, 0).UTC())
+			replacement.State = boshtask.StateFailed
+			Expect(history.Record(replacement)).To(Succeed())
+
+			entries, err := history.Entries()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(Equal([]boshtask.HistoryEntry{replacement}))
+		})
+
+		It("starts a new journal when the existing one cannot be read", func() {
+			err := fs.WriteFileString("/dir/path/task_history.json", "fake-invalid-json")
+			Expect(err).ToNot(HaveOccurred())
+
+			entry := entryFinishedAt("fake-task-id", time.Unix(9000, 0).UTC())
+			Expect(history.Record(entry)).To(Succeed())
+
+			entries, err := history.Entries()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(Equal([]boshtask.HistoryEntry{entry}))
+		})
+
+		It("returns an error when writing the journal fails", func() {
+			fs.WriteFileError = errors.New("fake-write-error")
+
+			err := history.Record(entryFinishedAt("fake-task-id", time.Unix(9000, 0).UTC()))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+		})
+
+		Context("when the number of entries exceeds the configured maximum", func() {
+			BeforeEach(func() {
+				options.MaxEntries = 2
+			})
+
+			It("keeps only the most recently finished tasks", func() {
+				Expect(history.Record(entryFinishedAt("fake-task-id-1", time.Unix(9001, 0).UTC()))).To(Succeed())
+				Expect(history.Record(entryFinishedAt("fake-task-id-3", time.Unix(9003, 0).UTC()))).To(Succeed())
+				Expect(history.Record(entryFinishedAt("fake-task-id-2", time.Unix(9002, 0).UTC()))).To(Succeed())
+
+				entries, err := history.Entries()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(HaveLen(2))
+				Expect(entries[0].TaskID).To(Equal("fake-task-id-3"))
+				Expect(entries[1].TaskID).To(Equal("fake-task-id-2"))
+			})
+		})
+
+		Context("when a maximum age is configured", func() {
+			BeforeEach(func() {
+				options.MaxAgeSeconds = 600
+			})
+
+			It("drops entries that finished before the cutoff", func() {
+				Expect(history.Record(entryFinishedAt("fake-old-task-id", time.Unix(9000, 0).UTC()))).To(Succeed())
+				Expect(history.Record(entryFinishedAt("fake-new-task-id", time.Unix(9500, 0).UTC()))).To(Succeed())
+
+				entries, err := history.Entries()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(HaveLen(1))
+				Expect(entries[0].TaskID).To(Equal("fake-new-task-id"))
+			})
+		})
+	})
+
+	Describe("Find", func() {
+		It("returns the entry with the given task id", func() {
+			entry := entryFinishedAt("fake-task-id", time.Unix(9000, 0).UTC())
+			Expect(history.Record(entry)).To(Succeed())
+
+			found, ok, err := history.Find("fake-task-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(found).To(Equal(entry))
+		})
+
+		It("reports when the task is not in the history", func() {
+			_, ok, err := history.Find("fake-unknown-task-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("returns an error when the journal cannot be read", func() {
+			err := fs.WriteFileString("/dir/path/task_history.json", "fake-invalid-json")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, err = history.Find("fake-task-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshaling task history json"))
+		})
+	})
+})
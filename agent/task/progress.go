@@ -0,0 +1,142 @@
+package task
+
+import (
+	"sync"
+)
+
+// progressOutputSize limits how much of the most recent output is reported
+const progressOutputSize = 4 * 1024
+
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+
+	// Most recent output of the command the task is running, if any
+	Output string `json:"output,omitempty"`
+}
+
+type ProgressFunc func() (Progress, bool)
+
+// ProgressTracker keeps the progress and cancellation of a single task.
+// Workers report completed steps and check whether the task was cancelled.
+type ProgressTracker struct {
+	lock      sync.Mutex
+	progress  Progress
+	output    []byte
+	cancelled bool
+}
+
+func NewProgressTracker() *ProgressTracker {
+	return &ProgressTracker{}
+}
+
+func (t *ProgressTracker) AddTotal(steps int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.progress.Total += steps
+}
+
+func (t *ProgressTracker) StepDone() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.progress.Done++
+}
+
+// Progress returns false until work has been added
+func (t *ProgressTracker) Progress() (Progress, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	progress := t.progress
+	progress.Output = string(t.output)
+
+	return progress, t.progress.Total > 0
+}
+
+// Write keeps the tail of command output so that it can be reported while the command runs
+func (t *ProgressTracker) Write(p []byte) (int, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.output = append(t.output, p...)
+	if len(t.output) > progressOutputSize {
+		t.output = append([]byte(nil), t.output[len(t.output)-progressOutputSize:]...)
+	}
+
+	return len(p), nil
+}
+
+func (t *ProgressTracker) Cancel() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.cancelled = true
+}
+
+func (t *ProgressTracker) Cancelled() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.cancelled
+}
+
+// RunningProgress is shared by an action and the components doing its work,
+// which serve all tasks of the action. It passes their progress on to the
+// tracker of the task that is running; tasks run one at a time.
+type RunningProgress struct {
+	lock    sync.Mutex
+	tracker *ProgressTracker
+}
+
+func NewRunningProgress() *RunningProgress {
+	return &RunningProgress{tracker: NewProgressTracker()}
+}
+
+// Track passes progress on to the tracker until the returned func is called
+func (p *RunningProgress) Track(tracker *ProgressTracker) (untrack func()) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	previous := p.tracker
+	p.tracker = tracker
+
+	return func() {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+
+		p.tracker = previous
+	}
+}
+
+func (p *RunningProgress) AddTotal(steps int) {
+	p.running().AddTotal(steps)
+}
+
+func (p *RunningProgress) StepDone() {
+	p.running().StepDone()
+}
+
+func (p *RunningProgress) Progress() (Progress, bool) {
+	return p.running().Progress()
+}
+
+func (p *RunningProgress) Write(b []byte) (int, error) {
+	return p.running().Write(b)
+}
+
+func (p *RunningProgress) Cancel() {
+	p.running().Cancel()
+}
+
+func (p *RunningProgress) Cancelled() bool {
+	return p.running().Cancelled()
+}
+
+func (p *RunningProgress) running() *ProgressTracker {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.tracker
+}
@@ -0,0 +1,97 @@
+package task_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+var _ = Describe("ProgressTracker", func() {
+	var tracker *ProgressTracker
+
+	BeforeEach(func() {
+		tracker = NewProgressTracker()
+	})
+
+	It("does not report progress until work is added", func() {
+		_, reported := tracker.Progress()
+		Expect(reported).To(BeFalse())
+	})
+
+	It("reports completed steps out of the total", func() {
+		tracker.AddTotal(3)
+		tracker.StepDone()
+
+		progress, reported := tracker.Progress()
+		Expect(reported).To(BeTrue())
+		Expect(progress).To(Equal(Progress{Done: 1, Total: 3}))
+	})
+
+	It("reports the most recent output", func() {
+		tracker.AddTotal(1)
+
+		_, err := tracker.Write([]byte(strings.Repeat("a", 4096)))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = tracker.Write([]byte("fake-output"))
+		Expect(err).ToNot(HaveOccurred())
+
+		progress, _ := tracker.Progress()
+		Expect(progress.Output).To(HaveLen(4096))
+		Expect(progress.Output).To(HaveSuffix("afake-output"))
+	})
+
+})
+
+var _ = Describe("RunningProgress", func() {
+	var (
+		running *RunningProgress
+		tracker *ProgressTracker
+	)
+
+	BeforeEach(func() {
+		running = NewRunningProgress()
+		tracker = NewProgressTracker()
+	})
+
+	It("passes progress, output and cancellation on to the tracked task", func() {
+		untrack := running.Track(tracker)
+		defer untrack()
+
+		running.AddTotal(2)
+		running.StepDone()
+		_, err := running.Write([]byte("fake-output"))
+		Expect(err).ToNot(HaveOccurred())
+		running.Cancel()
+
+		progress, reported := tracker.Progress()
+		Expect(reported).To(BeTrue())
+		Expect(progress).To(Equal(Progress{Done: 1, Total: 2, Output: "fake-output"}))
+		Expect(tracker.Cancelled()).To(BeTrue())
+		Expect(running.Cancelled()).To(BeTrue())
+	})
+
+	It("stops passing progress on once the task is untracked", func() {
+		untrack := running.Track(tracker)
+		untrack()
+
+		running.AddTotal(2)
+		running.Cancel()
+
+		_, reported := tracker.Progress()
+		Expect(reported).To(BeFalse())
+		Expect(tracker.Cancelled()).To(BeFalse())
+	})
+
+	It("reports cancellation of the tracked task only", func() {
+		tracker.Cancel()
+		Expect(running.Cancelled()).To(BeFalse())
+
+		untrack := running.Track(tracker)
+		defer untrack()
+
+		Expect(running.Cancelled()).To(BeTrue())
+	})
+})
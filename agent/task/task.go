@@ -23,6 +23,9 @@ type Task struct {
 	Func       Func
 	CancelFunc CancelFunc
 	EndFunc    EndFunc
+
+	// Optional; reports progress while the task is running
+	ProgressFunc ProgressFunc
 }
 
 func (t Task) Cancel() error {
@@ -33,6 +36,7 @@ func (t Task) Cancel() error {
 }
 
 type StateValue struct {
-	AgentTaskID string `json:"agent_task_id"`
-	State       State  `json:"state"`
+	AgentTaskID string    `json:"agent_task_id"`
+	State       State     `json:"state"`
+	Progress    *Progress `json:"progress,omitempty"`
 }
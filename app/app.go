@@ -17,6 +17,7 @@ import (
 
 	boshagent "github.com/cloudfoundry/bosh-agent/v2/agent"
 	boshaction "github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshalert "github.com/cloudfoundry/bosh-agent/v2/agent/alert"
 	boshapplier "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	boshbc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection"
@@ -28,6 +29,7 @@ import (
 	boshcomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
 	httpblobprovider "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	boshmetrics "github.com/cloudfoundry/bosh-agent/v2/agent/metrics"
 	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
 	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	boshinf "github.com/cloudfoundry/bosh-agent/v2/infrastructure"
@@ -36,6 +38,7 @@ import (
 	boshmbus "github.com/cloudfoundry/bosh-agent/v2/mbus"
 	boshnotif "github.com/cloudfoundry/bosh-agent/v2/notification"
 	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
+	boshdisk "github.com/cloudfoundry/bosh-agent/v2/platform/disk"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 	boshsigar "github.com/cloudfoundry/bosh-agent/v2/sigar"
@@ -48,12 +51,13 @@ type App interface {
 }
 
 type app struct {
-	logger      boshlog.Logger
-	agent       boshagent.Agent
-	platform    boshplatform.Platform
-	fs          boshsys.FileSystem
-	logTag      string
-	dirProvider boshdirs.Provider
+	logger        boshlog.Logger
+	agent         boshagent.Agent
+	platform      boshplatform.Platform
+	fs            boshsys.FileSystem
+	logTag        string
+	dirProvider   boshdirs.Provider
+	metricsServer *boshmetrics.Server
 }
 
 func New(logger boshlog.Logger, fs boshsys.FileSystem) App {
@@ -133,9 +137,12 @@ func (app *app) Setup(opts Options) error {
 		return bosherr.WrapError(err, "Getting blob manager")
 	}
 
+	blobCache := boshagentblobstore.NewBlobCache(app.platform.GetFs(), app.dirProvider.BlobCacheDir(), config.BlobCache, app.logger)
+
 	blobstore, err := app.setupBlobstore(
 		settingsService.GetSettings().GetBlobstore(),
 		[]boshagentblobstore.BlobManagerInterface{sensitiveBlobManager, inconsiderateBlobManager},
+		blobCache,
 	)
 	if err != nil {
 		return bosherr.WrapError(err, "Getting blobstore")
@@ -163,11 +170,29 @@ func (app *app) Setup(opts Options) error {
 		mbusHandler,
 	)
 
-	jobSupervisor, err := jobSupervisorProvider.Get(opts.JobSupervisor)
+	jobSupervisorName := opts.JobSupervisor
+	if jobSupervisorName == "monit" && config.Platform.Linux.JobSupervisor != "" {
+		jobSupervisorName = config.Platform.Linux.JobSupervisor
+	}
+
+	jobSupervisor, err := jobSupervisorProvider.Get(jobSupervisorName)
 	if err != nil {
 		return bosherr.WrapError(err, "Getting job supervisor")
 	}
 
+	if config.Metrics.Enabled() {
+		app.metricsServer = boshmetrics.NewServer(
+			config.Metrics,
+			app.platform.GetVitalsService(),
+			jobSupervisor,
+			app.logger,
+		)
+
+		if err = app.metricsServer.Validate(); err != nil {
+			return bosherr.WrapError(err, "Validating metrics config")
+		}
+	}
+
 	notifier := boshnotif.NewNotifier(mbusHandler)
 
 	blobstoreHTTPClient, err := httpblobprovider.NewBlobstoreHTTPClient(settingsService.GetSettings().GetBlobstore())
@@ -177,14 +202,19 @@ func (app *app) Setup(opts Options) error {
 
 	blobstoreDelegator := blobstore_delegator.NewBlobstoreDelegator(
 		httpblobprovider.NewHTTPBlobImpl(app.platform.GetFs(), blobstoreHTTPClient),
-		blobstore, app.logger,
+		blobstore, blobCache, app.logger,
 	)
 
+	applyProgress := boshtask.NewRunningProgress()
+	compileProgress := boshtask.NewRunningProgress()
+
 	applier, compiler := app.buildApplierAndCompiler(
 		app.dirProvider,
 		blobstoreDelegator,
 		jobSupervisor,
 		settingsService.GetSettings(),
+		applyProgress,
+		compileProgress,
 		timeService,
 	)
 
@@ -198,6 +228,14 @@ func (app *app) Setup(opts Options) error {
 		app.dirProvider.BoshDir(),
 	)
 
+	taskHistory := boshtask.NewHistory(
+		app.logger,
+		app.platform.GetFs(),
+		app.dirProvider.BoshDir(),
+		config.TaskHistory,
+		timeService,
+	)
+
 	jobScriptProvider := boshscript.NewConcreteJobScriptProvider(
 		app.platform.GetRunner(),
 		app.platform.GetFs(),
@@ -211,14 +249,18 @@ func (app *app) Setup(opts Options) error {
 		app.platform,
 		sensitiveBlobManager,
 		taskService,
+		taskHistory,
 		notifier,
 		applier,
+		applyProgress,
 		compiler,
+		compileProgress,
 		jobSupervisor,
 		specService,
 		jobScriptProvider,
 		app.logger,
 		blobstoreDelegator,
+		blobCache,
 	)
 
 	actionRunner := boshaction.NewRunner()
@@ -227,8 +269,10 @@ func (app *app) Setup(opts Options) error {
 		app.logger,
 		taskService,
 		taskManager,
+		taskHistory,
 		actionFactory,
 		actionRunner,
+		timeService,
 	)
 
 	startManager := bootonce.NewStartManager(
@@ -237,6 +281,32 @@ func (app *app) Setup(opts Options) error {
 		app.dirProvider,
 	)
 
+	heartbeatInterval := time.Second * 30
+
+	heartbeatSinks, err := boshagent.NewHeartbeatSinks(
+		config.Heartbeat,
+		heartbeatInterval,
+		app.platform.GetFs(),
+		timeService,
+	)
+	if err != nil {
+		return bosherr.WrapError(err, "Building heartbeat sinks")
+	}
+
+	alertPipeline := boshalert.NewPipeline(
+		app.logger,
+		app.platform.GetFs(),
+		app.dirProvider.BoshDir(),
+		config.Alerts,
+		timeService,
+		uuidGen,
+	)
+
+	alertReceivers, err := boshalert.NewReceivers(config.AlertReceivers, timeService)
+	if err != nil {
+		return bosherr.WrapError(err, "Building alert receivers")
+	}
+
 	app.agent = boshagent.New(
 		app.logger,
 		mbusHandler,
@@ -244,17 +314,30 @@ func (app *app) Setup(opts Options) error {
 		actionDispatcher,
 		jobSupervisor,
 		specService,
-		time.Second*30,
+		heartbeatInterval,
 		settingsService,
 		uuidGen,
 		timeService,
 		startManager,
+		heartbeatSinks,
+		alertPipeline,
+		alertReceivers,
 	)
 
 	return nil
 }
 
 func (app *app) Run() error {
+	if app.metricsServer != nil {
+		go func() {
+			defer app.logger.HandlePanic("Metrics Server")
+
+			if err := app.metricsServer.Start(); err != nil {
+				app.logger.Error(app.logTag, "Serving metrics: %s", err.Error())
+			}
+		}()
+	}
+
 	if err := app.agent.Run(); err != nil {
 		return bosherr.WrapError(err, "Running agent")
 	}
@@ -270,6 +353,8 @@ func (app *app) buildApplierAndCompiler(
 	blobstoreDelegator blobstore_delegator.BlobstoreDelegator,
 	jobSupervisor boshjobsuper.JobSupervisor,
 	settings boshsettings.Settings,
+	applyProgress *boshtask.RunningProgress,
+	compileProgress *boshtask.RunningProgress,
 	timeService clock.Clock,
 ) (boshapplier.Applier, boshcomp.Compiler) {
 	fileSystem := app.platform.GetFs()
@@ -303,6 +388,7 @@ func (app *app) buildApplierAndCompiler(
 		jobsBc,
 		jobSupervisor,
 		packageApplierProvider,
+		boshdisk.NewLinuxProjectQuota(app.platform.GetRunner(), app.logger),
 		boshaj.FixPermissions,
 		fileSystem,
 		app.logger,
@@ -315,6 +401,7 @@ func (app *app) buildApplierAndCompiler(
 		jobSupervisor,
 		dirProvider,
 		settings,
+		applyProgress,
 	)
 
 	cmdRunner := boshrunner.NewFileLoggingCmdRunner(
@@ -333,6 +420,8 @@ func (app *app) buildApplierAndCompiler(
 		packageApplierProvider.Root(),
 		packageApplierProvider.RootBundleCollection(),
 		clock.NewClock(),
+		compileProgress,
+		app.logger,
 	)
 
 	return applier, compiler
@@ -363,6 +452,7 @@ func (app *app) fileContents(path string) string {
 func (app *app) setupBlobstore(
 	blobstoreSettings boshsettings.Blobstore,
 	blobManagers []boshagentblobstore.BlobManagerInterface,
+	blobCache boshagentblobstore.BlobCache,
 ) (boshblob.DigestBlobstore, error) {
 	blobstoreProvider := boshblob.NewProvider(
 		app.platform.GetFs(),
@@ -378,7 +468,7 @@ func (app *app) setupBlobstore(
 		return nil, bosherr.WrapError(err, "Getting blobstore")
 	}
 
-	return boshagentblobstore.NewCascadingBlobstore(blobstore, blobManagers, app.logger), nil
+	return boshagentblobstore.NewCascadingBlobstore(blobstore, blobManagers, blobCache, app.logger), nil
 }
 
 func (app *app) patchBlobstoreOptions(blobstoreSettings boshsettings.Blobstore) boshsettings.Blobstore {
@@ -6,6 +6,11 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
+	boshagent "github.com/cloudfoundry/bosh-agent/v2/agent"
+	boshalert "github.com/cloudfoundry/bosh-agent/v2/agent/alert"
+	boshagentblobstore "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore"
+	boshmetrics "github.com/cloudfoundry/bosh-agent/v2/agent/metrics"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	boshinf "github.com/cloudfoundry/bosh-agent/v2/infrastructure"
 	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
 )
@@ -13,6 +18,12 @@ import (
 type Config struct {
 	Platform       boshplatform.Options
 	Infrastructure boshinf.Options
+	Metrics        boshmetrics.Options
+	TaskHistory    boshtask.HistoryOptions
+	Heartbeat      boshagent.HeartbeatOptions
+	BlobCache      boshagentblobstore.BlobCacheOptions
+	Alerts         boshalert.PipelineOptions
+	AlertReceivers boshalert.ReceiversOptions
 }
 
 func LoadConfigFromPath(fs boshsys.FileSystem, path string) (Config, error) {
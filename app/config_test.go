@@ -6,6 +6,7 @@ import (
 
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 
+	boshmetrics "github.com/cloudfoundry/bosh-agent/v2/agent/metrics"
 	boshinf "github.com/cloudfoundry/bosh-agent/v2/infrastructure"
 	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
 )
@@ -28,7 +29,8 @@ var _ = Describe("LoadConfigFromPath", func() {
 					"BindMountPersistentDisk": true,
 					"SkipDiskSetup": true,
 					"DevicePathResolutionType": "virtio",
-					"UseMonitIptablesFirewall": true
+					"UseMonitIptablesFirewall": true,
+					"JobSupervisor": "systemd"
 				}
 			},
 			"Infrastructure": {
@@ -63,6 +65,9 @@ var _ = Describe("LoadConfigFromPath", func() {
 					  }
 				  ]
 				}
+			},
+			"Metrics": {
+				"Address": "127.0.0.1:9323"
 			}
 		}`)
 		Expect(err).NotTo(HaveOccurred())
@@ -78,6 +83,7 @@ var _ = Describe("LoadConfigFromPath", func() {
 					SkipDiskSetup:                 true,
 					DevicePathResolutionType:      "virtio",
 					UseMonitIptablesFirewall:      true,
+					JobSupervisor:                 "systemd",
 				},
 			},
 			Infrastructure: boshinf.Options{
@@ -108,6 +114,9 @@ var _ = Describe("LoadConfigFromPath", func() {
 					},
 				},
 			},
+			Metrics: boshmetrics.Options{
+				Address: "127.0.0.1:9323",
+			},
 		}))
 	})
 
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
@@ -24,7 +26,8 @@ func (r valueResponse) Shorten() Response {
 
 type exceptionResponse struct {
 	Exception struct {
-		Message string `json:"message,omitempty"`
+		Message string                 `json:"message,omitempty"`
+		Details map[string]interface{} `json:"details,omitempty"`
 	} `json:"exception"`
 
 	err error
@@ -33,6 +36,7 @@ type exceptionResponse struct {
 func NewExceptionResponse(err error) (resp Response) {
 	r := exceptionResponse{}
 	r.Exception.Message = err.Error()
+	r.Exception.Details = errorDetails(err)
 	r.err = err
 	return r
 }
@@ -41,9 +45,56 @@ func (r exceptionResponse) Shorten() Response {
 	if typedErr, ok := r.err.(bosherr.ShortenableError); ok {
 		sr := exceptionResponse{}
 		sr.Exception.Message = typedErr.ShortError()
+		sr.Exception.Details = r.Exception.Details
 		sr.err = typedErr
 		return sr
 	}
 
 	return r
 }
+
+// DetailedError carries structured fields that are reported next to the
+// exception message so that they do not have to be parsed out of it
+type DetailedError struct {
+	Err     error
+	Details map[string]interface{}
+}
+
+func NewDetailedError(err error, details map[string]interface{}) error {
+	return DetailedError{Err: err, Details: details}
+}
+
+func (e DetailedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e DetailedError) ShortError() string {
+	if shortenableErr, ok := e.Err.(bosherr.ShortenableError); ok {
+		return shortenableErr.ShortError()
+	}
+	return e.Err.Error()
+}
+
+func (e DetailedError) Unwrap() error {
+	return e.Err
+}
+
+// errorDetails finds the details of a DetailedError that may have been
+// wrapped with bosherr, which does not support errors.Unwrap
+func errorDetails(err error) map[string]interface{} {
+	for err != nil {
+		switch typedErr := err.(type) {
+		case DetailedError:
+			return typedErr.Details
+		case bosherr.ComplexError:
+			if details := errorDetails(typedErr.Err); details != nil {
+				return details
+			}
+			err = typedErr.Cause
+		default:
+			err = errors.Unwrap(err)
+		}
+	}
+
+	return nil
+}
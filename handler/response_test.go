@@ -6,6 +6,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	. "github.com/cloudfoundry/bosh-agent/v2/handler"
 )
@@ -92,4 +93,31 @@ var _ = Describe("NewExceptionResponse", func() {
 			)
 		})
 	})
+
+	Context("with error that has details", func() {
+		var err error
+
+		BeforeEach(func() {
+			err = NewDetailedError(errors.New("fake-msg"), map[string]interface{}{"rolled_back": true})
+			err = bosherr.WrapError(bosherr.WrapError(err, "fake-wrapper"), "fake-outer-wrapper")
+		})
+
+		It("reports the details of wrapped errors next to the message", func() {
+			resp := NewExceptionResponse(err)
+			boshassert.MatchesJSONString(
+				GinkgoT(),
+				resp,
+				`{"exception":{"message":"fake-outer-wrapper: fake-wrapper: fake-msg","details":{"rolled_back":true}}}`,
+			)
+		})
+
+		It("keeps the details when shortening", func() {
+			resp := NewExceptionResponse(err)
+			boshassert.MatchesJSONString(
+				GinkgoT(),
+				resp.Shorten(),
+				`{"exception":{"message":"fake-outer-wrapper: fake-wrapper: fake-msg","details":{"rolled_back":true}}}`,
+			)
+		})
+	})
 })
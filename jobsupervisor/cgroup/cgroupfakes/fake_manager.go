@@ -0,0 +1,336 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package cgroupfakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	"github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/cgroup"
+)
+
+type FakeManager struct {
+	ConfigureJobStub        func(string, models.ResourceLimits) error
+	configureJobMutex       sync.RWMutex
+	configureJobArgsForCall []struct {
+		arg1 string
+		arg2 models.ResourceLimits
+	}
+	configureJobReturns struct {
+		result1 error
+	}
+	configureJobReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ProcessUsageStub        func(string) (cgroup.Usage, bool, error)
+	processUsageMutex       sync.RWMutex
+	processUsageArgsForCall []struct {
+		arg1 string
+	}
+	processUsageReturns struct {
+		result1 cgroup.Usage
+		result2 bool
+		result3 error
+	}
+	processUsageReturnsOnCall map[int]struct {
+		result1 cgroup.Usage
+		result2 bool
+		result3 error
+	}
+	RemoveAllJobsStub        func() error
+	removeAllJobsMutex       sync.RWMutex
+	removeAllJobsArgsForCall []struct {
+	}
+	removeAllJobsReturns struct {
+		result1 error
+	}
+	removeAllJobsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	WrapMonitConfigStub        func(string, string) (string, error)
+	wrapMonitConfigMutex       sync.RWMutex
+	wrapMonitConfigArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	wrapMonitConfigReturns struct {
+		result1 string
+		result2 error
+	}
+	wrapMonitConfigReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeManager) ConfigureJob(arg1 string, arg2 models.ResourceLimits) error {
+	fake.configureJobMutex.Lock()
+	ret, specificReturn := fake.configureJobReturnsOnCall[len(fake.configureJobArgsForCall)]
+	fake.configureJobArgsForCall = append(fake.configureJobArgsForCall, struct {
+		arg1 string
+		arg2 models.ResourceLimits
+	}{arg1, arg2})
+	stub := fake.ConfigureJobStub
+	fakeReturns := fake.configureJobReturns
+	fake.recordInvocation("ConfigureJob", []interface{}{arg1, arg2})
+	fake.configureJobMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) ConfigureJobCallCount() int {
+	fake.configureJobMutex.RLock()
+	defer fake.configureJobMutex.RUnlock()
+	return len(fake.configureJobArgsForCall)
+}
+
+func (fake *FakeManager) ConfigureJobCalls(stub func(string, models.ResourceLimits) error) {
+	fake.configureJobMutex.Lock()
+	defer fake.configureJobMutex.Unlock()
+	fake.ConfigureJobStub = stub
+}
+
+func (fake *FakeManager) ConfigureJobArgsForCall(i int) (string, models.ResourceLimits) {
+	fake.configureJobMutex.RLock()
+	defer fake.configureJobMutex.RUnlock()
+	argsForCall := fake.configureJobArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeManager) ConfigureJobReturns(result1 error) {
+	fake.configureJobMutex.Lock()
+	defer fake.configureJobMutex.Unlock()
+	fake.ConfigureJobStub = nil
+	fake.configureJobReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) ConfigureJobReturnsOnCall(i int, result1 error) {
+	fake.configureJobMutex.Lock()
+	defer fake.configureJobMutex.Unlock()
+	fake.ConfigureJobStub = nil
+	if fake.configureJobReturnsOnCall == nil {
+		fake.configureJobReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.configureJobReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) ProcessUsage(arg1 string) (cgroup.Usage, bool, error) {
+	fake.processUsageMutex.Lock()
+	ret, specificReturn := fake.processUsageReturnsOnCall[len(fake.processUsageArgsForCall)]
+	fake.processUsageArgsForCall = append(fake.processUsageArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ProcessUsageStub
+	fakeReturns := fake.processUsageReturns
+	fake.recordInvocation("ProcessUsage", []interface{}{arg1})
+	fake.processUsageMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeManager) ProcessUsageCallCount() int {
+	fake.processUsageMutex.RLock()
+	defer fake.processUsageMutex.RUnlock()
+	return len(fake.processUsageArgsForCall)
+}
+
+func (fake *FakeManager) ProcessUsageCalls(stub func(string) (cgroup.Usage, bool, error)) {
+	fake.processUsageMutex.Lock()
+	defer fake.processUsageMutex.Unlock()
+	fake.ProcessUsageStub = stub
+}
+
+func (fake *FakeManager) ProcessUsageArgsForCall(i int) string {
+	fake.processUsageMutex.RLock()
+	defer fake.processUsageMutex.RUnlock()
+	argsForCall := fake.processUsageArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeManager) ProcessUsageReturns(result1 cgroup.Usage, result2 bool, result3 error) {
+	fake.processUsageMutex.Lock()
+	defer fake.processUsageMutex.Unlock()
+	fake.ProcessUsageStub = nil
+	fake.processUsageReturns = struct {
+		result1 cgroup.Usage
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeManager) ProcessUsageReturnsOnCall(i int, result1 cgroup.Usage, result2 bool, result3 error) {
+	fake.processUsageMutex.Lock()
+	defer fake.processUsageMutex.Unlock()
+	fake.ProcessUsageStub = nil
+	if fake.processUsageReturnsOnCall == nil {
+		fake.processUsageReturnsOnCall = make(map[int]struct {
+			result1 cgroup.Usage
+			result2 bool
+			result3 error
+		})
+	}
+	fake.processUsageReturnsOnCall[i] = struct {
+		result1 cgroup.Usage
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeManager) RemoveAllJobs() error {
+	fake.removeAllJobsMutex.Lock()
+	ret, specificReturn := fake.removeAllJobsReturnsOnCall[len(fake.removeAllJobsArgsForCall)]
+	fake.removeAllJobsArgsForCall = append(fake.removeAllJobsArgsForCall, struct {
+	}{})
+	stub := fake.RemoveAllJobsStub
+	fakeReturns := fake.removeAllJobsReturns
+	fake.recordInvocation("RemoveAllJobs", []interface{}{})
+	fake.removeAllJobsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) RemoveAllJobsCallCount() int {
+	fake.removeAllJobsMutex.RLock()
+	defer fake.removeAllJobsMutex.RUnlock()
+	return len(fake.removeAllJobsArgsForCall)
+}
+
+func (fake *FakeManager) RemoveAllJobsCalls(stub func() error) {
+	fake.removeAllJobsMutex.Lock()
+	defer fake.removeAllJobsMutex.Unlock()
+	fake.RemoveAllJobsStub = stub
+}
+
+func (fake *FakeManager) RemoveAllJobsReturns(result1 error) {
+	fake.removeAllJobsMutex.Lock()
+	defer fake.removeAllJobsMutex.Unlock()
+	fake.RemoveAllJobsStub = nil
+	fake.removeAllJobsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) RemoveAllJobsReturnsOnCall(i int, result1 error) {
+	fake.removeAllJobsMutex.Lock()
+	defer fake.removeAllJobsMutex.Unlock()
+	fake.RemoveAllJobsStub = nil
+	if fake.removeAllJobsReturnsOnCall == nil {
+		fake.removeAllJobsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeAllJobsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) WrapMonitConfig(arg1 string, arg2 string) (string, error) {
+	fake.wrapMonitConfigMutex.Lock()
+	ret, specificReturn := fake.wrapMonitConfigReturnsOnCall[len(fake.wrapMonitConfigArgsForCall)]
+	fake.wrapMonitConfigArgsForCall = append(fake.wrapMonitConfigArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.WrapMonitConfigStub
+	fakeReturns := fake.wrapMonitConfigReturns
+	fake.recordInvocation("WrapMonitConfig", []interface{}{arg1, arg2})
+	fake.wrapMonitConfigMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeManager) WrapMonitConfigCallCount() int {
+	fake.wrapMonitConfigMutex.RLock()
+	defer fake.wrapMonitConfigMutex.RUnlock()
+	return len(fake.wrapMonitConfigArgsForCall)
+}
+
+func (fake *FakeManager) WrapMonitConfigCalls(stub func(string, string) (string, error)) {
+	fake.wrapMonitConfigMutex.Lock()
+	defer fake.wrapMonitConfigMutex.Unlock()
+	fake.WrapMonitConfigStub = stub
+}
+
+func (fake *FakeManager) WrapMonitConfigArgsForCall(i int) (string, string) {
+	fake.wrapMonitConfigMutex.RLock()
+	defer fake.wrapMonitConfigMutex.RUnlock()
+	argsForCall := fake.wrapMonitConfigArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeManager) WrapMonitConfigReturns(result1 string, result2 error) {
+	fake.wrapMonitConfigMutex.Lock()
+	defer fake.wrapMonitConfigMutex.Unlock()
+	fake.WrapMonitConfigStub = nil
+	fake.wrapMonitConfigReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) WrapMonitConfigReturnsOnCall(i int, result1 string, result2 error) {
+	fake.wrapMonitConfigMutex.Lock()
+	defer fake.wrapMonitConfigMutex.Unlock()
+	fake.WrapMonitConfigStub = nil
+	if fake.wrapMonitConfigReturnsOnCall == nil {
+		fake.wrapMonitConfigReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.wrapMonitConfigReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeManager) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ cgroup.Manager = new(FakeManager)
@@ -0,0 +1,462 @@
+package cgroup
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
+const (
+	DefaultRoot = "/sys/fs/cgroup"
+
+	jobsCgroupName  = "bosh-jobs"
+	execWrapperName = "bosh-job-cgroup-exec"
+
+	cgroupManagerLogTag = "cgroupManager"
+
+	// removedLine marks monit config lines that are dropped when wrapping
+	removedLine = "\x00"
+)
+
+// execWrapper moves itself into the cgroup given as its first argument
+// before running the job's start program; children inherit the cgroup.
+// Only root can move processes between cgroups, so the wrapper runs as root
+// and drops privileges to the --uid and --gid of the start program itself.
+const execWrapper = `#!/bin/sh
+set -e
+uid=""
+gid=""
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --uid) uid="$2"; shift 2 ;;
+    --gid) gid="$2"; shift 2 ;;
+    *) break ;;
+  esac
+done
+cgroup="$1"
+shift
+echo $$ > "$cgroup/cgroup.procs"
+if [ -n "$gid" ]; then
+  gid=$(getent group "$gid" | cut -d: -f3)
+fi
+if [ -n "$uid" ]; then
+  exec setpriv --reuid="$(id -u "$uid")" --regid="${gid:-$(id -g "$uid")}" --init-groups "$@"
+fi
+if [ -n "$gid" ]; then
+  exec setpriv --regid="$gid" --keep-groups "$@"
+fi
+exec "$@"
+`
+
+var (
+	controllers = []string{"cpu", "io", "memory", "pids"}
+
+	memoryLimitPattern = regexp.MustCompile(`^(\d+)([KMG]?)$`)
+	checkProcessLine   = regexp.MustCompile(`(?i)^\s*check\s+process\s+(\S+)`)
+	startProgramQuote  = regexp.MustCompile(`(?i)\bstart\s+(?:program\s*)?(?:=\s*)?"`)
+	asUserClause       = regexp.MustCompile(`(?i)\s*\bas\s+(uid|gid)\s+(\S+)(?:\s+and\s+(uid|gid)\s+(\S+))?`)
+	continuationLine   = regexp.MustCompile(`(?i)^\s*(as|and|with)\s`)
+)
+
+type Usage struct {
+	MemoryBytes  uint64
+	CPUUsageUsec uint64
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Manager
+
+type Manager interface {
+	// ConfigureJob creates the job's cgroup and applies its limits
+	ConfigureJob(jobName string, limits models.ResourceLimits) error
+
+	// WrapMonitConfig creates a cgroup for every process checked in the monit config
+	// and rewrites start programs so that the processes start inside them
+	WrapMonitConfig(jobName, monitConfig string) (string, error)
+
+	// ProcessUsage reports cgroup accounting for a monit process;
+	// found is false when the process was not started in a job cgroup
+	ProcessUsage(processName string) (usage Usage, found bool, err error)
+
+	// RemoveAllJobs removes the cgroups of all jobs. Cgroups that still
+	// contain processes are kept and removed by a later call.
+	RemoveAllJobs() error
+}
+
+type manager struct {
+	fs      boshsys.FileSystem
+	root    string
+	binDir  string
+	logger  boshlog.Logger
+	jobsDir string
+}
+
+func NewManager(fs boshsys.FileSystem, root, binDir string, logger boshlog.Logger) Manager {
+	return manager{
+		fs:      fs,
+		root:    root,
+		binDir:  binDir,
+		logger:  logger,
+		jobsDir: path.Join(root, jobsCgroupName),
+	}
+}
+
+func (m manager) ConfigureJob(jobName string, limits models.ResourceLimits) error {
+	files, err := limitFiles(limits)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Validating resource limits for job '%s'", jobName)
+	}
+
+	if !m.fs.FileExists(path.Join(m.root, "cgroup.controllers")) {
+		return bosherr.Errorf("Checking for cgroup v2 hierarchy at '%s': cgroup.controllers does not exist", m.root)
+	}
+
+	// The root cgroup is managed by systemd; only the controllers
+	// it enabled for its children are available to the jobs cgroup
+	subtreeControl, err := m.fs.ReadFileString(path.Join(m.root, "cgroup.subtree_control"))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading controllers enabled at '%s'", m.root)
+	}
+	available := strings.Fields(subtreeControl)
+
+	jobDir := path.Join(m.jobsDir, jobName)
+
+	// Controllers have to be enabled on every level down to the job's process cgroups
+	for _, dir := range []string{m.jobsDir, jobDir} {
+		err = m.fs.MkdirAll(dir, 0755)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Creating cgroup '%s'", dir)
+		}
+
+		err = m.enableControllers(dir, available)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range sortedKeys(files) {
+		controller, _, _ := strings.Cut(name, ".")
+		if !slices.Contains(available, controller) {
+			m.logger.Warn(cgroupManagerLogTag, "Skipping '%s' for job '%s': %s controller is not available", name, jobName, controller)
+			continue
+		}
+
+		err = m.fs.WriteFileString(path.Join(jobDir, name), files[name])
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Writing '%s' for job '%s'", name, jobName)
+		}
+	}
+
+	wrapperPath := path.Join(m.binDir, execWrapperName)
+
+	err = m.fs.WriteFileString(wrapperPath, execWrapper)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing cgroup exec wrapper")
+	}
+
+	err = m.fs.Chmod(wrapperPath, 0755)
+	if err != nil {
+		return bosherr.WrapError(err, "Making cgroup exec wrapper executable")
+	}
+
+	return nil
+}
+
+func (m manager) WrapMonitConfig(jobName, monitConfig string) (string, error) {
+	jobDir := path.Join(m.jobsDir, jobName)
+	wrapperPath := path.Join(m.binDir, execWrapperName)
+
+	lines := strings.Split(monitConfig, "\n")
+
+	var processCgroup string
+	for i, line := range lines {
+		if match := checkProcessLine.FindStringSubmatch(line); match != nil {
+			processCgroup = path.Join(jobDir, match[1])
+
+			err := m.fs.MkdirAll(processCgroup, 0755)
+			if err != nil {
+				return "", bosherr.WrapErrorf(err, "Creating cgroup for process '%s'", match[1])
+			}
+			continue
+		}
+
+		if processCgroup == "" {
+			continue
+		}
+
+		loc := startProgramQuote.FindStringIndex(line)
+		if loc == nil {
+			continue
+		}
+
+		userArgs := takeAsUserClause(lines, i, loc[1])
+
+		lines[i] = fmt.Sprintf("%s%s%s %s %s", lines[i][:loc[1]], wrapperPath, userArgs, processCgroup, lines[i][loc[1]:])
+	}
+
+	// Lines that only held the clause are left out
+	wrapped := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line != removedLine {
+			wrapped = append(wrapped, line)
+		}
+	}
+
+	return strings.Join(wrapped, "\n"), nil
+}
+
+// takeAsUserClause removes the "as uid ... and gid ..." clause of the start program
+// starting at lines[i][programStart:] so that monit runs the exec wrapper as root,
+// and returns the wrapper options that drop privileges instead
+func takeAsUserClause(lines []string, i, programStart int) string {
+	closingQuote := strings.Index(lines[i][programStart:], `"`)
+	if closingQuote < 0 {
+		return ""
+	}
+
+	// The clause either follows the program on the same line or continues the statement
+	candidates := []int{i}
+	for j := i + 1; j < len(lines) && continuationLine.MatchString(lines[j]); j++ {
+		candidates = append(candidates, j)
+	}
+
+	for _, j := range candidates {
+		offset := 0
+		if j == i {
+			offset = programStart + closingQuote + 1
+		}
+
+		loc := asUserClause.FindStringSubmatchIndex(lines[j][offset:])
+		if loc == nil {
+			continue
+		}
+
+		clause := lines[j][offset+loc[0] : offset+loc[1]]
+		match := asUserClause.FindStringSubmatch(clause)
+
+		lines[j] = lines[j][:offset+loc[0]] + lines[j][offset+loc[1]:]
+		if j != i && strings.TrimSpace(lines[j]) == "" {
+			lines[j] = removedLine
+		}
+
+		var args string
+		for k := 1; k+1 < len(match); k += 2 {
+			if match[k] != "" {
+				args += fmt.Sprintf(" --%s %s", strings.ToLower(match[k]), match[k+1])
+			}
+		}
+
+		return args
+	}
+
+	return ""
+}
+
+func (m manager) ProcessUsage(processName string) (Usage, bool, error) {
+	var usage Usage
+
+	matches, err := m.fs.Glob(path.Join(m.jobsDir, "*", processName, "memory.current"))
+	if err != nil {
+		return usage, false, bosherr.WrapErrorf(err, "Finding cgroup for process '%s'", processName)
+	}
+
+	if len(matches) == 0 {
+		return usage, false, nil
+	}
+
+	processCgroup := path.Dir(matches[0])
+
+	memory, err := m.fs.ReadFileString(path.Join(processCgroup, "memory.current"))
+	if err != nil {
+		return usage, false, bosherr.WrapErrorf(err, "Reading memory usage of process '%s'", processName)
+	}
+
+	usage.MemoryBytes, err = strconv.ParseUint(strings.TrimSpace(memory), 10, 64)
+	if err != nil {
+		return usage, false, bosherr.WrapErrorf(err, "Parsing memory usage of process '%s'", processName)
+	}
+
+	cpuStat, err := m.fs.ReadFileString(path.Join(processCgroup, "cpu.stat"))
+	if err != nil {
+		return usage, false, bosherr.WrapErrorf(err, "Reading cpu usage of process '%s'", processName)
+	}
+
+	for _, line := range strings.Split(cpuStat, "\n") {
+		key, value, found := strings.Cut(line, " ")
+		if found && key == "usage_usec" {
+			usage.CPUUsageUsec, err = strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				return usage, false, bosherr.WrapErrorf(err, "Parsing cpu usage of process '%s'", processName)
+			}
+		}
+	}
+
+	return usage, true, nil
+}
+
+func (m manager) RemoveAllJobs() error {
+	jobDirs, err := m.fs.Glob(path.Join(m.jobsDir, "*", "cgroup.procs"))
+	if err != nil {
+		return bosherr.WrapError(err, "Finding job cgroups")
+	}
+
+	processDirs, err := m.fs.Glob(path.Join(m.jobsDir, "*", "*", "cgroup.procs"))
+	if err != nil {
+		return bosherr.WrapError(err, "Finding process cgroups")
+	}
+
+	// Cgroups can only be removed once they have no children
+	for _, procsFile := range append(processDirs, jobDirs...) {
+		dir := path.Dir(procsFile)
+
+		err = m.fs.RemoveAll(dir)
+		if err != nil {
+			m.logger.Warn(cgroupManagerLogTag, "Keeping cgroup '%s' that could not be removed: %s", dir, err.Error())
+			continue
+		}
+
+		m.logger.Debug(cgroupManagerLogTag, "Removed cgroup '%s'", dir)
+	}
+
+	return nil
+}
+
+func (m manager) enableControllers(dir string, available []string) error {
+	var enable []string
+	for _, controller := range controllers {
+		if slices.Contains(available, controller) {
+			enable = append(enable, "+"+controller)
+		}
+	}
+
+	if len(enable) == 0 {
+		return nil
+	}
+
+	err := m.fs.WriteFileString(path.Join(dir, "cgroup.subtree_control"), strings.Join(enable, " "))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Enabling controllers for cgroup '%s'", dir)
+	}
+
+	m.logger.Debug(cgroupManagerLogTag, "Enabled controllers %v for cgroup '%s'", enable, dir)
+
+	return nil
+}
+
+// limitFiles maps limits to cgroup interface files. Unset limits are written
+// as their kernel defaults so that removing a limit from the manifest resets it.
+func limitFiles(limits models.ResourceLimits) (map[string]string, error) {
+	cpuWeight, err := weight(limits.CPUWeight, "cpu weight")
+	if err != nil {
+		return nil, err
+	}
+
+	ioWeight, err := weight(limits.IOWeight, "io weight")
+	if err != nil {
+		return nil, err
+	}
+
+	memoryMax, err := memoryLimit(limits.MemoryMax)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing memory max")
+	}
+
+	memoryHigh, err := memoryLimit(limits.MemoryHigh)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing memory high")
+	}
+
+	pidsMax := "max"
+	if limits.PidsMax > 0 {
+		pidsMax = strconv.FormatUint(limits.PidsMax, 10)
+	}
+
+	return map[string]string{
+		"cpu.weight":  cpuWeight,
+		"io.weight":   "default " + ioWeight,
+		"memory.max":  memoryMax,
+		"memory.high": memoryHigh,
+		"pids.max":    pidsMax,
+	}, nil
+}
+
+// SliceProperties maps limits to the resource control properties of a
+// systemd slice, for processes that systemd places in cgroups itself
+func SliceProperties(limits models.ResourceLimits) ([]string, error) {
+	files, err := limitFiles(limits)
+	if err != nil {
+		return nil, err
+	}
+
+	systemdValue := func(value string) string {
+		if value == "max" {
+			return "infinity"
+		}
+		return value
+	}
+
+	return []string{
+		"CPUWeight=" + files["cpu.weight"],
+		"IOWeight=" + strings.TrimPrefix(files["io.weight"], "default "),
+		"MemoryMax=" + systemdValue(files["memory.max"]),
+		"MemoryHigh=" + systemdValue(files["memory.high"]),
+		"TasksMax=" + systemdValue(files["pids.max"]),
+	}, nil
+}
+
+func weight(value uint64, name string) (string, error) {
+	if value == 0 {
+		return "100", nil
+	}
+
+	if value > 10000 {
+		return "", bosherr.Errorf("Expected %s to be between 1 and 10000, got %d", name, value)
+	}
+
+	return strconv.FormatUint(value, 10), nil
+}
+
+func memoryLimit(value string) (string, error) {
+	if value == "" || value == "max" {
+		return "max", nil
+	}
+
+	match := memoryLimitPattern.FindStringSubmatch(value)
+	if match == nil {
+		return "", bosherr.Errorf("Expected bytes optionally suffixed with K, M or G, got '%s'", value)
+	}
+
+	bytes, err := strconv.ParseUint(match[1], 10, 64)
+	if err != nil {
+		return "", err
+	}
+
+	switch match[2] {
+	case "K":
+		bytes <<= 10
+	case "M":
+		bytes <<= 20
+	case "G":
+		bytes <<= 30
+	}
+
+	return strconv.FormatUint(bytes, 10), nil
+}
+
+func sortedKeys(files map[string]string) []string {
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
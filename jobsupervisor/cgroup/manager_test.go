@@ -0,0 +1,244 @@
+package cgroup_test
+
+import (
+	"errors"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	. "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/cgroup"
+)
+
+var _ = Describe("Manager", func() {
+	var (
+		fs      *fakesys.FakeFileSystem
+		manager Manager
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		manager = NewManager(fs, "/sys/fs/cgroup", "/var/vcap/bosh/bin", boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("ConfigureJob", func() {
+		BeforeEach(func() {
+			Expect(fs.WriteFileString("/sys/fs/cgroup/cgroup.controllers", "cpuset cpu io memory hugetlb pids rdma misc\n")).To(Succeed())
+			Expect(fs.WriteFileString("/sys/fs/cgroup/cgroup.subtree_control", "cpu io memory pids\n")).To(Succeed())
+		})
+
+		It("creates the job cgroup with controllers enabled and writes its limits", func() {
+			err := manager.ConfigureJob("nginx", models.ResourceLimits{
+				CPUWeight:  200,
+				MemoryMax:  "1G",
+				MemoryHigh: "768M",
+				PidsMax:    512,
+				IOWeight:   50,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, dir := range []string{"/sys/fs/cgroup/bosh-jobs", "/sys/fs/cgroup/bosh-jobs/nginx"} {
+				Expect(fs.ReadFileString(dir + "/cgroup.subtree_control")).To(Equal("+cpu +io +memory +pids"))
+			}
+			Expect(fs.ReadFileString("/sys/fs/cgroup/cgroup.subtree_control")).To(Equal("cpu io memory pids\n"))
+
+			Expect(fs.ReadFileString("/sys/fs/cgroup/bosh-jobs/nginx/cpu.weight")).To(Equal("200"))
+			Expect(fs.ReadFileString("/sys/fs/cgroup/bosh-jobs/nginx/memory.max")).To(Equal("1073741824"))
+			Expect(fs.ReadFileString("/sys/fs/cgroup/bosh-jobs/nginx/memory.high")).To(Equal("805306368"))
+			Expect(fs.ReadFileString("/sys/fs/cgroup/bosh-jobs/nginx/pids.max")).To(Equal("512"))
+			Expect(fs.ReadFileString("/sys/fs/cgroup/bosh-jobs/nginx/io.weight")).To(Equal("default 50"))
+
+			wrapper, err := fs.ReadFileString("/var/vcap/bosh/bin/bosh-job-cgroup-exec")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(wrapper).To(ContainSubstring(`echo $$ > "$cgroup/cgroup.procs"`))
+			Expect(wrapper).To(ContainSubstring(`exec setpriv --reuid="$(id -u "$uid")"`))
+			Expect(fs.GetFileTestStat("/var/vcap/bosh/bin/bosh-job-cgroup-exec").FileMode).To(Equal(os.FileMode(0755)))
+		})
+
+		It("resets limits that are not set to their defaults", func() {
+			err := manager.ConfigureJob("nginx", models.ResourceLimits{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/sys/fs/cgroup/bosh-jobs/nginx/cpu.weight")).To(Equal("100"))
+			Expect(fs.ReadFileString("/sys/fs/cgroup/bosh-jobs/nginx/memory.max")).To(Equal("max"))
+			Expect(fs.ReadFileString("/sys/fs/cgroup/bosh-jobs/nginx/memory.high")).To(Equal("max"))
+			Expect(fs.ReadFileString("/sys/fs/cgroup/bosh-jobs/nginx/pids.max")).To(Equal("max"))
+			Expect(fs.ReadFileString("/sys/fs/cgroup/bosh-jobs/nginx/io.weight")).To(Equal("default 100"))
+		})
+
+		It("skips limits for controllers that systemd did not enable for the jobs cgroup", func() {
+			Expect(fs.WriteFileString("/sys/fs/cgroup/cgroup.subtree_control", "cpu memory pids\n")).To(Succeed())
+
+			err := manager.ConfigureJob("nginx", models.ResourceLimits{IOWeight: 50})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/sys/fs/cgroup/bosh-jobs/nginx/cgroup.subtree_control")).To(Equal("+cpu +memory +pids"))
+			Expect(fs.FileExists("/sys/fs/cgroup/bosh-jobs/nginx/io.weight")).To(BeFalse())
+		})
+
+		It("returns an error for invalid limits", func() {
+			err := manager.ConfigureJob("nginx", models.ResourceLimits{MemoryMax: "lots"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing memory max"))
+
+			err = manager.ConfigureJob("nginx", models.ResourceLimits{CPUWeight: 10001})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cpu weight"))
+		})
+
+		It("returns an error when the cgroup v2 hierarchy is not mounted", func() {
+			Expect(fs.RemoveAll("/sys/fs/cgroup/cgroup.controllers")).To(Succeed())
+
+			err := manager.ConfigureJob("nginx", models.ResourceLimits{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Checking for cgroup v2 hierarchy"))
+		})
+	})
+
+	Describe("WrapMonitConfig", func() {
+		It("starts every process through the exec wrapper in its own cgroup", func() {
+			wrapped, err := manager.WrapMonitConfig("nginx", `check process nginx
+  with pidfile /var/vcap/sys/run/nginx/nginx.pid
+  start program "/var/vcap/jobs/nginx/bin/ctl start"
+  stop program "/var/vcap/jobs/nginx/bin/ctl stop"
+  group vcap
+
+check process nginx_worker
+  start program = "/var/vcap/jobs/nginx/bin/worker_ctl start" with timeout 60 seconds
+  stop program "/var/vcap/jobs/nginx/bin/worker_ctl stop"
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(wrapped).To(Equal(`check process nginx
+  with pidfile /var/vcap/sys/run/nginx/nginx.pid
+  start program "/var/vcap/bosh/bin/bosh-job-cgroup-exec /sys/fs/cgroup/bosh-jobs/nginx/nginx /var/vcap/jobs/nginx/bin/ctl start"
+  stop program "/var/vcap/jobs/nginx/bin/ctl stop"
+  group vcap
+
+check process nginx_worker
+  start program = "/var/vcap/bosh/bin/bosh-job-cgroup-exec /sys/fs/cgroup/bosh-jobs/nginx/nginx_worker /var/vcap/jobs/nginx/bin/worker_ctl start" with timeout 60 seconds
+  stop program "/var/vcap/jobs/nginx/bin/worker_ctl stop"
+`))
+
+			Expect(fs.FileExists("/sys/fs/cgroup/bosh-jobs/nginx/nginx")).To(BeTrue())
+			Expect(fs.FileExists("/sys/fs/cgroup/bosh-jobs/nginx/nginx_worker")).To(BeTrue())
+		})
+
+		It("lets the exec wrapper drop privileges instead of monit", func() {
+			wrapped, err := manager.WrapMonitConfig("nginx", `check process nginx
+  start program "/var/vcap/jobs/nginx/bin/ctl start" as uid vcap and gid vcap with timeout 60 seconds
+  stop program "/var/vcap/jobs/nginx/bin/ctl stop" as uid vcap and gid vcap
+
+check process nginx_worker
+  start program "/var/vcap/jobs/nginx/bin/worker_ctl start"
+    as uid vcap
+  group vcap
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(wrapped).To(Equal(`check process nginx
+  start program "/var/vcap/bosh/bin/bosh-job-cgroup-exec --uid vcap --gid vcap /sys/fs/cgroup/bosh-jobs/nginx/nginx /var/vcap/jobs/nginx/bin/ctl start" with timeout 60 seconds
+  stop program "/var/vcap/jobs/nginx/bin/ctl stop" as uid vcap and gid vcap
+
+check process nginx_worker
+  start program "/var/vcap/bosh/bin/bosh-job-cgroup-exec --uid vcap /sys/fs/cgroup/bosh-jobs/nginx/nginx_worker /var/vcap/jobs/nginx/bin/worker_ctl start"
+  group vcap
+`))
+		})
+	})
+
+	Describe("ProcessUsage", func() {
+		It("returns memory and cpu accounted to the process cgroup", func() {
+			fs.SetGlob("/sys/fs/cgroup/bosh-jobs/*/nginx/memory.current", []string{"/sys/fs/cgroup/bosh-jobs/nginx/nginx/memory.current"})
+			Expect(fs.WriteFileString("/sys/fs/cgroup/bosh-jobs/nginx/nginx/memory.current", "4194304\n")).To(Succeed())
+			Expect(fs.WriteFileString("/sys/fs/cgroup/bosh-jobs/nginx/nginx/cpu.stat", "usage_usec 1500000\nuser_usec 1000000\nsystem_usec 500000\n")).To(Succeed())
+
+			usage, found, err := manager.ProcessUsage("nginx")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(usage).To(Equal(Usage{MemoryBytes: 4194304, CPUUsageUsec: 1500000}))
+		})
+
+		It("reports processes that are not in a job cgroup as not found", func() {
+			_, found, err := manager.ProcessUsage("nginx")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("RemoveAllJobs", func() {
+		BeforeEach(func() {
+			for _, dir := range []string{"nginx", "nginx/nginx", "nginx/nginx_worker", "redis", "redis/redis"} {
+				Expect(fs.WriteFileString("/sys/fs/cgroup/bosh-jobs/"+dir+"/cgroup.procs", "")).To(Succeed())
+			}
+			fs.SetGlob("/sys/fs/cgroup/bosh-jobs/*/cgroup.procs", []string{
+				"/sys/fs/cgroup/bosh-jobs/nginx/cgroup.procs",
+				"/sys/fs/cgroup/bosh-jobs/redis/cgroup.procs",
+			})
+			fs.SetGlob("/sys/fs/cgroup/bosh-jobs/*/*/cgroup.procs", []string{
+				"/sys/fs/cgroup/bosh-jobs/nginx/nginx/cgroup.procs",
+				"/sys/fs/cgroup/bosh-jobs/nginx/nginx_worker/cgroup.procs",
+				"/sys/fs/cgroup/bosh-jobs/redis/redis/cgroup.procs",
+			})
+		})
+
+		It("removes the process cgroups before the job cgroups", func() {
+			var removed []string
+			fs.RemoveAllStub = func(path string) error {
+				removed = append(removed, path)
+				return nil
+			}
+
+			Expect(manager.RemoveAllJobs()).To(Succeed())
+
+			Expect(removed).To(Equal([]string{
+				"/sys/fs/cgroup/bosh-jobs/nginx/nginx",
+				"/sys/fs/cgroup/bosh-jobs/nginx/nginx_worker",
+				"/sys/fs/cgroup/bosh-jobs/redis/redis",
+				"/sys/fs/cgroup/bosh-jobs/nginx",
+				"/sys/fs/cgroup/bosh-jobs/redis",
+			}))
+			Expect(fs.FileExists("/sys/fs/cgroup/bosh-jobs/nginx")).To(BeFalse())
+		})
+
+		It("keeps cgroups that still contain processes", func() {
+			fs.RemoveAllStub = func(path string) error {
+				if path == "/sys/fs/cgroup/bosh-jobs/redis/redis" || path == "/sys/fs/cgroup/bosh-jobs/redis" {
+					return errors.New("device or resource busy")
+				}
+				return nil
+			}
+
+			Expect(manager.RemoveAllJobs()).To(Succeed())
+
+			Expect(fs.FileExists("/sys/fs/cgroup/bosh-jobs/redis/redis/cgroup.procs")).To(BeTrue())
+			Expect(fs.FileExists("/sys/fs/cgroup/bosh-jobs/nginx")).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("SliceProperties", func() {
+	It("maps limits to systemd resource control properties", func() {
+		properties, err := SliceProperties(models.ResourceLimits{
+			CPUWeight: 200,
+			MemoryMax: "1G",
+			PidsMax:   512,
+			IOWeight:  50,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(properties).To(Equal([]string{
+			"CPUWeight=200",
+			"IOWeight=50",
+			"MemoryMax=1073741824",
+			"MemoryHigh=infinity",
+			"TasksMax=512",
+		}))
+	})
+
+	It("returns an error for invalid limits", func() {
+		_, err := SliceProperties(models.ResourceLimits{MemoryMax: "lots"})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,127 @@
+package jobsupervisor
+
+import (
+	"path"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/cgroup"
+	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+const cgroupJobSupervisorLogTag = "cgroupJobSupervisor"
+
+// cgroupJobSupervisor starts the processes of jobs with resource limits in job
+// cgroups and reports memory and CPU usage accounted by those cgroups instead
+// of the delegate's numbers. It is meant for supervisors such as monit that
+// do not manage cgroups themselves.
+type cgroupJobSupervisor struct {
+	JobSupervisor
+
+	cgroupManager boshcgroup.Manager
+	fs            boshsys.FileSystem
+	dirProvider   boshdir.Provider
+	timeService   clock.Clock
+	logger        boshlog.Logger
+	cpuUsage      *cpuUsageTracker
+}
+
+func NewCgroupJobSupervisor(
+	delegate JobSupervisor,
+	cgroupManager boshcgroup.Manager,
+	fs boshsys.FileSystem,
+	dirProvider boshdir.Provider,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) JobSupervisor {
+	return &cgroupJobSupervisor{
+		JobSupervisor: delegate,
+		cgroupManager: cgroupManager,
+		fs:            fs,
+		dirProvider:   dirProvider,
+		timeService:   timeService,
+		logger:        logger,
+		cpuUsage:      newCPUUsageTracker(),
+	}
+}
+
+// AddJobWithResourceLimits configures the job cgroup and rewrites the start
+// programs of the monit file so that its processes run in the job's cgroup
+func (c *cgroupJobSupervisor) AddJobWithResourceLimits(jobName string, jobIndex int, configPath string, limitsName string, limits models.ResourceLimits) error {
+	err := c.cgroupManager.ConfigureJob(limitsName, limits)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Configuring resource limits for job %s", limitsName)
+	}
+
+	monitConfig, err := c.fs.ReadFileString(configPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading monit file")
+	}
+
+	monitConfig, err = c.cgroupManager.WrapMonitConfig(limitsName, monitConfig)
+	if err != nil {
+		return bosherr.WrapError(err, "Placing monit processes in job cgroup")
+	}
+
+	wrappedFilePath := path.Join(c.dirProvider.BoshDir(), "cgroup-monit", jobName+".monit")
+
+	err = c.fs.WriteFileString(wrappedFilePath, monitConfig)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing monit file")
+	}
+
+	return c.JobSupervisor.AddJob(jobName, jobIndex, wrappedFilePath)
+}
+
+// RemoveAllJobs also removes the job cgroups so that removed jobs
+// do not leave cgroups behind that usage would be reported from
+func (c *cgroupJobSupervisor) RemoveAllJobs() error {
+	err := c.JobSupervisor.RemoveAllJobs()
+	if err != nil {
+		return err
+	}
+
+	err = c.cgroupManager.RemoveAllJobs()
+	if err != nil {
+		return bosherr.WrapError(err, "Removing job cgroups")
+	}
+
+	return nil
+}
+
+func (c *cgroupJobSupervisor) Processes() ([]Process, error) {
+	processes, err := c.JobSupervisor.Processes()
+	if err != nil {
+		return processes, err
+	}
+
+	now := c.timeService.Now()
+
+	for i, process := range processes {
+		usage, found, err := c.cgroupManager.ProcessUsage(process.Name)
+		if err != nil {
+			c.logger.Warn(cgroupJobSupervisorLogTag, "Getting cgroup usage of process '%s': %s", process.Name, err.Error())
+			continue
+		}
+		if !found {
+			continue
+		}
+
+		kb := int(usage.MemoryBytes / 1024)
+
+		// The delegate's memory percentage is relative to the same total
+		if process.Memory.Kb > 0 {
+			processes[i].Memory.Percent = process.Memory.Percent * float64(kb) / float64(process.Memory.Kb)
+		}
+		processes[i].Memory.Kb = kb
+		processes[i].CPU.Total = c.cpuUsage.Percent(process.Name, usage.CPUUsageUsec*uint64(time.Microsecond), now)
+	}
+
+	return processes, nil
+}
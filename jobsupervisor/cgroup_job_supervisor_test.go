@@ -0,0 +1,146 @@
+package jobsupervisor_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	. "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/cgroup"
+	"github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/cgroup/cgroupfakes"
+	"github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/fakes"
+	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+var _ = Describe("cgroupJobSupervisor", func() {
+	var (
+		delegate      *fakes.FakeJobSupervisor
+		cgroupManager *cgroupfakes.FakeManager
+		fs            *fakesys.FakeFileSystem
+		timeService   *fakeclock.FakeClock
+		supervisor    JobSupervisor
+	)
+
+	BeforeEach(func() {
+		delegate = fakes.NewFakeJobSupervisor()
+		cgroupManager = &cgroupfakes.FakeManager{}
+		timeService = fakeclock.NewFakeClock(time.Date(2024, time.January, 4, 10, 21, 30, 0, time.UTC))
+		fs = fakesys.NewFakeFileSystem()
+		supervisor = NewCgroupJobSupervisor(delegate, cgroupManager, fs, boshdir.NewProvider("/var/vcap"), timeService, boshlog.NewLogger(boshlog.LevelNone))
+
+		delegate.ProcessesStatus = []Process{
+			{Name: "nginx", State: "running", Memory: MemoryVitals{Kb: 2048, Percent: 0.4}, CPU: CPUVitals{Total: 3}},
+			{Name: "worker", State: "running", Memory: MemoryVitals{Kb: 1024, Percent: 0.2}, CPU: CPUVitals{Total: 1}},
+		}
+
+		cgroupManager.ProcessUsageCalls(func(name string) (boshcgroup.Usage, bool, error) {
+			if name == "nginx" {
+				return boshcgroup.Usage{MemoryBytes: 4096 * 1024, CPUUsageUsec: 1000000}, true, nil
+			}
+			return boshcgroup.Usage{}, false, nil
+		})
+	})
+
+	It("reports memory accounted by the process cgroup", func() {
+		processes, err := supervisor.Processes()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(processes[0].Memory).To(Equal(MemoryVitals{Kb: 4096, Percent: 0.8}))
+		Expect(processes[0].CPU.Total).To(Equal(0.0))
+	})
+
+	It("leaves processes outside of job cgroups untouched", func() {
+		expected := delegate.ProcessesStatus[1]
+
+		processes, err := supervisor.Processes()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(processes[1]).To(Equal(expected))
+	})
+
+	It("reports cpu usage between samples", func() {
+		_, err := supervisor.Processes()
+		Expect(err).ToNot(HaveOccurred())
+
+		cgroupManager.ProcessUsageReturns(boshcgroup.Usage{MemoryBytes: 4096 * 1024, CPUUsageUsec: 6000000}, true, nil)
+		timeService.Increment(10 * time.Second)
+
+		processes, err := supervisor.Processes()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(processes[0].CPU.Total).To(BeNumerically("~", 50, 0.001))
+	})
+
+	It("keeps the delegate's numbers when cgroup usage cannot be read", func() {
+		cgroupManager.ProcessUsageCalls(nil)
+		cgroupManager.ProcessUsageReturns(boshcgroup.Usage{}, false, errors.New("fake-err"))
+		expected := append([]Process{}, delegate.ProcessesStatus...)
+
+		processes, err := supervisor.Processes()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(processes).To(Equal(expected))
+	})
+
+	It("returns the delegate's error", func() {
+		delegate.ProcessesError = errors.New("fake-processes-err")
+
+		_, err := supervisor.Processes()
+		Expect(err).To(MatchError("fake-processes-err"))
+	})
+
+	Describe("AddJobWithResourceLimits", func() {
+		BeforeEach(func() {
+			Expect(fs.WriteFileString("/var/vcap/jobs/nginx/sidecar.monit", "some conf")).To(Succeed())
+
+			cgroupManager.WrapMonitConfigStub = func(jobName, monitConfig string) (string, error) {
+				return "wrapped " + monitConfig + " for " + jobName, nil
+			}
+		})
+
+		It("configures the job cgroup and adds a monit file that starts processes in it", func() {
+			limits := models.ResourceLimits{CPUWeight: 50, MemoryMax: "1G"}
+
+			err := supervisor.AddJobWithResourceLimits("nginx_sidecar", 1, "/var/vcap/jobs/nginx/sidecar.monit", "nginx", limits)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cgroupManager.ConfigureJobCallCount()).To(Equal(1))
+			jobName, configuredLimits := cgroupManager.ConfigureJobArgsForCall(0)
+			Expect(jobName).To(Equal("nginx"))
+			Expect(configuredLimits).To(Equal(limits))
+
+			Expect(delegate.AddJobArgs).To(Equal([]fakes.AddJobArgs{
+				{Name: "nginx_sidecar", Index: 1, ConfigPath: "/var/vcap/bosh/cgroup-monit/nginx_sidecar.monit"},
+			}))
+			Expect(fs.ReadFileString("/var/vcap/bosh/cgroup-monit/nginx_sidecar.monit")).To(Equal("wrapped some conf for nginx"))
+		})
+
+		It("returns an error when configuring the cgroup fails", func() {
+			cgroupManager.ConfigureJobReturns(errors.New("fake-cgroup-err"))
+
+			err := supervisor.AddJobWithResourceLimits("nginx", 0, "/var/vcap/jobs/nginx/sidecar.monit", "nginx", models.ResourceLimits{})
+			Expect(err).To(MatchError(ContainSubstring("fake-cgroup-err")))
+			Expect(delegate.AddJobArgs).To(BeEmpty())
+		})
+	})
+
+	Describe("RemoveAllJobs", func() {
+		It("removes the job cgroups after the jobs", func() {
+			Expect(supervisor.RemoveAllJobs()).To(Succeed())
+
+			Expect(delegate.RemovedAllJobs).To(BeTrue())
+			Expect(cgroupManager.RemoveAllJobsCallCount()).To(Equal(1))
+		})
+
+		It("keeps the job cgroups when removing the jobs fails", func() {
+			delegate.RemovedAllJobsErr = errors.New("fake-remove-err")
+
+			Expect(supervisor.RemoveAllJobs()).To(MatchError("fake-remove-err"))
+			Expect(cgroupManager.RemoveAllJobsCallCount()).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,37 @@
+package jobsupervisor
+
+import (
+	"sync"
+	"time"
+)
+
+// cpuUsageTracker turns cumulative CPU time into a percentage
+// of one CPU used since the previous sample of the same process
+type cpuUsageTracker struct {
+	samples map[string]cpuSample
+	lock    sync.Mutex
+}
+
+type cpuSample struct {
+	usageNSec uint64
+	takenAt   time.Time
+}
+
+func newCPUUsageTracker() *cpuUsageTracker {
+	return &cpuUsageTracker{samples: map[string]cpuSample{}}
+}
+
+func (t *cpuUsageTracker) Percent(name string, usageNSec uint64, now time.Time) float64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	previous, found := t.samples[name]
+	t.samples[name] = cpuSample{usageNSec: usageNSec, takenAt: now}
+
+	elapsed := now.Sub(previous.takenAt)
+	if !found || elapsed <= 0 || usageNSec < previous.usageNSec {
+		return 0
+	}
+
+	return float64(usageNSec-previous.usageNSec) / float64(elapsed.Nanoseconds()) * 100
+}
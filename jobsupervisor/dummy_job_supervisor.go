@@ -1,5 +1,9 @@
 package jobsupervisor
 
+import (
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
 type dummyJobSupervisor struct {
 	status    string
 	processes []Process
@@ -45,6 +49,10 @@ func (s *dummyJobSupervisor) AddJob(jobName string, jobIndex int, configPath str
 	return nil
 }
 
+func (s *dummyJobSupervisor) AddJobWithResourceLimits(jobName string, jobIndex int, configPath string, limitsName string, limits models.ResourceLimits) error {
+	return nil
+}
+
 func (s *dummyJobSupervisor) RemoveAllJobs() error {
 	return nil
 }
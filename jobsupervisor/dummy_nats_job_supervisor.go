@@ -6,6 +6,7 @@ import (
 	bosherror "github.com/cloudfoundry/bosh-utils/errors"
 
 	boshalert "github.com/cloudfoundry/bosh-agent/v2/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
 )
 
@@ -75,6 +76,10 @@ func (d *dummyNatsJobSupervisor) AddJob(jobName string, jobIndex int, configPath
 	return nil
 }
 
+func (d *dummyNatsJobSupervisor) AddJobWithResourceLimits(jobName string, jobIndex int, configPath string, limitsName string, limits models.ResourceLimits) error {
+	return nil
+}
+
 func (d *dummyNatsJobSupervisor) Start() error {
 	if d.status == "fail_task" {
 		return bosherror.Error("fake-task-fail-error")
@@ -4,6 +4,7 @@ import (
 	"sync"
 
 	boshalert "github.com/cloudfoundry/bosh-agent/v2/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
 )
 
@@ -11,7 +12,8 @@ type FakeJobSupervisor struct {
 	Reloaded  bool
 	ReloadErr error
 
-	AddJobArgs []AddJobArgs
+	AddJobArgs   []AddJobArgs
+	AddJobLimits map[string]AddJobLimitsArgs
 
 	RemovedAllJobs    bool
 	RemovedAllJobsErr error
@@ -42,6 +44,11 @@ type AddJobArgs struct {
 	ConfigPath string
 }
 
+type AddJobLimitsArgs struct {
+	LimitsName string
+	Limits     models.ResourceLimits
+}
+
 func NewFakeJobSupervisor() *FakeJobSupervisor {
 	return &FakeJobSupervisor{}
 }
@@ -65,6 +72,14 @@ func (m *FakeJobSupervisor) AddJob(jobName string, jobIndex int, configPath stri
 	return nil
 }
 
+func (m *FakeJobSupervisor) AddJobWithResourceLimits(jobName string, jobIndex int, configPath string, limitsName string, limits models.ResourceLimits) error {
+	if m.AddJobLimits == nil {
+		m.AddJobLimits = map[string]AddJobLimitsArgs{}
+	}
+	m.AddJobLimits[jobName] = AddJobLimitsArgs{LimitsName: limitsName, Limits: limits}
+	return m.AddJob(jobName, jobIndex, configPath)
+}
+
 func (m *FakeJobSupervisor) RemoveAllJobs() error {
 	m.RemovedAllJobs = true
 	return m.RemovedAllJobsErr
@@ -2,6 +2,7 @@ package jobsupervisor
 
 import (
 	boshalert "github.com/cloudfoundry/bosh-agent/v2/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 )
 
 type Process struct {
@@ -45,6 +46,10 @@ type JobSupervisor interface {
 	Processes() ([]Process, error)
 	// Job management
 	AddJob(jobName string, jobIndex int, configPath string) error
+	// AddJobWithResourceLimits adds a job whose processes are constrained by the
+	// resource limits of the release job limitsName; additional monit files of
+	// a release job are added under their own job names but share its limits
+	AddJobWithResourceLimits(jobName string, jobIndex int, configPath string, limitsName string, limits models.ResourceLimits) error
 	RemoveAllJobs() error
 
 	MonitorJobFailures(handler JobFailureHandler) error
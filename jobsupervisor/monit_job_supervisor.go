@@ -16,6 +16,7 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	boshalert "github.com/cloudfoundry/bosh-agent/v2/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshmonit "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/monit"
 	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 )
@@ -338,6 +339,12 @@ func (m monitJobSupervisor) getIncarnation() (int, error) {
 	return monitStatus.GetIncarnation()
 }
 
+// AddJobWithResourceLimits is not supported by monit itself;
+// NewCgroupJobSupervisor places monit processes in job cgroups instead
+func (m monitJobSupervisor) AddJobWithResourceLimits(jobName string, jobIndex int, configPath string, limitsName string, limits models.ResourceLimits) error {
+	return bosherr.Errorf("Resource limits for job '%s' require job cgroups", limitsName)
+}
+
 func (m monitJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
 	targetFilename := fmt.Sprintf("%04d_%s.monitrc", jobIndex, jobName)
 	targetConfigPath := path.Join(m.dirProvider.MonitJobsDir(), targetFilename)
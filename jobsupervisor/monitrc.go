@@ -0,0 +1,256 @@
+package jobsupervisor
+
+import (
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// monitProcess is the subset of a monit `check process` entry
+// that can be expressed as a systemd service
+type monitProcess struct {
+	Name      string
+	PidFile   string
+	Group     string
+	DependsOn []string
+
+	Start monitProgram
+	Stop  monitProgram
+}
+
+type monitProgram struct {
+	Command        string
+	UID            string
+	GID            string
+	TimeoutSeconds int
+}
+
+type monitToken struct {
+	value  string
+	quoted bool
+}
+
+// parseMonitrc extracts process checks from a job's monit file.
+// Checks of other types (file, host, etc.) and resource tests
+// (`if ... then ...`) have no systemd equivalent and are skipped.
+func parseMonitrc(content string) ([]monitProcess, error) {
+	tokens, err := tokenizeMonitrc(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []monitProcess
+	var current *monitProcess
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if token.quoted {
+			continue
+		}
+
+		switch strings.ToLower(token.value) {
+		case "check":
+			if i+2 >= len(tokens) {
+				return nil, bosherr.Error("Incomplete check statement")
+			}
+
+			if current != nil {
+				processes = append(processes, *current)
+				current = nil
+			}
+
+			if strings.ToLower(tokens[i+1].value) == "process" {
+				current = &monitProcess{Name: tokens[i+2].value}
+			}
+			i += 2
+
+		case "pidfile":
+			if current == nil || i+1 >= len(tokens) {
+				continue
+			}
+			current.PidFile = tokens[i+1].value
+			i++
+
+		case "group":
+			if current == nil || i+1 >= len(tokens) {
+				continue
+			}
+			current.Group = tokens[i+1].value
+			i++
+
+		case "depends":
+			if current == nil {
+				continue
+			}
+			i = skipNoiseTokens(tokens, i+1, "on")
+			for ; i < len(tokens); i++ {
+				for _, name := range strings.Split(tokens[i].value, ",") {
+					if name != "" {
+						current.DependsOn = append(current.DependsOn, name)
+					}
+				}
+				if !strings.HasSuffix(tokens[i].value, ",") && (i+1 >= len(tokens) || tokens[i+1].value != ",") {
+					break
+				}
+			}
+
+		case "start", "stop":
+			if current == nil {
+				continue
+			}
+
+			var program monitProgram
+			program, i, err = parseMonitProgram(tokens, i+1)
+			if err != nil {
+				return nil, bosherr.WrapErrorf(err, "Parsing %s program for process '%s'", token.value, current.Name)
+			}
+
+			if strings.ToLower(token.value) == "start" {
+				current.Start = program
+			} else {
+				current.Stop = program
+			}
+
+		case "if":
+			// Resource tests end with `then <action>`; `exec` actions take a command
+			i = indexOfKeyword(tokens, i+1, "then")
+			if i+1 < len(tokens) {
+				i++
+				if strings.ToLower(tokens[i].value) == "exec" {
+					i++
+				}
+			}
+		}
+	}
+
+	if current != nil {
+		processes = append(processes, *current)
+	}
+
+	for _, process := range processes {
+		if process.Start.Command == "" {
+			return nil, bosherr.Errorf("Process '%s' does not specify a start program", process.Name)
+		}
+	}
+
+	return processes, nil
+}
+
+func parseMonitProgram(tokens []monitToken, i int) (monitProgram, int, error) {
+	var program monitProgram
+
+	i = skipNoiseTokens(tokens, i, "program", "=")
+	if i >= len(tokens) || !tokens[i].quoted {
+		return program, i, bosherr.Error("Expected quoted program command")
+	}
+	program.Command = tokens[i].value
+
+	for i+1 < len(tokens) {
+		next := i + 1
+
+		switch strings.ToLower(tokens[next].value) {
+		case "as":
+			next = skipNoiseTokens(tokens, next+1, "uid", "user")
+			if next >= len(tokens) {
+				return program, next, bosherr.Error("Expected uid")
+			}
+			program.UID = tokens[next].value
+		case "and", "with":
+			next = skipNoiseTokens(tokens, next+1, "and", "with")
+			if next >= len(tokens) {
+				return program, next, nil
+			}
+
+			switch strings.ToLower(tokens[next].value) {
+			case "gid", "group":
+				if next+1 >= len(tokens) {
+					return program, next, bosherr.Error("Expected gid")
+				}
+				next++
+				program.GID = tokens[next].value
+			case "timeout":
+				if next+1 >= len(tokens) {
+					return program, next, bosherr.Error("Expected timeout")
+				}
+				next++
+
+				seconds, err := strconv.Atoi(tokens[next].value)
+				if err != nil {
+					return program, next, bosherr.WrapErrorf(err, "Parsing timeout '%s'", tokens[next].value)
+				}
+				program.TimeoutSeconds = seconds
+
+				next = skipNoiseTokens(tokens, next+1, "seconds", "second") - 1
+			default:
+				return program, i, nil
+			}
+		default:
+			return program, i, nil
+		}
+
+		i = next
+	}
+
+	return program, i, nil
+}
+
+func skipNoiseTokens(tokens []monitToken, i int, noise ...string) int {
+	for ; i < len(tokens); i++ {
+		isNoise := false
+		for _, n := range noise {
+			if !tokens[i].quoted && strings.EqualFold(tokens[i].value, n) {
+				isNoise = true
+				break
+			}
+		}
+		if !isNoise {
+			return i
+		}
+	}
+	return i
+}
+
+func indexOfKeyword(tokens []monitToken, i int, keyword string) int {
+	for ; i < len(tokens); i++ {
+		if !tokens[i].quoted && strings.EqualFold(tokens[i].value, keyword) {
+			return i
+		}
+	}
+	return i
+}
+
+func tokenizeMonitrc(content string) ([]monitToken, error) {
+	var tokens []monitToken
+
+	for i := 0; i < len(content); {
+		c := content[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '#':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(content[i+1:], c)
+			if end < 0 {
+				return nil, bosherr.Error("Unterminated quoted string")
+			}
+			tokens = append(tokens, monitToken{value: content[i+1 : i+1+end], quoted: true})
+			i += end + 2
+
+		default:
+			start := i
+			for i < len(content) && !strings.ContainsRune(" \t\r\n\"'#", rune(content[i])) {
+				i++
+			}
+			tokens = append(tokens, monitToken{value: content[start:i]})
+		}
+	}
+
+	return tokens, nil
+}
@@ -11,6 +11,7 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/cgroup"
 	boshmonit "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/monit"
 	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
 	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
@@ -48,9 +49,25 @@ func NewProvider(
 		platform.GetServiceManager(),
 	)
 
+	cgroupManager := boshcgroup.NewManager(fs, boshcgroup.DefaultRoot, dirProvider.BoshBinDir(), logger)
+
+	systemdJobSupervisor := NewSystemdJobSupervisor(
+		fs,
+		runner,
+		logger,
+		dirProvider,
+		SystemdJobSupervisorOptions{
+			UnitDir:              "/etc/systemd/system",
+			RuntimeUnitDir:       "/run/systemd/system",
+			FailureCheckInterval: 10 * time.Second,
+		},
+		timeService,
+	)
+
 	return Provider{
 		supervisors: map[string]JobSupervisor{
-			"monit":      NewWrapperJobSupervisor(monitJobSupervisor, fs, dirProvider, logger),
+			"monit":      NewWrapperJobSupervisor(NewCgroupJobSupervisor(monitJobSupervisor, cgroupManager, fs, dirProvider, timeService, logger), fs, dirProvider, logger),
+			"systemd":    NewWrapperJobSupervisor(systemdJobSupervisor, fs, dirProvider, logger),
 			"dummy":      NewDummyJobSupervisor(),
 			"dummy-nats": NewDummyNatsJobSupervisor(handler),
 		},
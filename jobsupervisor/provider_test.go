@@ -11,6 +11,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/cgroup"
 	fakemonit "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/monit/fakes"
 	fakembus "github.com/cloudfoundry/bosh-agent/v2/mbus/fakes"
 	"github.com/cloudfoundry/bosh-agent/v2/platform/platformfakes"
@@ -34,6 +35,7 @@ func init() { //nolint:gochecknoinits
 			timeService           clock.Clock
 			jobSupervisorName     string
 			serviceManager        *servicemanagerfakes.FakeServiceManager
+			cgroupManager         boshcgroup.Manager
 		)
 
 		BeforeEach(func() {
@@ -47,6 +49,7 @@ func init() { //nolint:gochecknoinits
 			handler = &fakembus.FakeHandler{}
 			timeService = clock.NewClock()
 			serviceManager = &servicemanagerfakes.FakeServiceManager{}
+			cgroupManager = boshcgroup.NewManager(fileSystem, boshcgroup.DefaultRoot, dirProvider.BoshBinDir(), logger)
 
 			platform.GetFsReturns(fileSystem)
 			platform.GetRunnerReturns(cmdRunner)
@@ -88,7 +91,7 @@ func init() { //nolint:gochecknoinits
 				)
 
 				expectedSupervisor := NewWrapperJobSupervisor(
-					delegateSupervisor,
+					NewCgroupJobSupervisor(delegateSupervisor, cgroupManager, fileSystem, dirProvider, timeService, logger),
 					fileSystem,
 					dirProvider,
 					logger,
@@ -98,6 +101,37 @@ func init() { //nolint:gochecknoinits
 			}
 		})
 
+		It("provides a systemd job supervisor", func() {
+			if runtime.GOOS == "windows" {
+				Skip("systemd job supervisor is not available on windows")
+			}
+
+			actualSupervisor, err := provider.Get("systemd")
+			Expect(err).ToNot(HaveOccurred())
+
+			delegateSupervisor := NewSystemdJobSupervisor(
+				fileSystem,
+				cmdRunner,
+				logger,
+				dirProvider,
+				SystemdJobSupervisorOptions{
+					UnitDir:              "/etc/systemd/system",
+					RuntimeUnitDir:       "/run/systemd/system",
+					FailureCheckInterval: 10 * time.Second,
+				},
+				timeService,
+			)
+
+			expectedSupervisor := NewWrapperJobSupervisor(
+				delegateSupervisor,
+				fileSystem,
+				dirProvider,
+				logger,
+			)
+
+			Expect(actualSupervisor).To(Equal(expectedSupervisor))
+		})
+
 		It("provides a dummy job supervisor", func() {
 			actualSupervisor, err := provider.Get("dummy")
 			Expect(err).ToNot(HaveOccurred())
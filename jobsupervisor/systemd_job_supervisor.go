@@ -0,0 +1,763 @@
+package jobsupervisor
+
+import (
+	"bufio"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/v2/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/cgroup"
+	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+const (
+	systemdJobSupervisorLogTag = "systemdJobSupervisor"
+
+	SystemdJobSlice      = "bosh-jobs.slice"
+	systemdJobUnitPrefix = "bosh-job-"
+)
+
+type SystemdJobSupervisorOptions struct {
+	// Directory that generated job units are written to
+	UnitDir string
+
+	// Directory for runtime drop-ins that are discarded on reboot,
+	// used to disable restarts of unmonitored jobs
+	RuntimeUnitDir string
+
+	// How often unit states are polled for failures
+	FailureCheckInterval time.Duration
+}
+
+type systemdUnitState struct {
+	ID          string
+	ActiveState string
+	SubState    string
+	Result      string
+
+	// Time since boot the unit became active; systemctl versions before 248
+	// can only print wall clock timestamps in the local time zone
+	ActiveEnterTimestampMonotonic time.Duration
+
+	MemoryCurrent uint64
+	CPUUsageNSec  uint64
+	NRestarts     int
+}
+
+type systemdJobSupervisor struct {
+	fs          boshsys.FileSystem
+	runner      boshsys.CmdRunner
+	logger      boshlog.Logger
+	dirProvider boshdir.Provider
+	options     SystemdJobSupervisorOptions
+	timeService clock.Clock
+	cpuUsage    *cpuUsageTracker
+}
+
+func NewSystemdJobSupervisor(
+	fs boshsys.FileSystem,
+	runner boshsys.CmdRunner,
+	logger boshlog.Logger,
+	dirProvider boshdir.Provider,
+	options SystemdJobSupervisorOptions,
+	timeService clock.Clock,
+) JobSupervisor {
+	return &systemdJobSupervisor{
+		fs:          fs,
+		runner:      runner,
+		logger:      logger,
+		dirProvider: dirProvider,
+		options:     options,
+		timeService: timeService,
+		cpuUsage:    newCPUUsageTracker(),
+	}
+}
+
+func (s *systemdJobSupervisor) Reload() error {
+	_, _, _, err := s.runner.RunCommand("systemctl", "daemon-reload")
+	if err != nil {
+		return bosherr.WrapError(err, "Reloading systemd units")
+	}
+
+	return nil
+}
+
+func (s *systemdJobSupervisor) Start() error {
+	units, err := s.units()
+	if err != nil {
+		return err
+	}
+
+	units, removedJobs, err := s.removeJobsPendingRemoval(units)
+	if err != nil {
+		return err
+	}
+
+	// Starting jobs re-monitors them
+	err = s.fs.RemoveAll(s.unmonitorDropInPath())
+	if err != nil {
+		return bosherr.WrapError(err, "Removing unmonitor drop-in")
+	}
+
+	if len(units) > 0 || removedJobs {
+		err = s.Reload()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(units) > 0 {
+		s.logger.Debug(systemdJobSupervisorLogTag, "Starting units %v", units)
+		_, _, _, err = s.runner.RunCommand("systemctl", append([]string{"start"}, units...)...)
+		if err != nil {
+			return bosherr.WrapError(err, "Starting job units")
+		}
+	}
+
+	err = s.fs.RemoveAll(s.stoppedFilePath())
+	if err != nil {
+		return bosherr.WrapError(err, "Removing stopped File")
+	}
+
+	return nil
+}
+
+func (s *systemdJobSupervisor) Stop() error {
+	units, err := s.units()
+	if err != nil {
+		return err
+	}
+
+	if len(units) > 0 {
+		s.logger.Debug(systemdJobSupervisorLogTag, "Stopping units %v", units)
+		_, _, _, err = s.runner.RunCommand("systemctl", append([]string{"stop", "--no-block"}, units...)...)
+		if err != nil {
+			return bosherr.WrapError(err, "Stopping job units")
+		}
+	}
+
+	err = s.fs.WriteFileString(s.stoppedFilePath(), "")
+	if err != nil {
+		return bosherr.WrapError(err, "Creating stopped File")
+	}
+
+	return nil
+}
+
+func (s *systemdJobSupervisor) StopAndWait() error {
+	units, err := s.units()
+	if err != nil {
+		return err
+	}
+
+	err = s.fs.WriteFileString(s.stoppedFilePath(), "")
+	if err != nil {
+		return bosherr.WrapError(err, "Creating stopped File")
+	}
+
+	if len(units) == 0 {
+		return nil
+	}
+
+	// Without --no-block systemctl waits for the stop jobs to complete
+	_, _, _, err = s.runner.RunCommand("systemctl", append([]string{"stop"}, units...)...)
+	if err != nil {
+		return bosherr.WrapError(err, "Stopping job units")
+	}
+
+	states, err := s.unitStates(units)
+	if err != nil {
+		return err
+	}
+
+	var failedUnits []string
+	for _, state := range states {
+		if state.ActiveState == "failed" {
+			failedUnits = append(failedUnits, state.ID)
+		}
+	}
+
+	if len(failedUnits) > 0 {
+		return bosherr.Errorf("Stopping units '%s' failed", strings.Join(failedUnits, ", "))
+	}
+
+	s.logger.Debug(systemdJobSupervisorLogTag, "Successfully stopped all units")
+	return nil
+}
+
+func (s *systemdJobSupervisor) Unmonitor() error {
+	units, err := s.units()
+	if err != nil {
+		return err
+	}
+
+	if len(units) == 0 {
+		return nil
+	}
+
+	// A runtime drop-in on the slice's units keeps systemd from
+	// restarting jobs until they are started again
+	err = s.fs.WriteFileString(s.unmonitorDropInPath(), "[Service]\nRestart=no\n")
+	if err != nil {
+		return bosherr.WrapError(err, "Writing unmonitor drop-in")
+	}
+
+	return s.Reload()
+}
+
+func (s *systemdJobSupervisor) Status() string {
+	if s.fs.FileExists(s.stoppedFilePath()) {
+		return "stopped"
+	}
+
+	units, err := s.units()
+	if err != nil {
+		return "unknown"
+	}
+
+	states, err := s.unitStates(units)
+	if err != nil {
+		return "unknown"
+	}
+
+	status := "running"
+	for _, state := range states {
+		if state.ActiveState == "activating" || state.ActiveState == "reloading" {
+			return "starting"
+		}
+		if state.ActiveState != "active" {
+			status = "failing"
+		}
+	}
+
+	return status
+}
+
+func (s *systemdJobSupervisor) Processes() ([]Process, error) {
+	processes := []Process{}
+
+	units, err := s.units()
+	if err != nil {
+		return processes, err
+	}
+
+	states, err := s.unitStates(units)
+	if err != nil {
+		return processes, bosherr.WrapError(err, "Getting unit states")
+	}
+
+	totalMemory := s.totalMemoryBytes()
+	systemUptime := s.systemUptime()
+	now := s.timeService.Now()
+
+	for _, state := range states {
+		process := Process{
+			Name:  processNameForUnit(state.ID),
+			State: processState(state),
+			Memory: MemoryVitals{
+				Kb: int(state.MemoryCurrent / 1024),
+			},
+			CPU: CPUVitals{
+				Total: s.cpuUsage.Percent(state.ID, state.CPUUsageNSec, now),
+			},
+		}
+
+		if state.ActiveState == "active" && state.ActiveEnterTimestampMonotonic > 0 && systemUptime > state.ActiveEnterTimestampMonotonic {
+			process.Uptime.Secs = int((systemUptime - state.ActiveEnterTimestampMonotonic).Seconds())
+		}
+
+		if totalMemory > 0 {
+			process.Memory.Percent = float64(state.MemoryCurrent) / float64(totalMemory) * 100
+		}
+
+		processes = append(processes, process)
+	}
+
+	return processes, nil
+}
+
+func (s *systemdJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
+	return s.addJob(jobName, configPath, SystemdJobSlice)
+}
+
+// AddJobWithResourceLimits places the job's units in a slice of their own that
+// carries the limits, so that systemd keeps the processes in the unit cgroups
+func (s *systemdJobSupervisor) AddJobWithResourceLimits(jobName string, jobIndex int, configPath string, limitsName string, limits models.ResourceLimits) error {
+	properties, err := boshcgroup.SliceProperties(limits)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Validating resource limits for job '%s'", limitsName)
+	}
+
+	slice := jobSliceName(limitsName)
+
+	err = s.fs.WriteFileString(path.Join(s.options.UnitDir, slice), systemdJobSliceWithLimitsUnit(limitsName, properties))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing slice unit for job '%s'", limitsName)
+	}
+
+	return s.addJob(jobName, configPath, slice)
+}
+
+func (s *systemdJobSupervisor) addJob(jobName string, configPath string, slice string) error {
+	configContent, err := s.fs.ReadFileString(configPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading job config from file")
+	}
+
+	processes, err := parseMonitrc(configContent)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing monit file for job '%s'", jobName)
+	}
+
+	err = s.fs.WriteFileString(path.Join(s.options.UnitDir, SystemdJobSlice), systemdJobSliceUnit)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing job slice unit")
+	}
+
+	names := []string{slice}
+
+	for _, process := range processes {
+		unitPath := path.Join(s.options.UnitDir, unitNameForProcess(process.Name))
+
+		err = s.fs.WriteFileString(unitPath, systemdServiceUnit(jobName, process, slice))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Writing unit for process '%s'", process.Name)
+		}
+
+		names = append(names, unitNameForProcess(process.Name))
+	}
+
+	return s.keepJobUnits(names...)
+}
+
+// RemoveAllJobs marks the units and slices of all jobs for removal. They are
+// only stopped and removed by Start if their jobs were not added again, so
+// that jobs that are still part of the spec keep running.
+func (s *systemdJobSupervisor) RemoveAllJobs() error {
+	units, err := s.units()
+	if err != nil {
+		return err
+	}
+
+	jobSlices, err := s.fs.Glob(path.Join(s.options.UnitDir, jobSliceName("*")))
+	if err != nil {
+		return bosherr.WrapError(err, "Listing job slices")
+	}
+
+	pending := units
+	for _, slice := range jobSlices {
+		pending = append(pending, path.Base(slice))
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	err = s.fs.WriteFileString(s.pendingRemovalFilePath(), strings.Join(pending, "\n")+"\n")
+	if err != nil {
+		return bosherr.WrapError(err, "Recording jobs pending removal")
+	}
+
+	return nil
+}
+
+// keepJobUnits takes the units and slices of an added job off the list of
+// units pending removal
+func (s *systemdJobSupervisor) keepJobUnits(names ...string) error {
+	pending, err := s.pendingRemoval()
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	var remaining []string
+	for _, name := range pending {
+		if !slices.Contains(names, name) {
+			remaining = append(remaining, name)
+		}
+	}
+
+	if len(remaining) == 0 {
+		err = s.fs.RemoveAll(s.pendingRemovalFilePath())
+	} else {
+		err = s.fs.WriteFileString(s.pendingRemovalFilePath(), strings.Join(remaining, "\n")+"\n")
+	}
+
+	if err != nil {
+		return bosherr.WrapError(err, "Recording jobs pending removal")
+	}
+
+	return nil
+}
+
+// removeJobsPendingRemoval stops, disables and removes the units and slices of
+// jobs that were not added again since RemoveAllJobs and returns the remaining units
+func (s *systemdJobSupervisor) removeJobsPendingRemoval(units []string) ([]string, bool, error) {
+	pending, err := s.pendingRemoval()
+	if err != nil || len(pending) == 0 {
+		return units, false, err
+	}
+
+	var remainingUnits, removedUnits []string
+	for _, unit := range units {
+		if slices.Contains(pending, unit) {
+			removedUnits = append(removedUnits, unit)
+		} else {
+			remainingUnits = append(remainingUnits, unit)
+		}
+	}
+
+	if len(removedUnits) > 0 {
+		err = s.stopAndDisable(removedUnits)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	for _, name := range pending {
+		err = s.fs.RemoveAll(path.Join(s.options.UnitDir, name))
+		if err != nil {
+			return nil, false, bosherr.WrapErrorf(err, "Removing '%s'", name)
+		}
+	}
+
+	err = s.fs.RemoveAll(s.pendingRemovalFilePath())
+	if err != nil {
+		return nil, false, bosherr.WrapError(err, "Removing jobs pending removal")
+	}
+
+	return remainingUnits, true, nil
+}
+
+func (s *systemdJobSupervisor) pendingRemoval() ([]string, error) {
+	if !s.fs.FileExists(s.pendingRemovalFilePath()) {
+		return nil, nil
+	}
+
+	contents, err := s.fs.ReadFileString(s.pendingRemovalFilePath())
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading jobs pending removal")
+	}
+
+	return strings.Fields(contents), nil
+}
+
+func (s *systemdJobSupervisor) stopAndDisable(units []string) error {
+	// Units have to be stopped while their unit files still exist so that
+	// systemd runs their stop programs
+	s.logger.Debug(systemdJobSupervisorLogTag, "Stopping and disabling units %v", units)
+	_, _, _, err := s.runner.RunCommand("systemctl", append([]string{"stop"}, units...)...)
+	if err != nil {
+		return bosherr.WrapError(err, "Stopping job units")
+	}
+
+	_, _, _, err = s.runner.RunCommand("systemctl", append([]string{"disable"}, units...)...)
+	if err != nil {
+		return bosherr.WrapError(err, "Disabling job units")
+	}
+
+	return nil
+}
+
+// MonitorJobFailures polls job units and reports restarts and failed units
+// with the same events monit would use so that alerts are classified the same way
+func (s *systemdJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
+	previous := map[string]systemdUnitState{}
+
+	ticker := s.timeService.NewTicker(s.options.FailureCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C() {
+		if s.fs.FileExists(s.stoppedFilePath()) {
+			continue
+		}
+
+		units, err := s.units()
+		if err != nil {
+			s.logger.Error(systemdJobSupervisorLogTag, "Listing job units: %s", err.Error())
+			continue
+		}
+
+		states, err := s.unitStates(units)
+		if err != nil {
+			s.logger.Error(systemdJobSupervisorLogTag, "Getting unit states: %s", err.Error())
+			continue
+		}
+
+		current := map[string]systemdUnitState{}
+		for _, state := range states {
+			current[state.ID] = state
+
+			alert, found := s.failureAlert(previous[state.ID], state)
+			if !found {
+				continue
+			}
+
+			err = handler(alert)
+			if err != nil {
+				s.logger.Error(systemdJobSupervisorLogTag, "Handling failure of unit '%s': %s", state.ID, err.Error())
+			}
+		}
+		previous = current
+	}
+
+	return nil
+}
+
+func (s *systemdJobSupervisor) HealthRecorder(status string) {
+}
+
+func (s *systemdJobSupervisor) failureAlert(previous, current systemdUnitState) (boshalert.MonitAlert, bool) {
+	alert := boshalert.MonitAlert{
+		Service: processNameForUnit(current.ID),
+		Date:    s.timeService.Now().Format(time.RFC1123Z),
+	}
+
+	switch {
+	case current.ActiveState == "failed" && previous.ActiveState != "failed":
+		alert.Event = "execution failed"
+		alert.Action = "alert"
+		alert.Description = fmt.Sprintf("unit %s failed with result '%s'", current.ID, current.Result)
+	case previous.ID != "" && current.NRestarts > previous.NRestarts:
+		alert.Event = "does not exist"
+		alert.Action = "restart"
+		alert.Description = fmt.Sprintf("process is not running; unit %s restarted %d time(s)", current.ID, current.NRestarts-previous.NRestarts)
+	default:
+		return alert, false
+	}
+
+	alert.ID = fmt.Sprintf("%d.%s@localhost", s.timeService.Now().UnixNano(), current.ID)
+
+	return alert, true
+}
+
+func (s *systemdJobSupervisor) units() ([]string, error) {
+	matches, err := s.fs.Glob(path.Join(s.options.UnitDir, systemdJobUnitPrefix+"*.service"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing job units")
+	}
+
+	units := make([]string, 0, len(matches))
+	for _, match := range matches {
+		units = append(units, path.Base(match))
+	}
+	sort.Strings(units)
+
+	return units, nil
+}
+
+func (s *systemdJobSupervisor) unitStates(units []string) ([]systemdUnitState, error) {
+	if len(units) == 0 {
+		return nil, nil
+	}
+
+	args := append([]string{
+		"show",
+		"--property=Id,ActiveState,SubState,Result,ActiveEnterTimestampMonotonic,MemoryCurrent,CPUUsageNSec,NRestarts",
+	}, units...)
+
+	stdout, _, _, err := s.runner.RunCommand("systemctl", args...)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Showing job units")
+	}
+
+	return parseSystemctlShow(stdout), nil
+}
+
+func (s *systemdJobSupervisor) totalMemoryBytes() uint64 {
+	meminfo, err := s.fs.ReadFileString("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+
+	for _, line := range strings.Split(meminfo, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0
+			}
+			return kb * 1024
+		}
+	}
+
+	return 0
+}
+
+// systemUptime is compared with monotonic unit timestamps; both
+// only differ by the time the system was suspended
+func (s *systemdJobSupervisor) systemUptime() time.Duration {
+	uptime, err := s.fs.ReadFileString("/proc/uptime")
+	if err != nil {
+		return 0
+	}
+
+	fields := strings.Fields(uptime)
+	if len(fields) == 0 {
+		return 0
+	}
+
+	secs, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0
+	}
+
+	return time.Duration(secs * float64(time.Second))
+}
+
+func (s *systemdJobSupervisor) stoppedFilePath() string {
+	return path.Join(s.dirProvider.BoshDir(), "jobs_stopped")
+}
+
+func (s *systemdJobSupervisor) pendingRemovalFilePath() string {
+	return path.Join(s.dirProvider.BoshDir(), "systemd_jobs_pending_removal")
+}
+
+func (s *systemdJobSupervisor) unmonitorDropInPath() string {
+	// Drop-ins for "bosh-job-.service" apply to every "bosh-job-*.service" unit
+	return path.Join(s.options.RuntimeUnitDir, systemdJobUnitPrefix+".service.d", "unmonitor.conf")
+}
+
+func parseSystemctlShow(stdout string) []systemdUnitState {
+	var states []systemdUnitState
+	var current *systemdUnitState
+
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if !found {
+			if current != nil {
+				states = append(states, *current)
+				current = nil
+			}
+			continue
+		}
+
+		if current == nil {
+			current = &systemdUnitState{}
+		}
+
+		switch key {
+		case "Id":
+			current.ID = value
+		case "ActiveState":
+			current.ActiveState = value
+		case "SubState":
+			current.SubState = value
+		case "Result":
+			current.Result = value
+		case "ActiveEnterTimestampMonotonic":
+			usecs, _ := strconv.ParseUint(value, 10, 64) //nolint:errcheck
+			current.ActiveEnterTimestampMonotonic = time.Duration(usecs) * time.Microsecond
+		case "MemoryCurrent":
+			// "[not set]" when memory accounting is disabled
+			current.MemoryCurrent, _ = strconv.ParseUint(value, 10, 64) //nolint:errcheck
+		case "CPUUsageNSec":
+			current.CPUUsageNSec, _ = strconv.ParseUint(value, 10, 64) //nolint:errcheck
+		case "NRestarts":
+			current.NRestarts, _ = strconv.Atoi(value) //nolint:errcheck
+		}
+	}
+
+	if current != nil {
+		states = append(states, *current)
+	}
+
+	return states
+}
+
+// processState maps unit states onto the states monit reports for processes
+func processState(state systemdUnitState) string {
+	switch state.ActiveState {
+	case "active", "reloading":
+		return "running"
+	case "activating":
+		return "starting"
+	case "deactivating", "inactive":
+		return "stopped"
+	case "failed":
+		return "failing"
+	default:
+		return "unknown"
+	}
+}
+
+func unitNameForProcess(processName string) string {
+	return systemdJobUnitPrefix + processName + ".service"
+}
+
+func processNameForUnit(unit string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(unit, systemdJobUnitPrefix), ".service")
+}
+
+const systemdJobSliceUnit = `[Unit]
+Description=BOSH jobs
+Before=slices.target
+`
+
+// jobSliceName names the slice of a job with resource limits. Dashes separate
+// the levels of the slice hierarchy, so they are escaped in the job name.
+func jobSliceName(jobName string) string {
+	return strings.TrimSuffix(SystemdJobSlice, ".slice") + "-" + strings.ReplaceAll(jobName, "-", `\x2d`) + ".slice"
+}
+
+func systemdJobSliceWithLimitsUnit(jobName string, properties []string) string {
+	return fmt.Sprintf("[Unit]\nDescription=BOSH job %s\nBefore=slices.target\n\n[Slice]\n%s\n", jobName, strings.Join(properties, "\n"))
+}
+
+func systemdServiceUnit(jobName string, process monitProcess, slice string) string {
+	var unit strings.Builder
+
+	fmt.Fprintf(&unit, "[Unit]\nDescription=BOSH job %s process %s\n", jobName, process.Name)
+	for _, dependency := range process.DependsOn {
+		fmt.Fprintf(&unit, "Requires=%s\nAfter=%s\n", unitNameForProcess(dependency), unitNameForProcess(dependency))
+	}
+
+	fmt.Fprintf(&unit, "\n[Service]\nSlice=%s\nType=forking\n", slice)
+	if process.PidFile != "" {
+		fmt.Fprintf(&unit, "PIDFile=%s\n", process.PidFile)
+	}
+
+	writeExec(&unit, "ExecStart", process.Start)
+	if process.Start.TimeoutSeconds > 0 {
+		fmt.Fprintf(&unit, "TimeoutStartSec=%d\n", process.Start.TimeoutSeconds)
+	}
+
+	if process.Stop.Command != "" {
+		writeExec(&unit, "ExecStop", process.Stop)
+		if process.Stop.TimeoutSeconds > 0 {
+			fmt.Fprintf(&unit, "TimeoutStopSec=%d\n", process.Stop.TimeoutSeconds)
+		}
+	}
+
+	// Monit runs start and stop programs as root unless told otherwise;
+	// systemd only supports a single identity per service
+	if process.Start.UID != "" {
+		fmt.Fprintf(&unit, "User=%s\n", process.Start.UID)
+	}
+	if process.Start.GID != "" {
+		fmt.Fprintf(&unit, "Group=%s\n", process.Start.GID)
+	}
+
+	unit.WriteString("Restart=on-failure\nRestartSec=10\n")
+
+	return unit.String()
+}
+
+func writeExec(unit *strings.Builder, directive string, program monitProgram) {
+	// Monit splits program strings into arguments itself; running them through a
+	// shell handles the quoting used in job monit files the same way
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$").Replace(program.Command)
+	fmt.Fprintf(unit, "%s=/bin/sh -c \"%s\"\n", directive, escaped)
+}
@@ -0,0 +1,442 @@
+package jobsupervisor_test
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/v2/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	. "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
+	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+var _ = Describe("systemdJobSupervisor", func() {
+	const showCmd = "systemctl show --property=Id,ActiveState,SubState,Result,ActiveEnterTimestampMonotonic,MemoryCurrent,CPUUsageNSec,NRestarts bosh-job-nginx.service bosh-job-worker.service"
+
+	var (
+		fs          *fakesys.FakeFileSystem
+		runner      *fakesys.FakeCmdRunner
+		timeService *fakeclock.FakeClock
+		supervisor  JobSupervisor
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		runner = fakesys.NewFakeCmdRunner()
+		timeService = fakeclock.NewFakeClock(time.Date(2024, time.January, 4, 10, 21, 30, 0, time.UTC))
+
+		supervisor = NewSystemdJobSupervisor(
+			fs,
+			runner,
+			boshlog.NewLogger(boshlog.LevelNone),
+			boshdir.NewProvider("/var/vcap"),
+			SystemdJobSupervisorOptions{
+				UnitDir:              "/etc/systemd/system",
+				RuntimeUnitDir:       "/run/systemd/system",
+				FailureCheckInterval: 10 * time.Second,
+			},
+			timeService,
+		)
+
+		fs.SetGlob("/etc/systemd/system/bosh-job-*.service", []string{
+			"/etc/systemd/system/bosh-job-worker.service",
+			"/etc/systemd/system/bosh-job-nginx.service",
+		})
+	})
+
+	unitState := func(name, activeState string, nRestarts string) string {
+		return "Id=bosh-job-" + name + ".service\n" +
+			"ActiveState=" + activeState + "\n" +
+			"SubState=running\n" +
+			"Result=exit-code\n" +
+			"ActiveEnterTimestampMonotonic=3600000000\n" +
+			"MemoryCurrent=2097152\n" +
+			"CPUUsageNSec=1000000000\n" +
+			"NRestarts=" + nRestarts + "\n"
+	}
+
+	Describe("AddJob", func() {
+		It("translates monit process checks into systemd units", func() {
+			err := fs.WriteFileString("/fake/nginx.monitrc", `
+check process nginx
+  with pidfile /var/vcap/sys/run/nginx/nginx.pid
+  start program "/var/vcap/jobs/nginx/bin/ctl start" with timeout 60 seconds
+  stop program "/var/vcap/jobs/nginx/bin/ctl stop"
+  group vcap
+  depends on worker
+  if totalmem > 100 Mb then restart
+
+# sidecar processes are supervised too
+check process worker
+  start program "/bin/bash -c 'echo $HOME > /tmp/out; exec /var/vcap/jobs/nginx/bin/worker'"
+    as uid vcap and gid vcap
+  group vcap
+
+check file nginx_config with path /var/vcap/jobs/nginx/config/nginx.conf
+  if changed checksum then alert
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.AddJob("nginx", 0, "/fake/nginx.monitrc")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/etc/systemd/system/bosh-jobs.slice")).To(BeTrue())
+
+			nginxUnit, err := fs.ReadFileString("/etc/systemd/system/bosh-job-nginx.service")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nginxUnit).To(Equal(`[Unit]
+Description=BOSH job nginx process nginx
+Requires=bosh-job-worker.service
+After=bosh-job-worker.service
+
+[Service]
+Slice=bosh-jobs.slice
+Type=forking
+PIDFile=/var/vcap/sys/run/nginx/nginx.pid
+ExecStart=/bin/sh -c "/var/vcap/jobs/nginx/bin/ctl start"
+TimeoutStartSec=60
+ExecStop=/bin/sh -c "/var/vcap/jobs/nginx/bin/ctl stop"
+Restart=on-failure
+RestartSec=10
+`))
+
+			workerUnit, err := fs.ReadFileString("/etc/systemd/system/bosh-job-worker.service")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(workerUnit).To(Equal(`[Unit]
+Description=BOSH job nginx process worker
+
+[Service]
+Slice=bosh-jobs.slice
+Type=forking
+ExecStart=/bin/sh -c "/bin/bash -c 'echo $$HOME > /tmp/out; exec /var/vcap/jobs/nginx/bin/worker'"
+User=vcap
+Group=vcap
+Restart=on-failure
+RestartSec=10
+`))
+
+			Expect(fs.FileExists("/etc/systemd/system/bosh-job-nginx_config.service")).To(BeFalse())
+		})
+
+		It("places the units of jobs with resource limits in a slice with the limits", func() {
+			err := fs.WriteFileString("/fake/web-server.monitrc", `
+check process nginx
+  start program "/var/vcap/jobs/web-server/bin/ctl start"
+  group vcap
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.AddJobWithResourceLimits("web-server_sidecar", 0, "/fake/web-server.monitrc", "web-server", models.ResourceLimits{CPUWeight: 50, MemoryMax: "1G"})
+			Expect(err).ToNot(HaveOccurred())
+
+			slice, err := fs.ReadFileString(`/etc/systemd/system/bosh-jobs-web\x2dserver.slice`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(slice).To(Equal(`[Unit]
+Description=BOSH job web-server
+Before=slices.target
+
+[Slice]
+CPUWeight=50
+IOWeight=100
+MemoryMax=1073741824
+MemoryHigh=infinity
+TasksMax=infinity
+`))
+
+			nginxUnit, err := fs.ReadFileString("/etc/systemd/system/bosh-job-nginx.service")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nginxUnit).To(ContainSubstring("Slice=bosh-jobs-web\\x2dserver.slice\n"))
+			Expect(nginxUnit).ToNot(ContainSubstring("bosh-job-cgroup-exec"))
+		})
+
+		It("returns an error for invalid resource limits", func() {
+			err := supervisor.AddJobWithResourceLimits("nginx", 0, "/fake/nginx.monitrc", "nginx", models.ResourceLimits{MemoryMax: "lots"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating resource limits for job 'nginx'"))
+		})
+
+		It("returns an error when a process has no start program", func() {
+			err := fs.WriteFileString("/fake/broken.monitrc", "check process broken\n  group vcap\n")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.AddJob("broken", 0, "/fake/broken.monitrc")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("does not specify a start program"))
+		})
+
+		It("returns an error when the monit file cannot be read", func() {
+			err := supervisor.AddJob("missing", 0, "/fake/missing.monitrc")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading job config from file"))
+		})
+	})
+
+	Describe("RemoveAllJobs", func() {
+		It("marks the units and slices for removal without stopping them", func() {
+			fs.SetGlob("/etc/systemd/system/bosh-jobs-*.slice", []string{"/etc/systemd/system/bosh-jobs-nginx.slice"})
+
+			Expect(supervisor.RemoveAllJobs()).To(Succeed())
+
+			Expect(runner.RunCommands).To(BeEmpty())
+			Expect(fs.ReadFileString("/var/vcap/bosh/systemd_jobs_pending_removal")).To(Equal(
+				"bosh-job-nginx.service\nbosh-job-worker.service\nbosh-jobs-nginx.slice\n",
+			))
+		})
+
+		It("does nothing when there are no units", func() {
+			fs.SetGlob("/etc/systemd/system/bosh-job-*.service", []string{})
+
+			Expect(supervisor.RemoveAllJobs()).To(Succeed())
+			Expect(runner.RunCommands).To(BeEmpty())
+			Expect(fs.FileExists("/var/vcap/bosh/systemd_jobs_pending_removal")).To(BeFalse())
+		})
+	})
+
+	Describe("Start", func() {
+		It("re-monitors and starts all job units", func() {
+			Expect(fs.WriteFileString("/run/systemd/system/bosh-job-.service.d/unmonitor.conf", "")).To(Succeed())
+			Expect(fs.WriteFileString("/var/vcap/bosh/jobs_stopped", "")).To(Succeed())
+
+			Expect(supervisor.Start()).To(Succeed())
+
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "daemon-reload"},
+				{"systemctl", "start", "bosh-job-nginx.service", "bosh-job-worker.service"},
+			}))
+			Expect(fs.FileExists("/run/systemd/system/bosh-job-.service.d/unmonitor.conf")).To(BeFalse())
+			Expect(fs.FileExists("/var/vcap/bosh/jobs_stopped")).To(BeFalse())
+		})
+
+		Context("when jobs were removed", func() {
+			BeforeEach(func() {
+				Expect(fs.WriteFileString("/etc/systemd/system/bosh-job-nginx.service", "")).To(Succeed())
+				Expect(fs.WriteFileString("/etc/systemd/system/bosh-job-worker.service", "")).To(Succeed())
+				Expect(fs.WriteFileString("/etc/systemd/system/bosh-jobs-worker.slice", "")).To(Succeed())
+				fs.SetGlob("/etc/systemd/system/bosh-jobs-*.slice", []string{"/etc/systemd/system/bosh-jobs-worker.slice"})
+
+				Expect(supervisor.RemoveAllJobs()).To(Succeed())
+
+				Expect(fs.WriteFileString("/fake/nginx.monitrc", `
+check process nginx
+  start program "/var/vcap/jobs/nginx/bin/ctl start"
+  group vcap
+`)).To(Succeed())
+				Expect(supervisor.AddJob("nginx", 0, "/fake/nginx.monitrc")).To(Succeed())
+			})
+
+			It("stops, disables and removes the units of jobs that were not added again", func() {
+				Expect(supervisor.Start()).To(Succeed())
+
+				Expect(runner.RunCommands).To(Equal([][]string{
+					{"systemctl", "stop", "bosh-job-worker.service"},
+					{"systemctl", "disable", "bosh-job-worker.service"},
+					{"systemctl", "daemon-reload"},
+					{"systemctl", "start", "bosh-job-nginx.service"},
+				}))
+				Expect(fs.FileExists("/etc/systemd/system/bosh-job-nginx.service")).To(BeTrue())
+				Expect(fs.FileExists("/etc/systemd/system/bosh-job-worker.service")).To(BeFalse())
+				Expect(fs.FileExists("/etc/systemd/system/bosh-jobs-worker.slice")).To(BeFalse())
+				Expect(fs.FileExists("/var/vcap/bosh/systemd_jobs_pending_removal")).To(BeFalse())
+			})
+
+			It("keeps the units when stopping them fails", func() {
+				runner.AddCmdResult("systemctl stop bosh-job-worker.service", fakesys.FakeCmdResult{Error: errors.New("fake-stop-err")})
+
+				Expect(supervisor.Start()).To(MatchError(ContainSubstring("fake-stop-err")))
+				Expect(fs.FileExists("/etc/systemd/system/bosh-job-worker.service")).To(BeTrue())
+				Expect(fs.FileExists("/var/vcap/bosh/systemd_jobs_pending_removal")).To(BeTrue())
+			})
+		})
+
+		It("returns an error when starting units fails", func() {
+			runner.AddCmdResult("systemctl start bosh-job-nginx.service bosh-job-worker.service", fakesys.FakeCmdResult{Error: errors.New("fake-start-err")})
+
+			err := supervisor.Start()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-start-err"))
+		})
+	})
+
+	Describe("Stop", func() {
+		It("stops all job units without waiting and records that jobs are stopped", func() {
+			Expect(supervisor.Stop()).To(Succeed())
+
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "stop", "--no-block", "bosh-job-nginx.service", "bosh-job-worker.service"},
+			}))
+			Expect(fs.FileExists("/var/vcap/bosh/jobs_stopped")).To(BeTrue())
+		})
+	})
+
+	Describe("StopAndWait", func() {
+		It("stops all job units and waits for them", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{
+				Stdout: unitState("nginx", "inactive", "0") + "\n" + unitState("worker", "inactive", "0"),
+			})
+
+			Expect(supervisor.StopAndWait()).To(Succeed())
+
+			Expect(runner.RunCommands[0]).To(Equal([]string{"systemctl", "stop", "bosh-job-nginx.service", "bosh-job-worker.service"}))
+			Expect(fs.FileExists("/var/vcap/bosh/jobs_stopped")).To(BeTrue())
+		})
+
+		It("returns an error when units failed to stop", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{
+				Stdout: unitState("nginx", "failed", "0") + "\n" + unitState("worker", "inactive", "0"),
+			})
+
+			err := supervisor.StopAndWait()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("bosh-job-nginx.service"))
+		})
+	})
+
+	Describe("Unmonitor", func() {
+		It("disables restarts with a runtime drop-in", func() {
+			Expect(supervisor.Unmonitor()).To(Succeed())
+
+			dropIn, err := fs.ReadFileString("/run/systemd/system/bosh-job-.service.d/unmonitor.conf")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dropIn).To(Equal("[Service]\nRestart=no\n"))
+			Expect(runner.RunCommands).To(Equal([][]string{{"systemctl", "daemon-reload"}}))
+		})
+	})
+
+	Describe("Status", func() {
+		It("returns running when all units are active", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{
+				Stdout: unitState("nginx", "active", "0") + "\n" + unitState("worker", "active", "0"),
+			})
+
+			Expect(supervisor.Status()).To(Equal("running"))
+		})
+
+		It("returns starting when a unit is activating", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{
+				Stdout: unitState("nginx", "failed", "0") + "\n" + unitState("worker", "activating", "0"),
+			})
+
+			Expect(supervisor.Status()).To(Equal("starting"))
+		})
+
+		It("returns failing when a unit is not active", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{
+				Stdout: unitState("nginx", "active", "0") + "\n" + unitState("worker", "failed", "0"),
+			})
+
+			Expect(supervisor.Status()).To(Equal("failing"))
+		})
+
+		It("returns stopped when jobs were stopped", func() {
+			Expect(fs.WriteFileString("/var/vcap/bosh/jobs_stopped", "")).To(Succeed())
+
+			Expect(supervisor.Status()).To(Equal("stopped"))
+		})
+
+		It("returns unknown when unit states cannot be retrieved", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Error: errors.New("fake-show-err")})
+
+			Expect(supervisor.Status()).To(Equal("unknown"))
+		})
+	})
+
+	Describe("Processes", func() {
+		It("reports unit states, uptime, memory and cpu usage", func() {
+			Expect(fs.WriteFileString("/proc/meminfo", "MemTotal:        8192 kB\nMemFree:         1024 kB\n")).To(Succeed())
+			Expect(fs.WriteFileString("/proc/uptime", "3660.25 7000.00\n")).To(Succeed())
+
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{
+				Stdout: unitState("nginx", "active", "0") + "\n" + unitState("worker", "failed", "3"),
+			})
+
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes).To(Equal([]Process{
+				{
+					Name:   "nginx",
+					State:  "running",
+					Uptime: UptimeVitals{Secs: 60},
+					Memory: MemoryVitals{Kb: 2048, Percent: 25},
+					CPU:    CPUVitals{Total: 0},
+				},
+				{
+					Name:   "worker",
+					State:  "failing",
+					Memory: MemoryVitals{Kb: 2048, Percent: 25},
+					CPU:    CPUVitals{Total: 0},
+				},
+			}))
+
+			timeService.Increment(10 * time.Second)
+			Expect(fs.WriteFileString("/proc/uptime", "3670.25 7010.00\n")).To(Succeed())
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{
+				Stdout: strings.Replace(unitState("nginx", "active", "0"), "CPUUsageNSec=1000000000", "CPUUsageNSec=6000000000", 1) +
+					"\n" + unitState("worker", "failed", "3"),
+			})
+
+			processes, err = supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes[0].Uptime.Secs).To(Equal(70))
+			Expect(processes[0].CPU.Total).To(Equal(float64(50)))
+			Expect(processes[1].CPU.Total).To(Equal(float64(0)))
+		})
+
+		It("returns an error when unit states cannot be retrieved", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Error: errors.New("fake-show-err")})
+
+			_, err := supervisor.Processes()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-show-err"))
+		})
+	})
+
+	Describe("MonitorJobFailures", func() {
+		It("reports restarted and failed units as monit alerts", func() {
+			alerts := make(chan boshalert.MonitAlert, 10)
+
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{
+				Stdout: unitState("nginx", "active", "0") + "\n" + unitState("worker", "active", "0"),
+			})
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{
+				Stdout: unitState("nginx", "active", "2") + "\n" + unitState("worker", "failed", "0"),
+				Sticky: true,
+			})
+
+			go supervisor.MonitorJobFailures(func(alert boshalert.MonitAlert) error { //nolint:errcheck
+				alerts <- alert
+				return nil
+			})
+
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+			timeService.Increment(10 * time.Second)
+			Consistently(alerts).ShouldNot(Receive())
+
+			timeService.Increment(10 * time.Second)
+
+			var alert boshalert.MonitAlert
+			Eventually(alerts).Should(Receive(&alert))
+			Expect(alert.Service).To(Equal("nginx"))
+			Expect(alert.Event).To(Equal("does not exist"))
+			Expect(alert.Action).To(Equal("restart"))
+			Expect(alert.Description).To(ContainSubstring("restarted 2 time(s)"))
+			Expect(alert.Date).To(Equal("Thu, 04 Jan 2024 10:21:50 +0000"))
+
+			Eventually(alerts).Should(Receive(&alert))
+			Expect(alert.Service).To(Equal("worker"))
+			Expect(alert.Event).To(Equal("execution failed"))
+			Expect(alert.Description).To(ContainSubstring("failed with result 'exit-code'"))
+
+			timeService.Increment(10 * time.Second)
+			Consistently(alerts).ShouldNot(Receive())
+		})
+	})
+})
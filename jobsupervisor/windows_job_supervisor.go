@@ -28,6 +28,7 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	boshalert "github.com/cloudfoundry/bosh-agent/v2/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 )
 
@@ -317,6 +318,10 @@ func (w *windowsJobSupervisor) Processes() ([]Process, error) {
 	return procs, nil
 }
 
+func (w *windowsJobSupervisor) AddJobWithResourceLimits(jobName string, jobIndex int, configPath string, limitsName string, limits models.ResourceLimits) error {
+	return bosherr.Errorf("Resource limits for job '%s' are not supported on Windows", limitsName)
+}
+
 func (w *windowsJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
 	configFileContents, err := w.fs.ReadFile(configPath)
 	if err != nil {
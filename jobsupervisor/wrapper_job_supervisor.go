@@ -7,6 +7,7 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	"github.com/cloudfoundry/bosh-utils/system"
 
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	"github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 )
 
@@ -64,6 +65,9 @@ func (w *wrapperJobSupervisor) Processes() ([]Process, error) {
 func (w *wrapperJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
 	return w.delegate.AddJob(jobName, jobIndex, configPath)
 }
+func (w *wrapperJobSupervisor) AddJobWithResourceLimits(jobName string, jobIndex int, configPath string, limitsName string, limits models.ResourceLimits) error {
+	return w.delegate.AddJobWithResourceLimits(jobName, jobIndex, configPath, limitsName, limits)
+}
 func (w *wrapperJobSupervisor) RemoveAllJobs() error {
 	return w.delegate.RemoveAllJobs()
 }
@@ -92,6 +92,9 @@ func main() {
 		case "compile":
 			compileTarball(cmd, os.Args[2:])
 			return
+		case "verify":
+			verifyTarball(cmd, os.Args[2:])
+			return
 		}
 	}
 
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 
+	boshagentblobstore "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore"
 	"github.com/cloudfoundry/bosh-agent/v2/app"
 	"github.com/cloudfoundry/bosh-agent/v2/releasetarball"
 	"github.com/cloudfoundry/bosh-agent/v2/settings/directories"
@@ -36,28 +37,62 @@ func compileTarball(command string, args []string) {
 		log.Fatal(err)
 	}
 
-	compiler, err := releasetarball.NewCompiler(dirProvider)
+	compilers, err := releasetarball.NewParallelCompilers(dirProvider, options.Parallel, options.Compile.Reproducible, options.BlobCacheMaxSizeMB)
 	if err != nil {
 		log.Fatal(err)
 	}
+	report := releasetarball.Report{Stemcell: compiledReleaseFileSuffix}
 	for _, releaseTarballPath := range options.SourceReleases {
-		compiledReleaseTarballPath, err := releasetarball.Compile(compiler, releaseTarballPath, dirProvider.BlobsDir(), options.OutputDirectory, compiledReleaseFileSuffix)
+		releaseReport, err := releasetarball.CompileParallel(compilers, options.Compile, releaseTarballPath, dirProvider.BlobsDir(), options.OutputDirectory, compiledReleaseFileSuffix)
+		report.Releases = append(report.Releases, releaseReport)
 		if err != nil {
-			log.Fatal(err)
+			if !options.KeepGoing {
+				writeCompileReport(options.OutputDirectory, report)
+				log.Fatal(err)
+			}
+			log.Printf("Failed to compile %s: %s", releaseTarballPath, err)
+			continue
 		}
-		log.Printf("Finished archiving compiled tarball %s", compiledReleaseTarballPath)
+		log.Printf("Finished archiving compiled tarball %s", releaseReport.CompiledTarball)
 	}
+	writeCompileReport(options.OutputDirectory, report)
+	if failed := report.Failed(); failed > 0 {
+		log.Fatalf("Failed to compile %d of %d releases", failed, len(report.Releases))
+	}
+}
+
+func writeCompileReport(outputDirectory string, report releasetarball.Report) {
+	reportPath, err := releasetarball.WriteReport(outputDirectory, report)
+	if err != nil {
+		log.Printf("Failed to write compile report: %s", err)
+		return
+	}
+	log.Printf("Wrote compile report %s", reportPath)
 }
 
 type CompileTarballOptions struct {
-	OutputDirectory string
-	SourceReleases  []string
+	OutputDirectory    string
+	SourceReleases     []string
+	Parallel           int
+	BlobCacheMaxSizeMB int
+	Compile            releasetarball.CompileOptions
+	KeepGoing          bool
 }
 
 func newCompileTarballOptions(command string, args []string) (CompileTarballOptions, error) {
 	var options CompileTarballOptions
 	flags := flag.NewFlagSet(command, flag.ExitOnError)
 	flags.StringVar(&options.OutputDirectory, "output-directory", "/tmp", "the directory to put the compiled release tarball")
+	flags.IntVar(&options.Parallel, "parallel", 1, "the number of packages to compile at the same time; parallel compilations install their dependencies in separate directories that are mounted at /var/vcap/packages in a private mount namespace, which requires root privileges and unshare")
+	flags.Func("compiled-release", "a compiled release tarball with packages to reuse when their fingerprints, dependencies and stemcell match; may be repeated", func(value string) error {
+		options.Compile.Reuse.CompiledReleases = append(options.Compile.Reuse.CompiledReleases, value)
+		return nil
+	})
+	flags.IntVar(&options.BlobCacheMaxSizeMB, "blob-cache-size-mb", boshagentblobstore.DefaultBlobCacheMaxSizeMB, "the maximum size in megabytes of the cache of blobs, such as compiled dependencies, that are installed more than once; a negative value disables it")
+	flags.StringVar(&options.Compile.Reuse.CacheDirectory, "cache-directory", "", "the directory to keep compiled packages in for reuse by later compilations")
+	flags.BoolVar(&options.Compile.Reproducible, "reproducible", false, "sort tarball entries and normalize their timestamps and ownership so that identical inputs produce identical tarballs")
+	flags.StringVar(&options.Compile.SigningKey, "signing-key", "", "the path to an Ed25519 or ECDSA P-256 private key to sign the release manifest of compiled tarballs with; the signature is written next to the tarball with the suffix "+releasetarball.SignatureSuffix)
+	flags.BoolVar(&options.KeepGoing, "keep-going", false, "compile the remaining tarballs after one fails and exit with a failure only at the end; the outcome is written to "+releasetarball.ReportFilename+" in the output directory either way")
 	flags.Usage = func() {
 		_, _ = fmt.Fprintf(flags.Output(), //nolint:errcheck
 			`The BOSH Agent %[1]s command creates BOSH Release tarballs with compiled packages from tarballs with source packages.
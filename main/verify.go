@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/cloudfoundry/bosh-agent/v2/releasetarball"
+)
+
+func verifyTarball(command string, args []string) {
+	options, err := newVerifyTarballOptions(command, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if options.SignaturePath != "" && len(options.Releases) > 1 {
+		log.Fatal("a signature can only be given when verifying a single tarball")
+	}
+
+	failed := 0
+	for _, releaseTarballPath := range options.Releases {
+		if err := releasetarball.Verify(releaseTarballPath, options.PublicKeyPath, options.SignaturePath); err != nil {
+			log.Printf("Failed to verify %s: %s", releaseTarballPath, err)
+			failed++
+			continue
+		}
+		log.Printf("Verified %s", releaseTarballPath)
+	}
+	if failed > 0 {
+		log.Fatalf("Failed to verify %d of %d tarballs", failed, len(options.Releases))
+	}
+}
+
+type VerifyTarballOptions struct {
+	PublicKeyPath string
+	SignaturePath string
+	Releases      []string
+}
+
+func newVerifyTarballOptions(command string, args []string) (VerifyTarballOptions, error) {
+	var options VerifyTarballOptions
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	flags.StringVar(&options.PublicKeyPath, "public-key", "", "the path to a PEM or OpenSSH public key to verify the release manifest signature with; the signature is not checked when empty")
+	flags.StringVar(&options.SignaturePath, "signature", "", "the path to the release manifest signature; defaults to the tarball path with the suffix "+releasetarball.SignatureSuffix)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(flags.Output(), //nolint:errcheck
+			`The BOSH Agent %[1]s command checks that the jobs and packages in BOSH Release tarballs match the digests in their release manifest
+and optionally that the release manifest was signed by the %[2]s command.
+
+Usage:
+
+	%[1]s [FLAGS] [TARBALL...]
+
+Flags:
+`, command, "compile")
+		flags.PrintDefaults()
+	}
+	err := flags.Parse(args)
+	options.Releases = flags.Args()
+	return options, err
+}
@@ -9,6 +9,7 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	boshagentblobstore "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore"
+	boshlogtailer "github.com/cloudfoundry/bosh-agent/v2/agent/logtailer"
 	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
 	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
@@ -53,7 +54,14 @@ func (p HandlerProvider) Get(
 		return NewNatsHandler(p.settingsService, f, p.logger, platform), nil
 	case "https":
 		mbusKeyPair := p.settingsService.GetSettings().GetMbusCerts()
-		return NewHTTPSHandler(mbusURL, mbusKeyPair, blobManager, p.logger, p.auditLogger), nil
+		logTailer := boshlogtailer.NewLogTailer(
+			platform.GetFs(),
+			platform.GetRunner(),
+			platform.GetDirProvider().LogsDir(),
+			boshlogtailer.DefaultPollInterval,
+			p.logger,
+		)
+		return NewHTTPSHandler(mbusURL, mbusKeyPair, blobManager, logTailer, p.logger, p.auditLogger), nil
 	default:
 		return nil, bosherr.Errorf("Message Bus Handler with scheme %s could not be found", mbusURL.Scheme)
 	}
@@ -2,10 +2,12 @@ package mbus
 
 import (
 	"bufio"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 
 	"github.com/cloudfoundry/bosh-agent/v2/platform"
 	"github.com/cloudfoundry/bosh-agent/v2/settings"
@@ -14,6 +16,7 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	boshagentblobstore "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore"
+	boshlogtailer "github.com/cloudfoundry/bosh-agent/v2/agent/logtailer"
 	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
 )
 
@@ -22,6 +25,7 @@ const httpsHandlerLogTag = "https_handler"
 type HTTPSHandler struct {
 	parsedURL   *url.URL
 	blobManager boshagentblobstore.BlobManagerInterface
+	logTailer   boshlogtailer.LogTailer
 	logger      boshlog.Logger
 	dispatcher  *HTTPSDispatcher
 	auditLogger platform.AuditLogger
@@ -31,6 +35,7 @@ func NewHTTPSHandler(
 	parsedURL *url.URL,
 	keyPair settings.CertKeyPair,
 	blobManager boshagentblobstore.BlobManagerInterface,
+	logTailer boshlogtailer.LogTailer,
 	logger boshlog.Logger,
 	auditLogger platform.AuditLogger,
 ) HTTPSHandler {
@@ -38,6 +43,7 @@ func NewHTTPSHandler(
 		parsedURL:   parsedURL,
 		logger:      logger,
 		blobManager: blobManager,
+		logTailer:   logTailer,
 		dispatcher:  NewHTTPSDispatcher(parsedURL, keyPair, logger),
 		auditLogger: auditLogger,
 	}
@@ -54,6 +60,7 @@ func (h HTTPSHandler) Run(handlerFunc boshhandler.Func) error {
 func (h HTTPSHandler) Start(handlerFunc boshhandler.Func) error {
 	h.dispatcher.AddRoute("/agent", h.agentHandler(handlerFunc))
 	h.dispatcher.AddRoute("/blobs/", h.blobsHandler())
+	h.dispatcher.AddRoute("/tail_logs", h.tailLogsHandler())
 	return h.dispatcher.Start()
 }
 
@@ -164,6 +171,77 @@ func (h HTTPSHandler) getBlob(w http.ResponseWriter, r *http.Request) {
 	h.generateCEFLog(r, statusCode, "")
 }
 
+// tailLogsHandler streams the lines appended to job logs as newline delimited
+// JSON until the client disconnects. Logs are selected with "filter" globs,
+// "from_offset" sets where to start each log and "journal=true" adds journald.
+func (h HTTPSHandler) tailLogsHandler() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.WriteHeader(404)
+			h.generateCEFLog(r, 404, "")
+
+			return
+		}
+
+		request, err := h.tailLogsRequest(r.URL.Query())
+		if err != nil {
+			w.WriteHeader(400)
+			h.generateCEFLog(r, 400, "")
+			if _, wErr := w.Write([]byte(err.Error())); wErr != nil {
+				h.logger.Error(httpsHandlerLogTag, "Failed to write response body: %s", wErr.Error())
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(200)
+		h.generateCEFLog(r, 200, "")
+
+		flusher, _ := w.(http.Flusher)
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		encoder := json.NewEncoder(w)
+
+		err = h.logTailer.Tail(r.Context(), request, func(line boshlogtailer.Line) error {
+			err := encoder.Encode(line)
+			if err == nil && flusher != nil {
+				flusher.Flush()
+			}
+			return err
+		})
+		if err != nil && r.Context().Err() == nil {
+			err = bosherr.WrapError(err, "Tailing logs")
+			h.logger.Error(httpsHandlerLogTag, err.Error())
+
+			// The status was sent already, so failures end the stream with an error object
+			if eErr := encoder.Encode(map[string]string{"error": err.Error()}); eErr != nil {
+				h.logger.Error(httpsHandlerLogTag, "Failed to write response body: %s", eErr.Error())
+			}
+		}
+	}
+}
+
+func (h HTTPSHandler) tailLogsRequest(query url.Values) (boshlogtailer.Request, error) {
+	request := boshlogtailer.Request{
+		Filters: query["filter"],
+		Journal: query.Get("journal") == "true",
+	}
+
+	if rawOffset := query.Get("from_offset"); rawOffset != "" {
+		offset, err := strconv.ParseInt(rawOffset, 10, 64)
+		if err != nil {
+			return request, bosherr.WrapErrorf(err, "Parsing from_offset '%s'", rawOffset)
+		}
+
+		request.FromOffset = &offset
+	}
+
+	return request, request.Validate()
+}
+
 func (h HTTPSHandler) generateCEFLog(r *http.Request, respStatusCode int, respJSON string) {
 	cef := boshhandler.NewCommonEventFormat()
 
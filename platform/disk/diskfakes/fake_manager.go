@@ -8,6 +8,16 @@ import (
 )
 
 type FakeManager struct {
+	GetEncryptorStub        func() disk.Encryptor
+	getEncryptorMutex       sync.RWMutex
+	getEncryptorArgsForCall []struct {
+	}
+	getEncryptorReturns struct {
+		result1 disk.Encryptor
+	}
+	getEncryptorReturnsOnCall map[int]struct {
+		result1 disk.Encryptor
+	}
 	GetEphemeralDevicePartitionerStub        func() disk.Partitioner
 	getEphemeralDevicePartitionerMutex       sync.RWMutex
 	getEphemeralDevicePartitionerArgsForCall []struct {
@@ -85,6 +95,59 @@ type FakeManager struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeManager) GetEncryptor() disk.Encryptor {
+	fake.getEncryptorMutex.Lock()
+	ret, specificReturn := fake.getEncryptorReturnsOnCall[len(fake.getEncryptorArgsForCall)]
+	fake.getEncryptorArgsForCall = append(fake.getEncryptorArgsForCall, struct {
+	}{})
+	stub := fake.GetEncryptorStub
+	fakeReturns := fake.getEncryptorReturns
+	fake.recordInvocation("GetEncryptor", []interface{}{})
+	fake.getEncryptorMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) GetEncryptorCallCount() int {
+	fake.getEncryptorMutex.RLock()
+	defer fake.getEncryptorMutex.RUnlock()
+	return len(fake.getEncryptorArgsForCall)
+}
+
+func (fake *FakeManager) GetEncryptorCalls(stub func() disk.Encryptor) {
+	fake.getEncryptorMutex.Lock()
+	defer fake.getEncryptorMutex.Unlock()
+	fake.GetEncryptorStub = stub
+}
+
+func (fake *FakeManager) GetEncryptorReturns(result1 disk.Encryptor) {
+	fake.getEncryptorMutex.Lock()
+	defer fake.getEncryptorMutex.Unlock()
+	fake.GetEncryptorStub = nil
+	fake.getEncryptorReturns = struct {
+		result1 disk.Encryptor
+	}{result1}
+}
+
+func (fake *FakeManager) GetEncryptorReturnsOnCall(i int, result1 disk.Encryptor) {
+	fake.getEncryptorMutex.Lock()
+	defer fake.getEncryptorMutex.Unlock()
+	fake.GetEncryptorStub = nil
+	if fake.getEncryptorReturnsOnCall == nil {
+		fake.getEncryptorReturnsOnCall = make(map[int]struct {
+			result1 disk.Encryptor
+		})
+	}
+	fake.getEncryptorReturnsOnCall[i] = struct {
+		result1 disk.Encryptor
+	}{result1}
+}
+
 func (fake *FakeManager) GetEphemeralDevicePartitioner() disk.Partitioner {
 	fake.getEphemeralDevicePartitionerMutex.Lock()
 	ret, specificReturn := fake.getEphemeralDevicePartitionerReturnsOnCall[len(fake.getEphemeralDevicePartitionerArgsForCall)]
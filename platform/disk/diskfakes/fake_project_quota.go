@@ -0,0 +1,190 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package diskfakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/v2/platform/disk"
+)
+
+type FakeProjectQuota struct {
+	IsSupportedStub        func(string) (bool, error)
+	isSupportedMutex       sync.RWMutex
+	isSupportedArgsForCall []struct {
+		arg1 string
+	}
+	isSupportedReturns struct {
+		result1 bool
+		result2 error
+	}
+	isSupportedReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	SetDirectoryQuotaStub        func(string, string, uint64) error
+	setDirectoryQuotaMutex       sync.RWMutex
+	setDirectoryQuotaArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 uint64
+	}
+	setDirectoryQuotaReturns struct {
+		result1 error
+	}
+	setDirectoryQuotaReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeProjectQuota) IsSupported(arg1 string) (bool, error) {
+	fake.isSupportedMutex.Lock()
+	ret, specificReturn := fake.isSupportedReturnsOnCall[len(fake.isSupportedArgsForCall)]
+	fake.isSupportedArgsForCall = append(fake.isSupportedArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.IsSupportedStub
+	fakeReturns := fake.isSupportedReturns
+	fake.recordInvocation("IsSupported", []interface{}{arg1})
+	fake.isSupportedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeProjectQuota) IsSupportedCallCount() int {
+	fake.isSupportedMutex.RLock()
+	defer fake.isSupportedMutex.RUnlock()
+	return len(fake.isSupportedArgsForCall)
+}
+
+func (fake *FakeProjectQuota) IsSupportedCalls(stub func(string) (bool, error)) {
+	fake.isSupportedMutex.Lock()
+	defer fake.isSupportedMutex.Unlock()
+	fake.IsSupportedStub = stub
+}
+
+func (fake *FakeProjectQuota) IsSupportedArgsForCall(i int) string {
+	fake.isSupportedMutex.RLock()
+	defer fake.isSupportedMutex.RUnlock()
+	argsForCall := fake.isSupportedArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeProjectQuota) IsSupportedReturns(result1 bool, result2 error) {
+	fake.isSupportedMutex.Lock()
+	defer fake.isSupportedMutex.Unlock()
+	fake.IsSupportedStub = nil
+	fake.isSupportedReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeProjectQuota) IsSupportedReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.isSupportedMutex.Lock()
+	defer fake.isSupportedMutex.Unlock()
+	fake.IsSupportedStub = nil
+	if fake.isSupportedReturnsOnCall == nil {
+		fake.isSupportedReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.isSupportedReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeProjectQuota) SetDirectoryQuota(arg1 string, arg2 string, arg3 uint64) error {
+	fake.setDirectoryQuotaMutex.Lock()
+	ret, specificReturn := fake.setDirectoryQuotaReturnsOnCall[len(fake.setDirectoryQuotaArgsForCall)]
+	fake.setDirectoryQuotaArgsForCall = append(fake.setDirectoryQuotaArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 uint64
+	}{arg1, arg2, arg3})
+	stub := fake.SetDirectoryQuotaStub
+	fakeReturns := fake.setDirectoryQuotaReturns
+	fake.recordInvocation("SetDirectoryQuota", []interface{}{arg1, arg2, arg3})
+	fake.setDirectoryQuotaMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeProjectQuota) SetDirectoryQuotaCallCount() int {
+	fake.setDirectoryQuotaMutex.RLock()
+	defer fake.setDirectoryQuotaMutex.RUnlock()
+	return len(fake.setDirectoryQuotaArgsForCall)
+}
+
+func (fake *FakeProjectQuota) SetDirectoryQuotaCalls(stub func(string, string, uint64) error) {
+	fake.setDirectoryQuotaMutex.Lock()
+	defer fake.setDirectoryQuotaMutex.Unlock()
+	fake.SetDirectoryQuotaStub = stub
+}
+
+func (fake *FakeProjectQuota) SetDirectoryQuotaArgsForCall(i int) (string, string, uint64) {
+	fake.setDirectoryQuotaMutex.RLock()
+	defer fake.setDirectoryQuotaMutex.RUnlock()
+	argsForCall := fake.setDirectoryQuotaArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeProjectQuota) SetDirectoryQuotaReturns(result1 error) {
+	fake.setDirectoryQuotaMutex.Lock()
+	defer fake.setDirectoryQuotaMutex.Unlock()
+	fake.SetDirectoryQuotaStub = nil
+	fake.setDirectoryQuotaReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeProjectQuota) SetDirectoryQuotaReturnsOnCall(i int, result1 error) {
+	fake.setDirectoryQuotaMutex.Lock()
+	defer fake.setDirectoryQuotaMutex.Unlock()
+	fake.SetDirectoryQuotaStub = nil
+	if fake.setDirectoryQuotaReturnsOnCall == nil {
+		fake.setDirectoryQuotaReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setDirectoryQuotaReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeProjectQuota) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeProjectQuota) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ disk.ProjectQuota = new(FakeProjectQuota)
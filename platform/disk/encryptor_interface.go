@@ -0,0 +1,29 @@
+package disk
+
+import (
+	"path"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Encryptor
+
+// Encryptor keeps the file system of a partition in a dm-crypt container
+type Encryptor interface {
+	// IsEncrypted returns true when the partition holds a LUKS container
+	IsEncrypted(partitionPath string) (bool, error)
+
+	// Create formats the partition as a LUKS2 container, destroying its contents
+	Create(partitionPath, key string) error
+
+	// Open maps the container of the partition to MappedDevicePath(mappingName) unless it is already mapped
+	Open(partitionPath, mappingName, key string) (mappedPath string, err error)
+
+	// Resize grows an open mapping to the size of its partition
+	Resize(mappingName, key string) error
+
+	// Close removes the mapping unless it is already removed
+	Close(mappingName string) error
+}
+
+func MappedDevicePath(mappingName string) string {
+	return path.Join("/dev/mapper", mappingName)
+}
@@ -6,12 +6,38 @@ const (
 	FileSystemSwap    FileSystemType = "swap"
 	FileSystemExt4    FileSystemType = "ext4"
 	FileSystemXFS     FileSystemType = "xfs"
+	FileSystemBTRFS   FileSystemType = "btrfs"
 	FileSystemDefault FileSystemType = ""
 
-	FileSystemExtResizeUtility = "resize2fs"
-	FileSystemXFSResizeUtility = "xfs_growfs"
+	// Ext4 and XFS file systems that are mounted with project quotas
+	// so that the disk space of directories can be limited
+	FileSystemExt4ProjectQuota FileSystemType = "ext4-prjquota"
+	FileSystemXFSProjectQuota  FileSystemType = "xfs-prjquota"
+
+	FileSystemExtResizeUtility   = "resize2fs"
+	FileSystemXFSResizeUtility   = "xfs_growfs"
+	FileSystemBTRFSResizeUtility = "btrfs"
+
+	ProjectQuotaMountOption = "prjquota"
 )
 
+// HasProjectQuotas returns true for file system types that are mounted with project quotas
+func (t FileSystemType) HasProjectQuotas() bool {
+	return t == FileSystemExt4ProjectQuota || t == FileSystemXFSProjectQuota
+}
+
+// OnDiskType returns the type that blkid reports for file systems of this type
+func (t FileSystemType) OnDiskType() FileSystemType {
+	switch t {
+	case FileSystemExt4ProjectQuota:
+		return FileSystemExt4
+	case FileSystemXFSProjectQuota:
+		return FileSystemXFS
+	default:
+		return t
+	}
+}
+
 type Formatter interface {
 	Format(partitionPath string, fsType FileSystemType) (err error)
 	GetPartitionFormatType(string) (FileSystemType, error)
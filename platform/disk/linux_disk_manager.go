@@ -18,6 +18,7 @@ type linuxDiskManager struct {
 	diskUtil              Util
 
 	formatter Formatter
+	encryptor Encryptor
 
 	mounter        Mounter
 	mountsSearcher MountsSearcher
@@ -82,6 +83,7 @@ func NewLinuxDiskManager(
 	return linuxDiskManager{
 		ephemeralPartitioner:  ephemeralPartitioner,
 		diskUtil:              diskUtil,
+		encryptor:             NewLuksEncryptor(runner, fs),
 		formatter:             NewLinuxFormatter(runner, fs),
 		fs:                    fs,
 		logger:                logger,
@@ -111,6 +113,7 @@ func (m linuxDiskManager) GetPersistentDevicePartitioner(partitionerType string)
 	}
 }
 
+func (m linuxDiskManager) GetEncryptor() Encryptor           { return m.encryptor }
 func (m linuxDiskManager) GetFormatter() Formatter           { return m.formatter }
 func (m linuxDiskManager) GetMounter() Mounter               { return m.mounter }
 func (m linuxDiskManager) GetMountsSearcher() MountsSearcher { return m.mountsSearcher }
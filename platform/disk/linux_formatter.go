@@ -2,6 +2,7 @@ package disk
 
 import (
 	"regexp"
+	"slices"
 	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -31,8 +32,11 @@ func (f linuxFormatter) Format(partitionPath string, fsType FileSystemType) erro
 			return err
 		}
 		// swap is not user-configured, so we're not concerned about reformatting
-	} else if existingFsType == FileSystemExt4 || existingFsType == FileSystemXFS {
+	} else if existingFsType == FileSystemExt4 || existingFsType == FileSystemXFS || existingFsType == FileSystemBTRFS {
 		// never reformat if it is already formatted in a supported format
+		if fsType == FileSystemExt4ProjectQuota && existingFsType == FileSystemExt4 {
+			return f.enableExt4ProjectQuota(partitionPath)
+		}
 		return err
 	}
 
@@ -54,11 +58,28 @@ func (f linuxFormatter) Format(partitionPath string, fsType FileSystemType) erro
 			return bosherr.WrapError(err, "Shelling out to mke2fs")
 		}
 
-	case FileSystemXFS:
+	case FileSystemExt4ProjectQuota:
+		err = f.makeFileSystemExt4(partitionPath, "-O", "quota,project")
+		if err != nil {
+			if strings.Contains(err.Error(), "apparently in use by the system") {
+				err = f.makeFileSystemExt4(partitionPath, "-O", "quota,project")
+			}
+		}
+		if err != nil {
+			return bosherr.WrapError(err, "Shelling out to mke2fs")
+		}
+
+	case FileSystemXFS, FileSystemXFSProjectQuota:
 		_, _, _, err = f.runner.RunCommand("mkfs.xfs", partitionPath)
 		if err != nil {
 			return bosherr.WrapError(err, "Shelling out to mkfs.xfs")
 		}
+
+	case FileSystemBTRFS:
+		_, _, _, err = f.runner.RunCommand("mkfs.btrfs", partitionPath)
+		if err != nil {
+			return bosherr.WrapError(err, "Shelling out to mkfs.btrfs")
+		}
 	case FileSystemDefault:
 		return nil
 	}
@@ -66,6 +87,40 @@ func (f linuxFormatter) Format(partitionPath string, fsType FileSystemType) erro
 	return nil
 }
 
+// enableExt4ProjectQuota turns on the features that project quotas need on an
+// existing ext4 file system; tune2fs requires the file system to be unmounted
+func (f linuxFormatter) enableExt4ProjectQuota(partitionPath string) error {
+	stdout, _, _, err := f.runner.RunCommand("tune2fs", "-l", partitionPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Shelling out to tune2fs")
+	}
+
+	var enabledFeatures []string
+	for _, line := range strings.Split(stdout, "\n") {
+		if strings.HasPrefix(line, "Filesystem features:") {
+			enabledFeatures = strings.Fields(strings.TrimPrefix(line, "Filesystem features:"))
+		}
+	}
+
+	var missingFeatures []string
+	for _, feature := range []string{"quota", "project"} {
+		if !slices.Contains(enabledFeatures, feature) {
+			missingFeatures = append(missingFeatures, feature)
+		}
+	}
+
+	if len(missingFeatures) == 0 {
+		return nil
+	}
+
+	_, _, _, err = f.runner.RunCommand("tune2fs", "-O", strings.Join(missingFeatures, ","), partitionPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Enabling project quotas with tune2fs")
+	}
+
+	return nil
+}
+
 func (f linuxFormatter) GrowFilesystem(partitionPath string) error {
 	existingFsType, err := f.GetPartitionFormatType(partitionPath)
 	if err != nil {
@@ -91,19 +146,37 @@ func (f linuxFormatter) GrowFilesystem(partitionPath string) error {
 		if err != nil {
 			return bosherr.WrapError(err, "Failed to grow XFS filesystem")
 		}
+	case FileSystemBTRFS:
+		// btrfs can only be grown through the mount point of the file system
+		stdout, _, _, err := f.runner.RunCommand("findmnt", "-n", "-f", "-o", "TARGET", "--source", partitionPath)
+		if err != nil {
+			return bosherr.WrapError(err, "Finding mount point of BTRFS filesystem")
+		}
+
+		_, _, _, err = f.runner.RunCommand(
+			"btrfs",
+			"filesystem",
+			"resize",
+			"max",
+			strings.TrimSpace(stdout),
+		)
+		if err != nil {
+			return bosherr.WrapError(err, "Failed to grow BTRFS filesystem")
+		}
 	case FileSystemDefault, FileSystemSwap:
 		return nil
 	}
 	return nil
 }
 
-func (f linuxFormatter) makeFileSystemExt4(partitionPath string) error {
-	var err error
+func (f linuxFormatter) makeFileSystemExt4(partitionPath string, extraArgs ...string) error {
+	args := []string{"-t", string(FileSystemExt4), "-j"}
 	if f.fs.FileExists("/sys/fs/ext4/features/lazy_itable_init") {
-		_, _, _, err = f.runner.RunCommand("mke2fs", "-t", string(FileSystemExt4), "-j", "-E", "lazy_itable_init=1", partitionPath)
-	} else {
-		_, _, _, err = f.runner.RunCommand("mke2fs", "-t", string(FileSystemExt4), "-j", partitionPath)
+		args = append(args, "-E", "lazy_itable_init=1")
 	}
+	args = append(args, extraArgs...)
+
+	_, _, _, err := f.runner.RunCommand("mke2fs", append(args, partitionPath)...)
 	return err
 }
 
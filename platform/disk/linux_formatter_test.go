@@ -222,6 +222,135 @@ var _ = Describe("Linux Formatter", func() {
 				Expect(err.Error()).To(Equal("Shelling out to mkfs.xfs: Sadness"))
 			})
 		})
+
+		Context("when using xfs with project quotas", func() {
+			It("formats a blank disk with type xfs", func() {
+				fakeRunner := fakesys.NewFakeCmdRunner()
+				fakeFs := fakesys.NewFakeFileSystem()
+				fakeRunner.AddCmdResult("blkid -p /dev/xvda2", fakesys.FakeCmdResult{ExitStatus: 2, Error: errors.New("Exit code 2")})
+
+				formatter := NewLinuxFormatter(fakeRunner, fakeFs)
+				err := formatter.Format("/dev/xvda2", FileSystemXFSProjectQuota)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeRunner.RunCommands).To(Equal([][]string{
+					{"blkid", "-p", "/dev/xvda2"},
+					{"mkfs.xfs", "/dev/xvda2"},
+				}))
+			})
+		})
+
+		Context("when using ext4 with project quotas", func() {
+			It("formats a blank disk with the quota and project features", func() {
+				fakeRunner := fakesys.NewFakeCmdRunner()
+				fakeFs := fakesys.NewFakeFileSystem()
+				fakeRunner.AddCmdResult("blkid -p /dev/xvda2", fakesys.FakeCmdResult{ExitStatus: 2, Error: errors.New("Exit code 2")})
+
+				formatter := NewLinuxFormatter(fakeRunner, fakeFs)
+				err := formatter.Format("/dev/xvda2", FileSystemExt4ProjectQuota)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeRunner.RunCommands[1]).To(Equal([]string{"mke2fs", "-t", "ext4", "-j", "-O", "quota,project", "/dev/xvda2"}))
+			})
+
+			It("enables the missing features on an existing ext4 file system", func() {
+				fakeRunner := fakesys.NewFakeCmdRunner()
+				fakeFs := fakesys.NewFakeFileSystem()
+				fakeRunner.AddCmdResult("blkid -p /dev/xvda1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="ext4" yyyy zzzz`})
+				fakeRunner.AddCmdResult("tune2fs -l /dev/xvda1", fakesys.FakeCmdResult{
+					Stdout: "Filesystem volume name:   <none>\nFilesystem features:      has_journal ext_attr quota extent\nBlock size:               4096\n",
+				})
+
+				formatter := NewLinuxFormatter(fakeRunner, fakeFs)
+				err := formatter.Format("/dev/xvda1", FileSystemExt4ProjectQuota)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeRunner.RunCommands).To(Equal([][]string{
+					{"blkid", "-p", "/dev/xvda1"},
+					{"tune2fs", "-l", "/dev/xvda1"},
+					{"tune2fs", "-O", "project", "/dev/xvda1"},
+				}))
+			})
+
+			It("does not change an existing ext4 file system that has the features", func() {
+				fakeRunner := fakesys.NewFakeCmdRunner()
+				fakeFs := fakesys.NewFakeFileSystem()
+				fakeRunner.AddCmdResult("blkid -p /dev/xvda1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="ext4" yyyy zzzz`})
+				fakeRunner.AddCmdResult("tune2fs -l /dev/xvda1", fakesys.FakeCmdResult{
+					Stdout: "Filesystem features:      has_journal quota project extent\n",
+				})
+
+				formatter := NewLinuxFormatter(fakeRunner, fakeFs)
+				err := formatter.Format("/dev/xvda1", FileSystemExt4ProjectQuota)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeRunner.RunCommands).To(HaveLen(2))
+			})
+
+			It("returns an error when the features cannot be enabled", func() {
+				fakeRunner := fakesys.NewFakeCmdRunner()
+				fakeFs := fakesys.NewFakeFileSystem()
+				fakeRunner.AddCmdResult("blkid -p /dev/xvda1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="ext4" yyyy zzzz`})
+				fakeRunner.AddCmdResult("tune2fs -l /dev/xvda1", fakesys.FakeCmdResult{Stdout: "Filesystem features:      has_journal\n"})
+				fakeRunner.AddCmdResult("tune2fs -O quota,project /dev/xvda1", fakesys.FakeCmdResult{Error: errors.New("Sadness")})
+
+				formatter := NewLinuxFormatter(fakeRunner, fakeFs)
+				err := formatter.Format("/dev/xvda1", FileSystemExt4ProjectQuota)
+				Expect(err).To(MatchError("Enabling project quotas with tune2fs: Sadness"))
+			})
+		})
+
+		Context("when using btrfs", func() {
+			It("formats a blank disk with type btrfs", func() {
+				fakeRunner := fakesys.NewFakeCmdRunner()
+				fakeFs := fakesys.NewFakeFileSystem()
+				fakeRunner.AddCmdResult("blkid -p /dev/xvda2", fakesys.FakeCmdResult{ExitStatus: 2, Error: errors.New("Exit code 2")})
+
+				formatter := NewLinuxFormatter(fakeRunner, fakeFs)
+				err := formatter.Format("/dev/xvda2", FileSystemBTRFS)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(2).To(Equal(len(fakeRunner.RunCommands)))
+				Expect(fakeRunner.RunCommands[1]).To(Equal([]string{"mkfs.btrfs", "/dev/xvda2"}))
+			})
+
+			It("does not re-format if fs is already btrfs", func() {
+				fakeRunner := fakesys.NewFakeCmdRunner()
+				fakeFs := fakesys.NewFakeFileSystem()
+				fakeRunner.AddCmdResult("blkid -p /dev/xvda1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="btrfs" yyyy zzzz`})
+
+				formatter := NewLinuxFormatter(fakeRunner, fakeFs)
+				err := formatter.Format("/dev/xvda1", FileSystemExt4)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(1).To(Equal(len(fakeRunner.RunCommands)))
+			})
+
+			It("throws an error if formatting filesystem fails", func() {
+				fakeRunner := fakesys.NewFakeCmdRunner()
+				fakeFs := fakesys.NewFakeFileSystem()
+				fakeRunner.AddCmdResult("mkfs.btrfs /dev/xvda2", fakesys.FakeCmdResult{Error: errors.New("Sadness")})
+				fakeRunner.AddCmdResult("blkid -p /dev/xvda2", fakesys.FakeCmdResult{Stderr: "", ExitStatus: 2})
+
+				formatter := NewLinuxFormatter(fakeRunner, fakeFs)
+				err := formatter.Format("/dev/xvda2", FileSystemBTRFS)
+
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Shelling out to mkfs.btrfs: Sadness"))
+			})
+		})
+	})
+
+	Describe("GetPartitionFormatType", func() {
+		It("detects btrfs file systems", func() {
+			fakeRunner := fakesys.NewFakeCmdRunner()
+			fakeRunner.AddCmdResult("blkid -p /dev/xvda1", fakesys.FakeCmdResult{Stdout: `/dev/xvda1: UUID="fake-uuid" TYPE="btrfs" USAGE="filesystem"`})
+
+			formatter := NewLinuxFormatter(fakeRunner, fakesys.NewFakeFileSystem())
+			fsType, err := formatter.GetPartitionFormatType("/dev/xvda1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fsType).To(Equal(FileSystemBTRFS))
+		})
 	})
 
 	Describe("GrowFilesystem", func() {
@@ -305,5 +434,34 @@ var _ = Describe("Linux Formatter", func() {
 				})
 			})
 		})
+
+		Context("when using BTRFS", func() {
+			BeforeEach(func() {
+				fakeRunner.AddCmdResult("blkid -p /dev/nvme2n1p1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="btrfs" yyyy zzzz`})
+				formatter = NewLinuxFormatter(fakeRunner, fakeFs)
+			})
+
+			It("grows the BTRFS filesystem through its mount point", func() {
+				fakeRunner.AddCmdResult("findmnt -n -f -o TARGET --source /dev/nvme2n1p1", fakesys.FakeCmdResult{Stdout: "/var/vcap/store\n"})
+
+				err := formatter.GrowFilesystem("/dev/nvme2n1p1")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeRunner.RunCommands[2]).To(Equal([]string{"btrfs", "filesystem", "resize", "max", "/var/vcap/store"}))
+			})
+
+			Context("when the file system is not mounted", func() {
+				BeforeEach(func() {
+					fakeRunner.AddCmdResult("findmnt -n -f -o TARGET --source /dev/nvme2n1p1", fakesys.FakeCmdResult{ExitStatus: 1, Error: errors.New("findmnt failure")})
+				})
+
+				It("returns an error", func() {
+					err := formatter.GrowFilesystem("/dev/nvme2n1p1")
+
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Finding mount point of BTRFS filesystem"))
+				})
+			})
+		})
 	})
 })
@@ -0,0 +1,110 @@
+package disk
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// findmnt exits with 1 when nothing is mounted at the mount point
+const findmntNotFoundExitStatus = 1
+
+type linuxProjectQuota struct {
+	runner boshsys.CmdRunner
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewLinuxProjectQuota(runner boshsys.CmdRunner, logger boshlog.Logger) ProjectQuota {
+	return linuxProjectQuota{
+		runner: runner,
+		logTag: "linuxProjectQuota",
+		logger: logger,
+	}
+}
+
+func (q linuxProjectQuota) IsSupported(mountPoint string) (bool, error) {
+	fsType, mountOptions, mounted, err := q.mountedFileSystem(mountPoint)
+	if err != nil || !mounted {
+		return false, err
+	}
+
+	switch fsType {
+	case FileSystemXFS, FileSystemExt4:
+		return slices.Contains(mountOptions, ProjectQuotaMountOption), nil
+	default:
+		return false, nil
+	}
+}
+
+func (q linuxProjectQuota) SetDirectoryQuota(mountPoint, directory string, limitInBytes uint64) error {
+	fsType, mountOptions, mounted, err := q.mountedFileSystem(mountPoint)
+	if err != nil {
+		return err
+	}
+
+	if !mounted {
+		return bosherr.Errorf("No file system is mounted at '%s'", mountPoint)
+	}
+
+	if !slices.Contains(mountOptions, ProjectQuotaMountOption) {
+		return bosherr.Errorf("Project quotas are not enabled on the file system mounted at '%s'", mountPoint)
+	}
+
+	projectID := strconv.FormatUint(uint64(ProjectID(directory)), 10)
+	limitInKb := (limitInBytes + 1023) / 1024
+
+	q.logger.Debug(q.logTag, "Limiting '%s' to %d KiB with project %s", directory, limitInKb, projectID)
+
+	switch fsType {
+	case FileSystemXFS:
+		_, _, _, err = q.runner.RunCommand("xfs_quota", "-x", "-c", fmt.Sprintf("project -s -p %s %s", directory, projectID), mountPoint)
+		if err != nil {
+			return bosherr.WrapError(err, "Assigning directory to project with xfs_quota")
+		}
+
+		_, _, _, err = q.runner.RunCommand("xfs_quota", "-x", "-c", fmt.Sprintf("limit -p bhard=%dk %s", limitInKb, projectID), mountPoint)
+		if err != nil {
+			return bosherr.WrapError(err, "Limiting project with xfs_quota")
+		}
+
+	case FileSystemExt4:
+		_, _, _, err = q.runner.RunCommand("chattr", "-R", "-p", projectID, "+P", directory)
+		if err != nil {
+			return bosherr.WrapError(err, "Assigning directory to project with chattr")
+		}
+
+		_, _, _, err = q.runner.RunCommand("setquota", "-P", projectID, "0", strconv.FormatUint(limitInKb, 10), "0", "0", mountPoint)
+		if err != nil {
+			return bosherr.WrapError(err, "Limiting project with setquota")
+		}
+
+	default:
+		return bosherr.Errorf("Project quotas are not supported on %s file systems", fsType)
+	}
+
+	return nil
+}
+
+func (q linuxProjectQuota) mountedFileSystem(mountPoint string) (FileSystemType, []string, bool, error) {
+	stdout, _, exitStatus, err := q.runner.RunCommand("findmnt", "-n", "-f", "-o", "FSTYPE,OPTIONS", "--mountpoint", mountPoint)
+	if err != nil {
+		if exitStatus == findmntNotFoundExitStatus {
+			return "", nil, false, nil
+		}
+
+		return "", nil, false, bosherr.WrapErrorf(err, "Finding file system mounted at '%s'", mountPoint)
+	}
+
+	fields := strings.Fields(stdout)
+	if len(fields) != 2 {
+		return "", nil, false, bosherr.Errorf("Unexpected findmnt output for '%s': %s", mountPoint, stdout)
+	}
+
+	return FileSystemType(fields[0]), strings.Split(fields[1], ","), true, nil
+}
@@ -0,0 +1,130 @@
+package disk_test
+
+import (
+	"errors"
+	"strconv"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/platform/disk"
+)
+
+var _ = Describe("LinuxProjectQuota", func() {
+	var (
+		runner       *fakesys.FakeCmdRunner
+		projectQuota ProjectQuota
+		projectID    string
+	)
+
+	BeforeEach(func() {
+		runner = fakesys.NewFakeCmdRunner()
+		projectQuota = NewLinuxProjectQuota(runner, boshlog.NewLogger(boshlog.LevelNone))
+		projectID = strconv.FormatUint(uint64(ProjectID("/var/vcap/store/fake-job")), 10)
+	})
+
+	Describe("ProjectID", func() {
+		It("returns the same positive ID for the same directory", func() {
+			Expect(ProjectID("/var/vcap/store/fake-job")).To(Equal(ProjectID("/var/vcap/store/fake-job")))
+			Expect(ProjectID("/var/vcap/store/fake-job")).ToNot(Equal(ProjectID("/var/vcap/store/other-job")))
+			Expect(ProjectID("/var/vcap/store/fake-job")).To(BeNumerically(">", 0))
+		})
+	})
+
+	Context("when the file system is xfs", func() {
+		BeforeEach(func() {
+			runner.AddCmdResult("findmnt -n -f -o FSTYPE,OPTIONS --mountpoint /var/vcap/store", fakesys.FakeCmdResult{Stdout: "xfs    rw,relatime,attr2,inode64,prjquota\n"})
+		})
+
+		It("assigns the directory to a project and limits the project", func() {
+			err := projectQuota.SetDirectoryQuota("/var/vcap/store", "/var/vcap/store/fake-job", 10*1024*1024)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"findmnt", "-n", "-f", "-o", "FSTYPE,OPTIONS", "--mountpoint", "/var/vcap/store"},
+				{"xfs_quota", "-x", "-c", "project -s -p /var/vcap/store/fake-job " + projectID, "/var/vcap/store"},
+				{"xfs_quota", "-x", "-c", "limit -p bhard=10240k " + projectID, "/var/vcap/store"},
+			}))
+		})
+
+		It("returns an error when the limit cannot be set", func() {
+			runner.AddCmdResult("xfs_quota -x -c limit -p bhard=10240k "+projectID+" /var/vcap/store", fakesys.FakeCmdResult{Error: errors.New("fake-xfs-quota-error")})
+
+			err := projectQuota.SetDirectoryQuota("/var/vcap/store", "/var/vcap/store/fake-job", 10*1024*1024)
+			Expect(err).To(MatchError("Limiting project with xfs_quota: fake-xfs-quota-error"))
+		})
+	})
+
+	Context("when the file system is ext4", func() {
+		BeforeEach(func() {
+			runner.AddCmdResult("findmnt -n -f -o FSTYPE,OPTIONS --mountpoint /var/vcap/store", fakesys.FakeCmdResult{Stdout: "ext4   rw,relatime,prjquota\n"})
+		})
+
+		It("assigns the directory to a project and limits the project in KiB", func() {
+			err := projectQuota.SetDirectoryQuota("/var/vcap/store", "/var/vcap/store/fake-job", 10*1024*1024+1)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"findmnt", "-n", "-f", "-o", "FSTYPE,OPTIONS", "--mountpoint", "/var/vcap/store"},
+				{"chattr", "-R", "-p", projectID, "+P", "/var/vcap/store/fake-job"},
+				{"setquota", "-P", projectID, "0", "10241", "0", "0", "/var/vcap/store"},
+			}))
+		})
+	})
+
+	It("returns an error when the file system is not mounted with project quotas", func() {
+		runner.AddCmdResult("findmnt -n -f -o FSTYPE,OPTIONS --mountpoint /var/vcap/store", fakesys.FakeCmdResult{Stdout: "ext4   rw,relatime\n"})
+
+		err := projectQuota.SetDirectoryQuota("/var/vcap/store", "/var/vcap/store/fake-job", 1024)
+		Expect(err).To(MatchError("Project quotas are not enabled on the file system mounted at '/var/vcap/store'"))
+		Expect(runner.RunCommands).To(HaveLen(1))
+	})
+
+	It("returns an error when the file system does not support project quotas", func() {
+		runner.AddCmdResult("findmnt -n -f -o FSTYPE,OPTIONS --mountpoint /var/vcap/store", fakesys.FakeCmdResult{Stdout: "btrfs  rw,relatime,prjquota\n"})
+
+		err := projectQuota.SetDirectoryQuota("/var/vcap/store", "/var/vcap/store/fake-job", 1024)
+		Expect(err).To(MatchError("Project quotas are not supported on btrfs file systems"))
+	})
+
+	It("returns an error when nothing is mounted at the mount point", func() {
+		runner.AddCmdResult("findmnt -n -f -o FSTYPE,OPTIONS --mountpoint /var/vcap/store", fakesys.FakeCmdResult{ExitStatus: 1, Error: errors.New("fake-findmnt-error")})
+
+		err := projectQuota.SetDirectoryQuota("/var/vcap/store", "/var/vcap/store/fake-job", 1024)
+		Expect(err).To(MatchError("No file system is mounted at '/var/vcap/store'"))
+	})
+
+	It("returns an error when the mounted file system cannot be found", func() {
+		runner.AddCmdResult("findmnt -n -f -o FSTYPE,OPTIONS --mountpoint /var/vcap/store", fakesys.FakeCmdResult{ExitStatus: 2, Error: errors.New("fake-findmnt-error")})
+
+		err := projectQuota.SetDirectoryQuota("/var/vcap/store", "/var/vcap/store/fake-job", 1024)
+		Expect(err).To(MatchError("Finding file system mounted at '/var/vcap/store': fake-findmnt-error"))
+	})
+
+	Describe("IsSupported", func() {
+		DescribeTable("reports whether project quotas can be set on the mounted file system",
+			func(findmntResult fakesys.FakeCmdResult, expected bool) {
+				runner.AddCmdResult("findmnt -n -f -o FSTYPE,OPTIONS --mountpoint /var/vcap/store", findmntResult)
+
+				supported, err := projectQuota.IsSupported("/var/vcap/store")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(supported).To(Equal(expected))
+			},
+			Entry("xfs with project quotas", fakesys.FakeCmdResult{Stdout: "xfs    rw,relatime,prjquota\n"}, true),
+			Entry("ext4 with project quotas", fakesys.FakeCmdResult{Stdout: "ext4   rw,relatime,prjquota\n"}, true),
+			Entry("ext4 without project quotas", fakesys.FakeCmdResult{Stdout: "ext4   rw,relatime\n"}, false),
+			Entry("btrfs", fakesys.FakeCmdResult{Stdout: "btrfs  rw,relatime,prjquota\n"}, false),
+			Entry("no file system", fakesys.FakeCmdResult{ExitStatus: 1, Error: errors.New("fake-findmnt-error")}, false),
+		)
+
+		It("returns an error when the mounted file system cannot be found", func() {
+			runner.AddCmdResult("findmnt -n -f -o FSTYPE,OPTIONS --mountpoint /var/vcap/store", fakesys.FakeCmdResult{ExitStatus: 2, Error: errors.New("fake-findmnt-error")})
+
+			_, err := projectQuota.IsSupported("/var/vcap/store")
+			Expect(err).To(MatchError("Finding file system mounted at '/var/vcap/store': fake-findmnt-error"))
+		})
+	})
+})
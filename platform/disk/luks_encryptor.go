@@ -0,0 +1,81 @@
+package disk
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// cryptsetup isLuks exits with 1 when the device is not a LUKS device
+const cryptsetupNotLuksExitStatus = 1
+
+type luksEncryptor struct {
+	runner boshsys.CmdRunner
+	fs     boshsys.FileSystem
+}
+
+// NewLuksEncryptor passes keys to cryptsetup on stdin so that they do not
+// show up in process listings or logs
+func NewLuksEncryptor(runner boshsys.CmdRunner, fs boshsys.FileSystem) Encryptor {
+	return luksEncryptor{
+		runner: runner,
+		fs:     fs,
+	}
+}
+
+func (e luksEncryptor) IsEncrypted(partitionPath string) (bool, error) {
+	_, _, exitStatus, err := e.runner.RunCommand("cryptsetup", "isLuks", partitionPath)
+	if err != nil {
+		if exitStatus == cryptsetupNotLuksExitStatus {
+			return false, nil
+		}
+
+		return false, bosherr.WrapError(err, "Shelling out to cryptsetup isLuks")
+	}
+
+	return true, nil
+}
+
+func (e luksEncryptor) Create(partitionPath, key string) error {
+	_, _, _, err := e.runner.RunCommandWithInput(key, "cryptsetup", "luksFormat", "--type", "luks2", "--batch-mode", "--key-file", "-", partitionPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Shelling out to cryptsetup luksFormat")
+	}
+
+	return nil
+}
+
+func (e luksEncryptor) Open(partitionPath, mappingName, key string) (string, error) {
+	mappedPath := MappedDevicePath(mappingName)
+	if e.fs.FileExists(mappedPath) {
+		return mappedPath, nil
+	}
+
+	_, _, _, err := e.runner.RunCommandWithInput(key, "cryptsetup", "open", "--type", "luks2", "--key-file", "-", partitionPath, mappingName)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Shelling out to cryptsetup open")
+	}
+
+	return mappedPath, nil
+}
+
+func (e luksEncryptor) Resize(mappingName, key string) error {
+	_, _, _, err := e.runner.RunCommandWithInput(key, "cryptsetup", "resize", "--key-file", "-", mappingName)
+	if err != nil {
+		return bosherr.WrapError(err, "Shelling out to cryptsetup resize")
+	}
+
+	return nil
+}
+
+func (e luksEncryptor) Close(mappingName string) error {
+	if !e.fs.FileExists(MappedDevicePath(mappingName)) {
+		return nil
+	}
+
+	_, _, _, err := e.runner.RunCommand("cryptsetup", "close", mappingName)
+	if err != nil {
+		return bosherr.WrapError(err, "Shelling out to cryptsetup close")
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package disk_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/platform/disk"
+)
+
+var _ = Describe("LuksEncryptor", func() {
+	var (
+		runner    *fakesys.FakeCmdRunner
+		fs        *fakesys.FakeFileSystem
+		encryptor Encryptor
+	)
+
+	BeforeEach(func() {
+		runner = fakesys.NewFakeCmdRunner()
+		fs = fakesys.NewFakeFileSystem()
+		encryptor = NewLuksEncryptor(runner, fs)
+	})
+
+	Describe("IsEncrypted", func() {
+		It("returns true when the partition holds a LUKS container", func() {
+			encrypted, err := encryptor.IsEncrypted("/dev/sdf1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(encrypted).To(BeTrue())
+
+			Expect(runner.RunCommands).To(Equal([][]string{{"cryptsetup", "isLuks", "/dev/sdf1"}}))
+		})
+
+		It("returns false when the partition does not hold a LUKS container", func() {
+			runner.AddCmdResult("cryptsetup isLuks /dev/sdf1", fakesys.FakeCmdResult{ExitStatus: 1, Error: errors.New("Exit code 1")})
+
+			encrypted, err := encryptor.IsEncrypted("/dev/sdf1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(encrypted).To(BeFalse())
+		})
+
+		It("returns an error when cryptsetup fails", func() {
+			runner.AddCmdResult("cryptsetup isLuks /dev/sdf1", fakesys.FakeCmdResult{ExitStatus: 4, Error: errors.New("fake-cryptsetup-error")})
+
+			_, err := encryptor.IsEncrypted("/dev/sdf1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-cryptsetup-error"))
+		})
+	})
+
+	Describe("Create", func() {
+		It("formats the partition as a LUKS2 container with the key read from stdin", func() {
+			err := encryptor.Create("/dev/sdf1", "fake-key")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunCommandsWithInput).To(Equal([][]string{
+				{"fake-key", "cryptsetup", "luksFormat", "--type", "luks2", "--batch-mode", "--key-file", "-", "/dev/sdf1"},
+			}))
+			Expect(runner.RunCommands).To(BeEmpty())
+		})
+
+		It("returns an error when cryptsetup fails", func() {
+			runner.AddCmdResult("fake-key cryptsetup luksFormat --type luks2 --batch-mode --key-file - /dev/sdf1", fakesys.FakeCmdResult{Error: errors.New("fake-cryptsetup-error")})
+
+			err := encryptor.Create("/dev/sdf1", "fake-key")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-cryptsetup-error"))
+		})
+	})
+
+	Describe("Open", func() {
+		It("maps the container with the key read from stdin", func() {
+			mappedPath, err := encryptor.Open("/dev/sdf1", "sdf1_crypt", "fake-key")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mappedPath).To(Equal("/dev/mapper/sdf1_crypt"))
+
+			Expect(runner.RunCommandsWithInput).To(Equal([][]string{
+				{"fake-key", "cryptsetup", "open", "--type", "luks2", "--key-file", "-", "/dev/sdf1", "sdf1_crypt"},
+			}))
+		})
+
+		It("does not map the container again when it is already mapped", func() {
+			Expect(fs.WriteFileString("/dev/mapper/sdf1_crypt", "")).To(Succeed())
+
+			mappedPath, err := encryptor.Open("/dev/sdf1", "sdf1_crypt", "fake-key")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mappedPath).To(Equal("/dev/mapper/sdf1_crypt"))
+
+			Expect(runner.RunCommandsWithInput).To(BeEmpty())
+		})
+
+		It("returns an error when cryptsetup fails", func() {
+			runner.AddCmdResult("fake-key cryptsetup open --type luks2 --key-file - /dev/sdf1 sdf1_crypt", fakesys.FakeCmdResult{Error: errors.New("fake-cryptsetup-error")})
+
+			_, err := encryptor.Open("/dev/sdf1", "sdf1_crypt", "fake-key")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-cryptsetup-error"))
+		})
+	})
+
+	Describe("Resize", func() {
+		It("resizes the mapping with the key read from stdin", func() {
+			err := encryptor.Resize("sdf1_crypt", "fake-key")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunCommandsWithInput).To(Equal([][]string{
+				{"fake-key", "cryptsetup", "resize", "--key-file", "-", "sdf1_crypt"},
+			}))
+		})
+	})
+
+	Describe("Close", func() {
+		It("removes the mapping", func() {
+			Expect(fs.WriteFileString("/dev/mapper/sdf1_crypt", "")).To(Succeed())
+
+			err := encryptor.Close("sdf1_crypt")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunCommands).To(Equal([][]string{{"cryptsetup", "close", "sdf1_crypt"}}))
+		})
+
+		It("does nothing when the mapping does not exist", func() {
+			err := encryptor.Close("sdf1_crypt")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunCommands).To(BeEmpty())
+		})
+
+		It("returns an error when cryptsetup fails", func() {
+			Expect(fs.WriteFileString("/dev/mapper/sdf1_crypt", "")).To(Succeed())
+			runner.AddCmdResult("cryptsetup close sdf1_crypt", fakesys.FakeCmdResult{Error: errors.New("fake-cryptsetup-error")})
+
+			err := encryptor.Close("sdf1_crypt")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-cryptsetup-error"))
+		})
+	})
+})
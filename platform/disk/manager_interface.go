@@ -3,6 +3,7 @@ package disk
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Manager
 
 type Manager interface {
+	GetEncryptor() Encryptor
 	GetEphemeralDevicePartitioner() Partitioner
 	GetFormatter() Formatter
 	GetMounter() Mounter
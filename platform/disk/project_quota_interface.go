@@ -0,0 +1,24 @@
+package disk
+
+import (
+	"hash/crc32"
+	"math"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . ProjectQuota
+
+// ProjectQuota limits the disk space used below a directory through the
+// project quotas of the xfs or ext4 file system mounted at the mount point
+type ProjectQuota interface {
+	// IsSupported is false when no file system is mounted at the mount point
+	// or the file system is not mounted with project quotas
+	IsSupported(mountPoint string) (bool, error)
+
+	SetDirectoryQuota(mountPoint, directory string, limitInBytes uint64) error
+}
+
+// ProjectID derives a stable project ID from the directory
+// so that no registry of IDs has to be kept on the disk
+func ProjectID(directory string) uint32 {
+	return crc32.ChecksumIEEE([]byte(directory))%(math.MaxInt32-1) + 1
+}
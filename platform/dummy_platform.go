@@ -75,7 +75,7 @@ func NewDummyPlatform(
 		copier:             boshcmd.NewGenericCpCopier(fs, logger),
 		dirProvider:        dirProvider,
 		devicePathResolver: devicePathResolver,
-		vitalsService:      boshvitals.NewService(collector, dirProvider, nil),
+		vitalsService:      boshvitals.NewService(collector, dirProvider, nil, logger),
 		certManager:        boshcert.NewDummyCertManager(fs, cmdRunner, 0, logger),
 		logger:             logger,
 		auditLogger:        auditLogger,
@@ -435,6 +435,10 @@ func (p dummyPlatform) IsPersistentDiskMounted(diskSettings boshsettings.DiskSet
 	return true, nil
 }
 
+func (p dummyPlatform) GrowPersistentDisk(diskSettings boshsettings.DiskSettings) (PersistentDiskGrowth, error) {
+	return PersistentDiskGrowth{}, nil
+}
+
 func (p dummyPlatform) IsPersistentDiskMountable(diskSettings boshsettings.DiskSettings) (bool, error) {
 	var formattedDisks []formattedDisk
 	formattedDisksPath := filepath.Join(p.dirProvider.BoshDir(), "formatted_disks.json")
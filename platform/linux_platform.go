@@ -2,11 +2,13 @@ package platform
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -53,6 +55,8 @@ const (
 	sshAuthKeysFilePermissions = os.FileMode(0600)
 
 	minRootEphemeralSpaceInBytes = uint64(1024 * 1024 * 1024)
+
+	encryptedMappingSuffix = "_crypt"
 )
 
 type LinuxOptions struct {
@@ -87,6 +91,10 @@ type LinuxOptions struct {
 	// Used for device enumeration on NVMe systems
 	InstanceStorageDevicePattern string
 
+	// File system of the ephemeral data partition;
+	// possible values: ext4, xfs, btrfs, "" (default is ext4)
+	EphemeralDiskFilesystem string
+
 	// Strategy for resolving ephemeral & persistent disk partitioners;
 	// possible values: parted, "" (default is sfdisk if disk < 2TB, parted otherwise)
 	PartitionerType string
@@ -95,6 +103,14 @@ type LinuxOptions struct {
 	// possible values: systemd, ""
 	ServiceManager string
 
+	// Backend that network interfaces are configured with;
+	// possible values: netplan, "" (default is systemd-networkd); other values are rejected
+	NetworkConfigRenderer string
+
+	// Strategy for supervising jobs when the agent is started with the monit job supervisor;
+	// possible values: systemd, "" (default is monit)
+	JobSupervisor string
+
 	// Regular expression specifying what part of disk ID to strip and transform
 	// example: "pattern": "^(disk-.+)$", "replacement": "google-${1}",
 	DiskIDTransformPattern     string
@@ -187,6 +203,11 @@ func NewLinuxPlatform(
 
 const logTag = "linuxPlatform"
 
+// ErrPersistentDiskNotEncrypted is returned when mounting a disk that was created before persistent
+// disk encryption was enabled. File systems are never encrypted in place, so the data of such a disk
+// has to be moved to a new disk, or encryption has to stay disabled while the disk is in use.
+var ErrPersistentDiskNotEncrypted = errors.New("Persistent disk was created before encryption was enabled and is not encrypted")
+
 func (p linux) AssociateDisk(name string, settings boshsettings.DiskSettings) error {
 	disksDir := p.dirProvider.DisksDir()
 	err := p.fs.MkdirAll(disksDir, disksDirPermissions)
@@ -447,6 +468,9 @@ func (p linux) SetupRootDisk(ephemeralDiskPath string) error {
 	case boshdisk.FileSystemExt4:
 		resizeCmd = boshdisk.FileSystemExtResizeUtility
 		resizeCmdArgs = []string{"-f", rootDevice}
+	case boshdisk.FileSystemBTRFS:
+		resizeCmd = boshdisk.FileSystemBTRFSResizeUtility
+		resizeCmdArgs = []string{"filesystem", "resize", "max", "/"}
 	default:
 		return bosherr.Errorf("Cannot get filesystem type for root file system")
 	}
@@ -680,6 +704,11 @@ func (p linux) SetupEphemeralDiskWithPath(realPath string, desiredSwapSizeInByte
 		return nil
 	}
 
+	ephemeralDiskFS, err := p.ephemeralDiskFilesystem()
+	if err != nil {
+		return err
+	}
+
 	var swapPartitionPath, dataPartitionPath string
 
 	// Agent can only setup ephemeral data directory either on ephemeral device
@@ -727,10 +756,10 @@ func (p linux) SetupEphemeralDiskWithPath(realPath string, desiredSwapSizeInByte
 		return err
 	}
 
-	p.logger.Info(logTag, "Formatting `%s' (canonical path: %s) as ext4", dataPartitionPath, canonicalDataPartitionPath)
-	err = p.diskManager.GetFormatter().Format(canonicalDataPartitionPath, boshdisk.FileSystemExt4)
+	p.logger.Info(logTag, "Formatting `%s' (canonical path: %s) as %s", dataPartitionPath, canonicalDataPartitionPath, ephemeralDiskFS)
+	err = p.diskManager.GetFormatter().Format(canonicalDataPartitionPath, ephemeralDiskFS)
 	if err != nil {
-		return bosherr.WrapError(err, "Formatting data partition with ext4")
+		return bosherr.WrapErrorf(err, "Formatting data partition with %s", ephemeralDiskFS)
 	}
 
 	p.logger.Info(logTag, "Mounting `%s' (canonical path: %s) at `%s'", dataPartitionPath, canonicalDataPartitionPath, mountPoint)
@@ -742,6 +771,17 @@ func (p linux) SetupEphemeralDiskWithPath(realPath string, desiredSwapSizeInByte
 	return nil
 }
 
+func (p linux) ephemeralDiskFilesystem() (boshdisk.FileSystemType, error) {
+	switch fsType := boshdisk.FileSystemType(p.options.EphemeralDiskFilesystem); fsType {
+	case boshdisk.FileSystemDefault:
+		return boshdisk.FileSystemExt4, nil
+	case boshdisk.FileSystemExt4, boshdisk.FileSystemXFS, boshdisk.FileSystemBTRFS:
+		return fsType, nil
+	default:
+		return "", bosherr.Errorf(`The filesystem type "%s" is not supported for the ephemeral disk`, fsType)
+	}
+}
+
 func (p linux) SetupRawEphemeralDisks(devices []boshsettings.DiskSettings) (err error) {
 	if p.options.SkipDiskSetup {
 		return nil
@@ -1408,22 +1448,30 @@ func (p linux) AdjustPersistentDiskPartitioning(diskSetting boshsettings.DiskSet
 			return bosherr.WrapError(err, "Resizing disk partition")
 		}
 
+		filesystemPath := firstPartitionPath
+		if diskSetting.Encryption.Enabled {
+			filesystemPath, err = p.resizeEncryptedPartition(diskSetting, firstPartitionPath)
+			if err != nil {
+				return err
+			}
+		}
+
 		err = p.fs.MkdirAll(mountPoint, persistentDiskPermissions)
 		if err != nil {
 			return bosherr.WrapErrorf(err, "Creating directory %s", mountPoint)
 		}
 
-		err := p.diskManager.GetMounter().Mount(firstPartitionPath, mountPoint, diskSetting.MountOptions...)
+		err := p.diskManager.GetMounter().Mount(filesystemPath, mountPoint, diskSetting.MountOptions...)
 		if err != nil {
 			return bosherr.WrapError(err, "Failed to mount partition for filesystem growing")
 		}
 
-		err = p.diskManager.GetFormatter().GrowFilesystem(firstPartitionPath)
+		err = p.diskManager.GetFormatter().GrowFilesystem(filesystemPath)
 		if err != nil {
 			return bosherr.WrapError(err, "Failed to grow filesystem")
 		}
 
-		_, err = p.diskManager.GetMounter().Unmount(firstPartitionPath)
+		_, err = p.diskManager.GetMounter().Unmount(filesystemPath)
 		if err != nil {
 			return bosherr.WrapError(err, "Failed to unmount partition after filesystem growing")
 		}
@@ -1438,7 +1486,8 @@ func (p linux) AdjustPersistentDiskPartitioning(diskSetting boshsettings.DiskSet
 
 		persistentDiskFS := diskSetting.FileSystemType
 		switch persistentDiskFS {
-		case boshdisk.FileSystemExt4, boshdisk.FileSystemXFS:
+		case boshdisk.FileSystemExt4, boshdisk.FileSystemXFS, boshdisk.FileSystemBTRFS,
+			boshdisk.FileSystemExt4ProjectQuota, boshdisk.FileSystemXFSProjectQuota:
 		case boshdisk.FileSystemDefault:
 			persistentDiskFS = boshdisk.FileSystemExt4
 		case boshdisk.FileSystemSwap:
@@ -1447,7 +1496,15 @@ func (p linux) AdjustPersistentDiskPartitioning(diskSetting boshsettings.DiskSet
 			return bosherr.Error(fmt.Sprintf(`The filesystem type "%s" is not supported`, diskSetting.FileSystemType))
 		}
 
-		err = p.diskManager.GetFormatter().Format(firstPartitionPath, persistentDiskFS)
+		filesystemPath := firstPartitionPath
+		if diskSetting.Encryption.Enabled {
+			filesystemPath, err = p.createEncryptedPartition(diskSetting, firstPartitionPath)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = p.diskManager.GetFormatter().Format(filesystemPath, persistentDiskFS)
 		if err != nil {
 			return bosherr.WrapError(err, fmt.Sprintf("Formatting partition with %s", diskSetting.FileSystemType))
 		}
@@ -1455,6 +1512,216 @@ func (p linux) AdjustPersistentDiskPartitioning(diskSetting boshsettings.DiskSet
 	return nil
 }
 
+// createEncryptedPartition creates a LUKS container on a partition that does not hold one yet
+// and opens it. Partitions that already hold a file system are never encrypted since that would
+// destroy their data.
+func (p linux) createEncryptedPartition(diskSetting boshsettings.DiskSettings, partitionPath string) (string, error) {
+	encrypted, err := p.diskManager.GetEncryptor().IsEncrypted(partitionPath)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Checking whether partition is encrypted")
+	}
+
+	if !encrypted {
+		fsType, err := p.diskManager.GetFormatter().GetPartitionFormatType(partitionPath)
+		if err != nil {
+			return "", bosherr.WrapError(err, "Checking filesystem format of partition")
+		}
+
+		if fsType != boshdisk.FileSystemDefault {
+			return "", bosherr.Errorf("Partition '%s' already holds an unencrypted %s file system", partitionPath, fsType)
+		}
+
+		key, err := p.persistentDiskEncryptionKey(diskSetting.Encryption)
+		if err != nil {
+			return "", err
+		}
+
+		err = p.diskManager.GetEncryptor().Create(partitionPath, key)
+		if err != nil {
+			return "", bosherr.WrapError(err, "Creating encrypted partition")
+		}
+	}
+
+	return p.openEncryptedPartition(diskSetting, partitionPath)
+}
+
+func (p linux) openEncryptedPartition(diskSetting boshsettings.DiskSettings, partitionPath string) (string, error) {
+	key, err := p.persistentDiskEncryptionKey(diskSetting.Encryption)
+	if err != nil {
+		return "", err
+	}
+
+	mappedPath, err := p.diskManager.GetEncryptor().Open(partitionPath, encryptedMappingName(partitionPath), key)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening encrypted partition")
+	}
+
+	return mappedPath, nil
+}
+
+// resizeEncryptedPartition grows the open container to the size of its partition
+func (p linux) resizeEncryptedPartition(diskSetting boshsettings.DiskSettings, partitionPath string) (string, error) {
+	key, err := p.persistentDiskEncryptionKey(diskSetting.Encryption)
+	if err != nil {
+		return "", err
+	}
+
+	mappingName := encryptedMappingName(partitionPath)
+
+	mappedPath, err := p.diskManager.GetEncryptor().Open(partitionPath, mappingName, key)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening encrypted partition")
+	}
+
+	err = p.diskManager.GetEncryptor().Resize(mappingName, key)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Resizing encrypted partition")
+	}
+
+	return mappedPath, nil
+}
+
+func (p linux) persistentDiskEncryptionKey(encryption boshsettings.PersistentDiskEncryption) (string, error) {
+	switch {
+	case encryption.Key != "":
+		return encryption.Key, nil
+
+	case encryption.KeyFile != "":
+		key, err := p.fs.ReadFileWithOpts(encryption.KeyFile, boshsys.ReadOpts{Quiet: true})
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Reading persistent disk encryption key file '%s'", encryption.KeyFile)
+		}
+		return string(key), nil
+
+	case encryption.KeyringKey != "":
+		key, _, _, err := p.cmdRunner.RunCommandQuietly("keyctl", "pipe", "%user:"+encryption.KeyringKey)
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Reading persistent disk encryption key '%s' from the keyring", encryption.KeyringKey)
+		}
+		return key, nil
+
+	default:
+		return "", bosherr.Error("Persistent disk encryption requires a key, key_file or keyring_key")
+	}
+}
+
+// encryptedMappingName names the mapping after the partition so that the
+// old and new disks can both be open while migrating
+func encryptedMappingName(partitionPath string) string {
+	return filepath.Base(partitionPath) + encryptedMappingSuffix
+}
+
+// encryptedMappingNameOf returns the mapping name of a device path that
+// belongs to an encrypted persistent disk partition
+func encryptedMappingNameOf(devicePath string) (string, bool) {
+	if !strings.HasPrefix(devicePath, "/dev/mapper/") || !strings.HasSuffix(devicePath, encryptedMappingSuffix) {
+		return "", false
+	}
+
+	return filepath.Base(devicePath), true
+}
+
+func (p linux) GrowPersistentDisk(diskSetting boshsettings.DiskSettings) (PersistentDiskGrowth, error) {
+	var growth PersistentDiskGrowth
+
+	if p.options.UsePreformattedPersistentDisk {
+		return growth, bosherr.Error("Growing preformatted persistent disks is not supported")
+	}
+	p.logger.Debug(logTag, "Growing persistent disk %+v", diskSetting)
+
+	mounted, err := p.IsPersistentDiskMounted(diskSetting)
+	if err != nil {
+		return growth, bosherr.WrapError(err, "Checking whether persistent disk is mounted")
+	}
+	if !mounted {
+		return growth, bosherr.Errorf("Persistent disk '%s' is not mounted", diskSetting.ID)
+	}
+
+	devicePath, _, err := p.devicePathResolver.GetRealDevicePath(diskSetting)
+	if err != nil {
+		return growth, bosherr.WrapError(err, "Getting real device path")
+	}
+
+	partitioner, err := p.diskManager.GetPersistentDevicePartitioner(diskSetting.Partitioner)
+	if err != nil {
+		return growth, bosherr.WrapError(err, "Selecting partitioner")
+	}
+
+	growth.DeviceSizeBeforeInBytes, growth.PartitionSizeBeforeInBytes, err = p.persistentDiskSizes(partitioner, devicePath)
+	if err != nil {
+		return growth, err
+	}
+
+	err = p.rescanBlockDevice(devicePath)
+	if err != nil {
+		return growth, bosherr.WrapError(err, "Rescanning block device")
+	}
+
+	singlePartNeedsResize, err := partitioner.SinglePartitionNeedsResize(devicePath, boshdisk.PartitionTypeLinux)
+	if err != nil {
+		return growth, bosherr.WrapError(err, "Failed to determine whether partitions need rezising")
+	}
+	p.logger.Debug(logTag, "Persistent disk single partition needs resize: %+v", singlePartNeedsResize)
+
+	if singlePartNeedsResize {
+		err = partitioner.ResizeSinglePartition(devicePath)
+		if err != nil {
+			return growth, bosherr.WrapError(err, "Resizing disk partition")
+		}
+	}
+
+	filesystemPath := p.partitionPath(devicePath, 1)
+	if diskSetting.Encryption.Enabled {
+		filesystemPath, err = p.resizeEncryptedPartition(diskSetting, filesystemPath)
+		if err != nil {
+			return growth, err
+		}
+	}
+
+	// Growing is also attempted when the partition was grown before but its file system was not
+	err = p.diskManager.GetFormatter().GrowFilesystem(filesystemPath)
+	if err != nil {
+		return growth, bosherr.WrapError(err, "Failed to grow filesystem")
+	}
+
+	growth.DeviceSizeAfterInBytes, growth.PartitionSizeAfterInBytes, err = p.persistentDiskSizes(partitioner, devicePath)
+	if err != nil {
+		return growth, err
+	}
+
+	return growth, nil
+}
+
+func (p linux) persistentDiskSizes(partitioner boshdisk.Partitioner, devicePath string) (uint64, uint64, error) {
+	partitions, deviceSize, err := partitioner.GetPartitions(devicePath)
+	if err != nil {
+		return 0, 0, bosherr.WrapError(err, "Getting persistent disk partitions")
+	}
+
+	if len(partitions) != 1 {
+		return 0, 0, bosherr.Errorf("Expected a single partition on '%s' but found %d", devicePath, len(partitions))
+	}
+
+	return deviceSize, partitions[0].SizeInBytes, nil
+}
+
+// rescanBlockDevice makes the kernel pick up a new size of SCSI devices;
+// other devices such as NVMe namespaces are updated by their drivers
+func (p linux) rescanBlockDevice(devicePath string) error {
+	realPath, err := p.fs.ReadAndFollowLink(devicePath)
+	if err != nil {
+		realPath = devicePath
+	}
+
+	rescanPath := path.Join("/sys/class/block", filepath.Base(realPath), "device", "rescan")
+	if !p.fs.FileExists(rescanPath) {
+		p.logger.Debug(logTag, "Not rescanning %s since %s does not exist", devicePath, rescanPath)
+		return nil
+	}
+
+	return p.fs.WriteFileString(rescanPath, "1")
+}
+
 func (p linux) MountPersistentDisk(diskSetting boshsettings.DiskSettings, mountPoint string) error {
 	p.logger.Debug(logTag, "Mounting persistent disk %+v at %s", diskSetting, mountPoint)
 
@@ -1469,9 +1736,18 @@ func (p linux) MountPersistentDisk(diskSetting boshsettings.DiskSettings, mountP
 	}
 	p.logger.Info(logTag, "devicePath = %s, alreadyMountedPartPath = %s, hasMountedDevice = %t", devicePath, alreadyMountedPartPath, hasMountedDevice)
 
+	if diskSetting.Encryption.Enabled && p.options.UsePreformattedPersistentDisk {
+		return bosherr.Error("Encrypting preformatted persistent disks is not supported")
+	}
+
 	firstPartitionPath := p.partitionPath(devicePath, 1)
+	filesystemPath := firstPartitionPath
+	if diskSetting.Encryption.Enabled {
+		filesystemPath = boshdisk.MappedDevicePath(encryptedMappingName(firstPartitionPath))
+	}
+
 	if hasMountedDevice {
-		if alreadyMountedPartPath == firstPartitionPath {
+		if alreadyMountedPartPath == filesystemPath {
 			p.logger.Info(logTag, "device: %s is already mounted on %s, skipping mounting", alreadyMountedPartPath, mountPoint)
 			return nil
 		}
@@ -1487,11 +1763,30 @@ func (p linux) MountPersistentDisk(diskSetting boshsettings.DiskSettings, mountP
 	var partitionPathToMount string
 	if p.options.UsePreformattedPersistentDisk {
 		partitionPathToMount = devicePath
+	} else if diskSetting.Encryption.Enabled {
+		encrypted, err := p.diskManager.GetEncryptor().IsEncrypted(firstPartitionPath)
+		if err != nil {
+			return bosherr.WrapError(err, "Checking whether partition is encrypted")
+		}
+
+		if !encrypted {
+			return ErrPersistentDiskNotEncrypted
+		}
+
+		partitionPathToMount, err = p.openEncryptedPartition(diskSetting, firstPartitionPath)
+		if err != nil {
+			return err
+		}
 	} else {
 		partitionPathToMount = firstPartitionPath
 	}
 
-	err = p.diskManager.GetMounter().Mount(partitionPathToMount, mountPoint, diskSetting.MountOptions...)
+	mountOptions := diskSetting.MountOptions
+	if diskSetting.FileSystemType.HasProjectQuotas() && !slices.Contains(mountOptions, boshdisk.ProjectQuotaMountOption) {
+		mountOptions = append(slices.Clone(mountOptions), boshdisk.ProjectQuotaMountOption)
+	}
+
+	err = p.diskManager.GetMounter().Mount(partitionPathToMount, mountPoint, mountOptions...)
 	if err != nil {
 		return bosherr.WrapError(err, "Mounting partition")
 	}
@@ -1521,7 +1816,23 @@ func (p linux) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (bo
 		realPath = p.partitionPath(realPath, 1)
 	}
 
-	return p.diskManager.GetMounter().Unmount(realPath)
+	if !diskSettings.Encryption.Enabled {
+		return p.diskManager.GetMounter().Unmount(realPath)
+	}
+
+	mappingName := encryptedMappingName(realPath)
+
+	didUnmount, err := p.diskManager.GetMounter().Unmount(boshdisk.MappedDevicePath(mappingName))
+	if err != nil {
+		return false, err
+	}
+
+	err = p.diskManager.GetEncryptor().Close(mappingName)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Closing encrypted partition")
+	}
+
+	return didUnmount, nil
 }
 
 func (p linux) GetEphemeralDiskPath(diskSettings boshsettings.DiskSettings) (string, error) {
@@ -1570,22 +1881,25 @@ func (p linux) MigratePersistentDisk(fromMountPoint, toMountPoint string) error
 		return bosherr.WrapError(err, "Copying files from old disk to new disk")
 	}
 
+	mounts, err := p.diskManager.GetMountsSearcher().SearchMounts()
+	if err != nil {
+		return bosherr.WrapError(err, "Search persistent disk as readonly")
+	}
+
+	var fromPartitionPath string
+	for _, mount := range mounts {
+		if mount.MountPoint == fromMountPoint {
+			fromPartitionPath = mount.PartitionPath
+		}
+	}
+
 	// Find iSCSI device id of fromMountPoint
 	var iscsiID string
 	if p.options.DevicePathResolutionType == "iscsi" {
-		mounts, err := p.diskManager.GetMountsSearcher().SearchMounts()
-		if err != nil {
-			return bosherr.WrapError(err, "Search persistent disk as readonly")
-		}
-
 		devMapperPart1Regexp := regexp.MustCompile(`/dev/mapper/(.*?)-part1`)
-		for _, mount := range mounts {
-			if mount.MountPoint == fromMountPoint {
-				matches := devMapperPart1Regexp.FindStringSubmatch(mount.PartitionPath)
-				if len(matches) > 1 {
-					iscsiID = matches[1]
-				}
-			}
+		matches := devMapperPart1Regexp.FindStringSubmatch(fromPartitionPath)
+		if len(matches) > 1 {
+			iscsiID = matches[1]
 		}
 	}
 
@@ -1594,6 +1908,13 @@ func (p linux) MigratePersistentDisk(fromMountPoint, toMountPoint string) error
 		return bosherr.WrapError(err, "Unmounting old persistent disk")
 	}
 
+	if mappingName, encrypted := encryptedMappingNameOf(fromPartitionPath); encrypted {
+		err = p.diskManager.GetEncryptor().Close(mappingName)
+		if err != nil {
+			return bosherr.WrapError(err, "Closing encrypted partition of old persistent disk")
+		}
+	}
+
 	err = p.diskManager.GetMounter().Remount(toMountPoint, fromMountPoint)
 	if err != nil {
 		err = bosherr.WrapError(err, "Remounting new disk on original mountpoint")
@@ -1624,6 +1945,10 @@ func (p linux) IsPersistentDiskMounted(diskSettings boshsettings.DiskSettings) (
 		realPath = p.partitionPath(realPath, 1)
 	}
 
+	if diskSettings.Encryption.Enabled {
+		realPath = boshdisk.MappedDevicePath(encryptedMappingName(realPath))
+	}
+
 	return p.diskManager.GetMounter().IsMounted(realPath)
 }
 
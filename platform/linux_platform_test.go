@@ -76,6 +76,7 @@ var _ = Describe("LinuxPlatform", func() {
 		mounter        *diskfakes.FakeMounter
 		mountsSearcher *fakedisk.FakeMountsSearcher
 		diskUtil       *fakedisk.FakeDiskUtil
+		encryptor      *diskfakes.FakeEncryptor
 	)
 
 	BeforeEach(func() {
@@ -135,7 +136,13 @@ var _ = Describe("LinuxPlatform", func() {
 		diskUtil = fakedisk.NewFakeDiskUtil()
 		diskManager.GetUtilReturns(diskUtil)
 
-		vitalsService = boshvitals.NewService(collector, dirProvider, mounter)
+		encryptor = &diskfakes.FakeEncryptor{}
+		encryptor.OpenStub = func(_, mappingName, _ string) (string, error) {
+			return boshdisk.MappedDevicePath(mappingName), nil
+		}
+		diskManager.GetEncryptorReturns(encryptor)
+
+		vitalsService = boshvitals.NewService(collector, dirProvider, mounter, logger)
 	})
 
 	JustBeforeEach(func() {
@@ -331,6 +338,19 @@ bosh_foobar:...`
 				Expect(cmdRunner.RunComplexCommands[0]).To(Equal(boshsys.Command{Name: "xfs_growfs", Args: []string{"-d", "/dev/sda1"}}))
 			})
 
+			It("runs growpart and grows btrfs through the mounted root", func() {
+				cmdRunner.AddCmdResult(
+					"readlink -f /dev/sda1",
+					fakesys.FakeCmdResult{Error: nil, Stdout: "/dev/sda1"},
+				)
+				formatter.GetFileSystemType["/dev/sda1"] = "btrfs"
+				err := platform.SetupRootDisk("/dev/sdb")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cmdRunner.RunCommands[1]).To(Equal([]string{"growpart", "/dev/sda", "1"}))
+				Expect(cmdRunner.RunComplexCommands).To(Equal([]boshsys.Command{{Name: "btrfs", Args: []string{"filesystem", "resize", "max", "/"}}}))
+			})
+
 			It("runs growpart and resize2fs for the right root device number", func() {
 				err := platform.SetupEphemeralDiskWithPath("/dev/sda", nil, labelPrefix)
 				Expect(err).NotTo(HaveOccurred())
@@ -1154,6 +1174,33 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 						Expect(formatter.FormatFsTypes[1]).To(Equal(boshdisk.FileSystemExt4))
 					})
 
+					Context("when the ephemeral disk file system is btrfs", func() {
+						BeforeEach(func() {
+							options.EphemeralDiskFilesystem = "btrfs"
+						})
+
+						It("formats the data partition with btrfs", func() {
+							collector.MemStats.Total = uint64(1024 * 1024)
+							partitioner.GetDeviceSizeInBytesSizes[devicePath] = uint64(1024 * 1024)
+							err := act()
+							Expect(err).NotTo(HaveOccurred())
+
+							Expect(formatter.FormatFsTypes).To(Equal([]boshdisk.FileSystemType{boshdisk.FileSystemSwap, boshdisk.FileSystemBTRFS}))
+						})
+					})
+
+					Context("when the ephemeral disk file system is not supported", func() {
+						BeforeEach(func() {
+							options.EphemeralDiskFilesystem = "ext4-prjquota"
+						})
+
+						It("returns an error without partitioning the disk", func() {
+							err := act()
+							Expect(err).To(MatchError(`The filesystem type "ext4-prjquota" is not supported for the ephemeral disk`))
+							Expect(formatter.FormatPartitionPaths).To(BeEmpty())
+						})
+					})
+
 					It("mounts swap and data partitions", func() {
 						collector.MemStats.Total = uint64(1024 * 1024)
 						partitioner.GetDeviceSizeInBytesSizes[devicePath] = uint64(1024 * 1024)
@@ -3331,6 +3378,32 @@ sam:fakeanotheruser`)
 				})
 			})
 
+			Context("when settings specify btrfs filesystem", func() {
+				BeforeEach(func() {
+					diskSettings.FileSystemType = boshdisk.FileSystemBTRFS
+				})
+
+				It("formats with a btrfs filesystem", func() {
+					err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+
+					Expect(err).ToNot(HaveOccurred())
+					Expect(formatter.FormatFsTypes).To(Equal([]boshdisk.FileSystemType{boshdisk.FileSystemBTRFS}))
+				})
+			})
+
+			Context("when settings specify a filesystem with project quotas", func() {
+				BeforeEach(func() {
+					diskSettings.FileSystemType = boshdisk.FileSystemXFSProjectQuota
+				})
+
+				It("formats with the project quota filesystem", func() {
+					err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+
+					Expect(err).ToNot(HaveOccurred())
+					Expect(formatter.FormatFsTypes).To(Equal([]boshdisk.FileSystemType{boshdisk.FileSystemXFSProjectQuota}))
+				})
+			})
+
 			Context("when settings specify an unsupported filesystem", func() {
 				BeforeEach(func() {
 					diskSettings.FileSystemType = boshdisk.FileSystemType("blahblah")
@@ -3373,6 +3446,227 @@ sam:fakeanotheruser`)
 				})
 			})
 		})
+
+		Context("when persistent disk encryption is enabled", func() {
+			BeforeEach(func() {
+				devicePathResolver.RealDevicePath = "/dev/sdf"
+				diskSettings.Encryption = boshsettings.PersistentDiskEncryption{Enabled: true, Key: "fake-key"}
+			})
+
+			It("creates and opens a LUKS container on the partition and formats the mapped device", func() {
+				err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(encryptor.IsEncryptedArgsForCall(0)).To(Equal("/dev/sdf1"))
+
+				Expect(encryptor.CreateCallCount()).To(Equal(1))
+				partitionPath, key := encryptor.CreateArgsForCall(0)
+				Expect(partitionPath).To(Equal("/dev/sdf1"))
+				Expect(key).To(Equal("fake-key"))
+
+				Expect(encryptor.OpenCallCount()).To(Equal(1))
+				partitionPath, mappingName, key := encryptor.OpenArgsForCall(0)
+				Expect(partitionPath).To(Equal("/dev/sdf1"))
+				Expect(mappingName).To(Equal("sdf1_crypt"))
+				Expect(key).To(Equal("fake-key"))
+
+				Expect(formatter.FormatPartitionPaths).To(Equal([]string{"/dev/mapper/sdf1_crypt"}))
+			})
+
+			It("opens an existing LUKS container without recreating it", func() {
+				encryptor.IsEncryptedReturns(true, nil)
+
+				err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(encryptor.CreateCallCount()).To(Equal(0))
+				Expect(encryptor.OpenCallCount()).To(Equal(1))
+				Expect(formatter.FormatPartitionPaths).To(Equal([]string{"/dev/mapper/sdf1_crypt"}))
+			})
+
+			It("does not encrypt a partition that already holds a file system", func() {
+				formatter.GetFileSystemType["/dev/sdf1"] = boshdisk.FileSystemExt4
+
+				err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+				Expect(err).To(MatchError("Partition '/dev/sdf1' already holds an unencrypted ext4 file system"))
+
+				Expect(encryptor.CreateCallCount()).To(Equal(0))
+				Expect(formatter.FormatCalled).To(BeFalse())
+			})
+
+			It("reads the key from the key file", func() {
+				diskSettings.Encryption = boshsettings.PersistentDiskEncryption{Enabled: true, KeyFile: "/fake/key-file"}
+				Expect(fs.WriteFileString("/fake/key-file", "fake-file-key")).To(Succeed())
+
+				err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, key := encryptor.CreateArgsForCall(0)
+				Expect(key).To(Equal("fake-file-key"))
+			})
+
+			It("reads the key from the kernel keyring", func() {
+				diskSettings.Encryption = boshsettings.PersistentDiskEncryption{Enabled: true, KeyringKey: "bosh-disk-key"}
+				cmdRunner.AddCmdResult("keyctl pipe %user:bosh-disk-key", fakesys.FakeCmdResult{Stdout: "fake-keyring-key"})
+
+				err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cmdRunner.RunCommandsQuietly).To(ContainElement([]string{"keyctl", "pipe", "%user:bosh-disk-key"}))
+				_, key := encryptor.CreateArgsForCall(0)
+				Expect(key).To(Equal("fake-keyring-key"))
+			})
+
+			It("returns an error when no key is configured", func() {
+				diskSettings.Encryption = boshsettings.PersistentDiskEncryption{Enabled: true}
+
+				err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+				Expect(err).To(MatchError("Persistent disk encryption requires a key, key_file or keyring_key"))
+				Expect(formatter.FormatCalled).To(BeFalse())
+			})
+
+			It("returns an error when the container cannot be opened", func() {
+				encryptor.OpenReturns("", errors.New("fake-open-error"))
+				encryptor.OpenStub = nil
+
+				err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+				Expect(err).To(MatchError("Opening encrypted partition: fake-open-error"))
+				Expect(formatter.FormatCalled).To(BeFalse())
+			})
+
+			Context("when the partition needs to be resized", func() {
+				BeforeEach(func() {
+					partitioner.SinglePartitionNeedsResizeReturns.NeedResize = true
+				})
+
+				It("resizes the container and grows the file system on the mapped device", func() {
+					err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(encryptor.ResizeCallCount()).To(Equal(1))
+					mappingName, key := encryptor.ResizeArgsForCall(0)
+					Expect(mappingName).To(Equal("sdf1_crypt"))
+					Expect(key).To(Equal("fake-key"))
+
+					partitionPath, mountPoint, _ := mounter.MountArgsForCall(0)
+					Expect(partitionPath).To(Equal("/dev/mapper/sdf1_crypt"))
+					Expect(mountPoint).To(Equal(mntPoint))
+					Expect(formatter.GrowFilesystemPartitionPath).To(Equal("/dev/mapper/sdf1_crypt"))
+					Expect(mounter.UnmountArgsForCall(0)).To(Equal("/dev/mapper/sdf1_crypt"))
+
+					Expect(encryptor.CreateCallCount()).To(Equal(0))
+				})
+			})
+		})
+	})
+
+	Describe("GrowPersistentDisk", func() {
+		var diskSettings boshsettings.DiskSettings
+
+		BeforeEach(func() {
+			diskSettings = boshsettings.DiskSettings{ID: "fake-unique-id", Path: "fake-volume-id"}
+			devicePathResolver.RealDevicePath = "/dev/sdf"
+			mounter.IsMountedReturns(true, nil)
+
+			partitioner.GetPartitionsPartitions = []boshdisk.ExistingPartition{{Index: 1, SizeInBytes: 1024, Type: boshdisk.PartitionTypeLinux}}
+			partitioner.GetPartitionsSizes = map[string]uint64{"/dev/sdf": 2048}
+			partitioner.SinglePartitionNeedsResizeReturns.NeedResize = true
+		})
+
+		It("rescans the device, grows the partition and then grows the mounted file system", func() {
+			Expect(fs.WriteFileString("/sys/class/block/sdf/device/rescan", "")).To(Succeed())
+
+			growth, err := platform.GrowPersistentDisk(diskSettings)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(growth).To(Equal(PersistentDiskGrowth{
+				DeviceSizeBeforeInBytes:    2048,
+				DeviceSizeAfterInBytes:     2048,
+				PartitionSizeBeforeInBytes: 1024,
+				PartitionSizeAfterInBytes:  1024,
+			}))
+
+			Expect(fs.ReadFileString("/sys/class/block/sdf/device/rescan")).To(Equal("1"))
+			Expect(partitioner.SinglePartitionNeedsResizeDevicePath).To(Equal("/dev/sdf"))
+			Expect(partitioner.ResizeSinglePartitionDevicePath).To(Equal("/dev/sdf"))
+			Expect(formatter.GrowFilesystemPartitionPath).To(Equal("/dev/sdf1"))
+
+			Expect(mounter.MountCallCount()).To(Equal(0))
+			Expect(mounter.UnmountCallCount()).To(Equal(0))
+		})
+
+		It("grows the file system when the partition does not need to be resized", func() {
+			partitioner.SinglePartitionNeedsResizeReturns.NeedResize = false
+
+			_, err := platform.GrowPersistentDisk(diskSettings)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(partitioner.ResizeSinglePartitionCalled).To(BeFalse())
+			Expect(formatter.GrowFilesystemPartitionPath).To(Equal("/dev/sdf1"))
+		})
+
+		It("does not rescan devices the kernel cannot rescan", func() {
+			_, err := platform.GrowPersistentDisk(diskSettings)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/sys/class/block/sdf/device/rescan")).To(BeFalse())
+		})
+
+		It("returns an error when the disk is not mounted", func() {
+			mounter.IsMountedReturns(false, nil)
+
+			_, err := platform.GrowPersistentDisk(diskSettings)
+			Expect(err).To(MatchError("Persistent disk 'fake-unique-id' is not mounted"))
+			Expect(partitioner.ResizeSinglePartitionCalled).To(BeFalse())
+		})
+
+		It("returns an error when the disk does not have a single partition", func() {
+			partitioner.GetPartitionsPartitions = nil
+
+			_, err := platform.GrowPersistentDisk(diskSettings)
+			Expect(err).To(MatchError("Expected a single partition on '/dev/sdf' but found 0"))
+		})
+
+		It("returns an error when resizing the partition fails", func() {
+			partitioner.ResizeSinglePartitionErr = errors.New("fake-resize-error")
+
+			_, err := platform.GrowPersistentDisk(diskSettings)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-resize-error"))
+			Expect(formatter.GrowFilesystemCalled).To(BeFalse())
+		})
+
+		It("resizes the LUKS container before growing the file system when the disk is encrypted", func() {
+			diskSettings.Encryption = boshsettings.PersistentDiskEncryption{Enabled: true, Key: "fake-key"}
+
+			_, err := platform.GrowPersistentDisk(diskSettings)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mounter.IsMountedArgsForCall(0)).To(Equal("/dev/mapper/sdf1_crypt"))
+
+			mappingName, key := encryptor.ResizeArgsForCall(0)
+			Expect(mappingName).To(Equal("sdf1_crypt"))
+			Expect(key).To(Equal("fake-key"))
+			Expect(formatter.GrowFilesystemPartitionPath).To(Equal("/dev/mapper/sdf1_crypt"))
+		})
+
+		It("returns an error when growing the file system fails", func() {
+			formatter.GrowFilesystemError = errors.New("fake-grow-error")
+
+			_, err := platform.GrowPersistentDisk(diskSettings)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-grow-error"))
+		})
+
+		Context("when UsePreformattedPersistentDisk set to true", func() {
+			BeforeEach(func() {
+				options.UsePreformattedPersistentDisk = true
+			})
+
+			It("returns an error", func() {
+				_, err := platform.GrowPersistentDisk(diskSettings)
+				Expect(err).To(MatchError("Growing preformatted persistent disks is not supported"))
+			})
+		})
 	})
 
 	Describe("MountPersistentDisk", func() {
@@ -3469,6 +3763,18 @@ sam:fakeanotheruser`)
 					Expect(options).To(Equal([]string{"mntOpt1", "mntOpt2"}))
 				})
 
+				It("mounts the disk with project quotas when its file system has project quotas", func() {
+					diskSettings.FileSystemType = boshdisk.FileSystemExt4ProjectQuota
+
+					err := platform.MountPersistentDisk(diskSettings, mntPoint)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(mounter.MountCallCount()).To(Equal(1))
+					_, _, options := mounter.MountArgsForCall(0)
+					Expect(options).To(Equal([]string{"mntOpt1", "mntOpt2", "prjquota"}))
+					Expect(diskSettings.MountOptions).To(Equal([]string{"mntOpt1", "mntOpt2"}))
+				})
+
 				It("generates the managed disk settings file", func() {
 					err := platform.MountPersistentDisk(diskSettings, mntPoint)
 					Expect(err).ToNot(HaveOccurred())
@@ -3679,6 +3985,77 @@ sam:fakeanotheruser`)
 				Expect(err.Error()).To(ContainSubstring("fake-get-real-device-path-err"))
 			})
 		})
+
+		Context("when persistent disk encryption is enabled", func() {
+			BeforeEach(func() {
+				devicePathResolver.RealDevicePath = "/dev/sdf"
+				diskSettings.Encryption = boshsettings.PersistentDiskEncryption{Enabled: true, Key: "fake-key"}
+				encryptor.IsEncryptedReturns(true, nil)
+			})
+
+			It("opens the LUKS container and mounts the mapped device", func() {
+				err := platform.MountPersistentDisk(diskSettings, mntPoint)
+				Expect(err).ToNot(HaveOccurred())
+
+				partitionPath, mappingName, key := encryptor.OpenArgsForCall(0)
+				Expect(partitionPath).To(Equal("/dev/sdf1"))
+				Expect(mappingName).To(Equal("sdf1_crypt"))
+				Expect(key).To(Equal("fake-key"))
+
+				Expect(mounter.MountCallCount()).To(Equal(1))
+				partition, mntPt, _ := mounter.MountArgsForCall(0)
+				Expect(partition).To(Equal("/dev/mapper/sdf1_crypt"))
+				Expect(mntPt).To(Equal(mntPoint))
+			})
+
+			It("skips mounting when the mapped device is already mounted", func() {
+				mounter.IsMountPointReturns("/dev/mapper/sdf1_crypt", true, nil)
+
+				err := platform.MountPersistentDisk(diskSettings, mntPoint)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(encryptor.OpenCallCount()).To(Equal(0))
+				Expect(mounter.MountCallCount()).To(Equal(0))
+			})
+
+			It("returns an error when the container cannot be opened", func() {
+				encryptor.OpenStub = nil
+				encryptor.OpenReturns("", errors.New("fake-open-error"))
+
+				err := platform.MountPersistentDisk(diskSettings, mntPoint)
+				Expect(err).To(MatchError("Opening encrypted partition: fake-open-error"))
+				Expect(mounter.MountCallCount()).To(Equal(0))
+			})
+
+			It("returns an error without mounting when the disk was created unencrypted", func() {
+				encryptor.IsEncryptedReturns(false, nil)
+
+				err := platform.MountPersistentDisk(diskSettings, mntPoint)
+				Expect(err).To(MatchError(ErrPersistentDiskNotEncrypted))
+				Expect(encryptor.IsEncryptedArgsForCall(0)).To(Equal("/dev/sdf1"))
+				Expect(encryptor.OpenCallCount()).To(Equal(0))
+				Expect(mounter.MountCallCount()).To(Equal(0))
+			})
+
+			It("returns an error when checking the encryption of the disk fails", func() {
+				encryptor.IsEncryptedReturns(false, errors.New("fake-is-encrypted-error"))
+
+				err := platform.MountPersistentDisk(diskSettings, mntPoint)
+				Expect(err).To(MatchError("Checking whether partition is encrypted: fake-is-encrypted-error"))
+				Expect(mounter.MountCallCount()).To(Equal(0))
+			})
+
+			Context("when UsePreformattedPersistentDisk set to true", func() {
+				BeforeEach(func() {
+					options.UsePreformattedPersistentDisk = true
+				})
+
+				It("returns an error", func() {
+					err := platform.MountPersistentDisk(diskSettings, mntPoint)
+					Expect(err).To(MatchError("Encrypting preformatted persistent disks is not supported"))
+					Expect(mounter.MountCallCount()).To(Equal(0))
+				})
+			})
+		})
 	})
 
 	Describe("UnmountPersistentDisk", func() {
@@ -3779,6 +4156,49 @@ sam:fakeanotheruser`)
 				Expect(isMounted).To(BeFalse())
 			})
 		})
+
+		Context("when persistent disk encryption is enabled", func() {
+			var diskSettings boshsettings.DiskSettings
+
+			BeforeEach(func() {
+				devicePathResolver.RealDevicePath = "/dev/sdf"
+				diskSettings = boshsettings.DiskSettings{
+					Path:       "fake-device-path",
+					Encryption: boshsettings.PersistentDiskEncryption{Enabled: true, Key: "fake-key"},
+				}
+			})
+
+			It("unmounts the mapped device and then closes the LUKS container", func() {
+				mounter.UnmountReturns(true, nil)
+				mounter.UnmountStub = func(string) (bool, error) {
+					Expect(encryptor.CloseCallCount()).To(Equal(0))
+					return true, nil
+				}
+
+				didUnmount, err := platform.UnmountPersistentDisk(diskSettings)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(didUnmount).To(BeTrue())
+
+				Expect(mounter.UnmountArgsForCall(0)).To(Equal("/dev/mapper/sdf1_crypt"))
+				Expect(encryptor.CloseCallCount()).To(Equal(1))
+				Expect(encryptor.CloseArgsForCall(0)).To(Equal("sdf1_crypt"))
+			})
+
+			It("does not close the LUKS container when unmounting fails", func() {
+				mounter.UnmountReturns(false, errors.New("fake-unmount-err"))
+
+				_, err := platform.UnmountPersistentDisk(diskSettings)
+				Expect(err).To(MatchError("fake-unmount-err"))
+				Expect(encryptor.CloseCallCount()).To(Equal(0))
+			})
+
+			It("returns an error when the LUKS container cannot be closed", func() {
+				encryptor.CloseReturns(errors.New("fake-close-err"))
+
+				_, err := platform.UnmountPersistentDisk(diskSettings)
+				Expect(err).To(MatchError("Closing encrypted partition: fake-close-err"))
+			})
+		})
 	})
 
 	Describe("AssociateDisk", func() {
@@ -3969,6 +4389,38 @@ from-device-path  dm-0 NETAPP  ,LUN C-Mode
 				Expect(cmdRunner.RunCommands[2]).To(Equal([]string{"multipath", "-f", "from-device-path"}))
 			})
 		})
+
+		Context("when the old persistent disk is encrypted", func() {
+			BeforeEach(func() {
+				mountsSearcher.SearchMountsMounts = []boshdisk.Mount{
+					{PartitionPath: "/dev/mapper/sdf1_crypt", MountPoint: "/from/path"},
+					{PartitionPath: "/dev/mapper/sdg1_crypt", MountPoint: "/to/path"},
+				}
+			})
+
+			It("closes the LUKS container of the old disk after unmounting it", func() {
+				encryptor.CloseStub = func(string) error {
+					Expect(mounter.UnmountCallCount()).To(Equal(1))
+					Expect(mounter.RemountCallCount()).To(Equal(0))
+					return nil
+				}
+
+				err := platform.MigratePersistentDisk("/from/path", "/to/path")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(encryptor.CloseCallCount()).To(Equal(1))
+				Expect(encryptor.CloseArgsForCall(0)).To(Equal("sdf1_crypt"))
+				Expect(mounter.RemountCallCount()).To(Equal(1))
+			})
+
+			It("returns an error when the LUKS container cannot be closed", func() {
+				encryptor.CloseReturns(errors.New("fake-close-err"))
+
+				err := platform.MigratePersistentDisk("/from/path", "/to/path")
+				Expect(err).To(MatchError("Closing encrypted partition of old persistent disk: fake-close-err"))
+				Expect(mounter.RemountCallCount()).To(Equal(0))
+			})
+		})
 	})
 
 	Describe("IsPersistentDiskMounted", func() {
@@ -4142,6 +4594,21 @@ from-device-path  dm-0 NETAPP  ,LUN C-Mode
 				Expect(isMounted).To(BeFalse())
 			})
 		})
+
+		Context("when persistent disk encryption is enabled", func() {
+			It("checks whether the mapped device is mounted", func() {
+				devicePathResolver.RealDevicePath = "/dev/sdf"
+				mounter.IsMountedReturns(true, nil)
+
+				isMounted, err := platform.IsPersistentDiskMounted(boshsettings.DiskSettings{
+					Path:       "fake-device-path",
+					Encryption: boshsettings.PersistentDiskEncryption{Enabled: true},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(isMounted).To(BeTrue())
+				Expect(mounter.IsMountedArgsForCall(0)).To(Equal("/dev/mapper/sdf1_crypt"))
+			})
+		})
 	})
 
 	Describe("IsPersistentDiskMountable", func() {
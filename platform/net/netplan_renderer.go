@@ -0,0 +1,308 @@
+package net
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"gopkg.in/yaml.v3"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+const (
+	netplanFolder = "/etc/netplan"
+
+	netplanConfigHeader = "# Generated by bosh-agent\n"
+)
+
+type netplanConfig struct {
+	Network netplanNetwork `yaml:"network"`
+}
+
+type netplanNetwork struct {
+	Version   int                         `yaml:"version"`
+	Renderer  string                      `yaml:"renderer"`
+	Ethernets map[string]netplanInterface `yaml:"ethernets"`
+}
+
+type netplanInterface struct {
+	Match          *netplanMatch         `yaml:"match,omitempty"`
+	SetName        string                `yaml:"set-name,omitempty"`
+	DHCP4          bool                  `yaml:"dhcp4,omitempty"`
+	DHCP6          bool                  `yaml:"dhcp6,omitempty"`
+	DHCP4Overrides *netplanDHCPOverrides `yaml:"dhcp4-overrides,omitempty"`
+	DHCP6Overrides *netplanDHCPOverrides `yaml:"dhcp6-overrides,omitempty"`
+	AcceptRA       bool                  `yaml:"accept-ra,omitempty"`
+
+	// Either a plain address or a single address keyed map with its options
+	Addresses []interface{} `yaml:"addresses,omitempty"`
+
+	Routes      []netplanRoute      `yaml:"routes,omitempty"`
+	Nameservers *netplanNameservers `yaml:"nameservers,omitempty"`
+}
+
+type netplanMatch struct {
+	MACAddress string `yaml:"macaddress"`
+}
+
+type netplanDHCPOverrides struct {
+	UseDomains bool  `yaml:"use-domains"`
+	UseMTU     bool  `yaml:"use-mtu"`
+	UseRoutes  *bool `yaml:"use-routes,omitempty"`
+}
+
+type netplanAddressOptions struct {
+	Label string `yaml:"label"`
+}
+
+type netplanRoute struct {
+	To  string `yaml:"to"`
+	Via string `yaml:"via"`
+}
+
+type netplanNameservers struct {
+	Addresses []string `yaml:"addresses"`
+}
+
+// netplanRenderer writes one netplan YAML file per interface and lets netplan
+// generate the systemd-networkd configuration from it
+type netplanRenderer struct {
+	fs        boshsys.FileSystem
+	cmdRunner boshsys.CmdRunner
+}
+
+func NewNetplanRenderer(fs boshsys.FileSystem, cmdRunner boshsys.CmdRunner) NetworkConfigRenderer {
+	return netplanRenderer{fs: fs, cmdRunner: cmdRunner}
+}
+
+func netplanConfigurationFile(name string) string {
+	return filepath.Join(netplanFolder, fmt.Sprintf("10_%s.yaml", name))
+}
+
+func (r netplanRenderer) IsInterfaceConfigured(name string) bool {
+	return r.fs.FileExists(netplanConfigurationFile(name))
+}
+
+func (r netplanRenderer) RestartNetworking() error {
+	_, _, _, err := r.cmdRunner.RunCommand("netplan", "apply")
+	if err != nil {
+		return bosherr.WrapError(err, "Applying netplan configuration")
+	}
+	return nil
+}
+
+func (r netplanRenderer) WriteInterfaceConfigs(
+	dhcpConfigs DHCPInterfaceConfigurations,
+	staticConfigs StaticInterfaceConfigurations,
+	dnsServers []string,
+	opts boshsys.ConvergeFileContentsOpts) (bool, error) {
+	sort.Stable(dhcpConfigs)
+	sort.Stable(staticConfigs)
+
+	staleConfigFiles := make(map[string]bool)
+	err := r.fs.Walk(netplanFolder, func(match string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if match == netplanFolder || strings.HasSuffix(match, "unmanaged.yaml") {
+			return nil
+		}
+		staleConfigFiles[match] = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	anyChanged := false
+
+	anyIsDefaultForGateway := dhcpConfigs.IsDefaultForGateway()
+	for _, c := range staticConfigs {
+		anyIsDefaultForGateway = anyIsDefaultForGateway || c.IsDefaultForGateway
+	}
+
+	interfaces := map[string]netplanInterface{}
+
+	dhcpConfigsForOneInterface := make(map[string]DHCPInterfaceConfigurations)
+	for _, dynamicAddressConfiguration := range dhcpConfigs {
+		dhcpConfigsForOneInterface[dynamicAddressConfiguration.Name] = append(
+			dhcpConfigsForOneInterface[dynamicAddressConfiguration.Name],
+			dynamicAddressConfiguration,
+		)
+	}
+
+	for interfaceName, dynamicAddressConfigurations := range dhcpConfigsForOneInterface {
+		isDefaultGateway := !anyIsDefaultForGateway || dynamicAddressConfigurations.IsDefaultForGateway()
+
+		iface, err := r.dynamicInterface(dynamicAddressConfigurations, dnsServers, isDefaultGateway)
+		if err != nil {
+			return false, bosherr.WrapErrorf(err, "Updating network configuration for %s", interfaceName)
+		}
+
+		interfaces[interfaceName] = iface
+	}
+
+	for _, staticAddressConfiguration := range staticConfigs {
+		iface, err := r.staticInterface(staticAddressConfiguration, dnsServers)
+		if err != nil {
+			return false, bosherr.WrapErrorf(err, "Updating network configuration for %s", staticAddressConfiguration.Name)
+		}
+
+		interfaces[staticAddressConfiguration.Name] = iface
+	}
+
+	for interfaceName, iface := range interfaces {
+		configPath := netplanConfigurationFile(interfaceName)
+
+		changed, err := r.writeInterfaceConfig(configPath, interfaceName, iface, opts)
+		if err != nil {
+			return false, bosherr.WrapErrorf(err, "Updating network configuration for %s", interfaceName)
+		}
+
+		staleConfigFiles[configPath] = false
+		anyChanged = anyChanged || changed
+	}
+
+	for configFile, isStale := range staleConfigFiles {
+		if isStale {
+			err := r.fs.RemoveAll(configFile)
+			if err != nil {
+				return false, err
+			}
+			anyChanged = true
+		}
+	}
+
+	return anyChanged, nil
+}
+
+func (r netplanRenderer) writeInterfaceConfig(configPath, name string, iface netplanInterface, opts boshsys.ConvergeFileContentsOpts) (bool, error) {
+	config := netplanConfig{
+		Network: netplanNetwork{
+			Version:   2,
+			Renderer:  "networkd",
+			Ethernets: map[string]netplanInterface{name: iface},
+		},
+	}
+
+	buffer := bytes.NewBufferString(netplanConfigHeader)
+
+	encoder := yaml.NewEncoder(buffer)
+	encoder.SetIndent(2)
+
+	err := encoder.Encode(config)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Marshalling netplan configuration")
+	}
+
+	changed, err := r.fs.ConvergeFileContents(configPath, buffer.Bytes(), opts)
+	if err != nil {
+		return false, err
+	}
+
+	// netplan warns about configuration files that are readable by others
+	if changed && !opts.DryRun {
+		err = r.fs.Chmod(configPath, os.FileMode(0600))
+		if err != nil {
+			return false, bosherr.WrapErrorf(err, "Restricting permissions of %s", configPath)
+		}
+	}
+
+	return changed, nil
+}
+
+func (r netplanRenderer) staticInterface(config StaticInterfaceConfiguration, dnsServers []string) (netplanInterface, error) {
+	cidr, err := config.CIDR()
+	if err != nil {
+		return netplanInterface{}, err
+	}
+
+	// netplan derives the broadcast address from the prefix, so it is not set explicitly
+	iface := netplanInterface{
+		Addresses:   []interface{}{fmt.Sprintf("%s/%s", config.Address, cidr)},
+		AcceptRA:    config.IsVersion6(),
+		Nameservers: netplanNameserversFor(dnsServers),
+	}
+
+	// Interfaces of networks without a MAC address, such as aliased ones, are matched by name
+	if config.Mac != "" {
+		iface.Match = &netplanMatch{MACAddress: config.Mac}
+		iface.SetName = config.Name
+	}
+
+	for _, virtualInterface := range config.VirtualInterfaces {
+		iface.Addresses = append(iface.Addresses, map[string]netplanAddressOptions{
+			virtualInterface.Address: {Label: virtualInterface.Label},
+		})
+	}
+
+	if config.IsDefaultForGateway {
+		iface.Routes = append(iface.Routes, netplanRoute{To: "default", Via: config.Gateway})
+	}
+
+	iface.Routes, err = appendNetplanRoutes(iface.Routes, config.PostUpRoutes, config.IsVersion6())
+	if err != nil {
+		return netplanInterface{}, err
+	}
+
+	return iface, nil
+}
+
+func (r netplanRenderer) dynamicInterface(configs DHCPInterfaceConfigurations, dnsServers []string, isDefaultGateway bool) (netplanInterface, error) {
+	overrides := &netplanDHCPOverrides{UseDomains: true, UseMTU: true}
+	if !isDefaultGateway {
+		// only the designated default-gateway NIC installs the DHCP-supplied default route
+		useRoutes := false
+		overrides.UseRoutes = &useRoutes
+	}
+
+	// networkd requires the DHCPv4 and DHCPv6 overrides to be identical
+	iface := netplanInterface{
+		DHCP4:          true,
+		DHCP6:          true,
+		DHCP4Overrides: overrides,
+		DHCP6Overrides: overrides,
+		AcceptRA:       configs.HasVersion6(),
+		Nameservers:    netplanNameserversFor(dnsServers),
+	}
+
+	var err error
+	for _, config := range configs {
+		iface.Routes, err = appendNetplanRoutes(iface.Routes, config.PostUpRoutes, config.IsVersion6())
+		if err != nil {
+			return netplanInterface{}, err
+		}
+	}
+
+	return iface, nil
+}
+
+func appendNetplanRoutes(routes []netplanRoute, postUpRoutes boshsettings.Routes, isVersion6 bool) ([]netplanRoute, error) {
+	for _, postUpRoute := range postUpRoutes {
+		postUpRouteCidr, err := boshsettings.NetmaskToCIDR(postUpRoute.Netmask, isVersion6)
+		if err != nil {
+			return nil, err
+		}
+
+		routes = append(routes, netplanRoute{
+			To:  fmt.Sprintf("%s/%s", postUpRoute.Destination, postUpRouteCidr),
+			Via: postUpRoute.Gateway,
+		})
+	}
+
+	return routes, nil
+}
+
+func netplanNameserversFor(dnsServers []string) *netplanNameservers {
+	if len(dnsServers) == 0 {
+		return nil
+	}
+
+	return &netplanNameservers{Addresses: dnsServers}
+}
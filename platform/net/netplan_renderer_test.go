@@ -0,0 +1,244 @@
+//go:build !windows
+
+package net_test
+
+import (
+	"errors"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/platform/net"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+var _ = Describe("netplanRenderer", func() {
+	var (
+		fs        *fakesys.FakeFileSystem
+		cmdRunner *fakesys.FakeCmdRunner
+		renderer  NetworkConfigRenderer
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		renderer = NewNetplanRenderer(fs, cmdRunner)
+	})
+
+	Describe("WriteInterfaceConfigs", func() {
+		It("writes static interfaces matched by their MAC address", func() {
+			changed, err := renderer.WriteInterfaceConfigs(
+				nil,
+				StaticInterfaceConfigurations{{
+					Name:                "eth0",
+					Address:             "1.2.3.4",
+					Netmask:             "255.255.255.0",
+					Network:             "1.2.3.0",
+					Broadcast:           "1.2.3.255",
+					IsDefaultForGateway: true,
+					Mac:                 "aa:bb:cc:dd:ee:ff",
+					Gateway:             "1.2.3.1",
+					PostUpRoutes: boshsettings.Routes{
+						{Destination: "10.0.0.0", Netmask: "255.255.0.0", Gateway: "1.2.3.254"},
+					},
+					VirtualInterfaces: []VirtualInterface{
+						{Label: "eth0:1", Address: "1.2.3.5/24"},
+					},
+				}},
+				[]string{"8.8.8.8", "9.9.9.9"},
+				boshsys.ConvergeFileContentsOpts{},
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeTrue())
+
+			Expect(fs.ReadFileString("/etc/netplan/10_eth0.yaml")).To(Equal(`# Generated by bosh-agent
+network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      match:
+        macaddress: aa:bb:cc:dd:ee:ff
+      set-name: eth0
+      addresses:
+        - 1.2.3.4/24
+        - 1.2.3.5/24:
+            label: eth0:1
+      routes:
+        - to: default
+          via: 1.2.3.1
+        - to: 10.0.0.0/16
+          via: 1.2.3.254
+      nameservers:
+        addresses:
+          - 8.8.8.8
+          - 9.9.9.9
+`))
+			Expect(fs.GetFileTestStat("/etc/netplan/10_eth0.yaml").FileMode).To(Equal(os.FileMode(0600)))
+		})
+
+		It("writes IPv6 static interfaces that accept router advertisements", func() {
+			_, err := renderer.WriteInterfaceConfigs(
+				nil,
+				StaticInterfaceConfigurations{{
+					Name:    "eth0",
+					Address: "2601:646:100:e8e8::103",
+					Netmask: "ffff:ffff:ffff:ffff::",
+				}},
+				nil,
+				boshsys.ConvergeFileContentsOpts{},
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/etc/netplan/10_eth0.yaml")).To(Equal(`# Generated by bosh-agent
+network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      accept-ra: true
+      addresses:
+        - 2601:646:100:e8e8::103/64
+`))
+		})
+
+		It("only lets the default gateway interface use the DHCP routes", func() {
+			_, err := renderer.WriteInterfaceConfigs(
+				DHCPInterfaceConfigurations{
+					{Name: "eth0", IsDefaultForGateway: true},
+					{Name: "eth1", PostUpRoutes: boshsettings.Routes{
+						{Destination: "10.0.0.0", Netmask: "255.0.0.0", Gateway: "10.1.0.1"},
+					}},
+				},
+				nil,
+				[]string{"8.8.8.8"},
+				boshsys.ConvergeFileContentsOpts{},
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/etc/netplan/10_eth0.yaml")).To(Equal(`# Generated by bosh-agent
+network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      dhcp4: true
+      dhcp6: true
+      dhcp4-overrides:
+        use-domains: true
+        use-mtu: true
+      dhcp6-overrides:
+        use-domains: true
+        use-mtu: true
+      nameservers:
+        addresses:
+          - 8.8.8.8
+`))
+
+			Expect(fs.ReadFileString("/etc/netplan/10_eth1.yaml")).To(Equal(`# Generated by bosh-agent
+network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth1:
+      dhcp4: true
+      dhcp6: true
+      dhcp4-overrides:
+        use-domains: true
+        use-mtu: true
+        use-routes: false
+      dhcp6-overrides:
+        use-domains: true
+        use-mtu: true
+        use-routes: false
+      routes:
+        - to: 10.0.0.0/8
+          via: 10.1.0.1
+      nameservers:
+        addresses:
+          - 8.8.8.8
+`))
+		})
+
+		It("accepts router advertisements for IPv6 DHCP interfaces", func() {
+			_, err := renderer.WriteInterfaceConfigs(
+				DHCPInterfaceConfigurations{{Name: "eth0", Address: "2601:646:100:e8e8::103"}},
+				nil,
+				nil,
+				boshsys.ConvergeFileContentsOpts{},
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/etc/netplan/10_eth0.yaml")).To(ContainSubstring("      accept-ra: true\n"))
+		})
+
+		It("removes stale configs but keeps unmanaged ones", func() {
+			Expect(fs.WriteFileString("/etc/netplan/10_eth1.yaml", "stale")).To(Succeed())
+			Expect(fs.WriteFileString("/etc/netplan/50-cloud-init.yaml", "stale")).To(Succeed())
+			Expect(fs.WriteFileString("/etc/netplan/99-unmanaged.yaml", "unmanaged")).To(Succeed())
+
+			changed, err := renderer.WriteInterfaceConfigs(
+				DHCPInterfaceConfigurations{{Name: "eth0"}},
+				nil,
+				nil,
+				boshsys.ConvergeFileContentsOpts{},
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeTrue())
+
+			Expect(fs.FileExists("/etc/netplan/10_eth0.yaml")).To(BeTrue())
+			Expect(fs.FileExists("/etc/netplan/99-unmanaged.yaml")).To(BeTrue())
+			Expect(fs.FileExists("/etc/netplan/10_eth1.yaml")).To(BeFalse())
+			Expect(fs.FileExists("/etc/netplan/50-cloud-init.yaml")).To(BeFalse())
+		})
+
+		It("reports no change when the configs are up to date", func() {
+			configs := DHCPInterfaceConfigurations{{Name: "eth0"}}
+
+			_, err := renderer.WriteInterfaceConfigs(configs, nil, nil, boshsys.ConvergeFileContentsOpts{})
+			Expect(err).ToNot(HaveOccurred())
+
+			changed, err := renderer.WriteInterfaceConfigs(configs, nil, nil, boshsys.ConvergeFileContentsOpts{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeFalse())
+		})
+
+		It("does not write anything on a dry run", func() {
+			changed, err := renderer.WriteInterfaceConfigs(
+				DHCPInterfaceConfigurations{{Name: "eth0"}},
+				nil,
+				nil,
+				boshsys.ConvergeFileContentsOpts{DryRun: true},
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(fs.FileExists("/etc/netplan/10_eth0.yaml")).To(BeFalse())
+		})
+	})
+
+	Describe("IsInterfaceConfigured", func() {
+		It("checks for the netplan config of the interface", func() {
+			Expect(fs.WriteFileString("/etc/netplan/10_eth0.yaml", "")).To(Succeed())
+
+			Expect(renderer.IsInterfaceConfigured("eth0")).To(BeTrue())
+			Expect(renderer.IsInterfaceConfigured("eth1")).To(BeFalse())
+		})
+	})
+
+	Describe("RestartNetworking", func() {
+		It("applies the netplan configuration", func() {
+			Expect(renderer.RestartNetworking()).To(Succeed())
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"netplan", "apply"}}))
+		})
+
+		It("returns an error when applying fails", func() {
+			cmdRunner.AddCmdResult("netplan apply", fakesys.FakeCmdResult{Error: errors.New("fake-apply-err")})
+
+			Expect(renderer.RestartNetworking()).To(MatchError(ContainSubstring("fake-apply-err")))
+		})
+	})
+})
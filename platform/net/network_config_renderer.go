@@ -0,0 +1,25 @@
+package net
+
+import (
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// NetworkConfigRenderer turns the computed interface configurations into the
+// configuration files of the network backend of the host
+type NetworkConfigRenderer interface {
+	// WriteInterfaceConfigs converges the configuration files of all interfaces
+	// and removes the ones of interfaces that are no longer configured;
+	// it reports whether anything changed
+	WriteInterfaceConfigs(
+		dhcpConfigs DHCPInterfaceConfigurations,
+		staticConfigs StaticInterfaceConfigurations,
+		dnsServers []string,
+		opts boshsys.ConvergeFileContentsOpts,
+	) (bool, error)
+
+	// IsInterfaceConfigured reports whether a configuration file was written for the interface
+	IsInterfaceConfigured(name string) bool
+
+	// RestartNetworking applies the written configuration files
+	RestartNetworking() error
+}
@@ -0,0 +1,272 @@
+package net
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	ini "github.com/cloudfoundry/bosh-agent/v2/ini"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+const systemdNetworkFolder = "/etc/systemd/network"
+
+// systemdNetworkdRenderer writes one .network file per interface into the
+// systemd-networkd folder
+type systemdNetworkdRenderer struct {
+	fs        boshsys.FileSystem
+	cmdRunner boshsys.CmdRunner
+}
+
+func NewSystemdNetworkdRenderer(fs boshsys.FileSystem, cmdRunner boshsys.CmdRunner) NetworkConfigRenderer {
+	return systemdNetworkdRenderer{fs: fs, cmdRunner: cmdRunner}
+}
+
+func (r systemdNetworkdRenderer) IsInterfaceConfigured(name string) bool {
+	return r.fs.FileExists(interfaceConfigurationFile(name))
+}
+
+func (r systemdNetworkdRenderer) RestartNetworking() error {
+	_, _, _, err := r.cmdRunner.RunCommand("/var/vcap/bosh/bin/restart_networking")
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func interfaceConfigurationFile(name string) string {
+	interfaceBasename := fmt.Sprintf("10_%s.network", name)
+	return filepath.Join(systemdNetworkFolder, interfaceBasename)
+}
+
+func (r systemdNetworkdRenderer) WriteInterfaceConfigs(
+	dhcpConfigs DHCPInterfaceConfigurations,
+	staticConfigs StaticInterfaceConfigurations,
+	dnsServers []string,
+	opts boshsys.ConvergeFileContentsOpts) (bool, error) {
+	sort.Stable(dhcpConfigs)
+	sort.Stable(staticConfigs)
+
+	staleNetworkConfigFiles := make(map[string]bool)
+	err := r.fs.Walk(systemdNetworkFolder, func(match string, _ os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(match, "unmanaged.network") {
+			return nil
+		}
+		staleNetworkConfigFiles[match] = true
+		return nil
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	anyChanged := false
+
+	anyIsDefaultForGateway := dhcpConfigs.IsDefaultForGateway()
+	if !anyIsDefaultForGateway {
+		for _, c := range staticConfigs {
+			if c.IsDefaultForGateway {
+				anyIsDefaultForGateway = true
+				break
+			}
+		}
+	}
+
+	dhcpConfigsForOneInterface := make(map[string]DHCPInterfaceConfigurations)
+	for _, dynamicAddressConfiguration := range dhcpConfigs {
+		dhcpConfigsForOneInterface[dynamicAddressConfiguration.Name] = append(
+			dhcpConfigsForOneInterface[dynamicAddressConfiguration.Name],
+			dynamicAddressConfiguration,
+		)
+	}
+
+	for interfaceName, dynamicAddressConfigurations := range dhcpConfigsForOneInterface {
+		isDefaultGateway := !anyIsDefaultForGateway || dynamicAddressConfigurations.IsDefaultForGateway()
+		changed, err := r.writeDynamicInterfaceConfiguration(dynamicAddressConfigurations, dnsServers, isDefaultGateway, opts)
+		if err != nil {
+			return false, bosherr.WrapError(err, fmt.Sprintf("Updating network configuration for %s", interfaceName))
+		}
+
+		newNetworkFile := interfaceConfigurationFile(interfaceName)
+		if _, ok := staleNetworkConfigFiles[newNetworkFile]; ok {
+			staleNetworkConfigFiles[newNetworkFile] = false
+		}
+
+		anyChanged = anyChanged || changed
+	}
+
+	for _, staticAddressConfiguration := range staticConfigs {
+		changed, err := r.writeStaticInterfaceConfiguration(staticAddressConfiguration, dnsServers, opts)
+		if err != nil {
+			return false, bosherr.WrapError(err, fmt.Sprintf("Updating network configuration for %s", staticAddressConfiguration.Name))
+		}
+
+		newNetworkFile := interfaceConfigurationFile(staticAddressConfiguration.Name)
+		if _, ok := staleNetworkConfigFiles[newNetworkFile]; ok {
+			staleNetworkConfigFiles[newNetworkFile] = false
+		}
+
+		anyChanged = anyChanged || changed
+	}
+
+	for networkFile, isStale := range staleNetworkConfigFiles {
+		if networkFile == systemdNetworkFolder {
+			continue
+		}
+		if isStale {
+			err := r.fs.RemoveAll(networkFile)
+			if err != nil {
+				return false, err
+			}
+			anyChanged = true
+		}
+	}
+	return anyChanged, nil
+}
+
+func (r systemdNetworkdRenderer) writeStaticInterfaceConfiguration(config StaticInterfaceConfiguration, dnsServers []string, opts boshsys.ConvergeFileContentsOpts) (bool, error) {
+	var err error
+	configPath := interfaceConfigurationFile(config.Name)
+
+	cidr, err := config.CIDR()
+	if err != nil {
+		return false, err
+	}
+
+	file := ini.Empty()
+	file.Comment = "# Generated by bosh-agent"
+
+	// Match Section
+	matchSection := &ini.Section{Name: "Match"}
+	matchSection.AddKey("Name", config.Name)
+	file.AppendSection(matchSection)
+
+	// Address Section
+	addressSection := &ini.Section{Name: "Address"}
+	addressSection.AddKey("Address", fmt.Sprintf("%s/%s", config.Address, cidr))
+	if config.IsDefaultForGateway && !config.IsVersion6() {
+		addressSection.AddKey("Broadcast", config.Broadcast)
+	}
+	file.AppendSection(addressSection)
+
+	// Virtual Interfaces
+	for _, virtualInterface := range config.VirtualInterfaces {
+		addressSection := &ini.Section{Name: "Address"}
+		addressSection.AddKey("Label", virtualInterface.Label)
+		addressSection.AddKey("Address", virtualInterface.Address)
+		file.AppendSection(addressSection)
+	}
+
+	// Network Section
+	networkSection := &ini.Section{Name: "Network"}
+	if config.IsDefaultForGateway {
+		networkSection.AddKey("Gateway", config.Gateway)
+	}
+
+	if config.IsVersion6() {
+		networkSection.AddKey("IPv6AcceptRA", "true")
+	}
+
+	for _, dnsServer := range dnsServers {
+		networkSection.AddKey("DNS", dnsServer)
+	}
+	file.AppendSection(networkSection)
+
+	// Route Sections
+	for _, postUpRoute := range config.PostUpRoutes {
+		routeSection := &ini.Section{Name: "Route"}
+		postUpRouteCidr, err := boshsettings.NetmaskToCIDR(postUpRoute.Netmask, config.IsVersion6())
+		if err != nil {
+			return false, err
+		}
+
+		routeSection.AddKey("Destination", fmt.Sprintf("%s/%s", postUpRoute.Destination, postUpRouteCidr))
+		routeSection.AddKey("Gateway", postUpRoute.Gateway)
+
+		file.AppendSection(routeSection)
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	_, err = file.WriteTo(buffer)
+	if err != nil {
+		return false, err
+	}
+
+	return r.fs.ConvergeFileContents(configPath, buffer.Bytes(), opts)
+}
+
+func (r systemdNetworkdRenderer) writeDynamicInterfaceConfiguration(configs DHCPInterfaceConfigurations, dnsServers []string, isDefaultGateway bool, opts boshsys.ConvergeFileContentsOpts) (bool, error) {
+	var err error
+	// all configs share the same name, so we just use the name from the first config
+	configPath := interfaceConfigurationFile(configs[0].Name)
+
+	file := ini.Empty()
+	file.Comment = "# Generated by bosh-agent"
+
+	// Match Section
+	matchSection := &ini.Section{Name: "Match"}
+	matchSection.AddKey("Name", configs[0].Name)
+	file.AppendSection(matchSection)
+
+	// Network Section
+	networkSection := &ini.Section{Name: "Network"}
+	networkSection.AddKey("DHCP", "yes")
+	if configs.HasVersion6() {
+		networkSection.AddKey("IPv6AcceptRA", "true")
+	}
+
+	for _, dnsServer := range dnsServers {
+		networkSection.AddKey("DNS", dnsServer)
+	}
+	file.AppendSection(networkSection)
+
+	// DHCP Section
+	dhcpSection := &ini.Section{Name: "DHCP"}
+	dhcpSection.AddKey("UseDomains", "yes")
+	dhcpSection.AddKey("UseMTU", "yes")
+	if !isDefaultGateway {
+		// UseRoutes=no prevents systemd-networkd from installing the DHCP-supplied
+		// default gateway (and any classless static routes) for this NIC, so only
+		// the designated default-gateway NIC installs a default route.
+		//
+		// UseGateway=no would be more precise (gateway only, routes kept), but it
+		// was introduced in systemd 250. Ubuntu 22.04 Jammy ships systemd 249, so
+		// UseGateway= is silently ignored on current stemcells. UseRoutes=no has
+		// been supported since systemd 217 and works on all supported stemcells.
+		dhcpSection.AddKey("UseRoutes", "no")
+	}
+	file.AppendSection(dhcpSection)
+
+	// Route Sections
+	for _, config := range configs {
+		for _, postUpRoute := range config.PostUpRoutes {
+			routeSection := &ini.Section{Name: "Route"}
+			postUpRouteCidr, err := boshsettings.NetmaskToCIDR(postUpRoute.Netmask, config.IsVersion6())
+			if err != nil {
+				return false, err
+			}
+
+			routeSection.AddKey("Destination", fmt.Sprintf("%s/%s", postUpRoute.Destination, postUpRouteCidr))
+			routeSection.AddKey("Gateway", postUpRoute.Gateway)
+
+			file.AppendSection(routeSection)
+		}
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	_, err = file.WriteTo(buffer)
+	if err != nil {
+		return false, err
+	}
+
+	return r.fs.ConvergeFileContents(configPath, buffer.Bytes(), opts)
+}
@@ -3,10 +3,7 @@ package net
 import (
 	"bytes"
 	"fmt"
-	"os"
-	"path/filepath"
 	"regexp"
-	"sort"
 	"strings"
 	"text/template"
 	"time"
@@ -20,15 +17,11 @@ import (
 	boshdnsresolver "github.com/cloudfoundry/bosh-agent/v2/platform/net/dnsresolver"
 	boship "github.com/cloudfoundry/bosh-agent/v2/platform/net/ip"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
-
-	ini "github.com/cloudfoundry/bosh-agent/v2/ini"
 )
 
 const (
 	UbuntuNetManagerLogTag = "UbuntuNetManager"
 
-	systemdNetworkFolder = "/etc/systemd/network"
-
 	// DHCP Config file - /etc/dhcp/dhclient.conf
 	// Ubuntu 14.04 accepts several DNS as a list in a single prepend directive
 	dhclientConfTemplate = `# Generated by bosh-agent
@@ -56,6 +49,7 @@ type UbuntuNetManager struct {
 	dnsResolver                   boshdnsresolver.DNSResolver
 	addressBroadcaster            bosharp.AddressBroadcaster
 	kernelIPv6                    KernelIPv6
+	networkConfigRenderer         NetworkConfigRenderer
 	logger                        boshlog.Logger
 }
 
@@ -69,6 +63,7 @@ func NewUbuntuNetManager(
 	dnsResolver boshdnsresolver.DNSResolver,
 	addressBroadcaster bosharp.AddressBroadcaster,
 	kernelIPv6 KernelIPv6,
+	networkConfigRenderer NetworkConfigRenderer,
 	logger boshlog.Logger,
 ) Manager {
 	return UbuntuNetManager{
@@ -81,6 +76,7 @@ func NewUbuntuNetManager(
 		dnsResolver:                   dnsResolver,
 		addressBroadcaster:            addressBroadcaster,
 		kernelIPv6:                    kernelIPv6,
+		networkConfigRenderer:         networkConfigRenderer,
 		logger:                        logger,
 	}
 }
@@ -93,7 +89,7 @@ func (net UbuntuNetManager) GetConfiguredNetworkInterfaces() ([]string, error) {
 
 	interfaces := make([]string, 0, len(interfacesByMacAddress))
 	for _, iface := range interfacesByMacAddress {
-		if net.fs.FileExists(interfaceConfigurationFile(iface)) {
+		if net.networkConfigRenderer.IsInterfaceConfigured(iface) {
 			interfaces = append(interfaces, iface)
 		}
 	}
@@ -139,7 +135,7 @@ func (net UbuntuNetManager) SetupNetworking(networks boshsettings.Networks, mbus
 			return err
 		}
 
-		err := net.restartNetworking()
+		err := net.networkConfigRenderer.RestartNetworking()
 		if err != nil {
 			return bosherr.WrapError(err, "Failure restarting networking")
 		}
@@ -247,7 +243,7 @@ func (net UbuntuNetManager) writeNetConfigs(
 	staticConfigs StaticInterfaceConfigurations,
 	dnsServers []string,
 	opts boshsys.ConvergeFileContentsOpts) (bool, error) {
-	interfacesChanged, err := net.writeNetworkInterfaces(dhcpConfigs, staticConfigs, dnsServers, opts)
+	interfacesChanged, err := net.networkConfigRenderer.WriteInterfaceConfigs(dhcpConfigs, staticConfigs, dnsServers, opts)
 	if err != nil {
 		return false, bosherr.WrapError(err, "Writing network configuration")
 	}
@@ -319,14 +315,6 @@ func (net UbuntuNetManager) ifaceAddresses(staticConfigs []StaticInterfaceConfig
 	return staticAddresses, dynamicAddresses
 }
 
-func (net UbuntuNetManager) restartNetworking() error {
-	_, _, _, err := net.cmdRunner.RunCommand("/var/vcap/bosh/bin/restart_networking")
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
 func (net UbuntuNetManager) writeDHCPConfiguration(dnsServers []string, opts boshsys.ConvergeFileContentsOpts) (bool, error) {
 	buffer := bytes.NewBuffer([]byte{})
 	t := template.Must(template.New("dhcp-config").Parse(dhclientConfTemplate))
@@ -348,234 +336,3 @@ func (net UbuntuNetManager) writeDHCPConfiguration(dnsServers []string, opts bos
 
 	return changed, nil
 }
-
-func interfaceConfigurationFile(name string) string {
-	interfaceBasename := fmt.Sprintf("10_%s.network", name)
-	return filepath.Join(systemdNetworkFolder, interfaceBasename)
-}
-
-func (net UbuntuNetManager) writeNetworkInterfaces(
-	dhcpConfigs DHCPInterfaceConfigurations,
-	staticConfigs StaticInterfaceConfigurations,
-	dnsServers []string,
-	opts boshsys.ConvergeFileContentsOpts) (bool, error) {
-	sort.Stable(dhcpConfigs)
-	sort.Stable(staticConfigs)
-
-	staleNetworkConfigFiles := make(map[string]bool)
-	err := net.fs.Walk(systemdNetworkFolder, func(match string, _ os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if strings.HasSuffix(match, "unmanaged.network") {
-			return nil
-		}
-		staleNetworkConfigFiles[match] = true
-		return nil
-	})
-
-	if err != nil {
-		return false, err
-	}
-
-	anyChanged := false
-
-	anyIsDefaultForGateway := dhcpConfigs.IsDefaultForGateway()
-	if !anyIsDefaultForGateway {
-		for _, c := range staticConfigs {
-			if c.IsDefaultForGateway {
-				anyIsDefaultForGateway = true
-				break
-			}
-		}
-	}
-
-	dhcpConfigsForOneInterface := make(map[string]DHCPInterfaceConfigurations)
-	for _, dynamicAddressConfiguration := range dhcpConfigs {
-		dhcpConfigsForOneInterface[dynamicAddressConfiguration.Name] = append(
-			dhcpConfigsForOneInterface[dynamicAddressConfiguration.Name],
-			dynamicAddressConfiguration,
-		)
-	}
-
-	for interfaceName, dynamicAddressConfigurations := range dhcpConfigsForOneInterface {
-		isDefaultGateway := !anyIsDefaultForGateway || dynamicAddressConfigurations.IsDefaultForGateway()
-		changed, err := net.writeDynamicInterfaceConfiguration(dynamicAddressConfigurations, dnsServers, isDefaultGateway, opts)
-		if err != nil {
-			return false, bosherr.WrapError(err, fmt.Sprintf("Updating network configuration for %s", interfaceName))
-		}
-
-		newNetworkFile := interfaceConfigurationFile(interfaceName)
-		if _, ok := staleNetworkConfigFiles[newNetworkFile]; ok {
-			staleNetworkConfigFiles[newNetworkFile] = false
-		}
-
-		anyChanged = anyChanged || changed
-	}
-
-	for _, staticAddressConfiguration := range staticConfigs {
-		changed, err := net.writeStaticInterfaceConfiguration(staticAddressConfiguration, dnsServers, opts)
-		if err != nil {
-			return false, bosherr.WrapError(err, fmt.Sprintf("Updating network configuration for %s", staticAddressConfiguration.Name))
-		}
-
-		newNetworkFile := interfaceConfigurationFile(staticAddressConfiguration.Name)
-		if _, ok := staleNetworkConfigFiles[newNetworkFile]; ok {
-			staleNetworkConfigFiles[newNetworkFile] = false
-		}
-
-		anyChanged = anyChanged || changed
-	}
-
-	for networkFile, isStale := range staleNetworkConfigFiles {
-		if networkFile == systemdNetworkFolder {
-			continue
-		}
-		if isStale {
-			err := net.fs.RemoveAll(networkFile)
-			if err != nil {
-				return false, err
-			}
-			anyChanged = true
-		}
-	}
-	return anyChanged, nil
-}
-
-func (net UbuntuNetManager) writeStaticInterfaceConfiguration(config StaticInterfaceConfiguration, dnsServers []string, opts boshsys.ConvergeFileContentsOpts) (bool, error) {
-	var err error
-	configPath := interfaceConfigurationFile(config.Name)
-
-	cidr, err := config.CIDR()
-	if err != nil {
-		return false, err
-	}
-
-	file := ini.Empty()
-	file.Comment = "# Generated by bosh-agent"
-
-	// Match Section
-	matchSection := &ini.Section{Name: "Match"}
-	matchSection.AddKey("Name", config.Name)
-	file.AppendSection(matchSection)
-
-	// Address Section
-	addressSection := &ini.Section{Name: "Address"}
-	addressSection.AddKey("Address", fmt.Sprintf("%s/%s", config.Address, cidr))
-	if config.IsDefaultForGateway && !config.IsVersion6() {
-		addressSection.AddKey("Broadcast", config.Broadcast)
-	}
-	file.AppendSection(addressSection)
-
-	// Virtual Interfaces
-	for _, virtualInterface := range config.VirtualInterfaces {
-		addressSection := &ini.Section{Name: "Address"}
-		addressSection.AddKey("Label", virtualInterface.Label)
-		addressSection.AddKey("Address", virtualInterface.Address)
-		file.AppendSection(addressSection)
-	}
-
-	// Network Section
-	networkSection := &ini.Section{Name: "Network"}
-	if config.IsDefaultForGateway {
-		networkSection.AddKey("Gateway", config.Gateway)
-	}
-
-	if config.IsVersion6() {
-		networkSection.AddKey("IPv6AcceptRA", "true")
-	}
-
-	for _, dnsServer := range dnsServers {
-		networkSection.AddKey("DNS", dnsServer)
-	}
-	file.AppendSection(networkSection)
-
-	// Route Sections
-	for _, postUpRoute := range config.PostUpRoutes {
-		routeSection := &ini.Section{Name: "Route"}
-		postUpRouteCidr, err := boshsettings.NetmaskToCIDR(postUpRoute.Netmask, config.IsVersion6())
-		if err != nil {
-			return false, err
-		}
-
-		routeSection.AddKey("Destination", fmt.Sprintf("%s/%s", postUpRoute.Destination, postUpRouteCidr))
-		routeSection.AddKey("Gateway", postUpRoute.Gateway)
-
-		file.AppendSection(routeSection)
-	}
-
-	buffer := bytes.NewBuffer(nil)
-	_, err = file.WriteTo(buffer)
-	if err != nil {
-		return false, err
-	}
-
-	return net.fs.ConvergeFileContents(configPath, buffer.Bytes(), opts)
-}
-
-func (net UbuntuNetManager) writeDynamicInterfaceConfiguration(configs DHCPInterfaceConfigurations, dnsServers []string, isDefaultGateway bool, opts boshsys.ConvergeFileContentsOpts) (bool, error) {
-	var err error
-	// all configs share the same name, so we just use the name from the first config
-	configPath := interfaceConfigurationFile(configs[0].Name)
-
-	file := ini.Empty()
-	file.Comment = "# Generated by bosh-agent"
-
-	// Match Section
-	matchSection := &ini.Section{Name: "Match"}
-	matchSection.AddKey("Name", configs[0].Name)
-	file.AppendSection(matchSection)
-
-	// Network Section
-	networkSection := &ini.Section{Name: "Network"}
-	networkSection.AddKey("DHCP", "yes")
-	if configs.HasVersion6() {
-		networkSection.AddKey("IPv6AcceptRA", "true")
-	}
-
-	for _, dnsServer := range dnsServers {
-		networkSection.AddKey("DNS", dnsServer)
-	}
-	file.AppendSection(networkSection)
-
-	// DHCP Section
-	dhcpSection := &ini.Section{Name: "DHCP"}
-	dhcpSection.AddKey("UseDomains", "yes")
-	dhcpSection.AddKey("UseMTU", "yes")
-	if !isDefaultGateway {
-		// UseRoutes=no prevents systemd-networkd from installing the DHCP-supplied
-		// default gateway (and any classless static routes) for this NIC, so only
-		// the designated default-gateway NIC installs a default route.
-		//
-		// UseGateway=no would be more precise (gateway only, routes kept), but it
-		// was introduced in systemd 250. Ubuntu 22.04 Jammy ships systemd 249, so
-		// UseGateway= is silently ignored on current stemcells. UseRoutes=no has
-		// been supported since systemd 217 and works on all supported stemcells.
-		dhcpSection.AddKey("UseRoutes", "no")
-	}
-	file.AppendSection(dhcpSection)
-
-	// Route Sections
-	for _, config := range configs {
-		for _, postUpRoute := range config.PostUpRoutes {
-			routeSection := &ini.Section{Name: "Route"}
-			postUpRouteCidr, err := boshsettings.NetmaskToCIDR(postUpRoute.Netmask, config.IsVersion6())
-			if err != nil {
-				return false, err
-			}
-
-			routeSection.AddKey("Destination", fmt.Sprintf("%s/%s", postUpRoute.Destination, postUpRouteCidr))
-			routeSection.AddKey("Gateway", postUpRoute.Gateway)
-
-			file.AppendSection(routeSection)
-		}
-	}
-
-	buffer := bytes.NewBuffer(nil)
-	_, err = file.WriteTo(buffer)
-	if err != nil {
-		return false, err
-	}
-
-	return net.fs.ConvergeFileContents(configPath, buffer.Bytes(), opts)
-}
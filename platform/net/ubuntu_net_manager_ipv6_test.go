@@ -65,6 +65,7 @@ var _ = Describe("UbuntuNetManager (IPv6)", func() {
 			fakeDnsResolver,
 			addressBroadcaster,
 			kernelIPv6,
+			NewSystemdNetworkdRenderer(fs, cmdRunner),
 			logger,
 		).(UbuntuNetManager)
 	})
@@ -67,6 +67,7 @@ var _ = Describe("ubuntuNetManager", func() {
 			fakeDnsResolver,
 			addressBroadcaster,
 			kernelIPv6,
+			NewSystemdNetworkdRenderer(fs, cmdRunner),
 			logger,
 		).(UbuntuNetManager)
 
@@ -29,6 +29,14 @@ type AuditLoggerProvider interface {
 	ProvideErrorLogger() (*log.Logger, error)
 }
 
+// PersistentDiskGrowth reports the sizes of a persistent disk before and after it was grown
+type PersistentDiskGrowth struct {
+	DeviceSizeBeforeInBytes    uint64 `json:"device_size_before_in_bytes"`
+	DeviceSizeAfterInBytes     uint64 `json:"device_size_after_in_bytes"`
+	PartitionSizeBeforeInBytes uint64 `json:"partition_size_before_in_bytes"`
+	PartitionSizeAfterInBytes  uint64 `json:"partition_size_after_in_bytes"`
+}
+
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 //counterfeiter:generate . Platform
 //counterfeiter:generate . AuditLogger
@@ -83,6 +91,7 @@ type Platform interface {
 	// Disk management
 	AdjustPersistentDiskPartitioning(diskSettings boshsettings.DiskSettings, mountPoint string) error
 	MountPersistentDisk(diskSettings boshsettings.DiskSettings, mountPoint string) error
+	GrowPersistentDisk(diskSettings boshsettings.DiskSettings) (PersistentDiskGrowth, error)
 	UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (didUnmount bool, err error)
 	MigratePersistentDisk(fromMountPoint, toMountPoint string) (err error)
 	GetEphemeralDiskPath(diskSettings boshsettings.DiskSettings) (string, error)
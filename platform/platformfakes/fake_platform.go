@@ -344,6 +344,19 @@ type FakePlatform struct {
 	getVitalsServiceReturnsOnCall map[int]struct {
 		result1 vitals.Service
 	}
+	GrowPersistentDiskStub        func(settings.DiskSettings) (platform.PersistentDiskGrowth, error)
+	growPersistentDiskMutex       sync.RWMutex
+	growPersistentDiskArgsForCall []struct {
+		arg1 settings.DiskSettings
+	}
+	growPersistentDiskReturns struct {
+		result1 platform.PersistentDiskGrowth
+		result2 error
+	}
+	growPersistentDiskReturnsOnCall map[int]struct {
+		result1 platform.PersistentDiskGrowth
+		result2 error
+	}
 	IsMountPointStub        func(string) (string, bool, error)
 	isMountPointMutex       sync.RWMutex
 	isMountPointArgsForCall []struct {
@@ -2449,6 +2462,70 @@ func (fake *FakePlatform) GetVitalsServiceReturnsOnCall(i int, result1 vitals.Se
 	}{result1}
 }
 
+func (fake *FakePlatform) GrowPersistentDisk(arg1 settings.DiskSettings) (platform.PersistentDiskGrowth, error) {
+	fake.growPersistentDiskMutex.Lock()
+	ret, specificReturn := fake.growPersistentDiskReturnsOnCall[len(fake.growPersistentDiskArgsForCall)]
+	fake.growPersistentDiskArgsForCall = append(fake.growPersistentDiskArgsForCall, struct {
+		arg1 settings.DiskSettings
+	}{arg1})
+	stub := fake.GrowPersistentDiskStub
+	fakeReturns := fake.growPersistentDiskReturns
+	fake.recordInvocation("GrowPersistentDisk", []interface{}{arg1})
+	fake.growPersistentDiskMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakePlatform) GrowPersistentDiskCallCount() int {
+	fake.growPersistentDiskMutex.RLock()
+	defer fake.growPersistentDiskMutex.RUnlock()
+	return len(fake.growPersistentDiskArgsForCall)
+}
+
+func (fake *FakePlatform) GrowPersistentDiskCalls(stub func(settings.DiskSettings) (platform.PersistentDiskGrowth, error)) {
+	fake.growPersistentDiskMutex.Lock()
+	defer fake.growPersistentDiskMutex.Unlock()
+	fake.GrowPersistentDiskStub = stub
+}
+
+func (fake *FakePlatform) GrowPersistentDiskArgsForCall(i int) settings.DiskSettings {
+	fake.growPersistentDiskMutex.RLock()
+	defer fake.growPersistentDiskMutex.RUnlock()
+	argsForCall := fake.growPersistentDiskArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakePlatform) GrowPersistentDiskReturns(result1 platform.PersistentDiskGrowth, result2 error) {
+	fake.growPersistentDiskMutex.Lock()
+	defer fake.growPersistentDiskMutex.Unlock()
+	fake.GrowPersistentDiskStub = nil
+	fake.growPersistentDiskReturns = struct {
+		result1 platform.PersistentDiskGrowth
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakePlatform) GrowPersistentDiskReturnsOnCall(i int, result1 platform.PersistentDiskGrowth, result2 error) {
+	fake.growPersistentDiskMutex.Lock()
+	defer fake.growPersistentDiskMutex.Unlock()
+	fake.GrowPersistentDiskStub = nil
+	if fake.growPersistentDiskReturnsOnCall == nil {
+		fake.growPersistentDiskReturnsOnCall = make(map[int]struct {
+			result1 platform.PersistentDiskGrowth
+			result2 error
+		})
+	}
+	fake.growPersistentDiskReturnsOnCall[i] = struct {
+		result1 platform.PersistentDiskGrowth
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakePlatform) IsMountPoint(arg1 string) (string, bool, error) {
 	fake.isMountPointMutex.Lock()
 	ret, specificReturn := fake.isMountPointReturnsOnCall[len(fake.isMountPointArgsForCall)]
@@ -46,7 +46,7 @@ type Provider interface {
 }
 
 type provider struct {
-	platforms map[string]func() Platform
+	platforms map[string]func() (Platform, error)
 }
 
 type Options struct {
@@ -76,7 +76,7 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 	// Kick of stats collection as soon as possible
 	statsCollector.StartCollecting(SigarStatsCollectionInterval, nil)
 
-	vitalsService := boshvitals.NewService(statsCollector, dirProvider, linuxDiskManager.GetMounter())
+	vitalsService := boshvitals.NewService(statsCollector, dirProvider, linuxDiskManager.GetMounter(), logger)
 
 	ipResolver := boship.NewResolver(boship.NetworkInterfaceToAddrsFunc)
 
@@ -96,7 +96,18 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 	kernelIPv6 := boshnet.NewKernelIPv6Impl(fs, runner, logger)
 	macAddressDetector := boshnet.NewLinuxMacAddressDetector(fs, logger)
 
-	ubuntuNetManager := boshnet.NewUbuntuNetManager(fs, runner, ipResolver, macAddressDetector, interfaceConfigurationCreator, interfaceAddressesProvider, dnsResolver, arping, kernelIPv6, logger)
+	var networkConfigRenderer boshnet.NetworkConfigRenderer
+	var networkConfigRendererErr error
+	switch options.Linux.NetworkConfigRenderer {
+	case "netplan":
+		networkConfigRenderer = boshnet.NewNetplanRenderer(fs, runner)
+	case "":
+		networkConfigRenderer = boshnet.NewSystemdNetworkdRenderer(fs, runner)
+	default:
+		networkConfigRendererErr = bosherror.Errorf("Unknown network config renderer '%s'", options.Linux.NetworkConfigRenderer)
+	}
+
+	ubuntuNetManager := boshnet.NewUbuntuNetManager(fs, runner, ipResolver, macAddressDetector, interfaceConfigurationCreator, interfaceAddressesProvider, dnsResolver, arping, kernelIPv6, networkConfigRenderer, logger)
 
 	windowsNetManager := boshnet.NewWindowsNetManager(
 		runner,
@@ -159,9 +170,13 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 	// Symlink device resolver for NVMe instance storage discovery (filtering out EBS/managed disks)
 	symlinkDeviceResolver := devicepathresolver.NewSymlinkDeviceResolver(fs, udev, logger)
 	uuidGenerator := boshuuid.NewGenerator()
-	logsTarProvider := boshlogstarprovider.NewLogsTarProvider(compressor, copier, dirProvider)
+	logsTarProvider := boshlogstarprovider.NewLogsTarProvider(compressor, copier, dirProvider, fs, runner, logger)
+
+	var ubuntu = func() (Platform, error) {
+		if networkConfigRendererErr != nil {
+			return nil, networkConfigRendererErr
+		}
 
-	var ubuntu = func() Platform {
 		return NewLinuxPlatform(
 			fs,
 			runner,
@@ -185,10 +200,10 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 			auditLogger,
 			logsTarProvider,
 			serviceManager,
-		)
+		), nil
 	}
 
-	var windows = func() Platform {
+	var windows = func() (Platform, error) {
 		return NewWindowsPlatform(
 			statsCollector,
 			fs,
@@ -204,10 +219,10 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 			uuidGenerator,
 			windowsDiskManager,
 			logsTarProvider,
-		)
+		), nil
 	}
 
-	var dummy = func() Platform {
+	var dummy = func() (Platform, error) {
 		return NewDummyPlatform(
 			statsCollector,
 			fs,
@@ -217,11 +232,11 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 			logger,
 			auditLogger,
 			logsTarProvider,
-		)
+		), nil
 	}
 
 	return provider{
-		platforms: map[string]func() Platform{
+		platforms: map[string]func() (Platform, error){
 			"ubuntu":  ubuntu,
 			"dummy":   dummy,
 			"windows": windows,
@@ -234,5 +249,5 @@ func (p provider) Get(name string) (Platform, error) {
 	if !found {
 		return nil, bosherror.Errorf("Platform %s could not be found", name)
 	}
-	return plat(), nil
+	return plat()
 }
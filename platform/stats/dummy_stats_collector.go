@@ -2,6 +2,8 @@ package stats
 
 import (
 	"time"
+
+	sigar "github.com/cloudfoundry/gosigar"
 )
 
 type dummyStatsCollector struct{}
@@ -42,3 +44,18 @@ func (p dummyStatsCollector) GetUptimeStats() (stats UptimeStats, err error) {
 	stats.Secs = 5
 	return
 }
+
+func (p dummyStatsCollector) GetNetworkStats() (stats map[string]NetworkStats, err error) {
+	err = sigar.ErrNotImplemented
+	return
+}
+
+func (p dummyStatsCollector) GetDiskIOStats() (stats map[string]DiskIOStats, err error) {
+	err = sigar.ErrNotImplemented
+	return
+}
+
+func (p dummyStatsCollector) GetPressureStats() (stats PressureStats, err error) {
+	err = sigar.ErrNotImplemented
+	return
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"time"
 
+	sigar "github.com/cloudfoundry/gosigar"
+
 	boshstats "github.com/cloudfoundry/bosh-agent/v2/platform/stats"
 )
 
@@ -20,6 +22,16 @@ type FakeCollector struct {
 	DiskStats map[string]boshstats.DiskStats
 
 	UptimeStats boshstats.UptimeStats
+
+	NetworkStats    map[string]boshstats.NetworkStats
+	NetworkStatsErr error
+
+	DiskIOStats    map[string]boshstats.DiskIOStats
+	DiskIOStatsErr error
+
+	// GetPressureStats reports sigar.ErrNotImplemented when PressureStats is nil
+	PressureStats    *boshstats.PressureStats
+	PressureStatsErr error
 }
 
 func (c *FakeCollector) StartCollecting(collectionInterval time.Duration, latestGotUpdated chan struct{}) {
@@ -57,3 +69,21 @@ func (c *FakeCollector) GetUptimeStats() (stats boshstats.UptimeStats, err error
 	stats = c.UptimeStats
 	return
 }
+
+func (c *FakeCollector) GetNetworkStats() (map[string]boshstats.NetworkStats, error) {
+	return c.NetworkStats, c.NetworkStatsErr
+}
+
+func (c *FakeCollector) GetDiskIOStats() (map[string]boshstats.DiskIOStats, error) {
+	return c.DiskIOStats, c.DiskIOStatsErr
+}
+
+func (c *FakeCollector) GetPressureStats() (boshstats.PressureStats, error) {
+	if c.PressureStats == nil && c.PressureStatsErr == nil {
+		return boshstats.PressureStats{}, sigar.ErrNotImplemented
+	}
+	if c.PressureStats == nil {
+		return boshstats.PressureStats{}, c.PressureStatsErr
+	}
+	return *c.PressureStats, c.PressureStatsErr
+}
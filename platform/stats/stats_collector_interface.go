@@ -32,6 +32,43 @@ type UptimeStats struct {
 	Secs uint64
 }
 
+type NetworkStats struct {
+	RxBytesPerSec float64
+	TxBytesPerSec float64
+
+	// Cumulative counters since the interface came up
+	RxErrors  uint64
+	TxErrors  uint64
+	RxDropped uint64
+	TxDropped uint64
+}
+
+type DiskIOStats struct {
+	ReadIOPS         float64
+	WriteIOPS        float64
+	ReadBytesPerSec  float64
+	WriteBytesPerSec float64
+}
+
+type PressureAverages struct {
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+}
+
+type ResourcePressure struct {
+	Some PressureAverages
+
+	// Full is nil for resources that do not report it, e.g. cpu on older kernels
+	Full *PressureAverages
+}
+
+type PressureStats struct {
+	CPU    ResourcePressure
+	Memory ResourcePressure
+	IO     ResourcePressure
+}
+
 type Collector interface {
 	StartCollecting(time.Duration, chan struct{})
 
@@ -43,6 +80,12 @@ type Collector interface {
 	GetDiskStats(mountedPath string) (stats DiskStats, err error)
 
 	GetUptimeStats() (stats UptimeStats, err error)
+
+	// Rates are computed between the two most recent collection cycles;
+	// collectors that cannot provide a stat return sigar.ErrNotImplemented
+	GetNetworkStats() (stats map[string]NetworkStats, err error)
+	GetDiskIOStats() (stats map[string]DiskIOStats, err error)
+	GetPressureStats() (stats PressureStats, err error)
 }
 
 func (cpuStats CPUStats) UserPercent() Percentage {
@@ -6,12 +6,15 @@ import (
 	sigar "github.com/cloudfoundry/gosigar"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	boshdisk "github.com/cloudfoundry/bosh-agent/v2/platform/disk"
 	boshstats "github.com/cloudfoundry/bosh-agent/v2/platform/stats"
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 )
 
+const logTag = "vitalsService"
+
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Service
 
 type Service interface {
@@ -22,17 +25,20 @@ type concreteService struct {
 	statsCollector boshstats.Collector
 	dirProvider    boshdirs.Provider
 	diskMounter    boshdisk.Mounter
+	logger         boshlog.Logger
 }
 
 func NewService(
 	statsCollector boshstats.Collector,
 	dirProvider boshdirs.Provider,
 	diskMounter boshdisk.Mounter,
+	logger boshlog.Logger,
 ) Service {
 	return concreteService{
 		statsCollector: statsCollector,
 		dirProvider:    dirProvider,
 		diskMounter:    diskMounter,
+		logger:         logger,
 	}
 }
 
@@ -78,6 +84,29 @@ func (s concreteService) Get() (Vitals, error) {
 		return vitals, bosherr.WrapError(err, "Getting Uptime Stats")
 	}
 
+	// Network, disk IO and pressure stats are optional; kernels may lack or
+	// disable them, which must not keep the other vitals from being reported
+	networkStats, err := s.statsCollector.GetNetworkStats()
+	if err != nil {
+		s.logOmitted("network", err)
+		networkStats = nil
+	}
+
+	diskIOStats, err := s.statsCollector.GetDiskIOStats()
+	if err != nil {
+		s.logOmitted("disk IO", err)
+		diskIOStats = nil
+	}
+
+	var pressureVitals *PressureVitals
+
+	pressureStats, err := s.statsCollector.GetPressureStats()
+	if err != nil {
+		s.logOmitted("pressure", err)
+	} else {
+		pressureVitals = createPressureVitals(pressureStats)
+	}
+
 	return Vitals{
 		Load: createLoadVitals(loadStats),
 		CPU: CPUVitals{
@@ -89,6 +118,10 @@ func (s concreteService) Get() (Vitals, error) {
 		Swap:   createMemVitals(swapStats),
 		Disk:   diskStats,
 		Uptime: UptimeVitals{Secs: uptimeStats.Secs},
+
+		Network:  createNetworkVitals(networkStats),
+		DiskIO:   createDiskIOVitals(diskIOStats),
+		Pressure: pressureVitals,
 	}, nil
 }
 
@@ -137,6 +170,75 @@ func (s concreteService) addDiskStats(diskStats DiskVitals, path, name string) (
 	return diskStats, nil
 }
 
+func (s concreteService) logOmitted(stats string, err error) {
+	if err == sigar.ErrNotImplemented {
+		return
+	}
+
+	s.logger.Warn(logTag, "Omitting %s stats from vitals: %s", stats, err.Error())
+}
+
+func createPressureVitals(pressureStats boshstats.PressureStats) *PressureVitals {
+	return &PressureVitals{
+		CPU:    createResourcePressureVitals(pressureStats.CPU),
+		Memory: createResourcePressureVitals(pressureStats.Memory),
+		IO:     createResourcePressureVitals(pressureStats.IO),
+	}
+}
+
+func createNetworkVitals(networkStats map[string]boshstats.NetworkStats) NetworkVitals {
+	if len(networkStats) == 0 {
+		return nil
+	}
+
+	networkVitals := make(NetworkVitals, len(networkStats))
+	for name, stat := range networkStats {
+		networkVitals[name] = SpecificNetworkVitals{
+			RxBytesPerSec: fmt.Sprintf("%.0f", stat.RxBytesPerSec),
+			TxBytesPerSec: fmt.Sprintf("%.0f", stat.TxBytesPerSec),
+			RxErrors:      stat.RxErrors,
+			TxErrors:      stat.TxErrors,
+			RxDropped:     stat.RxDropped,
+			TxDropped:     stat.TxDropped,
+		}
+	}
+	return networkVitals
+}
+
+func createDiskIOVitals(diskIOStats map[string]boshstats.DiskIOStats) DiskIOVitals {
+	if len(diskIOStats) == 0 {
+		return nil
+	}
+
+	diskIOVitals := make(DiskIOVitals, len(diskIOStats))
+	for name, stat := range diskIOStats {
+		diskIOVitals[name] = SpecificDiskIOVitals{
+			ReadIOPS:         fmt.Sprintf("%.1f", stat.ReadIOPS),
+			WriteIOPS:        fmt.Sprintf("%.1f", stat.WriteIOPS),
+			ReadBytesPerSec:  fmt.Sprintf("%.0f", stat.ReadBytesPerSec),
+			WriteBytesPerSec: fmt.Sprintf("%.0f", stat.WriteBytesPerSec),
+		}
+	}
+	return diskIOVitals
+}
+
+func createResourcePressureVitals(pressure boshstats.ResourcePressure) ResourcePressureVitals {
+	vitals := ResourcePressureVitals{Some: createPressureAveragesVitals(pressure.Some)}
+	if pressure.Full != nil {
+		full := createPressureAveragesVitals(*pressure.Full)
+		vitals.Full = &full
+	}
+	return vitals
+}
+
+func createPressureAveragesVitals(averages boshstats.PressureAverages) PressureAveragesVitals {
+	return PressureAveragesVitals{
+		Avg10:  fmt.Sprintf("%.2f", averages.Avg10),
+		Avg60:  fmt.Sprintf("%.2f", averages.Avg60),
+		Avg300: fmt.Sprintf("%.2f", averages.Avg300),
+	}
+}
+
 func createMemVitals(memUsage boshstats.Usage) MemoryVitals {
 	return MemoryVitals{
 		Percent: memUsage.Percent().FormatFractionOf100(0),
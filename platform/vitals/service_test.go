@@ -1,6 +1,7 @@
 package vitals_test
 
 import (
+	"errors"
 	"path/filepath"
 	"runtime"
 	"time"
@@ -9,6 +10,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	"github.com/cloudfoundry/bosh-agent/v2/platform/disk/diskfakes"
 	boshstats "github.com/cloudfoundry/bosh-agent/v2/platform/stats"
@@ -71,7 +73,7 @@ var _ = Describe("Vitals service", func() {
 		mounter = &diskfakes.FakeMounter{}
 		mounter.IsMountPointReturns("/dev/fake-partition-device", true, nil)
 
-		service = NewService(statsCollector, dirProvider, mounter)
+		service = NewService(statsCollector, dirProvider, mounter, boshlog.NewLogger(boshlog.LevelNone))
 		statsCollector.StartCollecting(1*time.Millisecond, nil)
 	})
 
@@ -123,6 +125,65 @@ var _ = Describe("Vitals service", func() {
 		boshassert.MatchesJSONMap(GinkgoT(), vitals, expectedVitals)
 	})
 
+	It("does not include network, disk I/O or pressure vitals when they are not available", func() {
+		vitals, err := service.Get()
+		Expect(err).ToNot(HaveOccurred())
+
+		boshassert.LacksJSONKey(GinkgoT(), vitals, "network")
+		boshassert.LacksJSONKey(GinkgoT(), vitals, "disk_io")
+		boshassert.LacksJSONKey(GinkgoT(), vitals, "pressure")
+	})
+
+	Context("when network, disk I/O and pressure stats are available", func() {
+		BeforeEach(func() {
+			statsCollector.NetworkStats = map[string]boshstats.NetworkStats{
+				"eth0": {RxBytesPerSec: 1234.4, TxBytesPerSec: 99.6, RxErrors: 1, TxErrors: 2, RxDropped: 3, TxDropped: 4},
+			}
+			statsCollector.DiskIOStats = map[string]boshstats.DiskIOStats{
+				"sda": {ReadIOPS: 12.34, WriteIOPS: 5, ReadBytesPerSec: 4096, WriteBytesPerSec: 2048.7},
+			}
+			statsCollector.PressureStats = &boshstats.PressureStats{
+				CPU:    boshstats.ResourcePressure{Some: boshstats.PressureAverages{Avg10: 1.5, Avg60: 0.75, Avg300: 0.25}},
+				Memory: boshstats.ResourcePressure{Some: boshstats.PressureAverages{Avg10: 0.1}, Full: &boshstats.PressureAverages{Avg10: 0.01}},
+				IO:     boshstats.ResourcePressure{Some: boshstats.PressureAverages{Avg300: 3}},
+			}
+		})
+
+		It("includes them in the vitals", func() {
+			vitals, err := service.Get()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vitals.Network).To(Equal(NetworkVitals{
+				"eth0": {RxBytesPerSec: "1234", TxBytesPerSec: "100", RxErrors: 1, TxErrors: 2, RxDropped: 3, TxDropped: 4},
+			}))
+			Expect(vitals.DiskIO).To(Equal(DiskIOVitals{
+				"sda": {ReadIOPS: "12.3", WriteIOPS: "5.0", ReadBytesPerSec: "4096", WriteBytesPerSec: "2049"},
+			}))
+			Expect(vitals.Pressure).To(Equal(&PressureVitals{
+				CPU: ResourcePressureVitals{Some: PressureAveragesVitals{Avg10: "1.50", Avg60: "0.75", Avg300: "0.25"}},
+				Memory: ResourcePressureVitals{
+					Some: PressureAveragesVitals{Avg10: "0.10", Avg60: "0.00", Avg300: "0.00"},
+					Full: &PressureAveragesVitals{Avg10: "0.01", Avg60: "0.00", Avg300: "0.00"},
+				},
+				IO: ResourcePressureVitals{Some: PressureAveragesVitals{Avg10: "0.00", Avg60: "0.00", Avg300: "3.00"}},
+			}))
+		})
+
+		It("omits the stats that fail to be collected", func() {
+			statsCollector.NetworkStatsErr = errors.New("fake-network-err")
+			statsCollector.DiskIOStatsErr = errors.New("fake-disk-io-err")
+			statsCollector.PressureStatsErr = errors.New("fake-pressure-err")
+
+			vitals, err := service.Get()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vitals.Network).To(BeNil())
+			Expect(vitals.DiskIO).To(BeNil())
+			Expect(vitals.Pressure).To(BeNil())
+			Expect(vitals.Mem.Percent).To(Equal("70"))
+		})
+	})
+
 	Context("when missing stats for ephemeral and persistent disk", func() {
 		BeforeEach(func() {
 			statsCollector.DiskStats = map[string]boshstats.DiskStats{
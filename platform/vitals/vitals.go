@@ -7,6 +7,12 @@ type Vitals struct {
 	Mem    MemoryVitals `json:"mem"`
 	Swap   MemoryVitals `json:"swap"`
 	Uptime UptimeVitals `json:"uptime"`
+
+	// Omitted when the platform does not provide them, e.g. before the
+	// first two collection cycles or on kernels without PSI support
+	Network  NetworkVitals   `json:"network,omitempty"`
+	DiskIO   DiskIOVitals    `json:"disk_io,omitempty"`
+	Pressure *PressureVitals `json:"pressure,omitempty"`
 }
 
 type CPUVitals struct {
@@ -30,3 +36,40 @@ type MemoryVitals struct {
 type UptimeVitals struct {
 	Secs uint64 `json:"secs,omitempty"`
 }
+
+type NetworkVitals map[string]SpecificNetworkVitals
+
+type SpecificNetworkVitals struct {
+	RxBytesPerSec string `json:"rx_bytes_per_sec"`
+	TxBytesPerSec string `json:"tx_bytes_per_sec"`
+	RxErrors      uint64 `json:"rx_errors"`
+	TxErrors      uint64 `json:"tx_errors"`
+	RxDropped     uint64 `json:"rx_dropped"`
+	TxDropped     uint64 `json:"tx_dropped"`
+}
+
+type DiskIOVitals map[string]SpecificDiskIOVitals
+
+type SpecificDiskIOVitals struct {
+	ReadIOPS         string `json:"read_iops"`
+	WriteIOPS        string `json:"write_iops"`
+	ReadBytesPerSec  string `json:"read_bytes_per_sec"`
+	WriteBytesPerSec string `json:"write_bytes_per_sec"`
+}
+
+type PressureVitals struct {
+	CPU    ResourcePressureVitals `json:"cpu"`
+	Memory ResourcePressureVitals `json:"memory"`
+	IO     ResourcePressureVitals `json:"io"`
+}
+
+type ResourcePressureVitals struct {
+	Some PressureAveragesVitals  `json:"some"`
+	Full *PressureAveragesVitals `json:"full,omitempty"`
+}
+
+type PressureAveragesVitals struct {
+	Avg10  string `json:"avg10"`
+	Avg60  string `json:"avg60"`
+	Avg300 string `json:"avg300"`
+}
@@ -95,7 +95,7 @@ func NewWindowsPlatform(
 		dirProvider:            dirProvider,
 		netManager:             netManager,
 		devicePathResolver:     devicePathResolver,
-		vitalsService:          boshvitals.NewService(collector, dirProvider, nil),
+		vitalsService:          boshvitals.NewService(collector, dirProvider, nil, logger),
 		certManager:            certManager,
 		options:                options,
 		defaultNetworkResolver: defaultNetworkResolver,
@@ -651,6 +651,9 @@ func (p WindowsPlatform) AdjustPersistentDiskPartitioning(diskSettings boshsetti
 }
 
 func (p WindowsPlatform) MountPersistentDisk(diskSettings boshsettings.DiskSettings, mountPoint string) (err error) {
+	if diskSettings.Encryption.Enabled {
+		err = bosherr.Error("Encrypting persistent disks is not supported on Windows")
+	}
 	return
 }
 
@@ -730,6 +733,10 @@ func (p WindowsPlatform) IsPersistentDiskMounted(diskSettings boshsettings.DiskS
 	return true, nil
 }
 
+func (p WindowsPlatform) GrowPersistentDisk(diskSettings boshsettings.DiskSettings) (PersistentDiskGrowth, error) {
+	return PersistentDiskGrowth{}, bosherr.Error("Growing persistent disks is not supported on Windows")
+}
+
 func (p WindowsPlatform) IsPersistentDiskMountable(diskSettings boshsettings.DiskSettings) (bool, error) {
 	return true, nil
 }
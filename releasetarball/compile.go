@@ -23,6 +23,7 @@ import (
 	"path"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -45,6 +46,7 @@ import (
 	boshcomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	"github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 )
 
@@ -56,64 +58,270 @@ const (
 // NewCompiler can be used for multiple compilations and should be passed to Compile
 // It expects to be used in a stemcell image and has not been tested on non-warden stemcells.
 func NewCompiler(dirProvider directories.Provider) (boshcomp.Compiler, error) {
+	return newCompiler(dirProvider, dirProvider, false, newBlobCache(dirProvider, 0))
+}
+
+// NewParallelCompilers returns count compilers that may run at the same time and should be passed to CompileParallel.
+// Every compiler installs dependencies and compiles in its own root under the data directory so that
+// concurrent compilations do not remove each other's dependencies. They share the blobstore of dirProvider.
+// Packaging scripts of parallel compilers run in a private mount namespace in which the packages directory
+// of their root is mounted at the packages directory of dirProvider, so compiling more than one package at
+// a time requires root privileges and the unshare command.
+// When reproducible is set compiled packages are archived with sorted entries and normalized headers.
+// The compilers share a blob cache of up to blobCacheMaxSizeMB megabytes; zero picks the default size
+// and a negative value disables it.
+func NewParallelCompilers(dirProvider directories.Provider, count int, reproducible bool, blobCacheMaxSizeMB int) ([]boshcomp.Compiler, error) {
+	blobCache := newBlobCache(dirProvider, blobCacheMaxSizeMB)
+	if count <= 1 {
+		compiler, err := newCompiler(dirProvider, dirProvider, reproducible, blobCache)
+		if err != nil {
+			return nil, err
+		}
+		return []boshcomp.Compiler{compiler}, nil
+	}
+	compilers := make([]boshcomp.Compiler, 0, count)
+	for i := range count {
+		workerDirProvider := directories.NewProvider(filepath.Join(dirProvider.DataDir(), "compile-workers", strconv.Itoa(i)))
+		compiler, err := newCompiler(dirProvider, workerDirProvider, reproducible, blobCache)
+		if err != nil {
+			return nil, err
+		}
+		compilers = append(compilers, compiler)
+	}
+	return compilers, nil
+}
+
+// newBlobCache keeps compiled dependencies that several packages install, so that they are
+// verified once instead of being read from the blobstore for every package depending on them
+func newBlobCache(dirProvider directories.Provider, maxSizeMB int) boshagentblobstore.BlobCache {
+	logger := boshlog.New(boshlog.LevelWarn, log.Default())
+	options := boshagentblobstore.BlobCacheOptions{MaxSizeMB: maxSizeMB}
+	return boshagentblobstore.NewBlobCache(boshsys.NewOsFileSystem(logger), dirProvider.BlobCacheDir(), options, logger)
+}
+
+// newCompiler reads and writes blobs in the blobstore of dirProvider while installing and compiling packages in rootDirProvider
+func newCompiler(dirProvider, rootDirProvider directories.Provider, reproducible bool, bc boshagentblobstore.BlobCache) (boshcomp.Compiler, error) {
 	logger := boshlog.New(boshlog.LevelWarn, log.Default())
 	cmdRunner := boshsys.NewExecCmdRunner(logger)
 	filesystem := boshsys.NewOsFileSystem(logger)
 	compressor := boshcmd.NewTarballCompressor(cmdRunner, filesystem)
-	blobstoreProvider := boshblob.NewProvider(filesystem, cmdRunner, dirProvider.EtcDir(), logger)
-	db, err := blobstoreProvider.Get("local", map[string]any{"blobstore_path": dirProvider.BlobsDir()})
+	if reproducible {
+		compressor = reproducibleCompressor{Compressor: compressor}
+	}
+	bd, err := newBlobstore(dirProvider, filesystem, cmdRunner, bc, logger)
 	if err != nil {
 		return nil, err
 	}
-	bd := blobstore_delegator.NewBlobstoreDelegator(httpblobprovider.NewHTTPBlobImpl(filesystem, http.DefaultClient), boshagentblobstore.NewCascadingBlobstore(db, nil, logger), logger)
 	ts := clock.NewClock()
-	packageApplierProvider := boshap.NewCompiledPackageApplierProvider(dirProvider.DataDir(), dirProvider.BaseDir(), dirProvider.JobsDir(), "packages", bd, compressor, filesystem, ts, logger)
+	packageApplierProvider := boshap.NewCompiledPackageApplierProvider(rootDirProvider.DataDir(), rootDirProvider.BaseDir(), rootDirProvider.JobsDir(), "packages", bd, compressor, filesystem, ts, logger)
 	const truncateLen = 10 * 1024 // 10kb
-	runner := boshrunner.NewFileLoggingCmdRunner(filesystem, cmdRunner, dirProvider.LogsDir(), truncateLen)
-	compiler := boshcomp.NewConcreteCompiler(compressor, bd, filesystem, runner, dirProvider, packageApplierProvider.Root(), packageApplierProvider.RootBundleCollection(), ts)
+	var packagingCmdRunner boshsys.CmdRunner = cmdRunner
+	if rootDirProvider.BaseDir() != dirProvider.BaseDir() {
+		packagingCmdRunner = packagesNamespaceCmdRunner{
+			CmdRunner:   cmdRunner,
+			packagesDir: filepath.Join(rootDirProvider.BaseDir(), "packages"),
+			mountPoint:  filepath.Join(dirProvider.BaseDir(), "packages"),
+		}
+	}
+	runner := boshrunner.NewFileLoggingCmdRunner(filesystem, packagingCmdRunner, rootDirProvider.LogsDir(), truncateLen)
+	compiler := boshcomp.NewConcreteCompiler(compressor, bd, filesystem, runner, rootDirProvider, packageApplierProvider.Root(), packageApplierProvider.RootBundleCollection(), ts, boshtask.NewRunningProgress(), logger)
 	return compiler, nil
 }
 
+// newBlobstore reads blobs from the blobstore of dirProvider through bc
+func newBlobstore(dirProvider directories.Provider, filesystem boshsys.FileSystem, cmdRunner boshsys.CmdRunner, bc boshagentblobstore.BlobCache, logger boshlog.Logger) (blobstore_delegator.BlobstoreDelegator, error) {
+	blobstoreProvider := boshblob.NewProvider(filesystem, cmdRunner, dirProvider.EtcDir(), logger)
+	db, err := blobstoreProvider.Get("local", map[string]any{"blobstore_path": dirProvider.BlobsDir()})
+	if err != nil {
+		return nil, err
+	}
+	return blobstore_delegator.NewBlobstoreDelegator(httpblobprovider.NewHTTPBlobImpl(filesystem, http.DefaultClient), boshagentblobstore.NewCascadingBlobstore(db, nil, bc, logger), bc, logger), nil
+}
+
 // Compile expects the compiler returned by NewCompiler and may not work with compilers constructed differently.
 func Compile(compiler boshcomp.Compiler, boshReleaseTarballPath, blobsDirectory, outputDirectory, stemcellSlug string) (string, error) {
+	report, err := CompileParallel([]boshcomp.Compiler{compiler}, CompileOptions{}, boshReleaseTarballPath, blobsDirectory, outputDirectory, stemcellSlug)
+	return report.CompiledTarball, err
+}
+
+// CompileParallel expects the compilers returned by NewParallelCompilers. Each package is compiled as soon as
+// its dependencies are compiled and a compiler is free, so up to len(compilers) packages compile at the same time.
+// Packages found as configured by options.Reuse are not compiled again.
+// The report is filled in as far as compilation got, also when an error is returned.
+func CompileParallel(compilers []boshcomp.Compiler, options CompileOptions, boshReleaseTarballPath, blobsDirectory, outputDirectory, stemcellSlug string) (ReleaseReport, error) {
+	report := ReleaseReport{SourceTarball: boshReleaseTarballPath}
+	start := time.Now()
+	err := compileRelease(compilers, options, boshReleaseTarballPath, blobsDirectory, outputDirectory, stemcellSlug, &report)
+	report.DurationSeconds = time.Since(start).Seconds()
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return report, err
+}
+
+func compileRelease(compilers []boshcomp.Compiler, options CompileOptions, boshReleaseTarballPath, blobsDirectory, outputDirectory, stemcellSlug string, report *ReleaseReport) error {
 	log.Printf("Reading BOSH Release Manifest from tarball %s", boshReleaseTarballPath)
 
 	m, err := Manifest(boshReleaseTarballPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse release manifest: %w", err)
+		return fmt.Errorf("failed to parse release manifest: %w", err)
 	}
+	report.Release, report.Version = m.Name, m.Version
 	log.Printf("Release %s/%s has %d packages", m.Name, m.Version, len(m.Packages))
 
 	log.Printf("Extracting packages")
 	blobstoreIDs, err := extractPackages(m, blobsDirectory, boshReleaseTarballPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to extract packages from tarball: %w", err)
+		return fmt.Errorf("failed to extract packages from tarball: %w", err)
 	}
 
 	log.Printf("Starting packages compilation")
 	start := time.Now()
 	packages := slices.Clone(m.Packages)
 	if err := topologicalSort(packages, func(p manifest.PackageRef) string { return p.Name }, func(p manifest.PackageRef) []string { return slices.Clone(p.Dependencies) }); err != nil {
-		return "", err
+		return err
 	}
-	var compiledPackages []boshmodels.Package
-	for _, p := range packages {
-		compiledPackages, err = compilePackage(compiledPackages, p, blobstoreIDs, compiler)
-		if err != nil {
-			return "", fmt.Errorf("failed to compile package %s/%s: %w", p.Name, p.Version, err)
+	keys, err := sourcePackageKeys(packages, stemcellSlug)
+	if err != nil {
+		return err
+	}
+	reused, err := reusePackages(options.Reuse, packages, keys, blobsDirectory)
+	if err != nil {
+		return err
+	}
+	compiledPackages, packageReports, err := compilePackages(packages, reused, blobstoreIDs, compilers)
+	for i := range packageReports {
+		packageReports[i].Release = m.Name
+		if packageReports[i].Error == "" {
+			packageReports[i].OutputSizeBytes = blobSize(blobsDirectory, compiledPackages[i])
 		}
 	}
+	report.Packages = packageReports
+	if err != nil {
+		return err
+	}
+	if err := cacheCompiledPackages(options.Reuse.CacheDirectory, compiledPackages, keys, blobsDirectory); err != nil {
+		return fmt.Errorf("failed to cache compiled packages: %w", err)
+	}
+	logReuseSummary(packages, reused)
 	log.Printf("Finished packages compilation after %s", time.Since(start))
 
 	log.Printf("Archiving compiled BOSH Release %s/%s with stemcell %s", m.Name, m.Version, stemcellSlug)
-	return writeCompiledRelease(m, outputDirectory, stemcellSlug, blobsDirectory, boshReleaseTarballPath, m.Packages, compiledPackages)
+	report.CompiledTarball, err = writeCompiledRelease(m, outputDirectory, stemcellSlug, blobsDirectory, boshReleaseTarballPath, m.Packages, compiledPackages, options.Reproducible)
+	if err != nil || options.SigningKey == "" {
+		return err
+	}
+	report.Signature, err = SignManifest(report.CompiledTarball, options.SigningKey)
+	return err
+}
+
+type packageCompilation struct {
+	ref      manifest.PackageRef
+	compiled boshmodels.Package
+	compiler boshcomp.Compiler
+	duration time.Duration
+	err      error
 }
 
-func compilePackage(compiledPackages []boshmodels.Package, p manifest.PackageRef, blobstoreIDs map[string]string, compiler boshcomp.Compiler) ([]boshmodels.Package, error) {
+// compilePackages schedules topologically sorted packages that were not reused on the free compilers once
+// all their dependencies are compiled. After a failure no more compilations are started and the
+// ones already running are waited for. The results keep the order of packages and
+// cover every package, also when an error is returned.
+func compilePackages(packages []manifest.PackageRef, reused map[string]boshmodels.Package, blobstoreIDs map[string]string, compilers []boshcomp.Compiler) ([]boshmodels.Package, []PackageReport, error) {
+	var (
+		compiled = maps.Clone(reused)
+		pending  = slices.DeleteFunc(slices.Clone(packages), func(p manifest.PackageRef) bool {
+			_, found := reused[p.Name]
+			return found
+		})
+		free      = slices.Clone(compilers)
+		results   = make(chan packageCompilation)
+		running   = 0
+		errs      []error
+		durations = make(map[string]time.Duration, len(packages))
+		failures  = make(map[string]error)
+	)
+	for {
+		for i := 0; len(errs) == 0 && len(free) > 0 && i < len(pending); {
+			p := pending[i]
+			deps, ready := compiledDependencies(p, compiled)
+			if !ready {
+				i++
+				continue
+			}
+			pending = slices.Delete(pending, i, i+1)
+			compiler := free[len(free)-1]
+			free = free[:len(free)-1]
+			running++
+			go func() {
+				start := time.Now()
+				pkg, err := compilePackage(p, deps, blobstoreIDs, compiler)
+				results <- packageCompilation{ref: p, compiled: pkg, compiler: compiler, duration: time.Since(start), err: err}
+			}()
+		}
+		if running == 0 {
+			break
+		}
+		result := <-results
+		running--
+		free = append(free, result.compiler)
+		durations[result.ref.Name] = result.duration
+		if result.err != nil {
+			failures[result.ref.Name] = result.err
+			errs = append(errs, fmt.Errorf("failed to compile package %s/%s: %w", result.ref.Name, result.ref.Version, result.err))
+			continue
+		}
+		compiled[result.ref.Name] = result.compiled
+	}
+	if len(errs) == 0 && len(pending) > 0 {
+		errs = append(errs, fmt.Errorf("failed to compile package %s/%s: dependencies not found in release manifest", pending[0].Name, pending[0].Version))
+	}
+
+	compiledPackages := make([]boshmodels.Package, 0, len(packages))
+	reports := make([]PackageReport, 0, len(packages))
+	for _, p := range packages {
+		pkg, found := compiled[p.Name]
+		_, isReused := reused[p.Name]
+		report := PackageReport{Package: p.Name, Version: p.Version, Reused: isReused, DurationSeconds: durations[p.Name].Seconds()}
+		switch {
+		case found:
+			report.SHA1 = pkg.Source.Sha1.String()
+		case failures[p.Name] != nil:
+			report.Error = failures[p.Name].Error()
+		default:
+			report.Error = "not compiled because compilation of the release failed"
+		}
+		compiledPackages = append(compiledPackages, pkg)
+		reports = append(reports, report)
+	}
+	return compiledPackages, reports, errors.Join(errs...)
+}
+
+func blobSize(blobsDirectory string, pkg boshmodels.Package) int64 {
+	info, err := os.Stat(filepath.Join(blobsDirectory, pkg.Source.BlobstoreID))
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func compiledDependencies(p manifest.PackageRef, compiled map[string]boshmodels.Package) ([]boshmodels.Package, bool) {
+	deps := make([]boshmodels.Package, 0, len(p.Dependencies))
+	for _, dep := range p.Dependencies {
+		pkg, found := compiled[dep]
+		if !found {
+			return nil, false
+		}
+		deps = append(deps, pkg)
+	}
+	return deps, true
+}
+
+func compilePackage(p manifest.PackageRef, modelsDeps []boshmodels.Package, blobstoreIDs map[string]string, compiler boshcomp.Compiler) (boshmodels.Package, error) {
 	log.Printf("Compiling package %s/%s", p.Name, p.Version)
 	digest, err := boshcrypto.ParseMultipleDigest(p.SHA1)
 	if err != nil {
-		return nil, err
+		return boshmodels.Package{}, err
 	}
 	pkg := boshcomp.Package{
 		BlobstoreID: path.Base(blobstoreIDs[p.SHA1]),
@@ -121,26 +329,22 @@ func compilePackage(compiledPackages []boshmodels.Package, p manifest.PackageRef
 		Sha1:        digest,
 		Version:     p.Version,
 	}
-	modelsDeps := make([]boshmodels.Package, 0, len(p.Dependencies))
-	for _, dep := range p.Dependencies {
-		index := slices.IndexFunc(compiledPackages, func(b boshmodels.Package) bool {
-			return b.Name == dep
-		})
-		modelsDeps = append(modelsDeps, compiledPackages[index])
-	}
-	compiledBlobID, compiledDigest, err := compiler.Compile(pkg, modelsDeps)
+	compiledBlobID, compiledDigest, compileLog, err := compiler.Compile(pkg, modelsDeps)
 	if err != nil {
-		return nil, err
+		if compileLog.BlobID != "" {
+			log.Printf("Compile log of package %s/%s has BlobstoreID=%s", p.Name, p.Version, compileLog.BlobID)
+		}
+		return boshmodels.Package{}, err
 	}
 	log.Printf("Finished compiling release %s/%s BlobstoreID=%s", p.Name, p.Version, compiledBlobID)
-	return append(compiledPackages, boshmodels.Package{
+	return boshmodels.Package{
 		Name:    pkg.Name,
 		Version: pkg.Version,
 		Source: boshmodels.Source{
 			Sha1:        compiledDigest,
 			BlobstoreID: compiledBlobID,
 		},
-	}), nil
+	}, nil
 }
 
 func extractPackages(m manifest.Manifest, blobsDirectory, releaseTarballPath string) (map[string]string, error) {
@@ -198,7 +402,7 @@ func newCompiledPackageRef(pkg boshmodels.Package, src manifest.PackageRef, stem
 	return compiled
 }
 
-func writeCompiledRelease(m manifest.Manifest, outputDirectory, stemcellFilenameSuffix, blobsDirectory, initialTarball string, sourcePackages []manifest.PackageRef, compiledPackages []boshmodels.Package) (string, error) {
+func writeCompiledRelease(m manifest.Manifest, outputDirectory, stemcellFilenameSuffix, blobsDirectory, initialTarball string, sourcePackages []manifest.PackageRef, compiledPackages []boshmodels.Package, reproducible bool) (string, error) {
 	m.CompiledPkgs = make([]manifest.CompiledPackageRef, 0, len(compiledPackages))
 	for _, p := range compiledPackages {
 		srcIndex := slices.IndexFunc(sourcePackages, func(ref manifest.PackageRef) bool {
@@ -232,14 +436,34 @@ func writeCompiledRelease(m manifest.Manifest, outputDirectory, stemcellFilename
 	}
 	defer closeAndIgnoreErr(tw)
 
-	err = walkTarballFiles(initialTarball, writeCompiledTarballFiles(tw, compiledPackages, releaseManifestBuffer, blobsDirectory))
+	if !reproducible {
+		err = walkTarballFiles(initialTarball, writeCompiledTarballFiles(tw, compiledPackages, releaseManifestBuffer, blobsDirectory))
+		if err != nil {
+			return "", errors.Join(err, os.RemoveAll(filePath))
+		}
+		return filePath, nil
+	}
+
+	spool, err := os.CreateTemp(outputDirectory, ".spool-"+fileName)
+	if err != nil {
+		return "", errors.Join(err, os.RemoveAll(filePath))
+	}
+	defer func() {
+		closeAndIgnoreErr(spool)
+		_ = os.RemoveAll(spool.Name()) //nolint:errcheck
+	}()
+	sw := newSortingTarWriter(tw, spool)
+	err = walkTarballFiles(initialTarball, writeCompiledTarballFiles(sw, compiledPackages, releaseManifestBuffer, blobsDirectory))
+	if err == nil {
+		err = sw.Flush()
+	}
 	if err != nil {
 		return "", errors.Join(err, os.RemoveAll(filePath))
 	}
 	return filePath, nil
 }
 
-func writeCompiledTarballFiles(tw *tar.Writer, compiledPackages []boshmodels.Package, releaseManifestBuffer []byte, blobsDirectory string) tarballWalkFunc {
+func writeCompiledTarballFiles(tw tarWriter, compiledPackages []boshmodels.Package, releaseManifestBuffer []byte, blobsDirectory string) tarballWalkFunc {
 	return func(fullPath string, h *tar.Header, r io.Reader) (bool, error) {
 		switch {
 		case h.FileInfo().Name() == releaseManifestFilename:
@@ -273,7 +497,7 @@ func writeCompiledTarballFiles(tw *tar.Writer, compiledPackages []boshmodels.Pac
 	}
 }
 
-func insertFile(tw *tar.Writer, dir *tar.Header, tgzFilePath, osFilePath string) error {
+func insertFile(tw tarWriter, dir *tar.Header, tgzFilePath, osFilePath string) error {
 	f, err := os.Open(osFilePath)
 	if err != nil {
 		return err
@@ -381,7 +605,7 @@ func clearTimestamps(h *tar.Header) {
 	h.ModTime = time.Time{}
 }
 
-func writeToTar(tw *tar.Writer, h *tar.Header, fullPath string, r io.Reader) error {
+func writeToTar(tw tarWriter, h *tar.Header, fullPath string, r io.Reader) error {
 	clearTimestamps(h)
 	h.Name = "./" + fullPath
 	if err := tw.WriteHeader(h); err != nil {
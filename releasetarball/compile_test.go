@@ -4,8 +4,12 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
 	"crypto/sha1"
+	"crypto/x509"
 	"encoding/hex"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
@@ -13,6 +17,7 @@ import (
 	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
@@ -21,9 +26,13 @@ import (
 
 	"github.com/cloudfoundry/bosh-cli/v7/release/manifest"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	"gopkg.in/yaml.v3"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	boshagentblobstore "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
 	"github.com/cloudfoundry/bosh-agent/v2/releasetarball"
 	"github.com/cloudfoundry/bosh-agent/v2/releasetarball/internal/fakes"
@@ -63,6 +72,83 @@ var _ = Describe("NewCompiler", func() {
 	})
 })
 
+var _ = Describe("NewParallelCompilers", func() {
+	It("returns the requested number of compilers", func() {
+		d := directories.NewProvider(GinkgoT().TempDir())
+		Expect(os.MkdirAll(d.BlobsDir(), 0o766)).To(Succeed())
+
+		result, err := releasetarball.NewParallelCompilers(d, 3, false, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(HaveLen(3))
+	})
+
+	It("returns a single compiler when parallel compilation is not requested", func() {
+		d := directories.NewProvider(GinkgoT().TempDir())
+		Expect(os.MkdirAll(d.BlobsDir(), 0o766)).To(Succeed())
+
+		result, err := releasetarball.NewParallelCompilers(d, 0, false, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("compiler blobstore", func() {
+	It("reads compiled dependencies installed for several packages from the blob cache", func() {
+		d := directories.NewProvider(GinkgoT().TempDir())
+		Expect(os.MkdirAll(d.BlobsDir(), 0o766)).To(Succeed())
+
+		content := []byte("compiled base package")
+		Expect(os.WriteFile(filepath.Join(d.BlobsDir(), "base-blob"), content, 0o0644)).To(Succeed())
+		digester := sha1.New()
+		_, _ = digester.Write(content)
+		digest := boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, hex.EncodeToString(digester.Sum(nil)))
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		blobCache := boshagentblobstore.NewBlobCache(boshsys.NewOsFileSystem(logger), d.BlobCacheDir(), boshagentblobstore.BlobCacheOptions{MaxSizeMB: 10}, logger)
+		blobstore, err := releasetarball.NewBlobstore(d, blobCache)
+		Expect(err).NotTo(HaveOccurred())
+
+		for range 2 {
+			blobPath, err := blobstore.Get(digest, "", "base-blob", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.ReadFile(blobPath)).To(Equal(content))
+		}
+
+		Expect(blobCache.Stats().Hits).To(Equal(uint64(1)))
+	})
+})
+
+var _ = Describe("packagesNamespaceCmdRunner", func() {
+	It("runs packaging scripts with the packages directory of the compiler mounted at the stemcell packages directory", func() {
+		cmdRunner := fakesys.NewFakeCmdRunner()
+		runner := releasetarball.NewPackagesNamespaceCmdRunner(cmdRunner, "/var/vcap/data/compile-workers/1/packages", "/var/vcap/packages")
+
+		_, _, _, err := runner.RunComplexCommand(boshsys.Command{
+			Name: "bash",
+			Args: []string{"-x", "packaging"},
+			Env: map[string]string{
+				"BOSH_COMPILE_TARGET": "/var/vcap/data/compile-workers/1/data/compile/pkg",
+				"BOSH_INSTALL_TARGET": "/var/vcap/data/compile-workers/1/packages/pkg",
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(cmdRunner.RunComplexCommands).To(HaveLen(1))
+		cmd := cmdRunner.RunComplexCommands[0]
+		Expect(cmd.Name).To(Equal("unshare"))
+		Expect(cmd.Args).To(Equal([]string{
+			"--mount", "--propagation", "private", "--",
+			"sh", "-c", `mkdir -p "$2" && mount --bind "$1" "$2" && shift 2 && exec "$@"`, "sh",
+			"/var/vcap/data/compile-workers/1/packages", "/var/vcap/packages",
+			"bash", "-x", "packaging",
+		}))
+		Expect(cmd.Env).To(Equal(map[string]string{
+			"BOSH_COMPILE_TARGET": "/var/vcap/data/compile-workers/1/data/compile/pkg",
+			"BOSH_INSTALL_TARGET": "/var/vcap/packages/pkg",
+		}))
+	})
+})
+
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -fake-name Compiler -o internal/fakes/compiler.go github.com/cloudfoundry/bosh-agent/v2/agent/compiler.Compiler
 
 var _ = Describe("Compile", func() {
@@ -147,7 +233,7 @@ var _ = Describe("Compile", func() {
 	When("the compiler returns an error", func() {
 		BeforeEach(func() {
 			sourceTarballPath = filepath.Join("testdata", "log-cache-release-3.0.9.tgz")
-			pkgCompiler.CompileReturns("", nil, fmt.Errorf("banana"))
+			pkgCompiler.CompileReturns("", nil, compiler.CompileLog{}, fmt.Errorf("banana"))
 		})
 
 		It("does not compile any of the packages", func() {
@@ -193,7 +279,7 @@ var _ = Describe("Compile", func() {
 			} {
 				p := filepath.Join(d.BlobsDir(), blob)
 				Expect(os.WriteFile(p, []byte(blob), 0644)).To(Succeed())
-				multiplePackageCompiler.CompileReturnsOnCall(i, blob, boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, blob+"-checksum"), nil)
+				multiplePackageCompiler.CompileReturnsOnCall(i, blob, boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, blob+"-checksum"), compiler.CompileLog{}, nil)
 			}
 		})
 
@@ -270,6 +356,259 @@ var _ = Describe("Compile", func() {
 	})
 })
 
+var _ = Describe("CompileParallel", func() {
+	const stemcellSlug = "banana-slug/1.23"
+
+	var (
+		releasesOutputDir string
+		sourceTarballPath string
+		d                 directories.Provider
+
+		pkgCompilers []*fakes.Compiler
+	)
+
+	compilers := func() []compiler.Compiler {
+		result := make([]compiler.Compiler, 0, len(pkgCompilers))
+		for _, c := range pkgCompilers {
+			result = append(result, c)
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		d = directories.NewProvider(GinkgoT().TempDir())
+		Expect(os.MkdirAll(d.BlobsDir(), 0o766)).To(Succeed())
+		releasesOutputDir = GinkgoT().TempDir()
+		sourceTarballPath = filepath.Join("testdata", "log-cache-release-3.0.9.tgz")
+
+		pkgCompilers = nil
+		for range 3 {
+			c := new(fakes.Compiler)
+			c.CompileCalls(fakeCompilation(d))
+			pkgCompilers = append(pkgCompilers, c)
+		}
+	})
+
+	It("compiles every package once after its dependencies", func() {
+		report, err := releasetarball.CompileParallel(compilers(), releasetarball.CompileOptions{}, sourceTarballPath, d.BlobsDir(), releasesOutputDir, stemcellSlug)
+		Expect(err).NotTo(HaveOccurred())
+
+		sourceManifest, err := releasetarball.Manifest(sourceTarballPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		compiledNames := map[string]int{}
+		for _, c := range pkgCompilers {
+			for i := range c.CompileCallCount() {
+				pkg, deps := c.CompileArgsForCall(i)
+				compiledNames[pkg.Name]++
+
+				source := sourceManifest.Packages[slices.IndexFunc(sourceManifest.Packages, func(p manifest.PackageRef) bool {
+					return p.Name == pkg.Name
+				})]
+				depNames := make([]string, 0, len(deps))
+				for _, dep := range deps {
+					depNames = append(depNames, dep.Name)
+					Expect(dep.Source.BlobstoreID).To(Equal(dep.Name + "-compiled-blob"))
+				}
+				Expect(depNames).To(ConsistOf(source.Dependencies))
+			}
+		}
+		Expect(compiledNames).To(HaveLen(len(sourceManifest.Packages)))
+		for name, count := range compiledNames {
+			Expect(count).To(Equal(1), name)
+		}
+
+		compiledManifest, err := releasetarball.Manifest(report.CompiledTarball)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(compiledManifest.CompiledPkgs).To(HaveLen(len(sourceManifest.Packages)))
+
+		By("reporting every package", func() {
+			Expect(report.SourceTarball).To(Equal(sourceTarballPath))
+			Expect(report.Release).To(Equal("log-cache"))
+			Expect(report.Version).To(Equal("3.0.9"))
+			Expect(report.Error).To(BeEmpty())
+			Expect(report.Packages).To(HaveLen(len(sourceManifest.Packages)))
+			for _, p := range report.Packages {
+				Expect(p.Release).To(Equal("log-cache"))
+				Expect(p.Reused).To(BeFalse())
+				Expect(p.Error).To(BeEmpty())
+				Expect(p.OutputSizeBytes).To(BeNumerically(">", 0))
+
+				compiledIndex := slices.IndexFunc(compiledManifest.CompiledPkgs, func(c manifest.CompiledPackageRef) bool {
+					return c.Name == p.Package
+				})
+				Expect(compiledIndex).NotTo(Equal(-1))
+				Expect(p.Version).To(Equal(compiledManifest.CompiledPkgs[compiledIndex].Version))
+				Expect(p.SHA1).To(Equal(compiledManifest.CompiledPkgs[compiledIndex].SHA1))
+			}
+		})
+	})
+
+	When("compiling reproducibly", func() {
+		It("writes identical tarballs with sorted entries and normalized headers", func() {
+			options := releasetarball.CompileOptions{Reproducible: true}
+			first, err := releasetarball.CompileParallel(compilers(), options, sourceTarballPath, d.BlobsDir(), GinkgoT().TempDir(), stemcellSlug)
+			Expect(err).NotTo(HaveOccurred())
+			second, err := releasetarball.CompileParallel(compilers(), options, sourceTarballPath, d.BlobsDir(), releasesOutputDir, stemcellSlug)
+			Expect(err).NotTo(HaveOccurred())
+
+			firstContent, err := os.ReadFile(first.CompiledTarball)
+			Expect(err).NotTo(HaveOccurred())
+			secondContent, err := os.ReadFile(second.CompiledTarball)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secondContent).To(Equal(firstContent))
+
+			headers := listFileNamesInTarball(GinkgoT(), second.CompiledTarball)
+			names := make([]string, 0, len(headers))
+			for _, h := range headers {
+				names = append(names, h.Name)
+				Expect(h.ModTime.Unix()).To(BeZero())
+				Expect(h.Uid).To(BeZero())
+				Expect(h.Uname).To(BeEmpty())
+			}
+			Expect(slices.IsSorted(names)).To(BeTrue())
+			Expect(names).To(HaveLen(15))
+
+			spoolFiles, err := filepath.Glob(filepath.Join(releasesOutputDir, ".spool-*"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(spoolFiles).To(BeEmpty())
+		})
+	})
+
+	When("a signing key is given", func() {
+		It("signs the release manifest of the compiled tarball", func() {
+			_, private, err := ed25519.GenerateKey(rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			keyDir := GinkgoT().TempDir()
+			privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+			Expect(err).NotTo(HaveOccurred())
+			publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+			Expect(err).NotTo(HaveOccurred())
+			privateKeyPath := filepath.Join(keyDir, "key.pem")
+			publicKeyPath := filepath.Join(keyDir, "key.pub")
+			Expect(os.WriteFile(privateKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600)).To(Succeed())
+			Expect(os.WriteFile(publicKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o600)).To(Succeed())
+
+			options := releasetarball.CompileOptions{SigningKey: privateKeyPath}
+			report, err := releasetarball.CompileParallel(compilers(), options, sourceTarballPath, d.BlobsDir(), releasesOutputDir, stemcellSlug)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Signature).To(Equal(report.CompiledTarball + releasetarball.SignatureSuffix))
+
+			Expect(releasetarball.Verify(report.CompiledTarball, publicKeyPath, "")).To(Succeed())
+		})
+	})
+
+	When("reusing packages from a compiled release", func() {
+		var compiledReleasePath string
+
+		BeforeEach(func() {
+			report, err := releasetarball.CompileParallel(compilers(), releasetarball.CompileOptions{}, sourceTarballPath, d.BlobsDir(), GinkgoT().TempDir(), stemcellSlug)
+			Expect(err).NotTo(HaveOccurred())
+			compiledReleasePath = report.CompiledTarball
+
+			for i := range pkgCompilers {
+				pkgCompilers[i] = new(fakes.Compiler)
+				pkgCompilers[i].CompileCalls(fakeCompilation(d))
+			}
+		})
+
+		It("does not compile packages compiled for the same stemcell", func() {
+			options := releasetarball.CompileOptions{Reuse: releasetarball.ReuseOptions{CompiledReleases: []string{compiledReleasePath}}}
+			report, err := releasetarball.CompileParallel(compilers(), options, sourceTarballPath, d.BlobsDir(), releasesOutputDir, stemcellSlug)
+			Expect(err).NotTo(HaveOccurred())
+			resultPath := report.CompiledTarball
+			for _, p := range report.Packages {
+				Expect(p.Reused).To(BeTrue())
+			}
+
+			for _, c := range pkgCompilers {
+				Expect(c.CompileCallCount()).To(BeZero())
+			}
+
+			previousManifest, err := releasetarball.Manifest(compiledReleasePath)
+			Expect(err).NotTo(HaveOccurred())
+			compiledManifest, err := releasetarball.Manifest(resultPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(compiledManifest.CompiledPkgs).To(Equal(previousManifest.CompiledPkgs))
+			Expect(listFileNamesInTarball(GinkgoT(), resultPath)).To(HaveLen(len(listFileNamesInTarball(GinkgoT(), compiledReleasePath))))
+		})
+
+		It("compiles packages compiled for a different stemcell", func() {
+			options := releasetarball.CompileOptions{Reuse: releasetarball.ReuseOptions{CompiledReleases: []string{compiledReleasePath}}}
+			_, err := releasetarball.CompileParallel(compilers(), options, sourceTarballPath, d.BlobsDir(), releasesOutputDir, "banana-slug/1.24")
+			Expect(err).NotTo(HaveOccurred())
+
+			compileCount := 0
+			for _, c := range pkgCompilers {
+				compileCount += c.CompileCallCount()
+			}
+			Expect(compileCount).To(Equal(5))
+		})
+	})
+
+	When("reusing packages from a cache directory", func() {
+		var cacheDirectory string
+
+		BeforeEach(func() {
+			cacheDirectory = filepath.Join(GinkgoT().TempDir(), "cache")
+		})
+
+		It("compiles each package only once", func() {
+			options := releasetarball.CompileOptions{Reuse: releasetarball.ReuseOptions{CacheDirectory: cacheDirectory}}
+			first, err := releasetarball.CompileParallel(compilers(), options, sourceTarballPath, d.BlobsDir(), GinkgoT().TempDir(), stemcellSlug)
+			Expect(err).NotTo(HaveOccurred())
+
+			cached, err := filepath.Glob(filepath.Join(cacheDirectory, "*.tgz"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cached).To(HaveLen(5))
+
+			d = directories.NewProvider(GinkgoT().TempDir())
+			Expect(os.MkdirAll(d.BlobsDir(), 0o766)).To(Succeed())
+			second, err := releasetarball.CompileParallel(compilers(), options, sourceTarballPath, d.BlobsDir(), releasesOutputDir, stemcellSlug)
+			Expect(err).NotTo(HaveOccurred())
+
+			compileCount := 0
+			for _, c := range pkgCompilers {
+				compileCount += c.CompileCallCount()
+			}
+			Expect(compileCount).To(Equal(5))
+
+			firstManifest, err := releasetarball.Manifest(first.CompiledTarball)
+			Expect(err).NotTo(HaveOccurred())
+			secondManifest, err := releasetarball.Manifest(second.CompiledTarball)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secondManifest.CompiledPkgs).To(Equal(firstManifest.CompiledPkgs))
+		})
+	})
+
+	When("a compiler returns an error", func() {
+		BeforeEach(func() {
+			for _, c := range pkgCompilers {
+				c.CompileReturns("", nil, compiler.CompileLog{}, fmt.Errorf("banana"))
+			}
+		})
+
+		It("does not compile packages depending on the failed package", func() {
+			report, err := releasetarball.CompileParallel(compilers(), releasetarball.CompileOptions{}, sourceTarballPath, d.BlobsDir(), releasesOutputDir, stemcellSlug)
+			Expect(err).To(MatchError(ContainSubstring("banana")))
+			Expect(report.Error).To(ContainSubstring("banana"))
+			Expect(report.CompiledTarball).To(BeEmpty())
+			Expect(report.Packages).To(HaveLen(5))
+			for _, p := range report.Packages {
+				Expect(p.Error).NotTo(BeEmpty())
+				Expect(p.SHA1).To(BeEmpty())
+			}
+
+			for _, c := range pkgCompilers {
+				for i := range c.CompileCallCount() {
+					_, deps := c.CompileArgsForCall(i)
+					Expect(deps).To(BeEmpty())
+				}
+			}
+		})
+	})
+})
+
 func assertPackageFields(compiledManifest, sourceManifest manifest.Manifest, index int, stemcellSlug, expectedSHA string) {
 	GinkgoHelper()
 
@@ -361,8 +700,8 @@ func listFileNamesInTarball(t GinkgoTInterface, filePath string) []tar.Header {
 	return infos
 }
 
-func fakeCompilation(d directories.Provider) func(c compiler.Package, packages []models.Package) (string, boshcrypto.Digest, error) {
-	return func(c compiler.Package, packages []models.Package) (string, boshcrypto.Digest, error) {
+func fakeCompilation(d directories.Provider) func(c compiler.Package, packages []models.Package) (string, boshcrypto.Digest, compiler.CompileLog, error) {
+	return func(c compiler.Package, packages []models.Package) (string, boshcrypto.Digest, compiler.CompileLog, error) {
 		blobContent, err := createTGZ(simpleFile("packaging", fmt.Appendf(nil, `"echo Compiled %q`, c.Name), 0o0744))
 		if err != nil {
 			log.Fatal(err)
@@ -374,6 +713,6 @@ func fakeCompilation(d directories.Provider) func(c compiler.Package, packages [
 		digester := sha1.New()
 		_, _ = digester.Write(blobContent)
 		digest := boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, hex.EncodeToString(digester.Sum(nil)))
-		return compiledBlobstoreID, digest, nil
+		return compiledBlobstoreID, digest, compiler.CompileLog{}, nil
 	}
 }
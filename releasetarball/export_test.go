@@ -0,0 +1,24 @@
+package releasetarball
+
+import (
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshagentblobstore "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	"github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+func NewReproducibleCompressor(compressor boshcmd.Compressor) boshcmd.Compressor {
+	return reproducibleCompressor{Compressor: compressor}
+}
+
+func NewPackagesNamespaceCmdRunner(cmdRunner boshsys.CmdRunner, packagesDir, mountPoint string) boshsys.CmdRunner {
+	return packagesNamespaceCmdRunner{CmdRunner: cmdRunner, packagesDir: packagesDir, mountPoint: mountPoint}
+}
+
+func NewBlobstore(dirProvider directories.Provider, blobCache boshagentblobstore.BlobCache) (blobstore_delegator.BlobstoreDelegator, error) {
+	logger := boshlog.NewLogger(boshlog.LevelNone)
+	return newBlobstore(dirProvider, boshsys.NewOsFileSystem(logger), boshsys.NewExecCmdRunner(logger), blobCache, logger)
+}
@@ -10,7 +10,7 @@ import (
 )
 
 type Compiler struct {
-	CompileStub        func(compiler.Package, []models.Package) (string, crypto.Digest, error)
+	CompileStub        func(compiler.Package, []models.Package) (string, crypto.Digest, compiler.CompileLog, error)
 	compileMutex       sync.RWMutex
 	compileArgsForCall []struct {
 		arg1 compiler.Package
@@ -19,18 +19,20 @@ type Compiler struct {
 	compileReturns struct {
 		result1 string
 		result2 crypto.Digest
-		result3 error
+		result3 compiler.CompileLog
+		result4 error
 	}
 	compileReturnsOnCall map[int]struct {
 		result1 string
 		result2 crypto.Digest
-		result3 error
+		result3 compiler.CompileLog
+		result4 error
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *Compiler) Compile(arg1 compiler.Package, arg2 []models.Package) (string, crypto.Digest, error) {
+func (fake *Compiler) Compile(arg1 compiler.Package, arg2 []models.Package) (string, crypto.Digest, compiler.CompileLog, error) {
 	var arg2Copy []models.Package
 	if arg2 != nil {
 		arg2Copy = make([]models.Package, len(arg2))
@@ -50,9 +52,9 @@ func (fake *Compiler) Compile(arg1 compiler.Package, arg2 []models.Package) (str
 		return stub(arg1, arg2)
 	}
 	if specificReturn {
-		return ret.result1, ret.result2, ret.result3
+		return ret.result1, ret.result2, ret.result3, ret.result4
 	}
-	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3, fakeReturns.result4
 }
 
 func (fake *Compiler) CompileCallCount() int {
@@ -61,7 +63,7 @@ func (fake *Compiler) CompileCallCount() int {
 	return len(fake.compileArgsForCall)
 }
 
-func (fake *Compiler) CompileCalls(stub func(compiler.Package, []models.Package) (string, crypto.Digest, error)) {
+func (fake *Compiler) CompileCalls(stub func(compiler.Package, []models.Package) (string, crypto.Digest, compiler.CompileLog, error)) {
 	fake.compileMutex.Lock()
 	defer fake.compileMutex.Unlock()
 	fake.CompileStub = stub
@@ -74,18 +76,19 @@ func (fake *Compiler) CompileArgsForCall(i int) (compiler.Package, []models.Pack
 	return argsForCall.arg1, argsForCall.arg2
 }
 
-func (fake *Compiler) CompileReturns(result1 string, result2 crypto.Digest, result3 error) {
+func (fake *Compiler) CompileReturns(result1 string, result2 crypto.Digest, result3 compiler.CompileLog, result4 error) {
 	fake.compileMutex.Lock()
 	defer fake.compileMutex.Unlock()
 	fake.CompileStub = nil
 	fake.compileReturns = struct {
 		result1 string
 		result2 crypto.Digest
-		result3 error
-	}{result1, result2, result3}
+		result3 compiler.CompileLog
+		result4 error
+	}{result1, result2, result3, result4}
 }
 
-func (fake *Compiler) CompileReturnsOnCall(i int, result1 string, result2 crypto.Digest, result3 error) {
+func (fake *Compiler) CompileReturnsOnCall(i int, result1 string, result2 crypto.Digest, result3 compiler.CompileLog, result4 error) {
 	fake.compileMutex.Lock()
 	defer fake.compileMutex.Unlock()
 	fake.CompileStub = nil
@@ -93,14 +96,16 @@ func (fake *Compiler) CompileReturnsOnCall(i int, result1 string, result2 crypto
 		fake.compileReturnsOnCall = make(map[int]struct {
 			result1 string
 			result2 crypto.Digest
-			result3 error
+			result3 compiler.CompileLog
+			result4 error
 		})
 	}
 	fake.compileReturnsOnCall[i] = struct {
 		result1 string
 		result2 crypto.Digest
-		result3 error
-	}{result1, result2, result3}
+		result3 compiler.CompileLog
+		result4 error
+	}{result1, result2, result3, result4}
 }
 
 func (fake *Compiler) Invocations() map[string][][]interface{} {
@@ -0,0 +1,41 @@
+package releasetarball
+
+import (
+	"maps"
+	"path/filepath"
+	"strings"
+
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const installTargetEnv = "BOSH_INSTALL_TARGET"
+
+// packagesNamespaceCmdRunner runs packaging scripts of a parallel compiler in a private mount namespace
+// in which the packages directory of the compiler is bind mounted over the packages directory of the
+// stemcell. Packages are then installed to and find their dependencies at /var/vcap/packages/<name>
+// like on a VM, while every compiler keeps its own dependencies.
+type packagesNamespaceCmdRunner struct {
+	boshsys.CmdRunner
+
+	packagesDir string
+	mountPoint  string
+}
+
+func (r packagesNamespaceCmdRunner) RunComplexCommand(cmd boshsys.Command) (string, string, int, error) {
+	cmd.Env = maps.Clone(cmd.Env)
+	if installTarget, found := cmd.Env[installTargetEnv]; found {
+		relativeTarget, err := filepath.Rel(r.packagesDir, installTarget)
+		if err == nil && !strings.HasPrefix(relativeTarget, "..") {
+			cmd.Env[installTargetEnv] = filepath.Join(r.mountPoint, relativeTarget)
+		}
+	}
+
+	cmd.Args = append([]string{
+		"--mount", "--propagation", "private", "--",
+		"sh", "-c", `mkdir -p "$2" && mount --bind "$1" "$2" && shift 2 && exec "$@"`, "sh",
+		r.packagesDir, r.mountPoint, cmd.Name,
+	}, cmd.Args...)
+	cmd.Name = "unshare"
+
+	return r.CmdRunner.RunComplexCommand(cmd)
+}
@@ -0,0 +1,60 @@
+package releasetarball
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+const ReportFilename = "compile-report.json"
+
+// Report describes the compilation of a batch of releases for one stemcell
+type Report struct {
+	Stemcell string          `json:"stemcell"`
+	Releases []ReleaseReport `json:"releases"`
+}
+
+type ReleaseReport struct {
+	SourceTarball   string          `json:"source_tarball"`
+	CompiledTarball string          `json:"compiled_tarball,omitempty"`
+	Signature       string          `json:"signature,omitempty"`
+	Release         string          `json:"release"`
+	Version         string          `json:"version"`
+	DurationSeconds float64         `json:"duration_seconds"`
+	Error           string          `json:"error,omitempty"`
+	Packages        []PackageReport `json:"packages"`
+}
+
+// PackageReport describes the outcome for one package; packages that were not
+// compiled because another package failed first have an error but no duration
+type PackageReport struct {
+	Release         string  `json:"release"`
+	Package         string  `json:"package"`
+	Version         string  `json:"version"`
+	Reused          bool    `json:"reused"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	OutputSizeBytes int64   `json:"output_size_bytes"`
+	SHA1            string  `json:"sha1,omitempty"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// Failed returns the number of releases that could not be compiled
+func (r Report) Failed() int {
+	failed := 0
+	for _, release := range r.Releases {
+		if release.Error != "" {
+			failed++
+		}
+	}
+	return failed
+}
+
+// WriteReport writes the report as JSON to the output directory and returns its path
+func WriteReport(outputDirectory string, report Report) (string, error) {
+	buf, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	filePath := filepath.Join(outputDirectory, ReportFilename)
+	return filePath, os.WriteFile(filePath, append(buf, '\n'), defaultMode)
+}
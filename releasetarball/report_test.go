@@ -0,0 +1,57 @@
+package releasetarball_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/releasetarball"
+)
+
+var _ = Describe("Report", func() {
+	var report releasetarball.Report
+
+	BeforeEach(func() {
+		report = releasetarball.Report{
+			Stemcell: "banana-slug/1.23",
+			Releases: []releasetarball.ReleaseReport{
+				{
+					SourceTarball:   "log-cache.tgz",
+					CompiledTarball: "log-cache-compiled.tgz",
+					Release:         "log-cache",
+					Version:         "3.0.9",
+					Packages: []releasetarball.PackageReport{
+						{Release: "log-cache", Package: "golang", Version: "1", OutputSizeBytes: 42, SHA1: "abc"},
+					},
+				},
+				{SourceTarball: "banana.tgz", Error: "failed to parse release manifest"},
+			},
+		}
+	})
+
+	It("counts the releases that failed", func() {
+		Expect(report.Failed()).To(Equal(1))
+	})
+
+	It("writes the report as JSON to the output directory", func() {
+		outputDirectory := GinkgoT().TempDir()
+
+		reportPath, err := releasetarball.WriteReport(outputDirectory, report)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reportPath).To(Equal(filepath.Join(outputDirectory, releasetarball.ReportFilename)))
+
+		buf, err := os.ReadFile(reportPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		var written releasetarball.Report
+		Expect(json.Unmarshal(buf, &written)).To(Succeed())
+		Expect(written).To(Equal(report))
+
+		var fields map[string]any
+		Expect(json.Unmarshal(buf, &fields)).To(Succeed())
+		Expect(fields["releases"].([]any)[0].(map[string]any)["packages"].([]any)[0]).To(HaveKeyWithValue("output_size_bytes", BeEquivalentTo(42)))
+	})
+})
@@ -0,0 +1,180 @@
+package releasetarball
+
+import (
+	"archive/tar"
+	"cmp"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+)
+
+// normalizeHeader removes everything from a header that depends on the host or the time of the build
+func normalizeHeader(h *tar.Header) {
+	h.ModTime = time.Unix(0, 0)
+	h.AccessTime = time.Time{}
+	h.ChangeTime = time.Time{}
+	h.Uid, h.Gid = 0, 0
+	h.Uname, h.Gname = "", ""
+	h.PAXRecords = nil
+	h.Format = tar.FormatUnknown
+}
+
+// reproducibleCompressor creates tarballs with sorted entries and normalized headers in place of
+// the tar command so that compiled packages only change when their files change.
+// Go's gzip writer leaves the name and modification time out of the gzip header.
+type reproducibleCompressor struct {
+	boshcmd.Compressor
+}
+
+func (c reproducibleCompressor) CompressFilesInDir(dir string, options boshcmd.CompressorOptions) (string, error) {
+	return c.CompressSpecificFilesInDir(dir, []string{"."}, options)
+}
+
+func (c reproducibleCompressor) CompressSpecificFilesInDir(dir string, files []string, options boshcmd.CompressorOptions) (string, error) {
+	tarball, err := os.CreateTemp("", "bosh-reproducible-tarball")
+	if err != nil {
+		return "", err
+	}
+	defer closeAndIgnoreErr(tarball)
+
+	err = writeReproducibleTarball(tarball, dir, files, options.NoCompression)
+	if err != nil {
+		return "", errors.Join(err, os.RemoveAll(tarball.Name()))
+	}
+	return tarball.Name(), nil
+}
+
+func writeReproducibleTarball(w io.Writer, dir string, files []string, noCompression bool) error {
+	var gw *gzip.Writer
+	if !noCompression {
+		gw = gzip.NewWriter(w)
+		w = gw
+	}
+	tw := tar.NewWriter(w)
+	files = slices.Sorted(slices.Values(files))
+	for _, file := range files {
+		// WalkDir visits entries in lexical order
+		err := filepath.WalkDir(filepath.Join(dir, file), func(filePath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			return addReproducibleTarEntry(tw, dir, filePath, d)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if gw != nil {
+		return gw.Close()
+	}
+	return nil
+}
+
+func addReproducibleTarEntry(tw *tar.Writer, dir, filePath string, d fs.DirEntry) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+	var link string
+	if info.Mode()&fs.ModeSymlink != 0 {
+		link, err = os.Readlink(filePath)
+		if err != nil {
+			return err
+		}
+	}
+	h, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(dir, filePath)
+	if err != nil {
+		return err
+	}
+	// Match the names the tar command gives to entries below "."
+	h.Name = "./"
+	if rel != "." {
+		h.Name += filepath.ToSlash(rel)
+		if d.IsDir() {
+			h.Name += "/"
+		}
+	}
+	normalizeHeader(h)
+	if err := tw.WriteHeader(h); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer closeAndIgnoreErr(f)
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+type tarWriter interface {
+	WriteHeader(h *tar.Header) error
+	Write(b []byte) (int, error)
+}
+
+type spooledTarEntry struct {
+	header       *tar.Header
+	offset, size int64
+}
+
+// sortingTarWriter keeps entry contents in a spool file and writes them
+// sorted by name with normalized headers when flushed
+type sortingTarWriter struct {
+	tw      *tar.Writer
+	spool   *os.File
+	offset  int64
+	entries []spooledTarEntry
+}
+
+func newSortingTarWriter(tw *tar.Writer, spool *os.File) *sortingTarWriter {
+	return &sortingTarWriter{tw: tw, spool: spool}
+}
+
+func (w *sortingTarWriter) WriteHeader(h *tar.Header) error {
+	header := *h
+	normalizeHeader(&header)
+	w.entries = append(w.entries, spooledTarEntry{header: &header, offset: w.offset})
+	return nil
+}
+
+func (w *sortingTarWriter) Write(b []byte) (int, error) {
+	if len(w.entries) == 0 {
+		return 0, errors.New("tar: write before header")
+	}
+	n, err := w.spool.Write(b)
+	w.offset += int64(n)
+	w.entries[len(w.entries)-1].size += int64(n)
+	return n, err
+}
+
+func (w *sortingTarWriter) Flush() error {
+	slices.SortStableFunc(w.entries, func(a, b spooledTarEntry) int {
+		return cmp.Compare(a.header.Name, b.header.Name)
+	})
+	for _, e := range w.entries {
+		if err := w.tw.WriteHeader(e.header); err != nil {
+			return err
+		}
+		if _, err := io.Copy(w.tw, io.NewSectionReader(w.spool, e.offset, e.size)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
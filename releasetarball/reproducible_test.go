@@ -0,0 +1,72 @@
+package releasetarball_test
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+
+	"github.com/cloudfoundry/bosh-agent/v2/releasetarball"
+)
+
+var _ = Describe("reproducible compressor", func() {
+	var (
+		compressor boshcmd.Compressor
+		dir        string
+	)
+
+	BeforeEach(func() {
+		compressor = releasetarball.NewReproducibleCompressor(nil)
+		dir = GinkgoT().TempDir()
+
+		Expect(os.MkdirAll(filepath.Join(dir, "bin"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "bin", "banana"), []byte("#!/bin/sh"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "README"), []byte("banana"), 0o644)).To(Succeed())
+		Expect(os.Symlink("bin/banana", filepath.Join(dir, "link"))).To(Succeed())
+	})
+
+	compress := func() []byte {
+		GinkgoHelper()
+		tarballPath, err := compressor.CompressFilesInDir(dir, boshcmd.CompressorOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(os.Remove, tarballPath)
+		buf, err := os.ReadFile(tarballPath)
+		Expect(err).NotTo(HaveOccurred())
+		return buf
+	}
+
+	It("writes the same tarball for the same files", func() {
+		first := compress()
+
+		later := time.Now().Add(time.Hour)
+		Expect(os.Chtimes(filepath.Join(dir, "README"), later, later)).To(Succeed())
+		Expect(os.Chtimes(filepath.Join(dir, "bin"), later, later)).To(Succeed())
+
+		Expect(compress()).To(Equal(first))
+	})
+
+	It("sorts entries and normalizes their headers", func() {
+		tarballPath, err := compressor.CompressFilesInDir(dir, boshcmd.CompressorOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(os.Remove, tarballPath)
+
+		headers := listFileNamesInTarball(GinkgoT(), tarballPath)
+		names := make([]string, 0, len(headers))
+		for _, h := range headers {
+			names = append(names, h.Name)
+			Expect(h.ModTime.Unix()).To(BeZero())
+			Expect(h.Uid).To(BeZero())
+			Expect(h.Gid).To(BeZero())
+			Expect(h.Uname).To(BeEmpty())
+		}
+		Expect(names).To(Equal([]string{"./", "./README", "./bin/", "./bin/banana", "./link"}))
+		Expect(headers[3].Mode).To(Equal(int64(0o755)))
+		Expect(headers[4].Typeflag).To(Equal(byte(tar.TypeSymlink)))
+		Expect(headers[4].Linkname).To(Equal("bin/banana"))
+	})
+})
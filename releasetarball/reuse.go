@@ -0,0 +1,283 @@
+package releasetarball
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-cli/v7/release/manifest"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+
+	boshmodels "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
+type CompileOptions struct {
+	Reuse ReuseOptions
+	// Reproducible sorts the entries of compiled release tarballs and normalizes their headers so that
+	// compiling the same release twice yields identical tarballs. The compilers should be reproducible as well.
+	Reproducible bool
+	// SigningKey is the path to a private key to sign the release manifest of compiled tarballs with; see SignManifest
+	SigningKey string
+}
+
+// ReuseOptions configures where compiled packages are looked up before compiling them.
+// Packages match when their fingerprint, the fingerprints of all their dependencies
+// and the stemcell are the same.
+type ReuseOptions struct {
+	// CompiledReleases are paths to compiled release tarballs, for example of a previous release version
+	CompiledReleases []string
+	// CacheDirectory keeps every compiled package across runs; it is not used when empty
+	CacheDirectory string
+}
+
+type reusablePackage struct {
+	name string
+	sha1 string
+}
+
+type packageKeyInput struct {
+	fingerprint  string
+	stemcellSlug string
+	dependencies []string
+}
+
+// reusePackages adds the packages found in the compiled releases or the cache directory to the blobstore
+// and returns them keyed by name
+func reusePackages(options ReuseOptions, packages []manifest.PackageRef, keys map[string]string, blobsDirectory string) (map[string]boshmodels.Package, error) {
+	reused := make(map[string]boshmodels.Package, len(packages))
+
+	fromTarballs := map[string]map[string]string{}
+	for _, tarballPath := range options.CompiledReleases {
+		available, err := compiledReleasePackages(tarballPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read compiled release %s: %w", tarballPath, err)
+		}
+		for _, p := range packages {
+			found, ok := available[keys[p.Name]]
+			if _, done := reused[p.Name]; !ok || done {
+				continue
+			}
+			blobID := compiledBlobID(keys[p.Name])
+			if fromTarballs[tarballPath] == nil {
+				fromTarballs[tarballPath] = map[string]string{}
+			}
+			fromTarballs[tarballPath][found.name] = filepath.Join(blobsDirectory, blobID)
+			digest, err := boshcrypto.ParseMultipleDigest(found.sha1)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse digest of compiled package %s in %s: %w", found.name, tarballPath, err)
+			}
+			reused[p.Name] = boshmodels.Package{
+				Name:    p.Name,
+				Version: p.Version,
+				Source:  boshmodels.Source{Sha1: digest, BlobstoreID: blobID},
+			}
+		}
+	}
+	for tarballPath, destinations := range fromTarballs {
+		if err := extractCompiledPackages(tarballPath, destinations); err != nil {
+			return nil, fmt.Errorf("failed to extract compiled packages from %s: %w", tarballPath, err)
+		}
+	}
+	for name, pkg := range reused {
+		f, err := os.Open(filepath.Join(blobsDirectory, pkg.Source.BlobstoreID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open compiled package %s: %w", name, err)
+		}
+		err = pkg.Source.Sha1.Verify(f)
+		closeAndIgnoreErr(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify compiled package %s: %w", name, err)
+		}
+	}
+
+	if options.CacheDirectory == "" {
+		return reused, nil
+	}
+	for _, p := range packages {
+		if _, done := reused[p.Name]; done {
+			continue
+		}
+		cachedPath := filepath.Join(options.CacheDirectory, keys[p.Name]+".tgz")
+		if _, err := os.Stat(cachedPath); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		blobID := compiledBlobID(keys[p.Name])
+		digest, err := copyAndDigest(cachedPath, filepath.Join(blobsDirectory, blobID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy cached package %s: %w", p.Name, err)
+		}
+		reused[p.Name] = boshmodels.Package{
+			Name:    p.Name,
+			Version: p.Version,
+			Source:  boshmodels.Source{Sha1: digest, BlobstoreID: blobID},
+		}
+	}
+	return reused, nil
+}
+
+// cacheCompiledPackages copies compiled packages into the cache directory unless they are already there
+func cacheCompiledPackages(cacheDirectory string, compiledPackages []boshmodels.Package, keys map[string]string, blobsDirectory string) error {
+	if cacheDirectory == "" {
+		return nil
+	}
+	if err := os.MkdirAll(cacheDirectory, 0o700); err != nil {
+		return err
+	}
+	for _, p := range compiledPackages {
+		cachedPath := filepath.Join(cacheDirectory, keys[p.Name]+".tgz")
+		if _, err := os.Stat(cachedPath); err == nil {
+			continue
+		}
+		// Writing to a temporary file first keeps interrupted runs from leaving truncated packages behind
+		tmpPath := cachedPath + ".tmp"
+		if _, err := copyAndDigest(filepath.Join(blobsDirectory, p.Source.BlobstoreID), tmpPath); err != nil {
+			return errors.Join(err, os.RemoveAll(tmpPath))
+		}
+		if err := os.Rename(tmpPath, cachedPath); err != nil {
+			return errors.Join(err, os.RemoveAll(tmpPath))
+		}
+	}
+	return nil
+}
+
+func compiledReleasePackages(tarballPath string) (map[string]reusablePackage, error) {
+	m, err := Manifest(tarballPath)
+	if err != nil {
+		return nil, err
+	}
+	inputs := make(map[string]packageKeyInput, len(m.CompiledPkgs))
+	for _, p := range m.CompiledPkgs {
+		inputs[p.Name] = packageKeyInput{fingerprint: p.Fingerprint, stemcellSlug: p.OSVersionSlug, dependencies: p.Dependencies}
+	}
+	keys, err := packageKeys(inputs)
+	if err != nil {
+		return nil, err
+	}
+	available := make(map[string]reusablePackage, len(m.CompiledPkgs))
+	for _, p := range m.CompiledPkgs {
+		available[keys[p.Name]] = reusablePackage{name: p.Name, sha1: p.SHA1}
+	}
+	return available, nil
+}
+
+func sourcePackageKeys(packages []manifest.PackageRef, stemcellSlug string) (map[string]string, error) {
+	inputs := make(map[string]packageKeyInput, len(packages))
+	for _, p := range packages {
+		inputs[p.Name] = packageKeyInput{fingerprint: p.Fingerprint, stemcellSlug: stemcellSlug, dependencies: p.Dependencies}
+	}
+	return packageKeys(inputs)
+}
+
+// packageKeys identifies packages by their fingerprint, stemcell and the keys of their dependencies
+// so that a change anywhere in the dependency tree leads to a different key
+func packageKeys(inputs map[string]packageKeyInput) (map[string]string, error) {
+	keys := make(map[string]string, len(inputs))
+	visiting := make(map[string]bool, len(inputs))
+	var visit func(string) (string, error)
+	visit = func(name string) (string, error) {
+		if key, found := keys[name]; found {
+			return key, nil
+		}
+		input, found := inputs[name]
+		if !found {
+			return "", fmt.Errorf("dependency %s not found", name)
+		}
+		if visiting[name] {
+			return "", fmt.Errorf("cycle detected")
+		}
+		visiting[name] = true
+		dependencyKeys := make([]string, 0, len(input.dependencies))
+		for _, dep := range input.dependencies {
+			key, err := visit(dep)
+			if err != nil {
+				return "", err
+			}
+			dependencyKeys = append(dependencyKeys, key)
+		}
+		slices.Sort(dependencyKeys)
+		h := sha256.New()
+		_, _ = fmt.Fprintf(h, "%s\n%s\n%s\n%s", name, input.fingerprint, input.stemcellSlug, strings.Join(dependencyKeys, ",")) //nolint:errcheck
+		keys[name] = hex.EncodeToString(h.Sum(nil))
+		return keys[name], nil
+	}
+	for name := range inputs {
+		if _, err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+func extractCompiledPackages(tarballPath string, destinations map[string]string) error {
+	extracted := 0
+	err := walkTarballFiles(tarballPath, func(name string, h *tar.Header, r io.Reader) (bool, error) {
+		if path.Dir(path.Clean(name)) != "compiled_packages" {
+			return true, nil
+		}
+		dstFilepath, found := destinations[strings.TrimSuffix(path.Base(name), ".tgz")]
+		if !found {
+			return true, nil
+		}
+		dst, err := os.OpenFile(dstFilepath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, defaultMode)
+		if err != nil {
+			return false, err
+		}
+		defer closeAndIgnoreErr(dst)
+		if _, err := io.Copy(dst, r); err != nil {
+			return false, errors.Join(err, os.RemoveAll(dstFilepath))
+		}
+		extracted++
+		return extracted < len(destinations), nil
+	})
+	if err != nil {
+		return err
+	}
+	if extracted < len(destinations) {
+		return fmt.Errorf("compiled packages missing from tarball")
+	}
+	return nil
+}
+
+func copyAndDigest(srcPath, dstPath string) (boshcrypto.Digest, error) {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAndIgnoreErr(src)
+	dst, err := os.OpenFile(dstPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, defaultMode)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAndIgnoreErr(dst)
+	return boshcrypto.DigestAlgorithmSHA1.CreateDigest(io.TeeReader(src, dst))
+}
+
+func compiledBlobID(key string) string {
+	return "compiled-" + key
+}
+
+func logReuseSummary(packages []manifest.PackageRef, reused map[string]boshmodels.Package) {
+	var reusedNames, compiledNames []string
+	for _, p := range packages {
+		if _, found := reused[p.Name]; found {
+			reusedNames = append(reusedNames, p.Name)
+		} else {
+			compiledNames = append(compiledNames, p.Name)
+		}
+	}
+	log.Printf("Reused %d packages: %s", len(reusedNames), strings.Join(reusedNames, ", "))
+	log.Printf("Compiled %d packages: %s", len(compiledNames), strings.Join(compiledNames, ", "))
+}
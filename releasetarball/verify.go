@@ -0,0 +1,230 @@
+package releasetarball
+
+import (
+	"archive/tar"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"gopkg.in/yaml.v3"
+
+	"github.com/cloudfoundry/bosh-cli/v7/release/manifest"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+)
+
+// SignatureSuffix is appended to the tarball path to name its detached manifest signature
+const SignatureSuffix = ".sig"
+
+// SignManifest writes a detached signature over the release manifest in the tarball next to it.
+// The private key file may hold an Ed25519 or ECDSA P-256 key in PKCS #8 PEM or OpenSSH format.
+// Like cosign blob signatures, the signature file contains the base64 encoded signature;
+// ECDSA signatures are made over the SHA-256 digest of the manifest.
+func SignManifest(tarballPath, privateKeyPath string) (string, error) {
+	key, err := readPrivateKey(privateKeyPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read signing key: %w", err)
+	}
+	releaseManifest, err := manifestBytes(tarballPath)
+	if err != nil {
+		return "", err
+	}
+	var signature []byte
+	switch key.Public().(type) {
+	case ed25519.PublicKey:
+		signature, err = key.Sign(rand.Reader, releaseManifest, crypto.Hash(0))
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(releaseManifest)
+		signature, err = key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	default:
+		return "", fmt.Errorf("unsupported signing key type %T", key.Public())
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to sign release manifest: %w", err)
+	}
+	signaturePath := tarballPath + SignatureSuffix
+	return signaturePath, os.WriteFile(signaturePath, []byte(base64.StdEncoding.EncodeToString(signature)+"\n"), defaultMode)
+}
+
+// Verify checks every job, package and compiled package in the tarball against the digest in the release manifest.
+// When publicKeyPath is set it also checks the detached manifest signature created by SignManifest,
+// read from signaturePath or from the default location next to the tarball.
+func Verify(tarballPath, publicKeyPath, signaturePath string) error {
+	releaseManifest, err := manifestBytes(tarballPath)
+	if err != nil {
+		return err
+	}
+	if publicKeyPath != "" {
+		if signaturePath == "" {
+			signaturePath = tarballPath + SignatureSuffix
+		}
+		if err := verifySignature(releaseManifest, publicKeyPath, signaturePath); err != nil {
+			return err
+		}
+	}
+	var m manifest.Manifest
+	if err := yaml.Unmarshal(releaseManifest, &m); err != nil {
+		return fmt.Errorf("failed to parse release manifest: %w", err)
+	}
+	return verifyBlobs(tarballPath, m)
+}
+
+func verifySignature(releaseManifest []byte, publicKeyPath, signaturePath string) error {
+	key, err := readPublicKey(publicKeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read public key: %w", err)
+	}
+	encoded, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return fmt.Errorf("failed to read signature: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+	var valid bool
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(k, releaseManifest, signature)
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(releaseManifest)
+		valid = ecdsa.VerifyASN1(k, digest[:], signature)
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+	if !valid {
+		return fmt.Errorf("release manifest signature %s does not match public key %s", signaturePath, publicKeyPath)
+	}
+	return nil
+}
+
+func verifyBlobs(tarballPath string, m manifest.Manifest) error {
+	expected := make(map[string]string, len(m.Jobs)+len(m.Packages)+len(m.CompiledPkgs)+1)
+	for _, j := range m.Jobs {
+		expected[path.Join("jobs", j.Name+".tgz")] = j.SHA1
+	}
+	for _, p := range m.Packages {
+		expected[path.Join("packages", p.Name+".tgz")] = p.SHA1
+	}
+	for _, p := range m.CompiledPkgs {
+		expected[path.Join("compiled_packages", p.Name+".tgz")] = p.SHA1
+	}
+	if m.License != nil && m.License.SHA1 != "" {
+		expected["license.tgz"] = m.License.SHA1
+	}
+
+	var errs []error
+	// Extracting a tarball keeps the last of entries with the same name, which would not be the verified one
+	seen := make(map[string]bool)
+	err := walkTarballFiles(tarballPath, func(name string, h *tar.Header, r io.Reader) (bool, error) {
+		name = path.Clean(name)
+		if !h.FileInfo().IsDir() {
+			if seen[name] {
+				errs = append(errs, fmt.Errorf("%s appears more than once in the tarball", name))
+				return true, nil
+			}
+			seen[name] = true
+		}
+		sha1, found := expected[name]
+		if !found || h.FileInfo().IsDir() {
+			return true, nil
+		}
+		delete(expected, name)
+		digest, err := boshcrypto.ParseMultipleDigest(sha1)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to parse digest of %s: %w", name, err))
+			return true, nil
+		}
+		if err := digest.Verify(r); err != nil {
+			errs = append(errs, fmt.Errorf("%s does not match the release manifest: %w", name, err))
+		}
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+	for name := range expected {
+		errs = append(errs, fmt.Errorf("%s is missing from the tarball", name))
+	}
+	return errors.Join(errs...)
+}
+
+// manifestBytes only reads the release manifest at the root of the tarball, which is the one the director uses
+func manifestBytes(tarballPath string) ([]byte, error) {
+	var buf []byte
+	err := walkTarballFiles(tarballPath, func(name string, _ *tar.Header, r io.Reader) (bool, error) {
+		if path.Clean(name) != releaseManifestFilename {
+			return true, nil
+		}
+		var err error
+		buf, err = io.ReadAll(r)
+		return false, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if buf == nil {
+		return nil, fmt.Errorf("failed to find %s in tarball", releaseManifestFilename)
+	}
+	return buf, nil
+}
+
+func readPrivateKey(keyPath string) (crypto.Signer, error) {
+	buf, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(buf)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	var key any
+	if block.Type == "OPENSSH PRIVATE KEY" {
+		key, err = ssh.ParseRawPrivateKey(buf)
+	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case *ed25519.PrivateKey:
+		return *k, nil
+	case crypto.Signer:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// readPublicKey accepts PKIX PEM files as written by openssl and cosign as well as OpenSSH public keys
+func readPublicKey(keyPath string) (crypto.PublicKey, error) {
+	buf, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(buf); block != nil {
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	sshKey, _, _, _, err := ssh.ParseAuthorizedKey(buf)
+	if err != nil {
+		return nil, err
+	}
+	cryptoKey, ok := sshKey.(ssh.CryptoPublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type %s", sshKey.Type())
+	}
+	return cryptoKey.CryptoPublicKey(), nil
+}
@@ -0,0 +1,174 @@
+package releasetarball_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-cli/v7/release/manifest"
+	"golang.org/x/crypto/ssh"
+	"gopkg.in/yaml.v3"
+
+	"github.com/cloudfoundry/bosh-agent/v2/releasetarball"
+)
+
+var _ = Describe("Verify", func() {
+	It("accepts tarballs whose blobs match the release manifest", func() {
+		Expect(releasetarball.Verify(filepath.Join("testdata", "log-cache-release-3.0.9.tgz"), "", "")).To(Succeed())
+	})
+
+	When("a blob does not match the release manifest", func() {
+		var tarballPath string
+
+		BeforeEach(func() {
+			tarballPath = filepath.Join(GinkgoT().TempDir(), "banana.tgz")
+			releaseMF, err := yaml.Marshal(manifest.Manifest{
+				CompiledPkgs: []manifest.CompiledPackageRef{
+					{Name: "a", SHA1: "da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+					{Name: "b", SHA1: "da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			tgz, err := createTGZ(
+				simpleFile("release.MF", releaseMF, 0o0644),
+				simpleFile("compiled_packages/a.tgz", []byte("tampered"), 0o0644),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(tarballPath, tgz, 0o0644)).To(Succeed())
+		})
+
+		It("reports mismatching and missing blobs", func() {
+			err := releasetarball.Verify(tarballPath, "", "")
+			Expect(err).To(MatchError(ContainSubstring("compiled_packages/a.tgz does not match the release manifest")))
+			Expect(err).To(MatchError(ContainSubstring("compiled_packages/b.tgz is missing from the tarball")))
+		})
+	})
+
+	It("rejects tarballs with more than one entry of the same name", func() {
+		tarballPath := filepath.Join(GinkgoT().TempDir(), "banana.tgz")
+		releaseMF, err := yaml.Marshal(manifest.Manifest{
+			CompiledPkgs: []manifest.CompiledPackageRef{
+				{Name: "a", SHA1: "da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		tgz, err := createTGZ(
+			simpleFile("release.MF", releaseMF, 0o0644),
+			simpleFile("compiled_packages/a.tgz", nil, 0o0644),
+			simpleFile("./compiled_packages/a.tgz", []byte("tampered"), 0o0644),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(tarballPath, tgz, 0o0644)).To(Succeed())
+
+		err = releasetarball.Verify(tarballPath, "", "")
+		Expect(err).To(MatchError(ContainSubstring("compiled_packages/a.tgz appears more than once in the tarball")))
+	})
+
+	It("ignores release manifests that are not at the root of the tarball", func() {
+		tarballPath := filepath.Join(GinkgoT().TempDir(), "banana.tgz")
+		tgz, err := createTGZ(
+			simpleFile("nested/release.MF", []byte("{}"), 0o0644),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(tarballPath, tgz, 0o0644)).To(Succeed())
+
+		err = releasetarball.Verify(tarballPath, "", "")
+		Expect(err).To(MatchError(ContainSubstring("failed to find release.MF in tarball")))
+	})
+})
+
+var _ = Describe("SignManifest", func() {
+	var (
+		tarballPath string
+		keyDir      string
+	)
+
+	writePEM := func(name, blockType string, der []byte) string {
+		GinkgoHelper()
+		keyPath := filepath.Join(keyDir, name)
+		Expect(os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600)).To(Succeed())
+		return keyPath
+	}
+
+	writeKeyPair := func(private crypto.Signer) (string, string) {
+		GinkgoHelper()
+		privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+		Expect(err).NotTo(HaveOccurred())
+		publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+		Expect(err).NotTo(HaveOccurred())
+		return writePEM("key.pem", "PRIVATE KEY", privateDER), writePEM("key.pub", "PUBLIC KEY", publicDER)
+	}
+
+	BeforeEach(func() {
+		keyDir = GinkgoT().TempDir()
+		source, err := os.ReadFile(filepath.Join("testdata", "log-cache-3.0.9-banana-slug-1.23.tgz"))
+		Expect(err).NotTo(HaveOccurred())
+		tarballPath = filepath.Join(GinkgoT().TempDir(), "log-cache.tgz")
+		Expect(os.WriteFile(tarballPath, source, 0o0644)).To(Succeed())
+	})
+
+	It("writes a signature that verifies with the Ed25519 public key", func() {
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		privateKeyPath, publicKeyPath := writeKeyPair(private)
+
+		signaturePath, err := releasetarball.SignManifest(tarballPath, privateKeyPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signaturePath).To(Equal(tarballPath + releasetarball.SignatureSuffix))
+
+		Expect(releasetarball.Verify(tarballPath, publicKeyPath, "")).To(Succeed())
+
+		By("accepting the OpenSSH form of the public key", func() {
+			sshKey, err := ssh.NewPublicKey(private.Public())
+			Expect(err).NotTo(HaveOccurred())
+			sshKeyPath := filepath.Join(keyDir, "id_ed25519.pub")
+			Expect(os.WriteFile(sshKeyPath, ssh.MarshalAuthorizedKey(sshKey), 0o600)).To(Succeed())
+
+			Expect(releasetarball.Verify(tarballPath, sshKeyPath, signaturePath)).To(Succeed())
+		})
+	})
+
+	It("writes a signature that verifies with the ECDSA public key", func() {
+		private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		privateKeyPath, publicKeyPath := writeKeyPair(private)
+
+		_, err = releasetarball.SignManifest(tarballPath, privateKeyPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(releasetarball.Verify(tarballPath, publicKeyPath, "")).To(Succeed())
+	})
+
+	It("rejects signatures made with another key", func() {
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		privateKeyPath, _ := writeKeyPair(private)
+		_, err = releasetarball.SignManifest(tarballPath, privateKeyPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		other, _, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		otherDER, err := x509.MarshalPKIXPublicKey(other)
+		Expect(err).NotTo(HaveOccurred())
+		otherKeyPath := writePEM("other.pub", "PUBLIC KEY", otherDER)
+
+		Expect(releasetarball.Verify(tarballPath, otherKeyPath, "")).To(MatchError(ContainSubstring("does not match public key")))
+	})
+
+	It("fails without a signature when a public key is given", func() {
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		_, publicKeyPath := writeKeyPair(private)
+
+		Expect(releasetarball.Verify(tarballPath, publicKeyPath, "")).To(MatchError(ContainSubstring("failed to read signature")))
+	})
+})
@@ -124,6 +124,10 @@ func (p Provider) BlobsDir() string {
 	return filepath.Join(p.DataDir(), "blobs")
 }
 
+func (p Provider) BlobCacheDir() string {
+	return filepath.Join(p.DataDir(), "blob_cache")
+}
+
 func (p Provider) SensitiveBlobsDir() string {
 	return filepath.Join(p.DataDir(), "sensitive_blobs")
 }
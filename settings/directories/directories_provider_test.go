@@ -41,6 +41,7 @@ var _ = Describe("Provider", func() {
 		Entry("InstanceDir()", p.InstanceDir(), "/some/dir/instance"),
 		Entry("DisksDir()", p.DisksDir(), "/some/dir/instance/disks"),
 		Entry("BlobsDir()", p.BlobsDir(), "/some/dir/data/blobs"),
+		Entry("BlobCacheDir()", p.BlobCacheDir(), "/some/dir/data/blob_cache"),
 		Entry("InstanceDNSDir()", p.InstanceDNSDir(), "/some/dir/instance/dns"),
 	)
 
@@ -84,6 +84,8 @@ type DiskSettings struct {
 	MountOptions   []string
 
 	Partitioner string
+
+	Encryption PersistentDiskEncryption
 }
 
 type ISCSISettings struct {
@@ -245,6 +247,7 @@ func (s Settings) populatePersistentDiskSettings(diskID string, settingsInfo int
 	diskSettings.FileSystemType = s.Env.PersistentDiskFS
 	diskSettings.MountOptions = s.Env.PersistentDiskMountOptions
 	diskSettings.Partitioner = s.Env.PersistentDiskPartitioner
+	diskSettings.Encryption = s.Env.PersistentDiskEncryption
 
 	return diskSettings
 }
@@ -254,6 +257,29 @@ type Env struct {
 	PersistentDiskFS           disk.FileSystemType `json:"persistent_disk_fs"`
 	PersistentDiskMountOptions []string            `json:"persistent_disk_mount_options"`
 	PersistentDiskPartitioner  string              `json:"persistent_disk_partitioner"`
+
+	PersistentDiskEncryption PersistentDiskEncryption `json:"persistent_disk_encryption"`
+}
+
+// PersistentDiskEncryption makes the agent keep the persistent disk file system
+// in a LUKS2 container. The key is taken from exactly one of Key, KeyFile
+// (a path on the VM) or KeyringKey (the description of a user key in the kernel keyring).
+// Disks created while encryption was disabled are not encrypted in place and are refused when mounted.
+type PersistentDiskEncryption struct {
+	Enabled    bool   `json:"enabled"`
+	Key        string `json:"key"`
+	KeyFile    string `json:"key_file"`
+	KeyringKey string `json:"keyring_key"`
+}
+
+// String redacts the key since disk settings are logged
+func (e PersistentDiskEncryption) String() string {
+	key := ""
+	if e.Key != "" {
+		key = "<redacted>"
+	}
+
+	return fmt.Sprintf("{Enabled:%t Key:%s KeyFile:%s KeyringKey:%s}", e.Enabled, key, e.KeyFile, e.KeyringKey)
 }
 
 func (e Env) GetPassword() string {
@@ -2,6 +2,7 @@ package settings_test
 
 import (
 	"encoding/json"
+	"fmt"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -372,6 +373,28 @@ var _ = Describe("Settings", func() {
 					}))
 				})
 
+				It("gets the encryption settings from env", func() {
+					settingsJSON := `{"env": {"persistent_disk_encryption": {"enabled": true, "key_file": "/fake/key-file"}}}`
+
+					err := json.Unmarshal([]byte(settingsJSON), &settings)
+					Expect(err).NotTo(HaveOccurred())
+					diskSettings := settings.PersistentDiskSettingsFromHint("fake-disk-id", diskHint)
+					Expect(diskSettings.Encryption).To(Equal(PersistentDiskEncryption{
+						Enabled: true,
+						KeyFile: "/fake/key-file",
+					}))
+				})
+
+				It("does not include the encryption key when disk settings are printed", func() {
+					settingsJSON := `{"env": {"persistent_disk_encryption": {"enabled": true, "key": "fake-secret-key"}}}`
+
+					err := json.Unmarshal([]byte(settingsJSON), &settings)
+					Expect(err).NotTo(HaveOccurred())
+					diskSettings := settings.PersistentDiskSettingsFromHint("fake-disk-id", diskHint)
+					Expect(diskSettings.Encryption.Key).To(Equal("fake-secret-key"))
+					Expect(fmt.Sprintf("%+v", diskSettings)).ToNot(ContainSubstring("fake-secret-key"))
+				})
+
 				It("does not crash if env does not have a filesystem type or a persistent_disk_mount_options", func() {
 					settingsJSON := `{"env": {"bosh": {"password": "secret"}}}`
 
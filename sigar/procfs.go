@@ -0,0 +1,238 @@
+package sigar
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	sigar "github.com/cloudfoundry/gosigar"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshstats "github.com/cloudfoundry/bosh-agent/v2/platform/stats"
+)
+
+// /proc/diskstats always counts in 512 byte sectors regardless of the device's block size
+const diskStatsSectorSize = 512
+
+type netDevCounters struct {
+	RxBytes   uint64
+	TxBytes   uint64
+	RxErrors  uint64
+	TxErrors  uint64
+	RxDropped uint64
+	TxDropped uint64
+}
+
+type diskCounters struct {
+	ReadsCompleted  uint64
+	SectorsRead     uint64
+	WritesCompleted uint64
+	SectorsWritten  uint64
+}
+
+type procCounters struct {
+	netDev    map[string]netDevCounters
+	diskStats map[string]diskCounters
+}
+
+func readProcCounters(procDir string) (counters procCounters, err error) {
+	counters.netDev, err = readProcFile(filepath.Join(procDir, "net", "dev"), parseNetDev)
+	if err != nil {
+		return
+	}
+
+	counters.diskStats, err = readProcFile(filepath.Join(procDir, "diskstats"), parseDiskStats)
+	return
+}
+
+func readProcFile[T any](path string, parse func(io.Reader) (T, error)) (T, error) {
+	var empty T
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return empty, sigar.ErrNotImplemented
+		}
+		return empty, bosherr.WrapErrorf(err, "Opening '%s'", path)
+	}
+	defer file.Close() //nolint:errcheck
+
+	result, err := parse(file)
+	if err != nil {
+		// e.g. /proc/pressure files exist but cannot be read on kernels booted with psi=0
+		if errors.Is(err, syscall.EOPNOTSUPP) {
+			return empty, sigar.ErrNotImplemented
+		}
+		return empty, bosherr.WrapErrorf(err, "Parsing '%s'", path)
+	}
+
+	return result, nil
+}
+
+// parseNetDev reads /proc/net/dev, skipping the loopback interface
+func parseNetDev(r io.Reader) (map[string]netDevCounters, error) {
+	counters := map[string]netDevCounters{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		name, values, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			// Header lines
+			continue
+		}
+
+		name = strings.TrimSpace(name)
+		if name == "lo" {
+			continue
+		}
+
+		fields, err := parseUints(strings.Fields(values))
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Parsing counters for interface '%s'", name)
+		}
+		if len(fields) < 12 {
+			return nil, bosherr.Errorf("Expected at least 12 counters for interface '%s', got %d", name, len(fields))
+		}
+
+		counters[name] = netDevCounters{
+			RxBytes:   fields[0],
+			RxErrors:  fields[2],
+			RxDropped: fields[3],
+			TxBytes:   fields[8],
+			TxErrors:  fields[10],
+			TxDropped: fields[11],
+		}
+	}
+
+	return counters, scanner.Err()
+}
+
+// parseDiskStats reads /proc/diskstats, skipping loop and ram devices
+// and devices that have never been used
+func parseDiskStats(r io.Reader) (map[string]diskCounters, error) {
+	counters := map[string]diskCounters{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+
+		name := fields[2]
+		if strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") {
+			continue
+		}
+
+		values, err := parseUints(fields[3:10])
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Parsing counters for device '%s'", name)
+		}
+
+		device := diskCounters{
+			ReadsCompleted:  values[0],
+			SectorsRead:     values[2],
+			WritesCompleted: values[4],
+			SectorsWritten:  values[6],
+		}
+		if device == (diskCounters{}) {
+			continue
+		}
+
+		counters[name] = device
+	}
+
+	return counters, scanner.Err()
+}
+
+func readPressure(procDir string) (stats boshstats.PressureStats, err error) {
+	resources := map[string]*boshstats.ResourcePressure{
+		"cpu":    &stats.CPU,
+		"memory": &stats.Memory,
+		"io":     &stats.IO,
+	}
+
+	for name, resource := range resources {
+		*resource, err = readProcFile(filepath.Join(procDir, "pressure", name), parsePressure)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// parsePressure reads a /proc/pressure/<resource> file, e.g.
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func parsePressure(r io.Reader) (pressure boshstats.ResourcePressure, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		var averages boshstats.PressureAverages
+		for _, field := range fields[1:] {
+			key, value, found := strings.Cut(field, "=")
+			if !found {
+				return pressure, bosherr.Errorf("Unexpected pressure field '%s'", field)
+			}
+
+			var target *float64
+			switch key {
+			case "avg10":
+				target = &averages.Avg10
+			case "avg60":
+				target = &averages.Avg60
+			case "avg300":
+				target = &averages.Avg300
+			default:
+				continue
+			}
+
+			*target, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				return pressure, bosherr.WrapErrorf(err, "Parsing pressure field '%s'", field)
+			}
+		}
+
+		switch fields[0] {
+		case "some":
+			pressure.Some = averages
+		case "full":
+			pressure.Full = &averages
+		}
+	}
+
+	return pressure, scanner.Err()
+}
+
+func parseUints(fields []string) ([]uint64, error) {
+	values := make([]uint64, len(fields))
+	for i, field := range fields {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// counterRate returns the per second rate between two samples of a
+// monotonic counter, treating counter resets as no activity
+func counterRate(previous, current uint64, seconds float64) float64 {
+	if current < previous || seconds <= 0 {
+		return 0
+	}
+	return float64(current-previous) / seconds
+}
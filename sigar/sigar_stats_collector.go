@@ -4,6 +4,7 @@ import (
 	"sync"
 	"time"
 
+	"code.cloudfoundry.org/clock"
 	sigar "github.com/cloudfoundry/gosigar"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -15,11 +16,29 @@ type sigarStatsCollector struct {
 	statsSigar         sigar.Sigar
 	latestCPUStats     boshstats.CPUStats
 	latestCPUStatsLock sync.RWMutex
+
+	procDir     string
+	timeService clock.Clock
+
+	previousProcCounters   procCounters
+	previousProcSampleTime time.Time
+	latestNetworkStats     map[string]boshstats.NetworkStats
+	latestDiskIOStats      map[string]boshstats.DiskIOStats
+	latestProcErr          error
+	latestProcStatsLock    sync.RWMutex
 }
 
 func NewSigarStatsCollector(sigar sigar.Sigar) boshstats.Collector {
+	return NewProcSigarStatsCollector(sigar, "/proc", clock.NewClock())
+}
+
+// NewProcSigarStatsCollector reads network, disk I/O and pressure stats
+// from the given procfs mount in addition to the stats provided by sigar
+func NewProcSigarStatsCollector(sigar sigar.Sigar, procDir string, timeService clock.Clock) boshstats.Collector {
 	return &sigarStatsCollector{
-		statsSigar: sigar,
+		statsSigar:  sigar,
+		procDir:     procDir,
+		timeService: timeService,
 	}
 }
 
@@ -36,6 +55,8 @@ func (s *sigarStatsCollector) StartCollecting(collectionInterval time.Duration,
 			s.latestCPUStats.Total = cpuSample.Total()
 			s.latestCPUStatsLock.Unlock()
 
+			s.collectProcStats()
+
 			if latestGotUpdated != nil {
 				latestGotUpdated <- struct{}{}
 			}
@@ -43,6 +64,58 @@ func (s *sigarStatsCollector) StartCollecting(collectionInterval time.Duration,
 	}()
 }
 
+func (s *sigarStatsCollector) collectProcStats() {
+	counters, err := readProcCounters(s.procDir)
+	now := s.timeService.Now()
+
+	s.latestProcStatsLock.Lock()
+	defer s.latestProcStatsLock.Unlock()
+
+	s.latestProcErr = err
+	if err != nil {
+		return
+	}
+
+	if !s.previousProcSampleTime.IsZero() {
+		seconds := now.Sub(s.previousProcSampleTime).Seconds()
+
+		s.latestNetworkStats = map[string]boshstats.NetworkStats{}
+		for name, current := range counters.netDev {
+			previous, found := s.previousProcCounters.netDev[name]
+			if !found {
+				continue
+			}
+
+			s.latestNetworkStats[name] = boshstats.NetworkStats{
+				RxBytesPerSec: counterRate(previous.RxBytes, current.RxBytes, seconds),
+				TxBytesPerSec: counterRate(previous.TxBytes, current.TxBytes, seconds),
+				RxErrors:      current.RxErrors,
+				TxErrors:      current.TxErrors,
+				RxDropped:     current.RxDropped,
+				TxDropped:     current.TxDropped,
+			}
+		}
+
+		s.latestDiskIOStats = map[string]boshstats.DiskIOStats{}
+		for name, current := range counters.diskStats {
+			previous, found := s.previousProcCounters.diskStats[name]
+			if !found {
+				continue
+			}
+
+			s.latestDiskIOStats[name] = boshstats.DiskIOStats{
+				ReadIOPS:         counterRate(previous.ReadsCompleted, current.ReadsCompleted, seconds),
+				WriteIOPS:        counterRate(previous.WritesCompleted, current.WritesCompleted, seconds),
+				ReadBytesPerSec:  counterRate(previous.SectorsRead, current.SectorsRead, seconds) * diskStatsSectorSize,
+				WriteBytesPerSec: counterRate(previous.SectorsWritten, current.SectorsWritten, seconds) * diskStatsSectorSize,
+			}
+		}
+	}
+
+	s.previousProcCounters = counters
+	s.previousProcSampleTime = now
+}
+
 func (s *sigarStatsCollector) GetCPULoad() (load boshstats.CPULoad, err error) {
 	l, err := s.statsSigar.GetLoadAverage()
 	if err != nil {
@@ -122,3 +195,29 @@ func (s *sigarStatsCollector) GetUptimeStats() (stats boshstats.UptimeStats, err
 	stats.Secs = uint64(uptime.Length)
 	return
 }
+
+func (s *sigarStatsCollector) GetNetworkStats() (map[string]boshstats.NetworkStats, error) {
+	s.latestProcStatsLock.RLock()
+	defer s.latestProcStatsLock.RUnlock()
+
+	if s.latestProcErr != nil {
+		return nil, s.latestProcErr
+	}
+
+	return s.latestNetworkStats, nil
+}
+
+func (s *sigarStatsCollector) GetDiskIOStats() (map[string]boshstats.DiskIOStats, error) {
+	s.latestProcStatsLock.RLock()
+	defer s.latestProcStatsLock.RUnlock()
+
+	if s.latestProcErr != nil {
+		return nil, s.latestProcErr
+	}
+
+	return s.latestDiskIOStats, nil
+}
+
+func (s *sigarStatsCollector) GetPressureStats() (boshstats.PressureStats, error) {
+	return readPressure(s.procDir)
+}
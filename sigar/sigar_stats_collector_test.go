@@ -1,8 +1,12 @@
 package sigar_test
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	sigar "github.com/cloudfoundry/gosigar"
 	fakesigar "github.com/cloudfoundry/gosigar/fakes"
 	. "github.com/onsi/ginkgo/v2"
@@ -135,4 +139,121 @@ var _ = Describe("sigarStatsCollector", func() {
 			Expect(stats.InodeUsage.Used).To(Equal(uint64(400)))
 		})
 	})
+
+	Describe("proc stats", func() {
+		var (
+			procDir   string
+			fakeClock *fakeclock.FakeClock
+		)
+
+		writeProcFile := func(name, contents string) {
+			path := filepath.Join(procDir, name)
+			Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+			Expect(os.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+		}
+
+		writeCounters := func(ethRxBytes, ethTxBytes, sdaReads, sdaSectorsRead int) {
+			writeProcFile("net/dev", fmt.Sprintf(`Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:    5000      50    0    0    0     0          0         0     5000      50    0    0    0     0       0          0
+  eth0: %d     100    2    3    0     0          0         0 %d     90    4    5    0     0       0          0
+`, ethRxBytes, ethTxBytes))
+
+			writeProcFile("diskstats", fmt.Sprintf(`   7       0 loop0 10 0 20 0 0 0 0 0 0 0 0
+   8       0 sda %d 0 %d 10 30 0 60 20 0 30 30
+   8      16 sdb 0 0 0 0 0 0 0 0 0 0 0
+`, sdaReads, sdaSectorsRead))
+		}
+
+		BeforeEach(func() {
+			procDir = GinkgoT().TempDir()
+			fakeClock = fakeclock.NewFakeClock(time.Now())
+			collector = boshsigar.NewProcSigarStatsCollector(fakeSigar, procDir, fakeClock)
+		})
+
+		It("computes network and disk I/O rates between collection cycles", func() {
+			latestGotUpdated := make(chan struct{})
+			collector.StartCollecting(1*time.Millisecond, latestGotUpdated)
+
+			writeCounters(1000, 2000, 10, 100)
+			fakeSigar.CollectCpuStatsCpuCh <- sigar.Cpu{}
+			<-latestGotUpdated
+
+			networkStats, err := collector.GetNetworkStats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(networkStats).To(BeEmpty())
+
+			writeCounters(3000, 2500, 30, 300)
+			fakeClock.Increment(10 * time.Second)
+			fakeSigar.CollectCpuStatsCpuCh <- sigar.Cpu{}
+			<-latestGotUpdated
+
+			networkStats, err = collector.GetNetworkStats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(networkStats).To(Equal(map[string]NetworkStats{
+				"eth0": {
+					RxBytesPerSec: 200,
+					TxBytesPerSec: 50,
+					RxErrors:      2,
+					RxDropped:     3,
+					TxErrors:      4,
+					TxDropped:     5,
+				},
+			}))
+
+			diskIOStats, err := collector.GetDiskIOStats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(diskIOStats).To(Equal(map[string]DiskIOStats{
+				"sda": {
+					ReadIOPS:         2,
+					WriteIOPS:        0,
+					ReadBytesPerSec:  20 * 512,
+					WriteBytesPerSec: 0,
+				},
+			}))
+
+			fakeSigar.CollectCpuStatsStopCh <- struct{}{}
+		})
+
+		It("returns ErrNotImplemented when procfs files are missing", func() {
+			latestGotUpdated := make(chan struct{})
+			collector.StartCollecting(1*time.Millisecond, latestGotUpdated)
+
+			fakeSigar.CollectCpuStatsCpuCh <- sigar.Cpu{}
+			<-latestGotUpdated
+
+			_, err := collector.GetNetworkStats()
+			Expect(err).To(Equal(sigar.ErrNotImplemented))
+
+			_, err = collector.GetDiskIOStats()
+			Expect(err).To(Equal(sigar.ErrNotImplemented))
+
+			_, err = collector.GetPressureStats()
+			Expect(err).To(Equal(sigar.ErrNotImplemented))
+
+			fakeSigar.CollectCpuStatsStopCh <- struct{}{}
+		})
+
+		It("returns pressure stall information", func() {
+			writeProcFile("pressure/cpu", "some avg10=1.50 avg60=0.75 avg300=0.25 total=12345\n")
+			writeProcFile("pressure/memory", "some avg10=0.10 avg60=0.20 avg300=0.30 total=10\nfull avg10=0.01 avg60=0.02 avg300=0.03 total=1\n")
+			writeProcFile("pressure/io", "some avg10=5.00 avg60=4.00 avg300=3.00 total=10\nfull avg10=2.00 avg60=1.00 avg300=0.50 total=1\n")
+
+			stats, err := collector.GetPressureStats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats).To(Equal(PressureStats{
+				CPU: ResourcePressure{
+					Some: PressureAverages{Avg10: 1.5, Avg60: 0.75, Avg300: 0.25},
+				},
+				Memory: ResourcePressure{
+					Some: PressureAverages{Avg10: 0.1, Avg60: 0.2, Avg300: 0.3},
+					Full: &PressureAverages{Avg10: 0.01, Avg60: 0.02, Avg300: 0.03},
+				},
+				IO: ResourcePressure{
+					Some: PressureAverages{Avg10: 5, Avg60: 4, Avg300: 3},
+					Full: &PressureAverages{Avg10: 2, Avg60: 1, Avg300: 0.5},
+				},
+			}))
+		})
+	})
 })